@@ -0,0 +1,33 @@
+package database
+
+import (
+	"0xkowalskidev/gameservers/models"
+)
+
+// CreateAuditLog inserts an audit log entry
+func (dm *DatabaseManager) CreateAuditLog(entry *models.AuditLog) error {
+	if err := dm.db.Create(entry).Error; err != nil {
+		return &models.DatabaseError{Op: "create_audit_log", Msg: "failed to record audit log entry", Err: err}
+	}
+	return nil
+}
+
+// ListAuditLogs retrieves up to limit audit log entries after skipping offset, newest first,
+// optionally only those of one gameserver, along with how many entries match in total
+func (dm *DatabaseManager) ListAuditLogs(gameserverID string, offset, limit int) ([]*models.AuditLog, int64, error) {
+	query := dm.db.Model(&models.AuditLog{})
+	if gameserverID != "" {
+		query = query.Where("gameserver_id = ?", gameserverID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, &models.DatabaseError{Op: "list_audit_logs", Msg: "failed to count audit log entries", Err: err}
+	}
+
+	var entries []*models.AuditLog
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&entries).Error; err != nil {
+		return nil, 0, &models.DatabaseError{Op: "list_audit_logs", Msg: "failed to query audit log", Err: err}
+	}
+	return entries, total, nil
+}
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// bulkActionWorkers is how many gameservers a bulk action works on at once
+const bulkActionWorkers = 4
+
+// BulkGameserverAction starts, stops or restarts several gameservers, a few at a time, and
+// reports the outcome for each in the order given. Starts are checked against host memory
+// as a batch, so starting many servers together can't overcommit it.
+func (gss *GameserverRepository) BulkGameserverAction(action string, ids []string) ([]models.BulkActionResult, error) {
+	var run func(id string) error
+	switch action {
+	case models.BulkActionStart:
+		run = gss.StartGameserver
+	case models.BulkActionStop:
+		run = gss.StopGameserver
+	case models.BulkActionRestart:
+		run = gss.RestartGameserver
+	default:
+		return nil, &models.OperationError{Op: "bulk_action", Msg: fmt.Sprintf("action %q", action), Err: models.ErrUnknownBulkAction}
+	}
+
+	results := make([]models.BulkActionResult, len(ids))
+	servers := make([]*models.Gameserver, len(ids))
+	for i, id := range ids {
+		results[i].ID = id
+		server, err := gss.db.GetGameserver(id)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Name = server.Name
+		if reason := bulkSkipReason(action, server); reason != "" {
+			results[i].OK = true
+			results[i].Skipped = reason
+			continue
+		}
+		servers[i] = server
+	}
+
+	if action != models.BulkActionStop {
+		if err := gss.reserveBulkMemory(servers, results); err != nil {
+			return nil, err
+		}
+	}
+
+	sem := make(chan struct{}, bulkActionWorkers)
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		if server == nil {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := run(id); err != nil {
+				log.Error().Err(err).Str("gameserver_id", id).Str("action", action).Msg("Bulk action failed")
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].OK = true
+		}(i, server.ID)
+	}
+	wg.Wait()
+
+	log.Info().Str("action", action).Int("gameservers", len(ids)).Msg("Bulk action finished")
+	return results, nil
+}
+
+// bulkSkipReason returns why a bulk action has nothing to do for a server, or "" if it applies
+func bulkSkipReason(action string, server *models.Gameserver) string {
+	if server.Status.IsTransitional() {
+		return fmt.Sprintf("busy (%s)", server.Status)
+	}
+	switch action {
+	case models.BulkActionStart:
+		if server.Status.ConsumesMemory() {
+			return "already " + string(server.Status)
+		}
+	case models.BulkActionStop:
+		if server.ContainerID == "" || server.Status == models.StatusStopped {
+			return "not running"
+		}
+	}
+	return ""
+}
+
+// reserveBulkMemory checks the servers a bulk action will bring up against allocatable host
+// memory, counting each one on top of those already running and the batch so far. Servers
+// that don't fit are dropped from servers and marked failed. Higher start priorities reserve first.
+func (gss *GameserverRepository) reserveBulkMemory(servers []*models.Gameserver, results []models.BulkActionResult) error {
+	systemInfo, err := models.GetSystemInfo()
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not get system memory info, skipping validation")
+		return nil
+	}
+	all, err := gss.db.ListGameservers()
+	if err != nil {
+		return &models.DatabaseError{Op: "validate_memory", Msg: "failed to check existing memory usage", Err: err}
+	}
+
+	// Restarting a running server frees and retakes its own memory, so only stopped ones add to the total
+	var order []int
+	for i, server := range servers {
+		if server != nil && !server.Status.ConsumesMemory() {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return servers[order[a]].StartPriority < servers[order[b]].StartPriority
+	})
+
+	available := gss.allocatableMemoryMB(systemInfo)
+	committed := committedMemoryMB(all)
+	for _, i := range order {
+		server := servers[i]
+		if committed+server.MemoryMB > available {
+			results[i].Error = fmt.Sprintf("starting would exceed allocatable system memory: %d MB (running and batch) + %d MB = %d MB > %d MB",
+				committed, server.MemoryMB, committed+server.MemoryMB, available)
+			servers[i] = nil
+			continue
+		}
+		committed += server.MemoryMB
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// gameserverOwnedTables lists tables keyed by gameserver_id that don't cascade when a gameserver is deleted.
+// Tables added later that hold per-gameserver rows should be registered here.
+var gameserverOwnedTables = []string{
+	"scheduled_tasks",
+}
+
+// PurgeOrphanedData hard-deletes rows that belong to gameservers which no longer exist,
+// along with soft-deleted rows, and returns the number of rows removed
+func (dm *DatabaseManager) PurgeOrphanedData() (int64, error) {
+	var total int64
+	for _, table := range gameserverOwnedTables {
+		result := dm.db.Exec(fmt.Sprintf(
+			"DELETE FROM %s WHERE deleted_at IS NOT NULL OR gameserver_id NOT IN (SELECT id FROM gameservers WHERE deleted_at IS NULL)",
+			table))
+		if result.Error != nil {
+			return total, &models.DatabaseError{Op: "purge_orphans", Msg: fmt.Sprintf("failed to purge orphaned rows from %s", table), Err: result.Error}
+		}
+		if result.RowsAffected > 0 {
+			log.Info().Str("table", table).Int64("rows", result.RowsAffected).Msg("Purged orphaned rows")
+		}
+		total += result.RowsAffected
+	}
+
+	// Task history goes with its task
+	result := dm.db.Exec("DELETE FROM task_executions WHERE task_id NOT IN (SELECT id FROM scheduled_tasks WHERE deleted_at IS NULL)")
+	if result.Error != nil {
+		return total, &models.DatabaseError{Op: "purge_orphans", Msg: "failed to purge orphaned task history", Err: result.Error}
+	}
+	total += result.RowsAffected
+
+	// Group memberships have no soft delete, so only orphans are removed
+	result = dm.db.Exec("DELETE FROM server_group_members WHERE gameserver_id NOT IN (SELECT id FROM gameservers WHERE deleted_at IS NULL) OR group_id NOT IN (SELECT id FROM server_groups)")
+	if result.Error != nil {
+		return total, &models.DatabaseError{Op: "purge_orphans", Msg: "failed to purge orphaned server group members", Err: result.Error}
+	}
+	total += result.RowsAffected
+
+	// Notes have no soft delete either
+	result = dm.db.Exec("DELETE FROM server_notes WHERE gameserver_id NOT IN (SELECT id FROM gameservers WHERE deleted_at IS NULL)")
+	if result.Error != nil {
+		return total, &models.DatabaseError{Op: "purge_orphans", Msg: "failed to purge orphaned server notes", Err: result.Error}
+	}
+	total += result.RowsAffected
+
+	// Nor do metrics samples
+	result = dm.db.Exec("DELETE FROM gameserver_metrics WHERE gameserver_id NOT IN (SELECT id FROM gameservers WHERE deleted_at IS NULL)")
+	if result.Error != nil {
+		return total, &models.DatabaseError{Op: "purge_orphans", Msg: "failed to purge orphaned gameserver metrics", Err: result.Error}
+	}
+	total += result.RowsAffected
+
+	// Expired login sessions
+	result = dm.db.Exec("DELETE FROM user_tokens WHERE expires_at IS NOT NULL AND expires_at < ?", time.Now())
+	if result.Error != nil {
+		return total, &models.DatabaseError{Op: "purge_orphans", Msg: "failed to purge expired sessions", Err: result.Error}
+	}
+	total += result.RowsAffected
+
+	// Gameservers are hard-deleted, but clear any soft-deleted leftovers as well
+	result = dm.db.Exec("DELETE FROM gameservers WHERE deleted_at IS NOT NULL")
+	if result.Error != nil {
+		return total, &models.DatabaseError{Op: "purge_orphans", Msg: "failed to purge deleted gameservers", Err: result.Error}
+	}
+	total += result.RowsAffected
+
+	return total, nil
+}
+
+// Vacuum rebuilds the SQLite database file to reclaim space freed by deleted rows
+func (dm *DatabaseManager) Vacuum() error {
+	if err := dm.db.Exec("VACUUM").Error; err != nil {
+		return &models.DatabaseError{Op: "vacuum", Msg: "failed to vacuum database", Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"io"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// ConsoleSession is an interactive console attached to a running gameserver. Reading it
+// follows the server's log output; Send writes commands, subject to the server's command policy.
+type ConsoleSession struct {
+	server *models.Gameserver
+	stream io.ReadWriteCloser
+}
+
+// AttachGameserverConsole opens an interactive console on a running gameserver
+func (gss *GameserverRepository) AttachGameserverConsole(id string) (*ConsoleSession, error) {
+	server, err := gss.db.GetGameserver(id)
+	if err != nil {
+		return nil, err
+	}
+	if server.ContainerID == "" || server.Status != models.StatusRunning {
+		return nil, &models.DatabaseError{Op: "attach_console", Msg: "cannot attach console", Err: models.ErrServerNotRunning}
+	}
+
+	stream, err := gss.docker.AttachInteractive(server.ContainerID)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Str("gameserver_id", id).Msg("Interactive console attached")
+	return &ConsoleSession{server: server, stream: stream}, nil
+}
+
+// Read returns the gameserver's log output
+func (s *ConsoleSession) Read(p []byte) (int, error) {
+	return s.stream.Read(p)
+}
+
+// Send writes a console command, rejecting it if the command policy blocks it
+func (s *ConsoleSession) Send(command string) error {
+	command = strings.TrimSpace(command)
+	if command == "" || strings.ContainsAny(command, "\r\n") {
+		return &models.DatabaseError{Op: "send_command", Msg: "command must be a single non-empty line"}
+	}
+	if err := s.server.CheckCommand(command); err != nil {
+		log.Warn().Str("gameserver_id", s.server.ID).Str("command", command).Msg("Console command blocked by policy")
+		return err
+	}
+
+	log.Info().Str("gameserver_id", s.server.ID).Str("command", command).Msg("Sending console command")
+	_, err := io.WriteString(s.stream, command+"\n")
+	return err
+}
+
+// Close ends the console session
+func (s *ConsoleSession) Close() error {
+	log.Info().Str("gameserver_id", s.server.ID).Msg("Interactive console detached")
+	return s.stream.Close()
+}
@@ -1,8 +1,11 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 
+	"gorm.io/gorm"
+
 	"0xkowalskidev/gameservers/models"
 )
 
@@ -47,3 +50,55 @@ func (dm *DatabaseManager) DeleteGame(id string) error {
 	}
 	return nil
 }
+
+// reseedColumns are the game fields a reseed refreshes from the built-in definitions
+var reseedColumns = []string{
+	"name", "slug", "image", "icon_path", "grid_image_path", "port_mappings", "config_vars", "config_templates",
+	"min_memory_mb", "rec_memory_mb", "min_cpu_cores", "rec_cpu_cores", "sysctls", "cap_add", "shm_size_mb", "stop_command", "stop_grace_secs",
+	"secret_files",
+}
+
+// ReseedGames upserts the built-in games: missing ones are added (with their mods) and
+// existing ones refreshed, except games that were customized in the panel
+func (dm *DatabaseManager) ReseedGames() (*models.ReseedResult, error) {
+	result := &models.ReseedResult{}
+	err := dm.db.Transaction(func(tx *gorm.DB) error {
+		for _, game := range builtinGames() {
+			var existing models.Game
+			err := tx.First(&existing, "id = ?", game.ID).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if err := tx.Create(game).Error; err != nil {
+					return fmt.Errorf("failed to add game %s: %w", game.ID, err)
+				}
+				for _, mod := range builtinMods() {
+					if mod.GameID != game.ID {
+						continue
+					}
+					if err := tx.Where("id = ?", mod.ID).FirstOrCreate(mod).Error; err != nil {
+						return fmt.Errorf("failed to add mod %s: %w", mod.ID, err)
+					}
+				}
+				result.Added = append(result.Added, game.ID)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get game %s: %w", game.ID, err)
+			}
+
+			if existing.Customized {
+				result.Skipped = append(result.Skipped, game.ID)
+				continue
+			}
+
+			if err := tx.Model(&existing).Select(reseedColumns).UpdateColumns(game).Error; err != nil {
+				return fmt.Errorf("failed to update game %s: %w", game.ID, err)
+			}
+			result.Updated = append(result.Updated, game.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, &models.DatabaseError{Op: "reseed_games", Msg: "failed to reseed games", Err: err}
+	}
+	return result, nil
+}
@@ -2,14 +2,21 @@ package database
 
 import (
 	"fmt"
+	"strings"
 
 	"gorm.io/gorm"
 
 	"0xkowalskidev/gameservers/models"
 )
 
+// SetAppVersion sets the app version recorded on gameservers created or updated from now on
+func (dm *DatabaseManager) SetAppVersion(version string) {
+	dm.appVersion = version
+}
+
 // CreateGameserver inserts a new gameserver into the database
 func (dm *DatabaseManager) CreateGameserver(server *models.Gameserver) error {
+	server.CreatedByVersion, server.UpdatedByVersion = dm.appVersion, dm.appVersion
 	if err := dm.db.Create(server).Error; err != nil {
 		return &models.DatabaseError{Op: "create_gameserver", Msg: fmt.Sprintf("failed to insert gameserver %s", server.Name), Err: err}
 	}
@@ -21,7 +28,7 @@ func (dm *DatabaseManager) GetGameserver(id string) (*models.Gameserver, error)
 	var server models.Gameserver
 	if err := dm.db.First(&server, "id = ?", id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, &models.DatabaseError{Op: "get_gameserver", Msg: fmt.Sprintf("gameserver %s not found", id), Err: nil}
+			return nil, &models.DatabaseError{Op: "get_gameserver", Msg: fmt.Sprintf("gameserver %s not found", id), Err: models.ErrGameserverNotFound}
 		}
 		return nil, &models.DatabaseError{Op: "get_gameserver", Msg: fmt.Sprintf("failed to query gameserver %s", id), Err: err}
 	}
@@ -30,6 +37,7 @@ func (dm *DatabaseManager) GetGameserver(id string) (*models.Gameserver, error)
 
 // UpdateGameserver updates an existing gameserver
 func (dm *DatabaseManager) UpdateGameserver(server *models.Gameserver) error {
+	server.UpdatedByVersion = dm.appVersion
 	result := dm.db.Save(server)
 	if result.Error != nil {
 		return &models.DatabaseError{Op: "update_gameserver", Msg: fmt.Sprintf("failed to update gameserver %s", server.ID), Err: result.Error}
@@ -40,6 +48,24 @@ func (dm *DatabaseManager) UpdateGameserver(server *models.Gameserver) error {
 	return nil
 }
 
+// UpdateStartupStatus writes the status and startup error of a gameserver that was waiting to
+// become ready. Nothing is written if the row has since left waiting_ready or changed container.
+// Reports whether the row was updated.
+func (dm *DatabaseManager) UpdateStartupStatus(server *models.Gameserver) (bool, error) {
+	result := dm.db.Model(&models.Gameserver{}).
+		Where("id = ? AND status = ? AND container_id = ?", server.ID, models.StatusWaitingReady, server.ContainerID).
+		Updates(map[string]interface{}{
+			"status":             server.Status,
+			"startup_error":      server.StartupError,
+			"updated_at":         server.UpdatedAt,
+			"updated_by_version": dm.appVersion,
+		})
+	if result.Error != nil {
+		return false, &models.DatabaseError{Op: "update_startup_status", Msg: fmt.Sprintf("failed to update status of gameserver %s", server.ID), Err: result.Error}
+	}
+	return result.RowsAffected > 0, nil
+}
+
 // DeleteGameserver deletes a gameserver by ID
 func (dm *DatabaseManager) DeleteGameserver(id string) error {
 	result := dm.db.Unscoped().Delete(&models.Gameserver{}, "id = ?", id)
@@ -61,6 +87,31 @@ func (dm *DatabaseManager) ListGameservers() ([]*models.Gameserver, error) {
 	return servers, nil
 }
 
+// ListGameserversPaged retrieves one page of gameservers, newest first, whose name or game
+// name contains filter (case-insensitive; empty matches all), along with the total number
+// of matches
+func (dm *DatabaseManager) ListGameserversPaged(filter string, limit, offset int) ([]*models.Gameserver, int64, error) {
+	query := dm.db.Model(&models.Gameserver{}).Joins("LEFT JOIN games ON games.id = gameservers.game_id")
+	if filter = strings.TrimSpace(filter); filter != "" {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(filter)) + "%"
+		query = query.Where("LOWER(gameservers.name) LIKE ? ESCAPE '\\' OR LOWER(games.name) LIKE ? ESCAPE '\\'", pattern, pattern)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, &models.DatabaseError{Op: "list_gameservers_paged", Msg: "failed to count gameservers", Err: err}
+	}
+
+	var servers []*models.Gameserver
+	if err := query.Select("gameservers.*").Order("gameservers.created_at DESC").Limit(limit).Offset(offset).Find(&servers).Error; err != nil {
+		return nil, 0, &models.DatabaseError{Op: "list_gameservers_paged", Msg: "failed to query gameservers", Err: err}
+	}
+	return servers, total, nil
+}
+
+// likeEscaper escapes LIKE wildcards so a search matches them literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 // GetGameserverByContainerID retrieves a gameserver by container ID
 func (dm *DatabaseManager) GetGameserverByContainerID(containerID string) (*models.Gameserver, error) {
 	var server models.Gameserver
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// CreateServerGroup inserts a new server group into the database
+func (dm *DatabaseManager) CreateServerGroup(group *models.ServerGroup) error {
+	if err := dm.db.Create(group).Error; err != nil {
+		return &models.DatabaseError{Op: "create_server_group", Msg: "failed to create server group", Err: err}
+	}
+	return nil
+}
+
+// GetServerGroup retrieves a server group by ID
+func (dm *DatabaseManager) GetServerGroup(id string) (*models.ServerGroup, error) {
+	var group models.ServerGroup
+	if err := dm.db.First(&group, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, &models.DatabaseError{Op: "get_server_group", Msg: fmt.Sprintf("server group %s not found", id), Err: nil}
+		}
+		return nil, &models.DatabaseError{Op: "get_server_group", Msg: fmt.Sprintf("failed to query server group %s", id), Err: err}
+	}
+	return &group, nil
+}
+
+// UpdateServerGroup updates an existing server group
+func (dm *DatabaseManager) UpdateServerGroup(group *models.ServerGroup) error {
+	result := dm.db.Save(group)
+	if result.Error != nil {
+		return &models.DatabaseError{Op: "update_server_group", Msg: "failed to update server group", Err: result.Error}
+	}
+	if result.RowsAffected == 0 {
+		return &models.DatabaseError{Op: "update_server_group", Msg: fmt.Sprintf("server group %s not found", group.ID), Err: nil}
+	}
+	return nil
+}
+
+// DeleteServerGroup deletes a server group and its memberships
+func (dm *DatabaseManager) DeleteServerGroup(id string) error {
+	return dm.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.ServerGroupMember{}, "group_id = ?", id).Error; err != nil {
+			return &models.DatabaseError{Op: "delete_server_group", Msg: "failed to delete server group members", Err: err}
+		}
+		result := tx.Delete(&models.ServerGroup{}, "id = ?", id)
+		if result.Error != nil {
+			return &models.DatabaseError{Op: "delete_server_group", Msg: "failed to delete server group", Err: result.Error}
+		}
+		if result.RowsAffected == 0 {
+			return &models.DatabaseError{Op: "delete_server_group", Msg: fmt.Sprintf("server group %s not found", id), Err: nil}
+		}
+		return nil
+	})
+}
+
+// ListServerGroups retrieves all server groups
+func (dm *DatabaseManager) ListServerGroups() ([]*models.ServerGroup, error) {
+	var groups []*models.ServerGroup
+	if err := dm.db.Order("name ASC").Find(&groups).Error; err != nil {
+		return nil, &models.DatabaseError{Op: "list_server_groups", Msg: "failed to query server groups", Err: err}
+	}
+	return groups, nil
+}
+
+// ListServerGroupMemberIDs retrieves the IDs of the gameservers in a group
+func (dm *DatabaseManager) ListServerGroupMemberIDs(groupID string) ([]string, error) {
+	var ids []string
+	if err := dm.db.Model(&models.ServerGroupMember{}).Where("group_id = ?", groupID).Pluck("gameserver_id", &ids).Error; err != nil {
+		return nil, &models.DatabaseError{Op: "list_server_group_members", Msg: fmt.Sprintf("failed to query members of server group %s", groupID), Err: err}
+	}
+	return ids, nil
+}
+
+// SetServerGroupMembers replaces a group's membership with the given gameservers
+func (dm *DatabaseManager) SetServerGroupMembers(groupID string, gameserverIDs []string) error {
+	return dm.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.ServerGroupMember{}, "group_id = ?", groupID).Error; err != nil {
+			return &models.DatabaseError{Op: "set_server_group_members", Msg: "failed to clear server group members", Err: err}
+		}
+		for _, id := range gameserverIDs {
+			if err := tx.Create(&models.ServerGroupMember{GroupID: groupID, GameserverID: id}).Error; err != nil {
+				return &models.DatabaseError{Op: "set_server_group_members", Msg: fmt.Sprintf("failed to add gameserver %s to server group", id), Err: err}
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveGameserverFromGroups drops a gameserver from every group it belongs to
+func (dm *DatabaseManager) RemoveGameserverFromGroups(gameserverID string) error {
+	if err := dm.db.Delete(&models.ServerGroupMember{}, "gameserver_id = ?", gameserverID).Error; err != nil {
+		return &models.DatabaseError{Op: "remove_from_server_groups", Msg: fmt.Sprintf("failed to remove gameserver %s from server groups", gameserverID), Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// defaultBackupHookTimeout bounds a backup hook call when none is configured
+const defaultBackupHookTimeout = 5 * time.Minute
+
+// backupHookEvent is the JSON body posted to the backup webhooks
+type backupHookEvent struct {
+	Event          string `json:"event"` // "backup.pre" or "backup.post"
+	GameserverID   string `json:"gameserver_id"`
+	GameserverName string `json:"gameserver_name"`
+	BackupFile     string `json:"backup_file,omitempty"` // Post hook only
+	Success        bool   `json:"success"`               // Post hook only: whether the backup was written
+	Error          string `json:"error,omitempty"`
+
+	Destinations []models.BackupDestinationResult `json:"destinations,omitempty"` // Post hook only: outcome at each destination
+}
+
+// callBackupHook posts event to url and waits for a 2xx response, up to the hook timeout
+func (gss *GameserverRepository) callBackupHook(url string, event backupHookEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), gss.backupHookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return &models.OperationError{Op: "backup_hook", Msg: fmt.Sprintf("invalid %s hook URL", event.Event), Err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return &models.OperationError{Op: "backup_hook", Msg: fmt.Sprintf("%s hook failed", event.Event), Err: err}
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &models.OperationError{Op: "backup_hook", Msg: fmt.Sprintf("%s hook returned %s", event.Event, resp.Status)}
+	}
+	return nil
+}
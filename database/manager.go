@@ -11,7 +11,8 @@ import (
 
 // DatabaseManager manages GORM database operations
 type DatabaseManager struct {
-	db *gorm.DB
+	db         *gorm.DB
+	appVersion string // Stamped on gameservers this build creates or modifies
 }
 
 // NewDatabaseManager creates a new database manager and performs migrations
@@ -78,19 +79,29 @@ func (dm *DatabaseManager) DB() *gorm.DB {
 	return dm.db
 }
 
-// migrate performs auto-migration of models
+// migrate performs auto-migration of models, then applies versioned migration steps
 func (dm *DatabaseManager) migrate() error {
 	err := dm.db.AutoMigrate(
 		&models.Game{},
 		&models.Gameserver{},
 		&models.ScheduledTask{},
+		&models.TaskExecution{},
+		&models.TaskTemplate{},
+		&models.ServerGroup{},
+		&models.ServerGroupMember{},
 		&models.Mod{},
+		&models.ServerNote{},
+		&models.GameserverMetric{},
+		&models.User{},
+		&models.UserToken{},
+		&models.AuditLog{},
+		&schemaMigration{},
 	)
 	if err != nil {
 		return &models.DatabaseError{Op: "db", Msg: "failed to auto-migrate", Err: err}
 	}
 
-	return nil
+	return dm.runMigrations()
 }
 
 // seedGames adds default game configurations to the database
@@ -104,7 +115,21 @@ func (dm *DatabaseManager) seedGames() error {
 		return nil // Games already seeded
 	}
 
-	games := []*models.Game{
+	games := builtinGames()
+	for _, game := range games {
+		if err := dm.db.Create(game).Error; err != nil {
+			log.Error().Err(err).Str("game_id", game.ID).Msg("Failed to seed game")
+			return &models.DatabaseError{Op: "db", Msg: "failed to create game", Err: err}
+		}
+	}
+
+	log.Info().Int("count", len(games)).Msg("Games seeded successfully")
+	return nil
+}
+
+// builtinGames returns the game configurations shipped with the panel
+func builtinGames() []*models.Game {
+	return []*models.Game{
 		{ID: "minecraft", Name: "Minecraft", Slug: "minecraft", Image: "registry.0xkowalski.dev/gameservers/minecraft:latest",
 			IconPath: "/static/games/minecraft/minecraft-icon.ico", GridImagePath: "/static/games/minecraft/minecraft-grid.png",
 			PortMappings: []models.PortMapping{
@@ -112,16 +137,17 @@ func (dm *DatabaseManager) seedGames() error {
 			},
 			ConfigVars: []models.ConfigVar{
 				{Name: "MINECRAFT_VERSION", DisplayName: "Minecraft Version", Required: false, Default: "latest", Description: "Server version (latest recommended, or specific version like 1.21.6 for mod compatibility)"},
+				{Name: "SERVER_TYPE", DisplayName: "Server Type", Type: "select", Options: "vanilla=Vanilla,paper=Paper,fabric=Fabric,forge=Forge", Required: false, Default: "vanilla", Description: "Server software: Paper for plugins, Fabric or Forge for mods"},
 				{Name: "EULA", DisplayName: "Accept Minecraft EULA", Required: true, Default: "true", Description: "You must accept the Minecraft End User License Agreement to run a server"},
 				{Name: "SERVER_NAME", DisplayName: "Server Name", Required: false, Default: "A Minecraft Server", Description: "The name shown in server lists"},
 				{Name: "MOTD", DisplayName: "Message of the Day", Required: false, Default: "Welcome to our server!", Description: "Message shown to players when joining"},
-				{Name: "DIFFICULTY", DisplayName: "Difficulty", Required: false, Default: "normal", Description: "Game difficulty (peaceful, easy, normal, hard)"},
-				{Name: "GAMEMODE", DisplayName: "Game Mode", Required: false, Default: "survival", Description: "Default game mode (survival, creative, adventure, spectator)"},
-				{Name: "MAX_PLAYERS", DisplayName: "Max Players", Required: false, Default: "20", Description: "Maximum number of players that can join"},
-				{Name: "VIEW_DISTANCE", DisplayName: "View Distance", Required: false, Default: "10", Description: "Chunk render distance (3-32, lower = better performance)"},
+				{Name: "DIFFICULTY", DisplayName: "Difficulty", Required: false, Default: "normal", Description: "Game difficulty (peaceful, easy, normal, hard)", Allowed: []string{"peaceful", "easy", "normal", "hard"}},
+				{Name: "GAMEMODE", DisplayName: "Game Mode", Required: false, Default: "survival", Description: "Default game mode (survival, creative, adventure, spectator)", Allowed: []string{"survival", "creative", "adventure", "spectator"}},
+				{Name: "MAX_PLAYERS", DisplayName: "Max Players", Required: false, Default: "20", Description: "Maximum number of players that can join", Min: bound(1)},
+				{Name: "VIEW_DISTANCE", DisplayName: "View Distance", Required: false, Default: "10", Description: "Chunk render distance (3-32, lower = better performance)", Min: bound(3), Max: bound(32)},
 				{Name: "PVP", DisplayName: "PvP Combat", Required: false, Default: "true", Description: "Allow players to damage each other"},
 				{Name: "WHITELIST", DisplayName: "Whitelist", Required: false, Default: "false", Description: "Only allow approved players to join"},
-			}, MinMemoryMB: 1024, RecMemoryMB: 3072},
+			}, MinMemoryMB: 1024, RecMemoryMB: 3072, MinCPUCores: 1, RecCPUCores: 2, SecretFiles: true},
 		{ID: "valheim", Name: "Valheim", Slug: "valheim", Image: "registry.0xkowalski.dev/gameservers/valheim:latest",
 			IconPath: "/static/games/valheim/valheim-icon.ico", GridImagePath: "/static/games/valheim/valheim-grid.png",
 			PortMappings: []models.PortMapping{
@@ -133,7 +159,7 @@ func (dm *DatabaseManager) seedGames() error {
 				{Name: "PASSWORD", DisplayName: "Server Password", Required: true, Default: "valheim123", Description: "Password to join server (minimum 5 characters required)"},
 				{Name: "PUBLIC", DisplayName: "Public Server", Required: false, Default: "1", Description: "Whether to list server publicly (1 for yes, 0 for no)"},
 				{Name: "CROSSPLAY", DisplayName: "Enable Crossplay", Required: false, Default: "1", Description: "Enable crossplay between Steam and Xbox (1 for yes, 0 for no)"},
-			}, MinMemoryMB: 2048, RecMemoryMB: 4096},
+			}, MinMemoryMB: 2048, RecMemoryMB: 4096, MinCPUCores: 2, RecCPUCores: 4, SecretFiles: true},
 		{ID: "terraria", Name: "Terraria", Slug: "terraria", Image: "registry.0xkowalski.dev/gameservers/terraria:latest",
 			IconPath: "/static/games/terraria/terraria-icon.ico", GridImagePath: "/static/games/terraria/terraria-grid.png",
 			PortMappings: []models.PortMapping{
@@ -144,7 +170,7 @@ func (dm *DatabaseManager) seedGames() error {
 				{Name: "MAX_PLAYERS", DisplayName: "Max Players", Required: false, Default: "8", Description: "Maximum number of players"},
 				{Name: "SERVER_PASSWORD", DisplayName: "Server Password", Required: false, Default: "", Description: "Password to join server (leave empty for public)"},
 				{Name: "DIFFICULTY", DisplayName: "Difficulty", Required: false, Default: "1", Description: "World difficulty (0=Classic, 1=Expert, 2=Master)"},
-			}, MinMemoryMB: 1024, RecMemoryMB: 2048},
+			}, MinMemoryMB: 1024, RecMemoryMB: 2048, MinCPUCores: 1, RecCPUCores: 2, SecretFiles: true},
 		{ID: "garrysmod", Name: "Garry's Mod", Slug: "garrys-mod", Image: "registry.0xkowalski.dev/gameservers/garrysmod:latest",
 			IconPath: "/static/games/garrysmod/garrys-mod-icon.ico", GridImagePath: "/static/games/garrysmod/garrys-mod-grid.png",
 			PortMappings: []models.PortMapping{
@@ -157,7 +183,7 @@ func (dm *DatabaseManager) seedGames() error {
 				{Name: "MAP", DisplayName: "Starting Map", Required: false, Default: "gm_flatgrass", Description: "The map to load on server start"},
 				{Name: "MAXPLAYERS", DisplayName: "Max Players", Required: false, Default: "16", Description: "Maximum number of players"},
 				{Name: "SERVER_PASSWORD", DisplayName: "Server Password", Required: false, Default: "", Description: "Password to join server (leave empty for public)"},
-			}, MinMemoryMB: 2048, RecMemoryMB: 4096},
+			}, MinMemoryMB: 2048, RecMemoryMB: 4096, MinCPUCores: 1, RecCPUCores: 2, SecretFiles: true},
 		{ID: "palworld", Name: "Palworld", Slug: "palworld", Image: "registry.0xkowalski.dev/gameservers/palworld:latest",
 			IconPath: "/static/games/palworld/palworld-icon.ico", GridImagePath: "/static/games/palworld/palworld-grid.png",
 			PortMappings: []models.PortMapping{
@@ -169,7 +195,7 @@ func (dm *DatabaseManager) seedGames() error {
 				{Name: "MAX_PLAYERS", DisplayName: "Max Players", Required: false, Default: "32", Description: "Maximum number of players"},
 				{Name: "SERVER_PASSWORD", DisplayName: "Server Password", Required: false, Default: "", Description: "Password to join server (leave empty for public)"},
 				{Name: "ADMIN_PASSWORD", DisplayName: "Admin Password", Required: false, Default: "", Description: "Password for admin access"},
-			}, MinMemoryMB: 8192, RecMemoryMB: 16384},
+			}, MinMemoryMB: 8192, RecMemoryMB: 16384, MinCPUCores: 2, RecCPUCores: 4, SecretFiles: true},
 		{ID: "rust", Name: "Rust", Slug: "rust", Image: "registry.0xkowalski.dev/gameservers/rust:latest",
 			IconPath: "/static/games/rust/rust-icon.ico", GridImagePath: "/static/games/rust/rust-grid.png",
 			PortMappings: []models.PortMapping{
@@ -181,17 +207,17 @@ func (dm *DatabaseManager) seedGames() error {
 			ConfigVars: []models.ConfigVar{
 				{Name: "NAME", DisplayName: "Server Name", Required: false, Default: "Rust Server", Description: "The name of your Rust server"},
 				{Name: "MAXPLAYERS", DisplayName: "Max Players", Required: false, Default: "50", Description: "Maximum number of players"},
-				{Name: "WORLDSIZE", DisplayName: "World Size", Required: false, Default: "3000", Description: "Size of the world map (1000-4000)"},
-				{Name: "SEED", DisplayName: "World Seed", Required: false, Default: "12345", Description: "Seed for world generation (numeric value)"},
+				{Name: "WORLDSIZE", DisplayName: "World Size", Required: false, Default: "3000", Description: "Size of the world map (1000-4000)", Min: bound(1000), Max: bound(4000)},
+				{Name: "SEED", DisplayName: "World Seed", Required: false, Default: "12345", Description: "Seed for world generation (numeric value)", Pattern: `-?[0-9]+`},
 				{Name: "PASSWORD", DisplayName: "Server Password", Required: false, Default: "", Description: "Password to join server (leave empty for public)"},
 				{Name: "RCON_PASSWORD", DisplayName: "RCON Password", Required: false, Default: "", Description: "Password for remote console access"},
-				{Name: "TICKRATE", DisplayName: "Tick Rate", Required: false, Default: "30", Description: "Server tick rate (10-30, higher = better performance)"},
+				{Name: "TICKRATE", DisplayName: "Tick Rate", Required: false, Default: "30", Description: "Server tick rate (10-30, higher = better performance)", Min: bound(10), Max: bound(30)},
 				{Name: "SAVEINTERVAL", DisplayName: "Save Interval", Required: false, Default: "300", Description: "How often to save the world (in seconds)"},
 				{Name: "UPDATE_ON_START", DisplayName: "Update on Start", Required: false, Default: "false", Description: "Update server files on container start"},
 				{Name: "SERVER_SECURE", DisplayName: "Secure Connection", Type: "boolean", Required: false, Default: "1", Description: "Enable VAC secure mode (disable for LAN/dev)"},
 				{Name: "SERVER_ENCRYPTION", DisplayName: "Voice Encryption", Type: "boolean", Required: false, Default: "1", Description: "Enable voice chat encryption"},
 				{Name: "SERVER_EAC", DisplayName: "Easy Anti-Cheat", Type: "boolean", Required: false, Default: "1", Description: "Enable Easy Anti-Cheat (disable for modded/dev servers)"},
-			}, MinMemoryMB: 4096, RecMemoryMB: 8192},
+			}, MinMemoryMB: 4096, RecMemoryMB: 8192, MinCPUCores: 2, RecCPUCores: 4, SecretFiles: true},
 		{ID: "ark-survival-evolved", Name: "ARK: Survival Evolved", Slug: "ark-survival-evolved", Image: "registry.0xkowalski.dev/gameservers/ark-survival-evolved:latest",
 			IconPath: "/static/games/ark-survival-evolved/ark-survival-evolved-icon.ico", GridImagePath: "/static/games/ark-survival-evolved/ark-survival-evolved-grid.png",
 			PortMappings: []models.PortMapping{
@@ -206,7 +232,7 @@ func (dm *DatabaseManager) seedGames() error {
 				{Name: "SERVER_PASSWORD", DisplayName: "Server Password", Required: false, Default: "", Description: "Password to join server (leave empty for public)"},
 				{Name: "ADMIN_PASSWORD", DisplayName: "Admin Password", Required: true, Default: "", Description: "Password for admin commands and RCON access"},
 				{Name: "DIFFICULTY", DisplayName: "Difficulty", Required: false, Default: "1.0", Description: "Difficulty multiplier (0.1-5.0)"},
-			}, MinMemoryMB: 8192, RecMemoryMB: 16384},
+			}, MinMemoryMB: 8192, RecMemoryMB: 16384, MinCPUCores: 2, RecCPUCores: 4, SecretFiles: true},
 		{ID: "counter-strike-2", Name: "Counter-Strike 2", Slug: "counter-strike-2", Image: "registry.0xkowalski.dev/gameservers/counter-strike-2:latest",
 			IconPath: "/static/games/counter-strike-2/counter-strike-2-icon.ico", GridImagePath: "/static/games/counter-strike-2/counter-strike-2-grid.png",
 			PortMappings: []models.PortMapping{
@@ -221,18 +247,13 @@ func (dm *DatabaseManager) seedGames() error {
 				{Name: "PASSWORD", DisplayName: "Server Password", Type: "password", Required: false, Default: "", Description: "Password to join (empty = public)"},
 				{Name: "RCON_PASSWORD", DisplayName: "RCON Password", Type: "password", Required: false, Default: "", Description: "Remote console password"},
 				{Name: "GSLT", DisplayName: "Game Server Login Token", Type: "password", Required: false, Default: "", Description: "GSLT from Steam (required for public servers)"},
-			}, MinMemoryMB: 2048, RecMemoryMB: 4096},
-	}
-
-	for _, game := range games {
-		if err := dm.db.Create(game).Error; err != nil {
-			log.Error().Err(err).Str("game_id", game.ID).Msg("Failed to seed game")
-			return &models.DatabaseError{Op: "db", Msg: "failed to create game", Err: err}
-		}
+			}, MinMemoryMB: 2048, RecMemoryMB: 4096, MinCPUCores: 2, RecCPUCores: 4, SecretFiles: true},
 	}
+}
 
-	log.Info().Int("count", len(games)).Msg("Games seeded successfully")
-	return nil
+// bound returns a pointer to a config var Min/Max value
+func bound(value float64) *float64 {
+	return &value
 }
 
 // seedMods adds default mod configurations to the database
@@ -246,10 +267,7 @@ func (dm *DatabaseManager) seedMods() error {
 		return nil // Mods already seeded
 	}
 
-	mods := []*models.Mod{
-		{ID: "oxide", GameID: "rust", Name: "Oxide", Description: "Modding framework for Rust that enables plugins and extensions"},
-	}
-
+	mods := builtinMods()
 	for _, mod := range mods {
 		if err := dm.db.Create(mod).Error; err != nil {
 			log.Error().Err(err).Str("mod_id", mod.ID).Msg("Failed to seed mod")
@@ -260,3 +278,10 @@ func (dm *DatabaseManager) seedMods() error {
 	log.Info().Int("count", len(mods)).Msg("Mods seeded successfully")
 	return nil
 }
+
+// builtinMods returns the mod configurations shipped with the panel
+func builtinMods() []*models.Mod {
+	return []*models.Mod{
+		{ID: "oxide", GameID: "rust", Name: "Oxide", Description: "Modding framework for Rust that enables plugins and extensions"},
+	}
+}
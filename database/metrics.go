@@ -0,0 +1,67 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// metricsMaxPoints caps the points returned for one chart window; longer windows are rolled up
+const metricsMaxPoints = 120
+
+// CreateGameserverMetrics inserts a batch of resource usage samples
+func (dm *DatabaseManager) CreateGameserverMetrics(metrics []*models.GameserverMetric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	if err := dm.db.Create(metrics).Error; err != nil {
+		return &models.DatabaseError{Op: "create_gameserver_metrics", Msg: "failed to record gameserver metrics", Err: err}
+	}
+	return nil
+}
+
+// ListGameserverMetrics retrieves a gameserver's samples taken at or after since, oldest first
+func (dm *DatabaseManager) ListGameserverMetrics(gameserverID string, since time.Time) ([]*models.GameserverMetric, error) {
+	var metrics []*models.GameserverMetric
+	if err := dm.db.Where("gameserver_id = ? AND timestamp >= ?", gameserverID, since).Order("timestamp ASC").Find(&metrics).Error; err != nil {
+		return nil, &models.DatabaseError{Op: "list_gameserver_metrics", Msg: fmt.Sprintf("failed to query metrics for gameserver %s", gameserverID), Err: err}
+	}
+	return metrics, nil
+}
+
+// PruneGameserverMetrics deletes every sample taken before cutoff and returns how many were removed
+func (dm *DatabaseManager) PruneGameserverMetrics(cutoff time.Time) (int64, error) {
+	result := dm.db.Where("timestamp < ?", cutoff).Delete(&models.GameserverMetric{})
+	if result.Error != nil {
+		return 0, &models.DatabaseError{Op: "prune_gameserver_metrics", Msg: "failed to prune gameserver metrics", Err: result.Error}
+	}
+	return result.RowsAffected, nil
+}
+
+// DeleteGameserverMetrics deletes all of a gameserver's samples
+func (dm *DatabaseManager) DeleteGameserverMetrics(gameserverID string) error {
+	if err := dm.db.Delete(&models.GameserverMetric{}, "gameserver_id = ?", gameserverID).Error; err != nil {
+		return &models.DatabaseError{Op: "delete_gameserver_metrics", Msg: fmt.Sprintf("failed to delete metrics for gameserver %s", gameserverID), Err: err}
+	}
+	return nil
+}
+
+// GetGameserverMetrics returns a gameserver's resource usage over the last window, rolled up
+// so a chart gets at most metricsMaxPoints points, along with the resolution used
+func (gss *GameserverRepository) GetGameserverMetrics(gameserverID string, window time.Duration) ([]*models.GameserverMetric, time.Duration, error) {
+	if _, err := gss.db.GetGameserver(gameserverID); err != nil {
+		return nil, 0, err
+	}
+
+	step := (window / metricsMaxPoints).Round(time.Second)
+	if step < time.Second {
+		step = time.Second
+	}
+	from := time.Now().Add(-window).Truncate(step)
+	samples, err := gss.db.ListGameserverMetrics(gameserverID, from)
+	if err != nil {
+		return nil, 0, err
+	}
+	return models.RollupMetrics(samples, from, step), step, nil
+}
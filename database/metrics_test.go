@@ -0,0 +1,42 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+func TestPruneGameserverMetrics(t *testing.T) {
+	db, err := NewDatabaseManager(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	now := time.Now().UTC().Truncate(time.Second)
+	var samples []*models.GameserverMetric
+	for _, age := range []time.Duration{72 * time.Hour, 25 * time.Hour, 23 * time.Hour, time.Minute} {
+		samples = append(samples, &models.GameserverMetric{GameserverID: "gs-1", Timestamp: now.Add(-age), CPUPercent: 1})
+	}
+	if err := db.CreateGameserverMetrics(samples); err != nil {
+		t.Fatal(err)
+	}
+
+	pruned, err := db.PruneGameserverMetrics(now.Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatalf("PruneGameserverMetrics: %v", err)
+	}
+	if pruned != 2 {
+		t.Errorf("pruned %d samples, want the 2 older than a day", pruned)
+	}
+
+	remaining, err := db.ListGameserverMetrics("gs-1", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 2 || !remaining[0].Timestamp.Equal(now.Add(-23*time.Hour)) || !remaining[1].Timestamp.Equal(now.Add(-time.Minute)) {
+		t.Errorf("remaining samples = %+v, want the last day's, oldest first", remaining)
+	}
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// schemaMigration records a migration step that has been applied
+type schemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"type:varchar(200);not null"`
+	AppliedAt time.Time
+}
+
+// migration is one ordered schema change. AutoMigrate still adds new tables and columns;
+// steps cover what it can't, like renames, type changes, backfills and data fixes.
+type migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+}
+
+// migrations are applied in order, each exactly once. Never edit or reorder a released
+// step: append a new one with the next version instead.
+var migrations = []migration{
+	{Version: 1, Name: "mark games edited before the customized flag", Up: markEditedGamesCustomized},
+	{Version: 2, Name: "keep restarting existing gameservers on crash", Up: enableRestartOnCrash},
+}
+
+// runMigrations applies every step newer than the recorded schema version, each in its own
+// transaction together with its version record
+func (dm *DatabaseManager) runMigrations() error {
+	var applied []schemaMigration
+	if err := dm.db.Find(&applied).Error; err != nil {
+		return &models.DatabaseError{Op: "db", Msg: "failed to read schema migrations", Err: err}
+	}
+	done := make(map[int]bool, len(applied))
+	for _, m := range applied {
+		done[m.Version] = true
+	}
+
+	for i, m := range migrations {
+		if i > 0 && m.Version <= migrations[i-1].Version {
+			return &models.DatabaseError{Op: "db", Msg: fmt.Sprintf("migration %d (%s) is out of order", m.Version, m.Name)}
+		}
+		if done[m.Version] {
+			continue
+		}
+
+		err := dm.db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return &models.DatabaseError{Op: "db", Msg: fmt.Sprintf("migration %d (%s) failed", m.Version, m.Name), Err: err}
+		}
+		log.Info().Int("version", m.Version).Str("name", m.Name).Msg("Applied database migration")
+	}
+	return nil
+}
+
+// markEditedGamesCustomized flags games that were edited in the panel before the Customized
+// flag existed, so reseeding built-in games doesn't overwrite them. Seeded games that were
+// never edited have matching created and updated timestamps.
+func markEditedGamesCustomized(tx *gorm.DB) error {
+	var games []*models.Game
+	if err := tx.Find(&games).Error; err != nil {
+		return err
+	}
+	for _, game := range games {
+		if game.Customized || game.UpdatedAt.Sub(game.CreatedAt) <= time.Second {
+			continue
+		}
+		if err := tx.Model(game).UpdateColumn("customized", true).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enableRestartOnCrash turns on crash restarts for gameservers created while every container
+// used Docker's unless-stopped policy, so upgrading doesn't change how they recover
+func enableRestartOnCrash(tx *gorm.DB) error {
+	return tx.Model(&models.Gameserver{}).Where("1 = 1").UpdateColumn("restart_on_crash", true).Error
+}
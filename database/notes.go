@@ -0,0 +1,77 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// CreateServerNote inserts a note into a gameserver's log
+func (dm *DatabaseManager) CreateServerNote(note *models.ServerNote) error {
+	if err := dm.db.Create(note).Error; err != nil {
+		return &models.DatabaseError{Op: "create_server_note", Msg: "failed to create server note", Err: err}
+	}
+	return nil
+}
+
+// ListServerNotes retrieves a gameserver's notes, newest first
+func (dm *DatabaseManager) ListServerNotes(gameserverID string) ([]*models.ServerNote, error) {
+	var notes []*models.ServerNote
+	if err := dm.db.Where("gameserver_id = ?", gameserverID).Order("created_at DESC").Find(&notes).Error; err != nil {
+		return nil, &models.DatabaseError{Op: "list_server_notes", Msg: fmt.Sprintf("failed to query notes for gameserver %s", gameserverID), Err: err}
+	}
+	return notes, nil
+}
+
+// DeleteServerNote deletes one of a gameserver's notes
+func (dm *DatabaseManager) DeleteServerNote(gameserverID, id string) error {
+	result := dm.db.Delete(&models.ServerNote{}, "id = ? AND gameserver_id = ?", id, gameserverID)
+	if result.Error != nil {
+		return &models.DatabaseError{Op: "delete_server_note", Msg: "failed to delete server note", Err: result.Error}
+	}
+	if result.RowsAffected == 0 {
+		return &models.DatabaseError{Op: "delete_server_note", Msg: fmt.Sprintf("server note %s not found", id), Err: nil}
+	}
+	return nil
+}
+
+// DeleteServerNotes deletes all of a gameserver's notes
+func (dm *DatabaseManager) DeleteServerNotes(gameserverID string) error {
+	if err := dm.db.Delete(&models.ServerNote{}, "gameserver_id = ?", gameserverID).Error; err != nil {
+		return &models.DatabaseError{Op: "delete_server_notes", Msg: fmt.Sprintf("failed to delete notes for gameserver %s", gameserverID), Err: err}
+	}
+	return nil
+}
+
+// AddServerNote records a note in a gameserver's log
+func (gss *GameserverRepository) AddServerNote(gameserverID, text, author string) (*models.ServerNote, error) {
+	if _, err := gss.db.GetGameserver(gameserverID); err != nil {
+		return nil, err
+	}
+
+	note := &models.ServerNote{
+		ID:           models.GenerateID(),
+		GameserverID: gameserverID,
+		Note:         text,
+		Author:       author,
+		CreatedAt:    time.Now(),
+	}
+	if err := gss.db.CreateServerNote(note); err != nil {
+		return nil, err
+	}
+	log.Info().Str("gameserver_id", gameserverID).Str("note_id", note.ID).Str("author", author).Msg("Server note added")
+	return note, nil
+}
+
+// ListServerNotes retrieves a gameserver's notes, newest first
+func (gss *GameserverRepository) ListServerNotes(gameserverID string) ([]*models.ServerNote, error) {
+	return gss.db.ListServerNotes(gameserverID)
+}
+
+// DeleteServerNote deletes one of a gameserver's notes
+func (gss *GameserverRepository) DeleteServerNote(gameserverID, id string) error {
+	return gss.db.DeleteServerNote(gameserverID, id)
+}
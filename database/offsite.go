@@ -0,0 +1,163 @@
+package database
+
+import (
+	"archive/tar"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// offsiteKey is where a gameserver's backup is stored offsite. Keys use the server name
+// rather than its ID so backups can still be found after the database is lost.
+func offsiteKey(server *models.Gameserver, backupFilename string) string {
+	return server.Name + "/" + backupFilename
+}
+
+// OffsiteEnabled reports whether any destination besides the local volume is configured
+func (gss *GameserverRepository) OffsiteEnabled() bool {
+	return len(gss.backupStores) > 0
+}
+
+// BackupDestinations returns the names of every configured backup destination, local first
+func (gss *GameserverRepository) BackupDestinations() []string {
+	names := make([]string, 0, len(gss.backupStores))
+	for name := range gss.backupStores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{models.BackupDestinationLocal}, names...)
+}
+
+// OffsiteDestinations returns the configured destinations a gameserver's backups are copied to
+func (gss *GameserverRepository) OffsiteDestinations(server *models.Gameserver) []string {
+	var names []string
+	for _, name := range gss.BackupDestinations()[1:] {
+		if server.BacksUpTo(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// UpdateBackupDestinations sets where a gameserver's backups are stored. The local volume is
+// always included, since backups are created and restored there.
+func (gss *GameserverRepository) UpdateBackupDestinations(gameserverID string, destinations []string) error {
+	server, err := gss.db.GetGameserver(gameserverID)
+	if err != nil {
+		return err
+	}
+
+	selected := []string{models.BackupDestinationLocal}
+	for _, name := range destinations {
+		if name == models.BackupDestinationLocal {
+			continue
+		}
+		if _, ok := gss.backupStores[name]; !ok {
+			return &models.OperationError{Op: "update_backup_destinations", Msg: fmt.Sprintf("backup destination %q is not configured", name), Err: models.ErrUnknownBackupDestination}
+		}
+		selected = append(selected, name)
+	}
+
+	server.BackupDestinations = selected
+	server.UpdatedAt = time.Now()
+	return gss.db.UpdateGameserver(server)
+}
+
+// backupStore returns the named offsite backup destination
+func (gss *GameserverRepository) backupStore(destination string) (models.BackupStore, error) {
+	store, ok := gss.backupStores[destination]
+	if !ok {
+		return nil, &models.OperationError{Op: "backup_store", Msg: fmt.Sprintf("backup destination %q is not configured", destination), Err: models.ErrUnknownBackupDestination}
+	}
+	return store, nil
+}
+
+// copyBackupOffsite copies a new backup to each of the gameserver's offsite destinations,
+// continuing past failures so one unreachable destination doesn't cost the others their copy
+func (gss *GameserverRepository) copyBackupOffsite(server *models.Gameserver, backupFilename string) []models.BackupDestinationResult {
+	var results []models.BackupDestinationResult
+	for _, destination := range gss.OffsiteDestinations(server) {
+		result := models.BackupDestinationResult{Destination: destination, Success: true}
+		if err := gss.uploadBackupOffsite(server, destination, backupFilename); err != nil {
+			log.Error().Err(err).Str("gameserver_id", server.ID).Str("destination", destination).Str("backup_file", backupFilename).Msg("Failed to copy backup to destination")
+			result.Success, result.Error = false, err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// uploadBackupOffsite streams a backup from the gameserver's volume to an offsite destination
+func (gss *GameserverRepository) uploadBackupOffsite(server *models.Gameserver, destination, backupFilename string) error {
+	store, err := gss.backupStore(destination)
+	if err != nil {
+		return err
+	}
+
+	reader, err := gss.docker.DownloadFile(server.ContainerID, "/data/backups/"+backupFilename)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// Docker wraps copied files in a tar stream, whose header conveniently carries the size
+	tr := tar.NewReader(reader)
+	header, err := tr.Next()
+	if err != nil {
+		return &models.OperationError{Op: "offsite_upload", Msg: fmt.Sprintf("failed to read backup %s", backupFilename), Err: err}
+	}
+
+	key := offsiteKey(server, backupFilename)
+	if err := store.Upload(key, tr, header.Size); err != nil {
+		return err
+	}
+	log.Info().Str("gameserver_id", server.ID).Str("destination", destination).Str("key", key).Int64("size", header.Size).Msg("Backup uploaded offsite")
+	return nil
+}
+
+// ListOffsiteBackups lists a gameserver's backups at an offsite destination, newest first
+func (gss *GameserverRepository) ListOffsiteBackups(gameserverID, destination string) ([]*models.OffsiteBackup, error) {
+	store, err := gss.backupStore(destination)
+	if err != nil {
+		return nil, err
+	}
+	server, err := gss.db.GetGameserver(gameserverID)
+	if err != nil {
+		return nil, err
+	}
+	return store.List(offsiteKey(server, ""))
+}
+
+// RestoreOffsiteBackup copies a backup from an offsite destination into the gameserver's volume and restores it
+func (gss *GameserverRepository) RestoreOffsiteBackup(gameserverID, destination, backupFilename string) error {
+	store, err := gss.backupStore(destination)
+	if err != nil {
+		return err
+	}
+	server, err := gss.db.GetGameserver(gameserverID)
+	if err != nil {
+		return err
+	}
+
+	body, size, err := store.Download(offsiteKey(server, backupFilename))
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	if size < 0 {
+		return &models.OperationError{Op: "offsite_restore", Msg: fmt.Sprintf("backup destination %s didn't report the size of %s", destination, backupFilename)}
+	}
+
+	// Restoring is as IO-heavy as taking a backup, so share the backup slots
+	release := gss.acquireBackupSlot(gameserverID)
+	defer release()
+
+	if err := gss.docker.ImportBackup(server.ContainerID, backupFilename, body, size); err != nil {
+		return err
+	}
+	return gss.docker.RestoreBackup(server.ContainerID, backupFilename)
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// How long to wait for the game to write a player list change to its file
+const (
+	playerListSyncTimeout  = 3 * time.Second
+	playerListPollInterval = 250 * time.Millisecond
+)
+
+// ListPlayerLists returns a gameserver's whitelist and ban lists, read from the game's files.
+// Games without player list support return nil.
+func (gss *GameserverRepository) ListPlayerLists(id string) ([]*models.PlayerListEntries, error) {
+	server, err := gss.db.GetGameserver(id)
+	if err != nil {
+		return nil, err
+	}
+	slug, err := gss.gameSlug(server)
+	if err != nil {
+		return nil, err
+	}
+
+	var lists []*models.PlayerListEntries
+	for _, list := range models.PlayerListsForGame(slug) {
+		lists = append(lists, gss.readPlayerList(server, list))
+	}
+	return lists, nil
+}
+
+// ModifyPlayerList adds or removes a player via the game's console command, then waits briefly
+// for the game to write the change so the returned lists reflect it
+func (gss *GameserverRepository) ModifyPlayerList(id, listName, player string, add bool) ([]*models.PlayerListEntries, error) {
+	server, err := gss.db.GetGameserver(id)
+	if err != nil {
+		return nil, err
+	}
+	slug, err := gss.gameSlug(server)
+	if err != nil {
+		return nil, err
+	}
+	list, err := models.FindPlayerList(slug, listName)
+	if err != nil {
+		return nil, err
+	}
+	command, err := list.Command(add, player)
+	if err != nil {
+		return nil, &models.DatabaseError{Op: "modify_player_list", Msg: err.Error(), Err: models.ErrInvalidPlayer}
+	}
+
+	if _, err := gss.SendGameserverCommand(id, command); err != nil {
+		return nil, err
+	}
+	log.Info().Str("gameserver_id", id).Str("list", listName).Str("player", player).Bool("add", add).Msg("Player list changed")
+
+	deadline := time.Now().Add(playerListSyncTimeout)
+	for {
+		current := gss.readPlayerList(server, list)
+		if current.Contains(player) == add || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(playerListPollInterval)
+	}
+	return gss.ListPlayerLists(id)
+}
+
+// readPlayerList reads one player list from the game's file. A missing file is an empty list;
+// other failures are reported on the list rather than failing the whole panel.
+func (gss *GameserverRepository) readPlayerList(server *models.Gameserver, list models.PlayerList) *models.PlayerListEntries {
+	result := &models.PlayerListEntries{PlayerList: list}
+	if server.ContainerID == "" {
+		return result
+	}
+
+	content, err := gss.docker.ReadFile(server.ContainerID, "/data/server/"+list.File)
+	if errors.Is(err, models.ErrFileNotFound) {
+		return result
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	entries, err := list.Parse(content)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Entries = entries
+	return result
+}
+
+// gameSlug returns the slug of a gameserver's game
+func (gss *GameserverRepository) gameSlug(server *models.Gameserver) (string, error) {
+	game, err := gss.db.GetGame(server.GameID)
+	if err != nil {
+		return "", err
+	}
+	return game.Slug, nil
+}
@@ -0,0 +1,226 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// ReconcileDockerState cross-references the panel's gameserver containers and managed volumes
+// with the database and reports those no gameserver record accounts for
+func (gss *GameserverRepository) ReconcileDockerState() (*models.DockerState, error) {
+	containers, err := gss.docker.ListContainers()
+	if err != nil {
+		return nil, err
+	}
+	volumes, err := gss.docker.ListVolumes()
+	if err != nil {
+		return nil, err
+	}
+	servers, err := gss.db.ListGameservers()
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(servers))
+	usedVolumes := make(map[string]bool, len(servers)+len(containers))
+	for _, server := range servers {
+		known[server.ID] = true
+		usedVolumes[gss.docker.GetVolumeNameForServer(server)] = true
+	}
+
+	state := &models.DockerState{Containers: len(containers), Volumes: len(volumes)}
+	for _, c := range containers {
+		// An orphaned container's volume is reported, and removed, along with it
+		usedVolumes[c.Volume] = true
+		if !known[c.GameserverID()] {
+			state.OrphanContainers = append(state.OrphanContainers, c)
+		}
+	}
+	for _, volume := range volumes {
+		if !usedVolumes[volume.Name] {
+			state.OrphanVolumes = append(state.OrphanVolumes, volume)
+		}
+	}
+	return state, nil
+}
+
+// findOrphanContainer returns the orphaned container with the given ID
+func (gss *GameserverRepository) findOrphanContainer(containerID string) (*models.ContainerSummary, error) {
+	state, err := gss.ReconcileDockerState()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range state.OrphanContainers {
+		if c.ID == containerID {
+			return c, nil
+		}
+	}
+	return nil, &models.DatabaseError{
+		Op:  "find_orphan",
+		Msg: fmt.Sprintf("container %s is not orphaned", containerID),
+		Err: models.ErrOrphanNotFound,
+	}
+}
+
+// AdoptOrphanContainer recreates the gameserver record of an orphaned container, keeping the ID
+// and name from its labels and recovering the game, ports, data volume, environment and resource
+// limits from Docker. The container is kept, so a running server carries on running.
+func (gss *GameserverRepository) AdoptOrphanContainer(containerID string) (*models.Gameserver, error) {
+	orphan, err := gss.findOrphanContainer(containerID)
+	if err != nil {
+		return nil, err
+	}
+	game, err := gss.gameForContainer(orphan)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := gss.docker.InspectContainerSettings(orphan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &models.Gameserver{
+		ID:         orphan.GameserverID(),
+		Name:       orphan.GameserverName(),
+		GameID:     game.ID,
+		MemoryMB:   settings.MemoryMB,
+		CPUCores:   settings.CPUCores,
+		CPUSetCPUs: settings.CPUSetCPUs,
+	}
+	if server.MemoryMB <= 0 {
+		server.MemoryMB = game.RecMemoryMB
+	}
+
+	// Undo the variables the panel adds when creating a container
+	for _, entry := range settings.Env {
+		key, value, _ := strings.Cut(entry, "=")
+		switch {
+		case key == "MEMORY_MB":
+		case key == "ENABLED_MODS":
+			server.EnabledMods = strings.Split(value, ",")
+		case key == "TZ":
+			server.Timezone = value
+		case strings.HasPrefix(key, "FILE__"):
+			log.Warn().Str("container_id", orphan.ID).Str("var", strings.TrimPrefix(key, "FILE__")).Msg("Secret passed as a file can't be recovered, set it again after adopting")
+		default:
+			server.Environment = append(server.Environment, entry)
+		}
+	}
+
+	// Keep the published host ports if every game port has one, otherwise allocate new ones
+	for _, mapping := range game.PortMappings {
+		protocol := mapping.Protocol
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		for _, published := range orphan.Ports {
+			if published.ContainerPort == mapping.ContainerPort && strings.EqualFold(published.Protocol, protocol) {
+				mapping.HostPort = published.HostPort
+				break
+			}
+		}
+		if mapping.HostPort == 0 {
+			log.Warn().Str("container_id", orphan.ID).Str("port", mapping.Name).Msg("Container doesn't publish every game port, allocating new ports")
+			server.PortMappings = nil
+			break
+		}
+		server.PortMappings = append(server.PortMappings, mapping)
+	}
+
+	// A volume named after the server is found again by name; anything else is adopted explicitly
+	if orphan.Volume != "" && orphan.Volume != gss.docker.GetVolumeNameForServer(server) {
+		server.DataVolume = orphan.Volume
+	}
+
+	if err := gss.CreateGameserver(server); err != nil {
+		return nil, err
+	}
+
+	server.ContainerID = orphan.ID
+	if err := gss.db.UpdateGameserver(server); err != nil {
+		return nil, err
+	}
+	gss.syncStatus(server)
+
+	log.Info().Str("gameserver_id", server.ID).Str("container_id", orphan.ID).Str("name", server.Name).Str("game", game.ID).Msg("Adopted orphaned container")
+	return server, nil
+}
+
+// gameForContainer finds the game an orphaned container runs, by the game name it was labeled
+// with, falling back to its image
+func (gss *GameserverRepository) gameForContainer(orphan *models.ContainerSummary) (*models.Game, error) {
+	games, err := gss.db.ListGames()
+	if err != nil {
+		return nil, err
+	}
+	gameType := orphan.Labels["gameserver.type"]
+	for _, game := range games {
+		if gameType != "" && game.Name == gameType {
+			return game, nil
+		}
+	}
+	for _, game := range games {
+		if game.Image == orphan.Image {
+			return game, nil
+		}
+	}
+	return nil, &models.DatabaseError{
+		Op:  "adopt_container",
+		Msg: fmt.Sprintf("no game matches container %s (game %q, image %s)", orphan.Name, gameType, orphan.Image),
+		Err: models.ErrCannotAdopt,
+	}
+}
+
+// RemoveOrphanContainer force-removes an orphaned container and, if removeVolume is set, its data
+// volume. Host directories and volumes a gameserver still uses are left in place.
+func (gss *GameserverRepository) RemoveOrphanContainer(containerID string, removeVolume bool) error {
+	orphan, err := gss.findOrphanContainer(containerID)
+	if err != nil {
+		return err
+	}
+	if err := gss.docker.RemoveContainer(orphan.ID); err != nil {
+		return err
+	}
+	log.Info().Str("container_id", orphan.ID).Str("name", orphan.Name).Msg("Removed orphaned container")
+
+	if !removeVolume || orphan.Volume == "" {
+		return nil
+	}
+	if models.IsHostPath(orphan.Volume) {
+		log.Info().Str("path", orphan.Volume).Msg("Leaving host data directory in place")
+		return nil
+	}
+	servers, err := gss.db.ListGameservers()
+	if err != nil {
+		return err
+	}
+	for _, server := range servers {
+		if gss.docker.GetVolumeNameForServer(server) == orphan.Volume {
+			log.Warn().Str("volume", orphan.Volume).Str("gameserver_id", server.ID).Msg("Volume of orphaned container is used by a gameserver, keeping it")
+			return nil
+		}
+	}
+	return gss.docker.RemoveVolume(orphan.Volume)
+}
+
+// RemoveOrphanVolume deletes a managed volume that no gameserver or container uses
+func (gss *GameserverRepository) RemoveOrphanVolume(name string) error {
+	state, err := gss.ReconcileDockerState()
+	if err != nil {
+		return err
+	}
+	for _, volume := range state.OrphanVolumes {
+		if volume.Name == name {
+			return gss.docker.RemoveVolume(name)
+		}
+	}
+	return &models.DatabaseError{
+		Op:  "find_orphan",
+		Msg: fmt.Sprintf("volume %s is not orphaned", name),
+		Err: models.ErrOrphanNotFound,
+	}
+}
@@ -1,9 +1,14 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
@@ -15,6 +20,27 @@ import (
 // QueryServiceInterface defines the query service contract for readiness checks
 type QueryServiceInterface interface {
 	IsServerReady(gameserver *models.Gameserver, game *models.Game) bool
+	Invalidate(gameserverID string)
+}
+
+// RepositoryOptions holds tunable repository settings loaded from configuration
+type RepositoryOptions struct {
+	MaxConcurrentBackups int           // Host-wide limit on simultaneous backups (0 = unlimited)
+	MaxConcurrentStarts  int           // Host-wide limit on servers pulling, creating and starting containers at once (0 = unlimited)
+	BackupPreHookURL     string        // Called before archiving; a failed call aborts the backup (empty = none)
+	BackupPostHookURL    string        // Called after every backup attempt with the backup filename (empty = none)
+	BackupHookTimeout    time.Duration // How long a backup hook call may take
+	CrashLoopRestarts    int           // Restarts within CrashLoopWindow that count as a crash loop (0 = disabled)
+	CrashLoopWindow      time.Duration // Uptime below which a restarted container is considered unstable
+	StopCrashLooping     bool          // Stop crash-looping containers to break the loop
+	PersistentContainers bool          // Stop containers instead of removing them, reusing them until the config changes
+	BackupVerifyBootTime time.Duration // How long a restored backup must keep the server up to pass verification
+	MemoryReserveMB      int           // Host memory kept free for the OS and this app when starting servers
+	StartupTimeout       time.Duration // How long a started server has to respond to queries
+	StartupFailOnTimeout bool          // Treat a startup timeout as a failed start (otherwise assume it's running)
+	SessionTTL           time.Duration // How long a login session lasts
+
+	BackupStores map[string]models.BackupStore // Named destinations backups are copied to after they're created (empty = local only)
 }
 
 // GameserverRepository wraps DatabaseManager with Docker operations
@@ -22,85 +48,120 @@ type GameserverRepository struct {
 	db           *DatabaseManager
 	docker       models.DockerManagerInterface
 	queryService QueryServiceInterface
+	backupSlots  chan struct{} // Semaphore limiting concurrent backups (nil = unlimited)
+	startSlots   chan struct{} // Semaphore limiting concurrent container starts (nil = unlimited)
+
+	crashLoopRestarts int
+	crashLoopWindow   time.Duration
+	stopCrashLooping  bool
+
+	persistentContainers bool
+	backupVerifyBootTime time.Duration
+	memoryReserveMB      int
+	startupTimeout       time.Duration
+	startupFailOnTimeout bool
+	readyInterval        time.Duration // How often waitForReady polls a starting server
+	backupPreHookURL     string
+	backupPostHookURL    string
+	backupHookTimeout    time.Duration
+	backupStores         map[string]models.BackupStore
+	sessionTTL           time.Duration
+
+	opMu    sync.Mutex
+	opLocks map[string]bool // Gameservers with a lifecycle operation in progress
+
+	createMu sync.Mutex // Serializes name checks with the inserts and renames that depend on them
 }
 
 // NewGameserverRepository creates a new gameserver repository instance
-func NewGameserverRepository(db *DatabaseManager, docker models.DockerManagerInterface, queryService QueryServiceInterface) *GameserverRepository {
-	return &GameserverRepository{
+func NewGameserverRepository(db *DatabaseManager, docker models.DockerManagerInterface, queryService QueryServiceInterface, opts RepositoryOptions) *GameserverRepository {
+	repo := &GameserverRepository{
 		db:           db,
 		docker:       docker,
 		queryService: queryService,
+
+		crashLoopRestarts: opts.CrashLoopRestarts,
+		crashLoopWindow:   opts.CrashLoopWindow,
+		stopCrashLooping:  opts.StopCrashLooping,
+
+		persistentContainers: opts.PersistentContainers,
+		backupVerifyBootTime: opts.BackupVerifyBootTime,
+		memoryReserveMB:      opts.MemoryReserveMB,
+		startupTimeout:       opts.StartupTimeout,
+		startupFailOnTimeout: opts.StartupFailOnTimeout,
+		readyInterval:        defaultReadyInterval,
+		backupPreHookURL:     opts.BackupPreHookURL,
+		backupPostHookURL:    opts.BackupPostHookURL,
+		backupHookTimeout:    opts.BackupHookTimeout,
+		backupStores:         opts.BackupStores,
+		sessionTTL:           opts.SessionTTL,
+
+		opLocks: make(map[string]bool),
+	}
+	if opts.MaxConcurrentBackups > 0 {
+		repo.backupSlots = make(chan struct{}, opts.MaxConcurrentBackups)
 	}
+	if repo.backupHookTimeout <= 0 {
+		repo.backupHookTimeout = defaultBackupHookTimeout
+	}
+	if repo.sessionTTL <= 0 {
+		repo.sessionTTL = defaultSessionTTL
+	}
+	if opts.MaxConcurrentStarts > 0 {
+		repo.startSlots = make(chan struct{}, opts.MaxConcurrentStarts)
+	}
+	return repo
 }
 
 // CreateGameserver creates a new gameserver with Docker container integration
 func (gss *GameserverRepository) CreateGameserver(server *models.Gameserver) error {
-	now := time.Now()
-	server.CreatedAt, server.UpdatedAt, server.Status = now, now, models.StatusStopped
-	server.ContainerID = "" // No container created yet
-
-	// Populate derived fields from game
-	if err := gss.populateGameFields(server); err != nil {
-		return err
-	}
+	return gss.createGameserver(server, false)
+}
 
-	// Get game info for port mappings and validation
-	game, err := gss.db.GetGame(server.GameID)
-	if err != nil {
-		return err
-	}
+// CreateGameserverWithUniqueName creates a gameserver like CreateGameserver, but renames it to
+// the first free variant of its name (myserver-2, myserver-3, ...) if the name is taken.
+// For clones, imports and bulk operations, where a collision shouldn't fail the request.
+func (gss *GameserverRepository) CreateGameserverWithUniqueName(server *models.Gameserver) error {
+	return gss.createGameserver(server, true)
+}
 
-	// Validate required configuration variables
-	missingConfigs := game.ValidateEnvironment(server.Environment)
-	if len(missingConfigs) > 0 {
-		return &models.DatabaseError{
-			Op:  "validate_config",
-			Msg: fmt.Sprintf("missing required configuration: %v", missingConfigs),
-			Err: nil,
+func (gss *GameserverRepository) createGameserver(server *models.Gameserver, uniqueName bool) error {
+	if server.DataVolume != "" {
+		// The volume is inspected with the game's image
+		if err := gss.populateGameFields(server); err != nil {
+			return err
 		}
-	}
-
-	// Basic memory validation - ensure minimum requirements
-	if server.MemoryMB < game.MinMemoryMB {
-		return &models.DatabaseError{
-			Op:  "validate_memory",
-			Msg: fmt.Sprintf("memory (%d MB) is below game minimum (%d MB)", server.MemoryMB, game.MinMemoryMB),
-			Err: nil,
+		if err := gss.checkAdoptedVolume(server); err != nil {
+			return err
 		}
+		log.Info().Str("gameserver_id", server.ID).Str("volume", server.DataVolume).Msg("Adopting existing data volume")
 	}
 
-	// Validate against system memory (only for creation, not updates)
-	if err := gss.validateSystemMemory(server); err != nil {
-		return err
-	}
-
-	// Handle port mappings: manual (user-specified) vs auto (sequential allocation)
-	if len(server.PortMappings) > 0 && server.PortMappings[0].HostPort > 0 {
-		// Manual mode: user specified ports - validate them
-		if err := models.ValidateManualPorts(server.PortMappings); err != nil {
+	// Hold the name from the uniqueness check until the row exists, so concurrent
+	// creates can't both claim it
+	gss.createMu.Lock()
+	if uniqueName {
+		name, err := gss.UniqueGameserverName(server.Name)
+		if err != nil {
+			gss.createMu.Unlock()
 			return err
 		}
-		// Copy container ports from game template (user only specifies host ports)
-		for i := range server.PortMappings {
-			if i < len(game.PortMappings) {
-				server.PortMappings[i].ContainerPort = game.PortMappings[i].ContainerPort
-			}
-		}
-	} else {
-		// Auto mode: initialize from game template and allocate sequentially
-		server.PortMappings = make([]models.PortMapping, len(game.PortMappings))
-		copy(server.PortMappings, game.PortMappings)
-
-		// Allocate ports for the server
-		if err := gss.allocatePortsForServer(server); err != nil {
-			return err
+		if name != server.Name {
+			log.Info().Str("gameserver_id", server.ID).Str("requested", server.Name).Str("name", name).Msg("Gameserver name taken, using a unique variant")
+			server.Name = name
 		}
 	}
-
-	// Create the gameserver in database
-	if err := gss.db.CreateGameserver(server); err != nil {
+	warnings, err := gss.ValidateGameserver(server)
+	if err == nil {
+		err = gss.db.CreateGameserver(server)
+	}
+	gss.createMu.Unlock()
+	if err != nil {
 		return err
 	}
+	for _, warning := range warnings {
+		log.Warn().Str("gameserver_id", server.ID).Msg(warning)
+	}
 
 	// Create automatic daily backup task
 	backupTask := &models.ScheduledTask{
@@ -118,468 +179,2214 @@ func (gss *GameserverRepository) CreateGameserver(server *models.Gameserver) err
 		log.Info().Str("gameserver_id", server.ID).Msg("Created automatic daily backup task")
 	}
 
+	gss.applyTaskTemplates()
+
 	return nil
 }
 
-// UpdateGameserver updates an existing gameserver
-func (gss *GameserverRepository) UpdateGameserver(server *models.Gameserver) error {
-	// Get existing server to preserve certain fields
-	existing, err := gss.db.GetGameserver(server.ID)
+// uniqueNameSuffix matches a numeric suffix added by UniqueGameserverName
+var uniqueNameSuffix = regexp.MustCompile(`-\d+$`)
+
+// validateResources rejects a CPU set or swap limit the host can't apply before it reaches Docker
+func validateResources(server *models.Gameserver) error {
+	if server.CPUSetCPUs != "" {
+		if err := models.ValidateCPUSet(server.CPUSetCPUs, runtime.NumCPU()); err != nil {
+			return &models.DatabaseError{Op: "validate_resources", Msg: err.Error(), Err: models.ErrInvalidResources}
+		}
+	}
+	if err := models.ValidateSwapMB(server.SwapMB); err != nil {
+		return &models.DatabaseError{Op: "validate_resources", Msg: err.Error(), Err: models.ErrInvalidResources}
+	}
+	return nil
+}
+
+// checkNameAvailable makes sure no other gameserver uses server's name. Container and volume
+// names derive from the server name, so it must be unique.
+func (gss *GameserverRepository) checkNameAvailable(server *models.Gameserver) error {
+	existing, err := gss.db.ListGameservers()
 	if err != nil {
 		return err
 	}
+	for _, other := range existing {
+		if other.ID != server.ID && other.Name == server.Name {
+			return &models.DatabaseError{
+				Op:  "validate_name",
+				Msg: fmt.Sprintf("a gameserver named %s already exists", server.Name),
+				Err: models.ErrNameTaken,
+			}
+		}
+	}
+	return nil
+}
 
-	// Preserve fields that shouldn't be updated via form
-	server.CreatedAt = existing.CreatedAt
-	server.ContainerID = existing.ContainerID
-	server.Status = existing.Status
-	server.UpdatedAt = time.Now()
-
-	// Populate derived fields from game
-	if err := gss.populateGameFields(server); err != nil {
-		return err
+// UniqueGameserverName returns base if no gameserver uses it, otherwise the first free variant
+// base-2, base-3, ... A name that already carries such a suffix is counted on from its stem,
+// so cloning myserver-2 gives myserver-3 rather than myserver-2-2.
+func (gss *GameserverRepository) UniqueGameserverName(base string) (string, error) {
+	servers, err := gss.db.ListGameservers()
+	if err != nil {
+		return "", err
+	}
+	taken := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		taken[server.Name] = true
+	}
+	if !taken[base] {
+		return base, nil
 	}
 
-	return gss.db.UpdateGameserver(server)
+	stem := uniqueNameSuffix.ReplaceAllString(base, "")
+	for n := 2; ; n++ {
+		if name := fmt.Sprintf("%s-%d", stem, n); !taken[name] {
+			return name, nil
+		}
+	}
 }
 
-// populateGameFields fills in derived fields from the game configuration
-func (gss *GameserverRepository) populateGameFields(server *models.Gameserver) error {
-	game, err := gss.db.GetGame(server.GameID)
-	if err != nil {
+// checkAdoptedVolume makes sure an existing volume chosen for a new gameserver holds server
+// data and isn't already the data volume of another gameserver
+func (gss *GameserverRepository) checkAdoptedVolume(server *models.Gameserver) error {
+	if err := gss.checkDataLocationUnused(server, server.DataVolume); err != nil {
 		return err
 	}
-	server.GameType = game.Name
-	server.Image = game.Image
-	server.IconPath = game.IconPath
-	server.MemoryGB = float64(server.MemoryMB) / 1024.0
 
-	// Get volume information
-	volumeName := gss.docker.GetVolumeNameForServer(server)
-	if volumeInfo, err := gss.docker.GetVolumeInfo(volumeName); err == nil {
-		server.VolumeInfo = volumeInfo
+	hasData, err := gss.docker.VolumeHasServerData(server.DataVolume, server.Image)
+	if err != nil {
+		return err
+	}
+	if !hasData {
+		return &models.DatabaseError{
+			Op:  "validate_volume",
+			Msg: fmt.Sprintf("volume %s contains no server data", server.DataVolume),
+		}
 	}
-
 	return nil
 }
 
-// allocatePortsForServer finds available ports for all unassigned port mappings
-func (gss *GameserverRepository) allocatePortsForServer(server *models.Gameserver) error {
-	// Get all currently used ports from existing gameservers
+// checkDataLocationUnused makes sure no other gameserver keeps its data in location
+func (gss *GameserverRepository) checkDataLocationUnused(server *models.Gameserver, location string) error {
 	servers, err := gss.db.ListGameservers()
 	if err != nil {
 		return err
 	}
-
-	usedPorts := make(map[int]bool)
-	for _, existingServer := range servers {
-		// Skip the current server if it's being updated
-		if existingServer.ID == server.ID {
-			continue
-		}
-		for _, portMapping := range existingServer.PortMappings {
-			if portMapping.HostPort > 0 {
-				usedPorts[portMapping.HostPort] = true
+	for _, other := range servers {
+		if other.ID != server.ID && gss.docker.GetVolumeNameForServer(other) == location {
+			return &models.DatabaseError{
+				Op:  "validate_volume",
+				Msg: fmt.Sprintf("volume %s is already used by gameserver %s", location, other.Name),
 			}
 		}
 	}
-
-	// Allocate ports for the server
-	return models.AllocatePortsForServer(server, usedPorts)
+	return nil
 }
 
-// StartGameserver starts a gameserver asynchronously with status tracking
-func (gss *GameserverRepository) StartGameserver(id string) error {
+// MigrateGameserverData moves a stopped gameserver's data to another volume or host path.
+// The data is copied and verified before the server is switched over; the old location is
+// kept untouched so it can be checked and removed by hand.
+func (gss *GameserverRepository) MigrateGameserverData(id, target string) error {
+	unlock, err := gss.lockOperation(id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	server, err := gss.db.GetGameserver(id)
 	if err != nil {
 		return err
 	}
+	if server.Status != models.StatusStopped && server.Status != models.StatusError && server.Status != models.StatusCrashed {
+		return &models.DatabaseError{Op: "migrate_data", Msg: fmt.Sprintf("gameserver %s is %s", server.Name, server.Status), Err: models.ErrServerNotStopped}
+	}
+	if err := gss.populateGameFields(server); err != nil {
+		return err
+	}
 
-	// Check if starting this server would exceed system memory
-	if err := gss.validateSystemMemoryForStart(server); err != nil {
+	source := gss.docker.GetVolumeNameForServer(server)
+	if target == source {
+		return &models.DatabaseError{Op: "migrate_data", Msg: fmt.Sprintf("gameserver %s already keeps its data in %s", server.Name, target)}
+	}
+	if err := gss.checkDataLocationUnused(server, target); err != nil {
 		return err
 	}
 
-	// Populate latest settings from database
-	if err := gss.populateGameFields(server); err != nil {
+	log.Info().Str("gameserver_id", id).Str("source", source).Str("target", target).Msg("Migrating gameserver data")
+	if err := gss.docker.MigrateData(source, target, server.Image); err != nil {
 		return err
 	}
 
-	// Set initial status to pulling_image
-	server.Status = models.StatusPullingImage
+	// The mount is baked into the container, so drop it to have the next start recreate it
+	if server.ContainerID != "" {
+		if err := gss.docker.RemoveContainer(server.ContainerID); err != nil {
+			log.Warn().Err(err).Str("gameserver_id", id).Msg("Failed to remove container after data migration")
+		}
+		server.ContainerID = ""
+	}
+	server.DataVolume = target
 	server.UpdatedAt = time.Now()
 	if err := gss.db.UpdateGameserver(server); err != nil {
 		return err
 	}
 
-	// Start async startup process
-	go gss.performStartup(server)
-
+	log.Info().Str("gameserver_id", id).Str("old_location", source).Msg("Gameserver data migrated, old location kept")
 	return nil
 }
 
-// performStartup handles the actual startup process with status updates
-func (gss *GameserverRepository) performStartup(server *models.Gameserver) {
-	// Helper to update status in database
-	updateStatus := func(status models.GameserverStatus) {
-		server.Status = status
-		server.UpdatedAt = time.Now()
-		if err := gss.db.UpdateGameserver(server); err != nil {
-			log.Error().Err(err).Str("gameserver_id", server.ID).Str("status", string(status)).Msg("Failed to update status during startup")
-		}
+// CloneGameserver creates a copy of a gameserver's configuration under name, defaulting to
+// the source's name suffixed with -copy (made unique if taken). The clone gets fresh host
+// ports, its own data volume and the usual daily backup task. With copyData the source's
+// data is copied into the new volume, which requires the source to be stopped; if the copy
+// fails the clone is deleted again.
+func (gss *GameserverRepository) CloneGameserver(id, name string, copyData bool) (*models.Gameserver, error) {
+	unlock, err := gss.lockOperation(id)
+	if err != nil {
+		return nil, err
 	}
+	defer unlock()
 
-	// Create container with status callback
-	err := gss.docker.CreateContainerWithCallback(server, func(status models.GameserverStatus) {
-		updateStatus(status)
-	})
+	source, err := gss.db.GetGameserver(id)
 	if err != nil {
-		log.Error().Err(err).Str("gameserver_id", server.ID).Msg("Failed to create container")
-		updateStatus(models.StatusError)
-		return
+		return nil, err
 	}
-
-	// Update status to starting container
-	updateStatus(models.StatusStartingContainer)
-
-	// Start the container
-	if err := gss.docker.StartContainer(server.ContainerID); err != nil {
-		log.Error().Err(err).Str("gameserver_id", server.ID).Msg("Failed to start container")
-		updateStatus(models.StatusError)
-		return
+	if copyData && source.Status != models.StatusStopped && source.Status != models.StatusError && source.Status != models.StatusCrashed {
+		return nil, &models.DatabaseError{Op: "clone_gameserver", Msg: fmt.Sprintf("gameserver %s is %s", source.Name, source.Status), Err: models.ErrServerNotStopped}
 	}
 
-	// Update status to waiting for ready
-	updateStatus(models.StatusWaitingReady)
-
-	// Wait for server to be ready
-	gss.waitForReady(server, updateStatus)
-}
-
-// waitForReady polls until the server is responding or times out
-func (gss *GameserverRepository) waitForReady(server *models.Gameserver, updateStatus func(models.GameserverStatus)) {
-	timeout := time.After(5 * time.Minute)
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
-
-	// Get game info for query
-	game, err := gss.db.GetGame(server.GameID)
+	clone := &models.Gameserver{
+		ID:                 models.GenerateID(),
+		Name:               name,
+		GameID:             source.GameID,
+		MemoryMB:           source.MemoryMB,
+		CPUCores:           source.CPUCores,
+		MaxBackups:         source.MaxBackups,
+		StartPriority:      source.StartPriority,
+		BackupOnStop:       source.BackupOnStop,
+		ShmSizeMB:          source.ShmSizeMB,
+		BackupCompression:  source.BackupCompression,
+		BackupExcludes:     source.BackupExcludes,
+		Environment:        source.Environment,
+		EnabledMods:        source.EnabledMods,
+		Volumes:            source.Volumes,
+		Tags:               source.Tags,
+		CommandAllow:       source.CommandAllow,
+		CommandDeny:        source.CommandDeny,
+		Timezone:           source.Timezone,
+		ImageOverride:      source.ImageOverride,
+		ImageTag:           source.ImageTag,
+		BackupDestinations: source.BackupDestinations,
+		DNS:                source.DNS,
+		ExtraHosts:         source.ExtraHosts,
+		RestartOnCrash:     source.RestartOnCrash,
+		MaxRestartAttempts: source.MaxRestartAttempts,
+		CPUSetCPUs:         source.CPUSetCPUs,
+		SwapMB:             source.SwapMB,
+	}
+	if name == "" {
+		clone.Name = source.Name + "-copy"
+		err = gss.CreateGameserverWithUniqueName(clone)
+	} else {
+		err = gss.CreateGameserver(clone)
+	}
 	if err != nil {
-		log.Error().Err(err).Str("gameserver_id", server.ID).Msg("Failed to get game for readiness check")
-		updateStatus(models.StatusRunning) // Mark as running anyway
-		return
+		return nil, err
 	}
-
-	for {
-		select {
-		case <-timeout:
-			// Timeout reached, but container is running - mark as running
-			log.Warn().Str("gameserver_id", server.ID).Msg("Readiness timeout reached, marking as running")
-			updateStatus(models.StatusRunning)
-			return
-
-		case <-ticker.C:
-			// Check if container is still running
-			dockerStatus, err := gss.docker.GetContainerStatus(server.ContainerID)
-			if err != nil || dockerStatus == models.StatusStopped || dockerStatus == models.StatusError {
-				log.Error().Str("gameserver_id", server.ID).Str("docker_status", string(dockerStatus)).Msg("Container stopped during startup")
-				updateStatus(models.StatusError)
-				return
-			}
-
-			// Check if server is responding to queries
-			if gss.queryService != nil && gss.queryService.IsServerReady(server, game) {
-				log.Info().Str("gameserver_id", server.ID).Msg("Server is ready")
-				updateStatus(models.StatusRunning)
-				return
+	log.Info().Str("gameserver_id", clone.ID).Str("source_id", id).Str("name", clone.Name).Msg("Gameserver cloned")
+
+	if copyData {
+		sourceVolume := gss.docker.GetVolumeNameForServer(source)
+		targetVolume := gss.docker.GetVolumeNameForServer(clone)
+		log.Info().Str("gameserver_id", clone.ID).Str("source", sourceVolume).Str("target", targetVolume).Msg("Copying data to cloned gameserver")
+		if err := gss.docker.MigrateData(sourceVolume, targetVolume, clone.Image); err != nil {
+			if delErr := gss.DeleteGameserver(clone.ID); delErr != nil {
+				log.Error().Err(delErr).Str("gameserver_id", clone.ID).Msg("Failed to delete clone after its data copy failed")
 			}
+			return nil, err
 		}
 	}
+
+	return clone, nil
 }
 
-// StopGameserver stops a gameserver and removes its container
-func (gss *GameserverRepository) StopGameserver(id string) error {
-	server, err := gss.db.GetGameserver(id)
+// UpdateGameserver updates an existing gameserver
+func (gss *GameserverRepository) UpdateGameserver(server *models.Gameserver) error {
+	// Get existing server to preserve certain fields
+	existing, err := gss.db.GetGameserver(server.ID)
 	if err != nil {
 		return err
 	}
 
-	// Set status to stopping
-	server.Status = models.StatusStopping
+	gss.invalidateQuery(server.ID)
+
+	// Preserve fields that shouldn't be updated via form
+	server.CreatedAt = existing.CreatedAt
+	server.ContainerID = existing.ContainerID
+	server.Status = existing.Status
+	server.StartupError = existing.StartupError
+	server.DataVolume = existing.DataVolume
+	server.ImageDigest = existing.ImageDigest
+	server.PreviousImageDigest = existing.PreviousImageDigest
+	server.BackupDestinations = existing.BackupDestinations
+	server.CreatedByVersion = existing.CreatedByVersion
+	server.LastBackupResults = existing.LastBackupResults
+	server.LastStopForced = existing.LastStopForced
 	server.UpdatedAt = time.Now()
-	if err := gss.db.UpdateGameserver(server); err != nil {
+
+	// Populate derived fields from game
+	if err := gss.populateGameFields(server); err != nil {
 		return err
 	}
 
-	// Remove container entirely (this stops and removes)
-	if server.ContainerID != "" {
-		if err := gss.docker.RemoveContainer(server.ContainerID); err != nil {
-			return err
+	game, err := gss.db.GetGame(server.GameID)
+	if err != nil {
+		return err
+	}
+	if problems := game.ValidateEnvironment(server.Environment); len(problems) > 0 {
+		return &models.DatabaseError{
+			Op:  "validate_config",
+			Msg: "invalid configuration",
+			Err: &models.ConfigValidationError{Errors: problems},
 		}
-		server.ContainerID = "" // Clear container ID since it's gone
+	}
+	if err := validateResources(server); err != nil {
+		return err
 	}
 
-	server.Status = models.StatusStopped
-	server.UpdatedAt = time.Now()
-	return gss.db.UpdateGameserver(server)
-}
-
-// RestartGameserver restarts a gameserver by stopping and starting it
-func (gss *GameserverRepository) RestartGameserver(id string) error {
-	// Stop first (removes container)
-	if err := gss.StopGameserver(id); err != nil {
+	gss.createMu.Lock()
+	if server.Name != existing.Name {
+		err = gss.checkNameAvailable(server)
+	}
+	if err == nil {
+		err = gss.db.UpdateGameserver(server)
+	}
+	gss.createMu.Unlock()
+	if err != nil {
 		return err
 	}
 
-	// Then start (creates new container)
-	return gss.StartGameserver(id)
+	// Tags may have changed, so bring template-managed tasks up to date
+	gss.applyTaskTemplates()
+	return nil
 }
 
-// SendGameserverCommand sends a command to a running gameserver and returns output
-func (gss *GameserverRepository) SendGameserverCommand(id string, command string) (string, error) {
+// MissingGameDefaults lists the game config var defaults a gameserver's environment doesn't set yet
+func (gss *GameserverRepository) MissingGameDefaults(id string) ([]models.ConfigVar, error) {
 	server, err := gss.db.GetGameserver(id)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	game, err := gss.db.GetGame(server.GameID)
+	if err != nil {
+		return nil, err
+	}
+	return game.MissingConfigDefaults(server.Environment), nil
+}
 
-	if server.ContainerID == "" {
-		return "", &models.DatabaseError{
-			Op:  "send_command",
-			Msg: "gameserver has no container",
-			Err: nil,
-		}
+// ApplyGameDefaults adds the game's config var defaults that are missing from a gameserver's
+// environment, leaving values already set untouched. Takes effect on the next start.
+func (gss *GameserverRepository) ApplyGameDefaults(id string) ([]models.ConfigVar, error) {
+	server, err := gss.db.GetGameserver(id)
+	if err != nil {
+		return nil, err
+	}
+	game, err := gss.db.GetGame(server.GameID)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := game.MissingConfigDefaults(server.Environment)
+	if len(missing) == 0 {
+		return nil, nil
+	}
+	for _, configVar := range missing {
+		server.Environment = append(server.Environment, configVar.Name+"="+configVar.Default)
+	}
+
+	server.UpdatedAt = time.Now()
+	if err := gss.db.UpdateGameserver(server); err != nil {
+		return nil, err
 	}
+	log.Info().Str("gameserver_id", id).Int("added", len(missing)).Msg("Applied game config defaults")
+	return missing, nil
+}
 
+// ApplyLiveConfig pushes live-applicable config changes made since previousEnv to a running
+// gameserver through its console, returning the names of the vars applied. Other changes
+// still need a restart.
+func (gss *GameserverRepository) ApplyLiveConfig(id string, previousEnv []string) ([]string, error) {
+	server, err := gss.GetGameserver(id)
+	if err != nil {
+		return nil, err
+	}
 	if server.Status != models.StatusRunning {
-		return "", &models.DatabaseError{
-			Op:  "send_command",
-			Msg: "gameserver is not running",
+		return nil, nil
+	}
+	game, err := gss.db.GetGame(server.GameID)
+	if err != nil {
+		return nil, err
+	}
+
+	commands, err := game.LiveConfigCommands(previousEnv, server.Environment)
+	if err != nil {
+		return nil, &models.OperationError{Op: "apply_live_config", Msg: "failed to render live commands", Err: err}
+	}
+
+	var applied []string
+	for _, command := range commands {
+		if _, err := gss.SendGameserverCommand(id, command.Command); err != nil {
+			log.Error().Err(err).Str("gameserver_id", id).Str("var", command.Var).Msg("Failed to apply config change live")
+			continue
+		}
+		applied = append(applied, command.Var)
+	}
+	if len(applied) > 0 {
+		log.Info().Str("gameserver_id", id).Strs("vars", applied).Msg("Applied config changes live")
+	}
+	return applied, nil
+}
+
+// rotatedSecretLength is the length of passwords generated by RotateSecret
+const rotatedSecretLength = 24
+
+// RotateSecret replaces a password env var (e.g. RCON_PASSWORD) with a new random value and
+// returns it. Takes effect on the next start.
+func (gss *GameserverRepository) RotateSecret(id, name string) (string, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if !strings.HasSuffix(name, "PASSWORD") {
+		return "", models.ErrSecretNotRotatable
+	}
+
+	server, err := gss.db.GetGameserver(id)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := models.GenerateSecret(rotatedSecretLength)
+	if err != nil {
+		return "", &models.OperationError{Op: "rotate_secret", Msg: "failed to generate secret", Err: err}
+	}
+
+	entry := name + "=" + secret
+	replaced := false
+	for i, env := range server.Environment {
+		if strings.HasPrefix(env, name+"=") {
+			server.Environment[i] = entry
+			replaced = true
+		}
+	}
+	if !replaced {
+		server.Environment = append(server.Environment, entry)
+	}
+
+	server.UpdatedAt = time.Now()
+	if err := gss.db.UpdateGameserver(server); err != nil {
+		return "", err
+	}
+	// Never log the value itself
+	log.Info().Str("gameserver_id", id).Str("var", name).Msg("Rotated gameserver secret")
+	return secret, nil
+}
+
+// ValidateGameserver runs all of CreateGameserver's validation and port allocation without
+// persisting anything, filling in server as it would be created. Returned warnings are advisory.
+func (gss *GameserverRepository) ValidateGameserver(server *models.Gameserver) ([]string, error) {
+	now := time.Now()
+	server.CreatedAt, server.UpdatedAt, server.Status = now, now, models.StatusStopped
+	server.ContainerID = "" // No container created yet
+
+	// Populate derived fields from game
+	if err := gss.populateGameFields(server); err != nil {
+		return nil, err
+	}
+
+	// Get game info for port mappings and validation
+	game, err := gss.db.GetGame(server.GameID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := gss.checkNameAvailable(server); err != nil {
+		return nil, err
+	}
+
+	// Validate required configuration variables and value constraints
+	if problems := game.ValidateEnvironment(server.Environment); len(problems) > 0 {
+		return nil, &models.DatabaseError{
+			Op:  "validate_config",
+			Msg: "invalid configuration",
+			Err: &models.ConfigValidationError{Errors: problems},
+		}
+	}
+	if err := validateResources(server); err != nil {
+		return nil, err
+	}
+
+	// Basic memory validation - ensure minimum requirements
+	if server.MemoryMB < game.MinMemoryMB {
+		return nil, &models.DatabaseError{
+			Op:  "validate_memory",
+			Msg: fmt.Sprintf("memory (%d MB) is below game minimum (%d MB)", server.MemoryMB, game.MinMemoryMB),
 			Err: nil,
 		}
 	}
 
-	return gss.docker.SendCommand(server.ContainerID, command)
+	// CPU guidance is advisory, starved servers still run
+	var warnings []string
+	if warning := game.CPUWarning(server.CPUCores); warning != "" {
+		warnings = append(warnings, warning)
+	}
+
+	// Validate against system memory (only for creation, not updates)
+	if err := gss.validateSystemMemory(server); err != nil {
+		return nil, err
+	}
+
+	// Handle port mappings: manual (user-specified) vs auto (sequential allocation)
+	if len(server.PortMappings) > 0 && server.PortMappings[0].HostPort > 0 {
+		// Manual mode: user specified ports - validate them
+		if err := models.ValidateManualPorts(server.PortMappings); err != nil {
+			return nil, err
+		}
+		usedPorts, err := gss.usedHostPorts(server.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, portMapping := range server.PortMappings {
+			if usedPorts[portMapping.HostPort] {
+				return nil, &models.DatabaseError{
+					Op:  "validate_port",
+					Msg: fmt.Sprintf("port %d is already used by another gameserver", portMapping.HostPort),
+					Err: nil,
+				}
+			}
+		}
+		// Copy container ports from game template (user only specifies host ports)
+		for i := range server.PortMappings {
+			if i < len(game.PortMappings) {
+				server.PortMappings[i].ContainerPort = game.PortMappings[i].ContainerPort
+			}
+		}
+	} else {
+		// Auto mode: initialize from game template and allocate sequentially
+		server.PortMappings = make([]models.PortMapping, len(game.PortMappings))
+		copy(server.PortMappings, game.PortMappings)
+
+		// Allocate ports for the server
+		if err := gss.allocatePortsForServer(server); err != nil {
+			return nil, err
+		}
+	}
+
+	return warnings, nil
 }
 
-// DeleteGameserver deletes a gameserver and all its data
-func (gss *GameserverRepository) DeleteGameserver(id string) error {
+// populateGameFields fills in derived fields from the game configuration
+func (gss *GameserverRepository) populateGameFields(server *models.Gameserver) error {
+	game, err := gss.db.GetGame(server.GameID)
+	if err != nil {
+		return err
+	}
+	server.GameType = game.Name
+	server.GameSlug = game.Slug
+	server.Image = models.WithImageTag(game.Image, server.ImageTag)
+	if server.ImageOverride != "" {
+		server.Image = server.ImageOverride
+	}
+	server.IconPath = game.IconPath
+	server.MemoryGB = float64(server.MemoryMB) / 1024.0
+	server.Sysctls = game.Sysctls
+	server.CapAdd = game.CapAdd
+	server.GameShmSizeMB = game.ShmSizeMB
+	server.StopCommand = game.StopCommand
+	server.StopGrace = time.Duration(game.StopGraceSecs) * time.Second
+	if server.StopGrace <= 0 {
+		server.StopGrace = defaultStopGrace
+	}
+
+	server.GameSecretFiles = game.SecretFiles
+	server.SecretVars = nil
+	for _, configVar := range game.ConfigVars {
+		if configVar.Type == "password" {
+			server.SecretVars = append(server.SecretVars, configVar.Name)
+		}
+	}
+
+	// Get volume information
+	volumeName := gss.docker.GetVolumeNameForServer(server)
+	if volumeInfo, err := gss.docker.GetVolumeInfo(volumeName); err == nil {
+		server.VolumeInfo = volumeInfo
+	}
+
+	return nil
+}
+
+// allocatePortsForServer finds available ports for all unassigned port mappings
+func (gss *GameserverRepository) allocatePortsForServer(server *models.Gameserver) error {
+	usedPorts, err := gss.usedHostPorts(server.ID)
+	if err != nil {
+		return err
+	}
+
+	// Allocate ports for the server
+	return models.AllocatePortsForServer(server, usedPorts)
+}
+
+// usedHostPorts returns the host ports assigned to every gameserver except excludeID
+func (gss *GameserverRepository) usedHostPorts(excludeID string) (map[int]bool, error) {
+	servers, err := gss.db.ListGameservers()
+	if err != nil {
+		return nil, err
+	}
+
+	usedPorts := make(map[int]bool)
+	for _, existingServer := range servers {
+		// Skip the current server if it's being updated
+		if existingServer.ID == excludeID {
+			continue
+		}
+		for _, portMapping := range existingServer.PortMappings {
+			if portMapping.HostPort > 0 {
+				usedPorts[portMapping.HostPort] = true
+			}
+		}
+	}
+	return usedPorts, nil
+}
+
+// lockOperation claims a gameserver for a lifecycle operation (start, stop, restart, delete)
+// so concurrent requests can't interleave container create/remove. Fails with
+// ErrOperationInProgress if one is already running; the returned func releases the claim.
+func (gss *GameserverRepository) lockOperation(id string) (func(), error) {
+	gss.opMu.Lock()
+	defer gss.opMu.Unlock()
+
+	if gss.opLocks[id] {
+		return nil, &models.DatabaseError{
+			Op:  "lock_operation",
+			Msg: fmt.Sprintf("gameserver %s is busy", id),
+			Err: models.ErrOperationInProgress,
+		}
+	}
+	gss.opLocks[id] = true
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			gss.opMu.Lock()
+			delete(gss.opLocks, id)
+			gss.opMu.Unlock()
+		})
+	}, nil
+}
+
+// StartGameserver starts a gameserver asynchronously with status tracking
+func (gss *GameserverRepository) StartGameserver(id string) error {
+	unlock, err := gss.lockOperation(id)
+	if err != nil {
+		return err
+	}
+	if err := gss.startGameserver(id, unlock); err != nil {
+		unlock()
+		return err
+	}
+	return nil
+}
+
+// startGameserver begins startup of a gameserver whose operation lock is held.
+// Once startup is underway the lock is released by the startup goroutine.
+func (gss *GameserverRepository) startGameserver(id string, unlock func()) error {
+	gss.invalidateQuery(id)
+	server, err := gss.db.GetGameserver(id)
+	if err != nil {
+		return err
+	}
+
+	// Check if starting this server would exceed system memory
+	if err := gss.validateSystemMemoryForStart(server); err != nil {
+		return err
+	}
+
+	// Populate latest settings from database
+	if err := gss.populateGameFields(server); err != nil {
+		return err
+	}
+
+	// Queue the start; performStartup moves on to pulling_image once it gets a start slot
+	server.Status = models.StatusQueued
+	server.StartupError = ""
+	server.UpdatedAt = time.Now()
+	if err := gss.db.UpdateGameserver(server); err != nil {
+		return err
+	}
+
+	// Start async startup process
+	go gss.performStartup(server, unlock)
+
+	return nil
+}
+
+// performStartup handles the actual startup process with status updates
+func (gss *GameserverRepository) performStartup(server *models.Gameserver, unlock func()) {
+	defer unlock()
+
+	// Helper to update status in database
+	updateStatus := func(status models.GameserverStatus) {
+		server.Status = status
+		server.UpdatedAt = time.Now()
+		if err := gss.db.UpdateGameserver(server); err != nil {
+			log.Error().Err(err).Str("gameserver_id", server.ID).Str("status", string(status)).Msg("Failed to update status during startup")
+		}
+	}
+
+	// Pulling, creating and starting containers is what overloads Docker and the disk on a
+	// mass start, so hold a start slot until the container is running
+	releaseSlot := gss.acquireStartSlot(server.ID)
+	slotHeld := true
+	defer func() {
+		if slotHeld {
+			releaseSlot()
+		}
+	}()
+	updateStatus(models.StatusPullingImage)
+
+	// Create container with status callback, unless an unchanged persistent one can be reused
+	if !gss.reuseContainer(server) {
+		err := gss.docker.CreateContainerWithCallback(server, func(status models.GameserverStatus) {
+			updateStatus(status)
+		})
+		if err != nil {
+			log.Error().Err(err).Str("gameserver_id", server.ID).Msg("Failed to create container")
+			gss.failStartup(server, "Failed to create container: "+err.Error(), updateStatus)
+			return
+		}
+	}
+
+	// Seed config files before the game process first reads them
+	gss.writeConfigTemplates(server)
+
+	// Update status to starting container
+	updateStatus(models.StatusStartingContainer)
+
+	// Start the container
+	if err := gss.docker.StartContainer(server.ContainerID); err != nil {
+		log.Error().Err(err).Str("gameserver_id", server.ID).Msg("Failed to start container")
+		gss.failStartup(server, "Failed to start container: "+err.Error(), updateStatus)
+		return
+	}
+	gss.recordImageDigest(server)
+
+	// Update status to waiting for ready
+	updateStatus(models.StatusWaitingReady)
+	releaseSlot()
+	slotHeld = false
+
+	// The container is in place, so a server that is slow to become ready can already be stopped
+	// or deleted. From here on server may be stale, so the outcome is written by finishStartup.
+	unlock()
+
+	// Wait for server to be ready
+	gss.waitForReady(server)
+}
+
+// recordImageDigest notes the exact image a server was started with. When it differs from the
+// last start, the old one becomes the rollback target. Saved with the next status update.
+func (gss *GameserverRepository) recordImageDigest(server *models.Gameserver) {
+	digest, err := gss.docker.ContainerImageDigest(server.ContainerID)
+	if err != nil {
+		log.Warn().Err(err).Str("gameserver_id", server.ID).Msg("Failed to read the container's image digest")
+		return
+	}
+	if digest == server.ImageDigest {
+		return
+	}
+	if server.ImageDigest != "" {
+		log.Info().Str("gameserver_id", server.ID).Str("previous", server.ImageDigest).Str("digest", digest).Msg("Gameserver image changed")
+		server.PreviousImageDigest = server.ImageDigest
+	}
+	server.ImageDigest = digest
+}
+
+// RollbackImage pins a gameserver to the image it ran before its current one, restarting it
+// if it's running. Returns the image rolled back to and whether the server was restarted.
+func (gss *GameserverRepository) RollbackImage(id string) (string, bool, error) {
 	server, err := gss.db.GetGameserver(id)
+	if err != nil {
+		return "", false, err
+	}
+	if server.PreviousImageDigest == "" {
+		return "", false, &models.DatabaseError{Op: "rollback_image", Msg: fmt.Sprintf("gameserver %s has only run one image", server.Name), Err: models.ErrNoPreviousImage}
+	}
+
+	image := server.PreviousImageDigest
+	server.ImageOverride = image
+	server.UpdatedAt = time.Now()
+	if err := gss.db.UpdateGameserver(server); err != nil {
+		return "", false, err
+	}
+	log.Info().Str("gameserver_id", id).Str("image", image).Msg("Gameserver image rolled back")
+
+	if server.Status != models.StatusRunning {
+		return image, false, nil
+	}
+	if err := gss.RestartGameserver(id); err != nil {
+		return image, false, err
+	}
+	return image, true, nil
+}
+
+// CheckImageUpdate compares the image a gameserver runs with the latest its registry serves.
+// A server pinned to a digest is compared against the tag it would otherwise follow.
+func (gss *GameserverRepository) CheckImageUpdate(id string) (*models.ImageUpdateCheck, error) {
+	server, err := gss.db.GetGameserver(id)
+	if err != nil {
+		return nil, err
+	}
+	return gss.checkImageUpdate(server)
+}
+
+// UpdateImage pulls the latest version of the image a gameserver follows. A server pinned to a
+// digest is re-pinned to the new one. The server picks up the image on its next start.
+func (gss *GameserverRepository) UpdateImage(id string) (*models.ImageUpdateCheck, error) {
+	server, err := gss.db.GetGameserver(id)
+	if err != nil {
+		return nil, err
+	}
+	check, err := gss.checkImageUpdate(server)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := gss.docker.PullImage(check.Tracked); err != nil {
+		return nil, err
+	}
+	digest, err := gss.docker.LocalImageDigest(check.Tracked)
+	if err != nil {
+		return nil, err
+	}
+	if digest == "" {
+		digest = check.LatestDigest
+	}
+
+	if check.Pinned {
+		// A digest override, e.g. from a rollback, gives way to the tag it was pinned from
+		if models.ImagePinnedDigest(server.ImageOverride) != "" {
+			server.ImageOverride = ""
+		}
+		if models.ImagePinnedDigest(server.ImageTag) != "" {
+			server.ImageTag = digest
+		}
+		server.UpdatedAt = time.Now()
+		if err := gss.db.UpdateGameserver(server); err != nil {
+			return nil, err
+		}
+		gss.populateGameFields(server)
+	}
+
+	log.Info().Str("gameserver_id", id).Str("image", check.Tracked).Str("previous", check.CurrentDigest).Str("digest", digest).Msg("Gameserver image updated")
+	check.Image = server.Image
+	check.CurrentDigest = digest
+	check.UpdateAvailable = false
+	return check, nil
+}
+
+// checkImageUpdate looks up the latest digest of the image server follows
+func (gss *GameserverRepository) checkImageUpdate(server *models.Gameserver) (*models.ImageUpdateCheck, error) {
+	game, err := gss.db.GetGame(server.GameID)
+	if err != nil {
+		return nil, err
+	}
+	gss.populateGameFields(server)
+
+	check := &models.ImageUpdateCheck{Image: server.Image, Tracked: trackedImage(server, game.Image)}
+	if check.LatestDigest, err = gss.docker.RemoteImageDigest(check.Tracked); err != nil {
+		return nil, err
+	}
+	if pinned := models.ImagePinnedDigest(server.Image); pinned != "" {
+		check.Pinned = true
+		check.CurrentDigest = pinned
+	} else if check.CurrentDigest, err = gss.docker.LocalImageDigest(check.Tracked); err != nil {
+		return nil, err
+	}
+	check.UpdateAvailable = check.CurrentDigest != check.LatestDigest
+	return check, nil
+}
+
+// trackedImage returns the tagged image a gameserver follows for updates: its override or the
+// game's image with its tag applied, leaving out any digest it's pinned to
+func trackedImage(server *models.Gameserver, gameImage string) string {
+	if server.ImageOverride != "" && models.ImagePinnedDigest(server.ImageOverride) == "" {
+		return server.ImageOverride
+	}
+	tag := server.ImageTag
+	if models.ImagePinnedDigest(tag) != "" {
+		tag = ""
+	}
+	return models.WithImageTag(gameImage, tag)
+}
+
+// reuseContainer reports whether the server's existing container can be started as-is.
+// Otherwise any leftover container is removed so a new one can take its name.
+func (gss *GameserverRepository) reuseContainer(server *models.Gameserver) bool {
+	if server.ContainerID == "" {
+		return false
+	}
+
+	if gss.persistentContainers {
+		state, err := gss.docker.GetContainerState(server.ContainerID)
+		if err != nil {
+			log.Warn().Err(err).Str("gameserver_id", server.ID).Msg("Persistent container is gone, recreating")
+			server.ContainerID = ""
+			return false
+		}
+		if state.ConfigHash == server.ContainerConfigHash() {
+			log.Info().Str("gameserver_id", server.ID).Str("container_id", server.ContainerID).Msg("Reusing persistent container")
+			return true
+		}
+		log.Info().Str("gameserver_id", server.ID).Msg("Gameserver config changed, recreating container")
+	}
+
+	if err := gss.docker.RemoveContainer(server.ContainerID); err != nil {
+		log.Warn().Err(err).Str("gameserver_id", server.ID).Msg("Failed to remove old container")
+	}
+	server.ContainerID = ""
+	return false
+}
+
+// writeConfigTemplates renders the game's config templates and writes any that don't exist yet
+func (gss *GameserverRepository) writeConfigTemplates(server *models.Gameserver) {
+	game, err := gss.db.GetGame(server.GameID)
+	if err != nil || len(game.ConfigTemplates) == 0 {
+		return
+	}
+
+	files, err := game.RenderConfigTemplates(server.Environment)
+	if err != nil {
+		log.Error().Err(err).Str("gameserver_id", server.ID).Msg("Failed to render config templates")
+		return
+	}
+
+	written, err := gss.docker.WriteMissingFiles(server.ContainerID, files)
+	if err != nil {
+		log.Error().Err(err).Str("gameserver_id", server.ID).Msg("Failed to write config templates")
+		return
+	}
+	if len(written) > 0 {
+		log.Info().Str("gameserver_id", server.ID).Strs("files", written).Msg("Wrote config templates")
+	}
+}
+
+// defaultReadyInterval is how often a starting server is checked for readiness
+const defaultReadyInterval = 2 * time.Second
+
+// waitForReady polls until the server is responding or times out. It runs without the operation
+// lock, so it gives up as soon as the server is stopped, deleted or restarted underneath it.
+func (gss *GameserverRepository) waitForReady(server *models.Gameserver) {
+	updateStatus := func(status models.GameserverStatus) {
+		gss.finishStartup(server, status)
+	}
+
+	var timeout <-chan time.Time // nil (never fires) when the timeout is disabled
+	if gss.startupTimeout > 0 {
+		timeout = time.After(gss.startupTimeout)
+	}
+	ticker := time.NewTicker(gss.readyInterval)
+	defer ticker.Stop()
+
+	// Get game info for query
+	game, err := gss.db.GetGame(server.GameID)
+	if err != nil {
+		log.Error().Err(err).Str("gameserver_id", server.ID).Msg("Failed to get game for readiness check")
+		updateStatus(models.StatusRunning) // Mark as running anyway
+		return
+	}
+
+	for {
+		select {
+		case <-timeout:
+			if !gss.stillStarting(server) {
+				return
+			}
+			if !gss.startupFailOnTimeout {
+				// Container is up, it just never answered a query - assume it's running
+				log.Warn().Str("gameserver_id", server.ID).Msg("Readiness timeout reached, marking as running")
+				updateStatus(models.StatusRunning)
+				return
+			}
+
+			// Stop the container so the failed start isn't later mistaken for a healthy one
+			log.Error().Str("gameserver_id", server.ID).Dur("timeout", gss.startupTimeout).Msg("Server did not become ready in time")
+			reason := fmt.Sprintf("Server did not respond within %s of starting", gss.startupTimeout)
+			gss.failStartup(server, reason, updateStatus)
+			if err := gss.docker.StopContainer(server.ContainerID); err != nil {
+				log.Warn().Err(err).Str("gameserver_id", server.ID).Msg("Failed to stop container after startup timeout")
+			}
+			return
+
+		case <-ticker.C:
+			if !gss.stillStarting(server) {
+				return
+			}
+
+			// Check if container is still running
+			state, err := gss.docker.GetContainerState(server.ContainerID)
+			if err != nil || state.Status == models.StatusStopped || state.Status == models.StatusError {
+				log.Error().Err(err).Str("gameserver_id", server.ID).Msg("Container stopped during startup")
+				gss.failStartup(server, "Container exited during startup", updateStatus)
+				return
+			}
+
+			// A server that keeps crashing on boot will never become ready
+			if gss.isCrashLooping(state) {
+				gss.handleCrashLoop(server, state)
+				updateStatus(models.StatusCrashLooping)
+				return
+			}
+
+			// Check if server is responding to queries
+			if gss.queryService != nil && gss.queryService.IsServerReady(server, game) {
+				log.Info().Str("gameserver_id", server.ID).Msg("Server is ready")
+				updateStatus(models.StatusRunning)
+				return
+			}
+		}
+	}
+}
+
+// stillStarting reports whether server's row is still waiting for this start to become ready,
+// rather than having been stopped, deleted or started again with another container
+func (gss *GameserverRepository) stillStarting(server *models.Gameserver) bool {
+	current, err := gss.db.GetGameserver(server.ID)
+	if err != nil {
+		log.Info().Str("gameserver_id", server.ID).Msg("Gameserver is gone, no longer waiting for it to become ready")
+		return false
+	}
+	if current.Status != models.StatusWaitingReady || current.ContainerID != server.ContainerID {
+		log.Info().Str("gameserver_id", server.ID).Str("status", string(current.Status)).Msg("Gameserver changed during startup, no longer waiting for it to become ready")
+		return false
+	}
+	return true
+}
+
+// finishStartup records the outcome of waiting for a server to become ready. Only the status and
+// startup error are written, and only while the row is still waiting on this start.
+func (gss *GameserverRepository) finishStartup(server *models.Gameserver, status models.GameserverStatus) {
+	server.Status = status
+	server.UpdatedAt = time.Now()
+	updated, err := gss.db.UpdateStartupStatus(server)
+	if err != nil {
+		log.Error().Err(err).Str("gameserver_id", server.ID).Str("status", string(status)).Msg("Failed to update status during startup")
+		return
+	}
+	if !updated {
+		log.Info().Str("gameserver_id", server.ID).Str("status", string(status)).Msg("Gameserver changed during startup, startup result discarded")
+	}
+}
+
+// startupLogLines is how much of the container log is kept when a start fails
+const startupLogLines = 50
+
+// failStartup marks a start as failed, recording why along with the container's last log lines
+func (gss *GameserverRepository) failStartup(server *models.Gameserver, reason string, updateStatus func(models.GameserverStatus)) {
+	server.StartupError = reason
+	if server.ContainerID != "" {
+		if logs := gss.docker.ContainerLogTail(server.ContainerID, startupLogLines); logs != "" {
+			server.StartupError += "\n\n" + logs
+		}
+	}
+	log.Error().Str("gameserver_id", server.ID).Str("reason", reason).Msg("Gameserver failed to start")
+	updateStatus(models.StatusError)
+}
+
+// StopGameserver stops a gameserver and removes its container (or just stops it in persistent mode)
+func (gss *GameserverRepository) StopGameserver(id string) error {
+	unlock, err := gss.lockOperation(id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return gss.stopGameserver(id)
+}
+
+// stopGameserver stops a gameserver whose operation lock is held
+func (gss *GameserverRepository) stopGameserver(id string) error {
+	gss.invalidateQuery(id)
+	server, err := gss.db.GetGameserver(id)
+	if err != nil {
+		return err
+	}
+
+	wasPaused := server.Status == models.StatusPaused
+	wasRunning := server.Status == models.StatusRunning || wasPaused
+
+	// The game's stop command and grace period apply to every stop, including scheduled restarts
+	if err := gss.populateGameFields(server); err != nil {
+		log.Warn().Err(err).Str("gameserver_id", id).Msg("Failed to load game settings, stopping without a stop command")
+	}
+
+	// Set status to stopping
+	server.Status = models.StatusStopping
+	server.UpdatedAt = time.Now()
+	if err := gss.db.UpdateGameserver(server); err != nil {
+		return err
+	}
+
+	// A frozen container can't handle the stop signal, so resume it for a graceful shutdown
+	if wasPaused && server.ContainerID != "" {
+		if err := gss.docker.UnpauseContainer(server.ContainerID); err != nil {
+			log.Warn().Err(err).Str("gameserver_id", id).Msg("Failed to unpause gameserver before stopping")
+		}
+	}
+
+	if wasRunning {
+		gss.sendStopCommand(server)
+	}
+
+	forced := false
+	if server.ContainerID != "" && gss.persistentContainers {
+		// Keep the container, along with its logs, for the next start
+		if err := gss.docker.StopContainer(server.ContainerID); err != nil {
+			gss.resyncAfterFailedStop(server, err)
+			return err
+		}
+		forced = wasRunning && gss.stopWasForced(server.ContainerID)
+		gss.backupOnStop(server, wasRunning)
+	} else if server.ContainerID != "" {
+		// Stop first so the game gets SIGTERM and the stop timeout, and only remove it once it has
+		// exited; if that fails, removing kills it outright
+		if err := gss.docker.StopContainer(server.ContainerID); err != nil {
+			log.Warn().Err(err).Str("gameserver_id", id).Msg("Failed to stop container before removing it")
+			forced = wasRunning
+		} else {
+			forced = wasRunning && gss.stopWasForced(server.ContainerID)
+		}
+		gss.backupOnStop(server, wasRunning)
+		if err := gss.docker.RemoveContainer(server.ContainerID); err != nil {
+			return err
+		}
+		server.ContainerID = "" // Clear container ID since it's gone
+	}
+	if forced {
+		log.Warn().Str("gameserver_id", id).Msg("Gameserver didn't exit within the stop timeout and was killed")
+	}
+
+	server.LastStopForced = forced
+	server.Status = models.StatusStopped
+	server.UpdatedAt = time.Now()
+	return gss.db.UpdateGameserver(server)
+}
+
+// resyncAfterFailedStop moves a server whose stop failed out of stopping, which nothing else
+// would move it on from: back to its container's actual status, or error if that can't be read
+func (gss *GameserverRepository) resyncAfterFailedStop(server *models.Gameserver, stopErr error) {
+	status := models.StatusError
+	if state, err := gss.docker.GetContainerState(server.ContainerID); err == nil && !state.Status.IsTransitional() {
+		status = state.Status
+	}
+	log.Error().Err(stopErr).Str("gameserver_id", server.ID).Str("status", string(status)).Msg("Failed to stop container")
+
+	server.Status = status
+	server.UpdatedAt = time.Now()
+	if err := gss.db.UpdateGameserver(server); err != nil {
+		log.Error().Err(err).Str("gameserver_id", server.ID).Msg("Failed to update status after a failed stop")
+	}
+}
+
+// stopWasForced reports whether a just-stopped container had to be killed after the stop timeout
+func (gss *GameserverRepository) stopWasForced(containerID string) bool {
+	state, err := gss.docker.GetContainerState(containerID)
+	if err != nil {
+		return false
+	}
+	return state.ForceKilled()
+}
+
+// backupOnStop backs up a server that was just stopped, if it asks for that. It runs after the
+// game has exited so the files are consistent, but before the container is removed since the
+// backup is taken through it. A failed backup is logged and doesn't stop the stop.
+func (gss *GameserverRepository) backupOnStop(server *models.Gameserver, wasRunning bool) {
+	if !server.BackupOnStop || !wasRunning {
+		return
+	}
+	log.Info().Str("gameserver_id", server.ID).Msg("Backing up gameserver on stop")
+	if err := gss.CreateGameserverBackup(server.ID); err != nil {
+		log.Error().Err(err).Str("gameserver_id", server.ID).Msg("Backup on stop failed")
+	}
+}
+
+// defaultStopGrace is how long a server gets to exit after its stop command when the game doesn't say
+const defaultStopGrace = 30 * time.Second
+
+// sendStopCommand sends the game's stop command, if it has one, and waits up to the game's
+// grace period for the server to exit on its own, e.g. after saving the world
+func (gss *GameserverRepository) sendStopCommand(server *models.Gameserver) {
+	if server.StopCommand == "" || server.ContainerID == "" {
+		return
+	}
+
+	log.Info().Str("gameserver_id", server.ID).Str("command", server.StopCommand).Msg("Sending stop command")
+	if _, err := gss.docker.SendCommand(server.ContainerID, server.StopCommand); err != nil {
+		log.Warn().Err(err).Str("gameserver_id", server.ID).Msg("Failed to send stop command")
+		return
+	}
+
+	deadline := time.Now().Add(server.StopGrace)
+	for time.Now().Before(deadline) {
+		state, err := gss.docker.GetContainerState(server.ContainerID)
+		if err != nil || state.Status != models.StatusRunning {
+			return
+		}
+		time.Sleep(time.Second)
+	}
+	log.Warn().Str("gameserver_id", server.ID).Dur("grace", server.StopGrace).Msg("Server still running after stop command, stopping container")
+}
+
+// PauseGameserver freezes a running gameserver's container, freeing its CPU while keeping its state in memory
+func (gss *GameserverRepository) PauseGameserver(id string) error {
+	return gss.setPaused(id, true)
+}
+
+// UnpauseGameserver resumes a paused gameserver
+func (gss *GameserverRepository) UnpauseGameserver(id string) error {
+	return gss.setPaused(id, false)
+}
+
+// setPaused pauses or unpauses a gameserver's container and records the resulting status
+func (gss *GameserverRepository) setPaused(id string, pause bool) error {
+	unlock, err := gss.lockOperation(id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	server, err := gss.db.GetGameserver(id)
+	if err != nil {
+		return err
+	}
+
+	op, from, to := "unpause", models.StatusPaused, models.StatusRunning
+	if pause {
+		op, from, to = "pause", models.StatusRunning, models.StatusPaused
+	}
+	if server.ContainerID == "" || server.Status != from {
+		return &models.DatabaseError{
+			Op:  op,
+			Msg: fmt.Sprintf("gameserver %s is %s, not %s", id, server.Status, from),
+			Err: nil,
+		}
+	}
+
+	if pause {
+		err = gss.docker.PauseContainer(server.ContainerID)
+	} else {
+		err = gss.docker.UnpauseContainer(server.ContainerID)
+	}
+	if err != nil {
+		return err
+	}
+
+	server.Status = to
+	server.UpdatedAt = time.Now()
+	return gss.db.UpdateGameserver(server)
+}
+
+// RestartGameserver restarts a gameserver by stopping and starting it
+func (gss *GameserverRepository) RestartGameserver(id string) error {
+	// Hold the lock across both halves so nothing can slip in between
+	unlock, err := gss.lockOperation(id)
+	if err != nil {
+		return err
+	}
+
+	// Stop first (removes the container unless persistent)
+	if err := gss.stopGameserver(id); err != nil {
+		unlock()
+		return err
+	}
+
+	// Then start (creates a new container if needed)
+	if err := gss.startGameserver(id, unlock); err != nil {
+		unlock()
+		return err
+	}
+	return nil
+}
+
+// SendGameserverCommand sends a command to a running gameserver and returns output
+func (gss *GameserverRepository) SendGameserverCommand(id string, command string) (string, error) {
+	server, err := gss.db.GetGameserver(id)
+	if err != nil {
+		return "", err
+	}
+
+	if server.ContainerID == "" {
+		return "", &models.DatabaseError{
+			Op:  "send_command",
+			Msg: "gameserver has no container",
+			Err: nil,
+		}
+	}
+
+	if server.Status != models.StatusRunning {
+		return "", &models.DatabaseError{
+			Op:  "send_command",
+			Msg: "gameserver is not running",
+			Err: nil,
+		}
+	}
+
+	if err := server.CheckCommand(command); err != nil {
+		log.Warn().Str("gameserver_id", id).Str("command", command).Msg("Console command blocked by policy")
+		return "", err
+	}
+
+	return gss.docker.SendCommand(server.ContainerID, command)
+}
+
+// groupByStartPriority groups servers by StartPriority, returning groups in ascending priority order
+func groupByStartPriority(servers []*models.Gameserver) [][]*models.Gameserver {
+	byPriority := make(map[int][]*models.Gameserver)
+	var priorities []int
+	for _, server := range servers {
+		if _, seen := byPriority[server.StartPriority]; !seen {
+			priorities = append(priorities, server.StartPriority)
+		}
+		byPriority[server.StartPriority] = append(byPriority[server.StartPriority], server)
+	}
+	sort.Ints(priorities)
+
+	groups := make([][]*models.Gameserver, 0, len(priorities))
+	for _, priority := range priorities {
+		groups = append(groups, byPriority[priority])
+	}
+	return groups
+}
+
+// StartAllGameservers starts every stopped gameserver, one priority group at a time.
+// Each group must finish starting before the next begins, so backends come up before
+// the servers that depend on them.
+func (gss *GameserverRepository) StartAllGameservers() error {
+	servers, err := gss.db.ListGameservers()
+	if err != nil {
+		return err
+	}
+	gss.startInPriorityOrder(servers)
+	return nil
+}
+
+// startInPriorityOrder starts the stopped servers among servers, waiting for each priority group to settle
+func (gss *GameserverRepository) startInPriorityOrder(servers []*models.Gameserver) {
+	for _, group := range groupByStartPriority(servers) {
+		var started []string
+		for _, server := range group {
+			if server.Status == models.StatusRunning || server.Status == models.StatusPaused || server.Status.IsTransitional() {
+				continue
+			}
+			if err := gss.StartGameserver(server.ID); err != nil {
+				log.Error().Err(err).Str("gameserver_id", server.ID).Msg("Failed to start gameserver during start-all")
+				continue
+			}
+			started = append(started, server.ID)
+		}
+		gss.waitForSettled(started, 5*time.Minute)
+	}
+}
+
+// StopAllGameservers stops every running gameserver in reverse priority order
+func (gss *GameserverRepository) StopAllGameservers() error {
+	servers, err := gss.db.ListGameservers()
+	if err != nil {
+		return err
+	}
+	gss.stopInPriorityOrder(servers)
+	return nil
+}
+
+// stopInPriorityOrder stops the running servers among servers, highest priority group first
+func (gss *GameserverRepository) stopInPriorityOrder(servers []*models.Gameserver) {
+	groups := groupByStartPriority(servers)
+	for i := len(groups) - 1; i >= 0; i-- {
+		for _, server := range groups[i] {
+			if server.ContainerID == "" || server.Status == models.StatusStopped || server.Status.IsTransitional() {
+				continue
+			}
+			if err := gss.StopGameserver(server.ID); err != nil {
+				log.Error().Err(err).Str("gameserver_id", server.ID).Msg("Failed to stop gameserver during stop-all")
+			}
+		}
+	}
+}
+
+// waitForSettled blocks until none of the given servers are in a transitional state or the timeout passes
+func (gss *GameserverRepository) waitForSettled(ids []string, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for len(ids) > 0 && time.Now().Before(deadline) {
+		var pending []string
+		for _, id := range ids {
+			server, err := gss.db.GetGameserver(id)
+			if err == nil && server.Status.IsTransitional() {
+				pending = append(pending, id)
+			}
+		}
+		ids = pending
+		if len(ids) > 0 {
+			time.Sleep(2 * time.Second)
+		}
+	}
+}
+
+// DeleteGameserver deletes a gameserver and all its data
+func (gss *GameserverRepository) DeleteGameserver(id string) error {
+	unlock, err := gss.lockOperation(id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	server, err := gss.db.GetGameserver(id)
+	if err != nil {
+		return err
+	}
+
+	gss.invalidateQuery(id)
+
+	// Set status to deleting
+	server.Status = models.StatusDeleting
+	server.UpdatedAt = time.Now()
+	if err := gss.db.UpdateGameserver(server); err != nil {
+		return err
+	}
+
+	// Remove container if it exists
+	if server.ContainerID != "" {
+		gss.docker.RemoveContainer(server.ContainerID)
+	}
+
+	// Remove the auto-managed volume (this will delete all data!). Host directories are
+	// left for the admin to remove.
+	volumeName := gss.docker.GetVolumeNameForServer(server)
+	if models.IsHostPath(volumeName) {
+		log.Info().Str("path", volumeName).Msg("Leaving host data directory in place")
+	} else if err := gss.docker.RemoveVolume(volumeName); err != nil {
+		log.Warn().Err(err).Str("volume", volumeName).Msg("Failed to remove volume, may not exist")
+	}
+
+	if err := gss.db.RemoveGameserverFromGroups(id); err != nil {
+		log.Warn().Err(err).Str("gameserver_id", id).Msg("Failed to remove gameserver from its groups")
+	}
+	if err := gss.db.DeleteServerNotes(id); err != nil {
+		log.Warn().Err(err).Str("gameserver_id", id).Msg("Failed to delete gameserver notes")
+	}
+	if err := gss.db.DeleteGameserverMetrics(id); err != nil {
+		log.Warn().Err(err).Str("gameserver_id", id).Msg("Failed to delete gameserver metrics")
+	}
+
+	return gss.db.DeleteGameserver(id)
+}
+
+// invalidateQuery drops a gameserver's cached query result after it changes
+func (gss *GameserverRepository) invalidateQuery(id string) {
+	if gss.queryService != nil {
+		gss.queryService.Invalidate(id)
+	}
+}
+
+// syncStatus synchronizes the gameserver status with Docker container status
+func (gss *GameserverRepository) syncStatus(server *models.Gameserver) {
+	// Don't sync if in a transitional state (startup/shutdown goroutine controls status)
+	if server.Status.IsTransitional() {
+		return
+	}
+
+	if server.ContainerID == "" {
+		return
+	}
+
+	state, err := gss.docker.GetContainerState(server.ContainerID)
+	if err != nil {
+		return
+	}
+	server.RestartCount = state.RestartCount
+
+	status := state.Status
+	if gss.isCrashLooping(state) {
+		if server.Status != models.StatusCrashLooping {
+			gss.handleCrashLoop(server, state)
+		}
+		status = models.StatusCrashLooping
+	} else if server.Status == models.StatusCrashLooping && status == models.StatusStopped {
+		// Keep surfacing the crash loop after it was broken by stopping the container
+		return
+	} else if status == models.StatusStopped && state.Crashed() && (server.Status == models.StatusRunning || server.Status == models.StatusCrashed) {
+		// The game exited with an error on its own; panel stops mark the server stopped first
+		status = models.StatusCrashed
+	} else if server.Status == models.StatusError && server.StartupError != "" && status == models.StatusStopped {
+		// Keep surfacing a failed start until the server is started again
+		return
+	}
+
+	if server.Status != status {
+		server.Status, server.UpdatedAt = status, time.Now()
+		gss.db.UpdateGameserver(server)
+	}
+}
+
+// isCrashLooping reports whether a container has restarted repeatedly without staying up
+func (gss *GameserverRepository) isCrashLooping(state *models.ContainerState) bool {
+	if gss.crashLoopRestarts <= 0 || state.RestartCount < gss.crashLoopRestarts {
+		return false
+	}
+	if state.Status != models.StatusRunning && state.Status != models.StatusStartingContainer {
+		return false
+	}
+	return state.StartedAt.IsZero() || time.Since(state.StartedAt) < gss.crashLoopWindow
+}
+
+// handleCrashLoop logs a detected crash loop and optionally stops the container to break it
+func (gss *GameserverRepository) handleCrashLoop(server *models.Gameserver, state *models.ContainerState) {
+	log.Warn().Str("gameserver_id", server.ID).Int("restart_count", state.RestartCount).Msg("Gameserver is crash looping")
+
+	if !gss.stopCrashLooping {
+		return
+	}
+	if err := gss.docker.StopContainer(server.ContainerID); err != nil {
+		log.Error().Err(err).Str("gameserver_id", server.ID).Msg("Failed to stop crash-looping container")
+		return
+	}
+	state.Status = models.StatusStopped
+}
+
+// GetGameserver retrieves a gameserver with populated fields and synced status
+func (gss *GameserverRepository) GetGameserver(id string) (*models.Gameserver, error) {
+	server, err := gss.db.GetGameserver(id)
+	if err != nil {
+		return nil, err
+	}
+	gss.populateGameFields(server)
+	gss.syncStatus(server)
+	return server, nil
+}
+
+// InspectGameserver returns a gameserver's record alongside Docker's inspect output for its
+// container, with secrets masked in both. A missing container is reported, not returned as an
+// error; other Docker failures are returned.
+func (gss *GameserverRepository) InspectGameserver(id string) (*models.GameserverInspection, error) {
+	server, err := gss.GetGameserver(id)
+	if err != nil {
+		return nil, err
+	}
+	server.Environment = models.MaskSecretEnv(server.Environment, server.SecretVars)
+
+	inspection := &models.GameserverInspection{Gameserver: server}
+	if server.ContainerID == "" {
+		inspection.ContainerError = "no container"
+		return inspection, nil
+	}
+	container, err := gss.docker.InspectContainer(server.ContainerID, server.SecretVars)
+	if errors.Is(err, models.ErrContainerNotFound) {
+		inspection.ContainerError = err.Error()
+		return inspection, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	inspection.Container = container
+	return inspection, nil
+}
+
+// ListGameservers retrieves all gameservers with populated fields and synced status
+func (gss *GameserverRepository) ListGameservers() ([]*models.Gameserver, error) {
+	servers, err := gss.db.ListGameservers()
+	if err != nil {
+		return nil, err
+	}
+	for _, server := range servers {
+		gss.populateGameFields(server)
+		gss.syncStatus(server)
+	}
+	return servers, nil
+}
+
+// ListGameserversPage returns one page of gameservers matching query by name or game name.
+// Only servers on the page are populated and have their status synced with Docker.
+func (gss *GameserverRepository) ListGameserversPage(query string, page, perPage int) (*models.GameserverPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	servers, total, err := gss.db.ListGameserversPaged(query, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, err
+	}
+	for _, server := range servers {
+		gss.populateGameFields(server)
+		gss.syncStatus(server)
+	}
+	return &models.GameserverPage{Gameservers: servers, Query: query, Page: page, PerPage: perPage, Total: total}, nil
+}
+
+// StreamGameserverLogs returns a followed stream of gameserver logs starting where opts says
+func (gss *GameserverRepository) StreamGameserverLogs(id string, opts models.LogStreamOptions) (io.ReadCloser, error) {
+	server, err := gss.db.GetGameserver(id)
+	if err != nil {
+		return nil, err
+	}
+	if server.ContainerID == "" {
+		return nil, &models.DatabaseError{Op: "stream_logs", Msg: "container not created yet", Err: nil}
+	}
+	return gss.docker.StreamContainerLogs(server.ContainerID, opts)
+}
+
+// StreamGameserverStats returns a stream of gameserver statistics
+func (gss *GameserverRepository) StreamGameserverStats(id string) (io.ReadCloser, error) {
+	server, err := gss.db.GetGameserver(id)
+	if err != nil {
+		return nil, err
+	}
+	if server.ContainerID == "" {
+		return nil, &models.DatabaseError{Op: "stream_stats", Msg: "container not created yet", Err: nil}
+	}
+	return gss.docker.StreamContainerStats(server.ContainerID)
+}
+
+// ListGames returns all available games
+func (gss *GameserverRepository) ListGames() ([]*models.Game, error) {
+	return gss.db.ListGames()
+}
+
+// GetGame returns a specific game by ID
+func (gss *GameserverRepository) GetGame(id string) (*models.Game, error) {
+	return gss.db.GetGame(id)
+}
+
+// CreateGame creates a new game configuration
+func (gss *GameserverRepository) CreateGame(game *models.Game) error {
+	now := time.Now()
+	game.CreatedAt, game.UpdatedAt = now, now
+	return gss.db.CreateGame(game)
+}
+
+// UpdateGame updates an existing game configuration
+func (gss *GameserverRepository) UpdateGame(game *models.Game) error {
+	game.UpdatedAt = time.Now()
+	return gss.db.UpdateGame(game)
+}
+
+// imageRepoInvalidChars matches characters not allowed in an image repository name
+var imageRepoInvalidChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// BuildGameImage builds a custom image for a game from a Dockerfile and optional context
+// archive, and makes it the game's image. Servers pick it up when their containers are next
+// created. Returns the new image tag and the build output.
+func (gss *GameserverRepository) BuildGameImage(gameID, dockerfile string, contextArchive io.Reader) (string, string, error) {
+	game, err := gss.db.GetGame(gameID)
+	if err != nil {
+		return "", "", err
+	}
+
+	repo := strings.Trim(imageRepoInvalidChars.ReplaceAllString(strings.ToLower(game.ID), "-"), "-._")
+	tag := fmt.Sprintf("gameservers-custom/%s:%s", repo, time.Now().Format("20060102-150405"))
+	output, err := gss.docker.BuildImage(tag, dockerfile, contextArchive)
+	if err != nil {
+		return "", output, err
+	}
+
+	// Keep reseeding from resetting the game back to the stock image
+	previous := game.Image
+	game.Image = tag
+	game.Customized = true
+	if err := gss.UpdateGame(game); err != nil {
+		return "", output, err
+	}
+	log.Info().Str("game_id", gameID).Str("image", tag).Str("previous_image", previous).Msg("Game switched to custom image")
+	return tag, output, nil
+}
+
+// ReseedGames adds new built-in games and refreshes the ones that haven't been customized
+func (gss *GameserverRepository) ReseedGames() (*models.ReseedResult, error) {
+	result, err := gss.db.ReseedGames()
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Strs("added", result.Added).Strs("updated", result.Updated).Strs("skipped", result.Skipped).Msg("Reseeded built-in games")
+	return result, nil
+}
+
+// DeleteGame deletes a game if no gameservers are using it
+func (gss *GameserverRepository) DeleteGame(id string) error {
+	return gss.db.DeleteGame(id)
+}
+
+// ListMods returns all available mods
+func (gss *GameserverRepository) ListMods() ([]*models.Mod, error) {
+	return gss.db.ListMods()
+}
+
+// GetModsForGame returns all mods available for a specific game
+func (gss *GameserverRepository) GetModsForGame(gameID string) ([]*models.Mod, error) {
+	return gss.db.GetModsForGame(gameID)
+}
+
+// SaveModsForGame syncs mods for a game (deletes old, creates new)
+func (gss *GameserverRepository) SaveModsForGame(gameID string, mods []*models.Mod) error {
+	// Delete existing mods for this game
+	if err := gss.db.DeleteModsForGame(gameID); err != nil {
+		return err
+	}
+
+	// Create new mods
+	for _, mod := range mods {
+		mod.GameID = gameID
+		if err := gss.db.CreateMod(mod); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CountGameserversByGameID returns the count of gameservers using a specific game
+func (gss *GameserverRepository) CountGameserversByGameID(gameID string) (int64, error) {
+	return gss.db.CountGameserversByGameID(gameID)
+}
+
+// Scheduled Task Service Operations
+
+// CreateScheduledTask creates a new scheduled task
+func (gss *GameserverRepository) CreateScheduledTask(task *models.ScheduledTask) error {
+	now := time.Now()
+	task.CreatedAt, task.UpdatedAt = now, now
+	task.ID = models.GenerateID()
+
+	// Validate and calculate initial next run time
+	schedule, err := parseCronSchedule(task.CronSchedule)
+	if err != nil {
+		return err
+	}
+	nextRun := schedule.Next(now)
+	task.NextRun = &nextRun
+
+	return gss.db.CreateScheduledTask(task)
+}
+
+// parseCronSchedule parses a standard five-field cron expression (or a descriptor such as
+// @daily), rejecting ones that never fire, e.g. "0 0 30 2 *", since those would leave a task
+// without a next run
+func parseCronSchedule(cronSchedule string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(cronSchedule)
+	if err != nil {
+		return nil, &models.DatabaseError{
+			Op:  "parse_cron",
+			Msg: fmt.Sprintf("invalid cron schedule %q: %s", cronSchedule, err),
+			Err: models.ErrInvalidCronSchedule,
+		}
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return nil, &models.DatabaseError{
+			Op:  "parse_cron",
+			Msg: fmt.Sprintf("cron schedule %q never runs", cronSchedule),
+			Err: models.ErrInvalidCronSchedule,
+		}
+	}
+	return schedule, nil
+}
+
+// PreviewCronSchedule returns the next count run times for a cron schedule
+func (gss *GameserverRepository) PreviewCronSchedule(cronSchedule string, count int) ([]time.Time, error) {
+	schedule, err := parseCronSchedule(cronSchedule)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]time.Time, 0, count)
+	next := time.Now()
+	for i := 0; i < count; i++ {
+		next = schedule.Next(next)
+		if next.IsZero() {
+			break // Schedule never fires again
+		}
+		runs = append(runs, next)
+	}
+	return runs, nil
+}
+
+// GetScheduledTask retrieves a scheduled task by ID
+func (gss *GameserverRepository) GetScheduledTask(id string) (*models.ScheduledTask, error) {
+	return gss.db.GetScheduledTask(id)
+}
+
+// UpdateScheduledTask updates an existing scheduled task
+func (gss *GameserverRepository) UpdateScheduledTask(task *models.ScheduledTask) error {
+	if _, err := parseCronSchedule(task.CronSchedule); err != nil {
+		return err
+	}
+
+	task.UpdatedAt = time.Now()
+	// Clear next run time and pending retries so scheduler will recalculate it
+	task.NextRun = nil
+	task.RetryCount = 0
+	return gss.db.UpdateScheduledTask(task)
+}
+
+// DeleteScheduledTask deletes a scheduled task
+func (gss *GameserverRepository) DeleteScheduledTask(id string) error {
+	return gss.db.DeleteScheduledTask(id)
+}
+
+// ListTaskExecutions returns a gameserver's scheduled task with its most recent runs, newest first
+func (gss *GameserverRepository) ListTaskExecutions(gameserverID, taskID string, limit int) (*models.ScheduledTask, []*models.TaskExecution, error) {
+	task, err := gss.db.GetScheduledTask(taskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if task.GameserverID != gameserverID {
+		return nil, nil, &models.DatabaseError{Op: "list_task_executions", Msg: fmt.Sprintf("scheduled task %s not found", taskID), Err: nil}
+	}
+	executions, err := gss.db.ListTaskExecutionsForTask(taskID, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return task, executions, nil
+}
+
+// ListScheduledTasksForGameserver retrieves all scheduled tasks for a gameserver
+func (gss *GameserverRepository) ListScheduledTasksForGameserver(gameserverID string) ([]*models.ScheduledTask, error) {
+	return gss.db.ListScheduledTasksForGameserver(gameserverID)
+}
+
+// Task Template Service Operations
+
+// ListTaskTemplates returns all task templates with the number of gameservers each matches
+func (gss *GameserverRepository) ListTaskTemplates() ([]*models.TaskTemplate, error) {
+	templates, err := gss.db.ListTaskTemplates()
+	if err != nil {
+		return nil, err
+	}
+	servers, err := gss.db.ListGameservers()
+	if err != nil {
+		return nil, err
+	}
+	for _, template := range templates {
+		for _, server := range servers {
+			if server.HasTag(template.Tag) {
+				template.MatchCount++
+			}
+		}
+	}
+	return templates, nil
+}
+
+// GetTaskTemplate retrieves a task template by ID
+func (gss *GameserverRepository) GetTaskTemplate(id string) (*models.TaskTemplate, error) {
+	return gss.db.GetTaskTemplate(id)
+}
+
+// CreateTaskTemplate creates a task template and applies it to every matching gameserver
+func (gss *GameserverRepository) CreateTaskTemplate(template *models.TaskTemplate) error {
+	if _, err := parseCronSchedule(template.CronSchedule); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	template.ID = models.GenerateID()
+	template.CreatedAt, template.UpdatedAt = now, now
+	if err := gss.db.CreateTaskTemplate(template); err != nil {
+		return err
+	}
+	return gss.ApplyTaskTemplate(template.ID)
+}
+
+// UpdateTaskTemplate updates a task template and re-applies it
+func (gss *GameserverRepository) UpdateTaskTemplate(template *models.TaskTemplate) error {
+	if _, err := parseCronSchedule(template.CronSchedule); err != nil {
+		return err
+	}
+
+	template.UpdatedAt = time.Now()
+	if err := gss.db.UpdateTaskTemplate(template); err != nil {
+		return err
+	}
+	return gss.ApplyTaskTemplate(template.ID)
+}
+
+// DeleteTaskTemplate deletes a task template along with the tasks it created
+func (gss *GameserverRepository) DeleteTaskTemplate(id string) error {
+	tasks, err := gss.db.ListScheduledTasksForTemplate(id)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		if err := gss.db.DeleteScheduledTask(task.ID); err != nil {
+			return err
+		}
+	}
+	return gss.db.DeleteTaskTemplate(id)
+}
+
+// ApplyTaskTemplate brings the template's tasks in line with the gameservers carrying its tag:
+// missing tasks are created, existing ones updated, and tasks on servers that lost the tag removed.
+// Status and run history of existing tasks are kept, so a task disabled by hand stays disabled.
+func (gss *GameserverRepository) ApplyTaskTemplate(id string) error {
+	template, err := gss.db.GetTaskTemplate(id)
+	if err != nil {
+		return err
+	}
+	servers, err := gss.db.ListGameservers()
 	if err != nil {
 		return err
 	}
-
-	// Set status to deleting
-	server.Status = models.StatusDeleting
-	server.UpdatedAt = time.Now()
-	if err := gss.db.UpdateGameserver(server); err != nil {
+	tasks, err := gss.db.ListScheduledTasksForTemplate(id)
+	if err != nil {
 		return err
 	}
 
-	// Remove container if it exists
-	if server.ContainerID != "" {
-		gss.docker.RemoveContainer(server.ContainerID)
+	existing := make(map[string]*models.ScheduledTask, len(tasks))
+	for _, task := range tasks {
+		existing[task.GameserverID] = task
 	}
 
-	// Remove the auto-managed volume (this will delete all data!)
-	volumeName := gss.docker.GetVolumeNameForServer(server)
-	if err := gss.docker.RemoveVolume(volumeName); err != nil {
-		log.Warn().Err(err).Str("volume", volumeName).Msg("Failed to remove volume, may not exist")
+	var created, updated, removed int
+	for _, server := range servers {
+		task, ok := existing[server.ID]
+		delete(existing, server.ID)
+
+		switch {
+		case !server.HasTag(template.Tag):
+			if ok {
+				if err := gss.db.DeleteScheduledTask(task.ID); err != nil {
+					return err
+				}
+				removed++
+			}
+		case !ok:
+			task = &models.ScheduledTask{
+				GameserverID: server.ID,
+				Name:         template.Name,
+				Type:         template.Type,
+				Command:      template.Command,
+				Status:       models.TaskStatusActive,
+				CronSchedule: template.CronSchedule,
+				TemplateID:   template.ID,
+			}
+			if err := gss.CreateScheduledTask(task); err != nil {
+				return err
+			}
+			created++
+		case task.Name != template.Name || task.Type != template.Type || task.Command != template.Command || task.CronSchedule != template.CronSchedule:
+			task.Name, task.Type, task.Command, task.CronSchedule = template.Name, template.Type, template.Command, template.CronSchedule
+			if err := gss.UpdateScheduledTask(task); err != nil {
+				return err
+			}
+			updated++
+		}
 	}
 
-	return gss.db.DeleteGameserver(id)
-}
-
-// syncStatus synchronizes the gameserver status with Docker container status
-func (gss *GameserverRepository) syncStatus(server *models.Gameserver) {
-	// Don't sync if in a transitional state (startup/shutdown goroutine controls status)
-	if server.Status.IsTransitional() {
-		return
+	// Tasks left over belong to gameservers that no longer exist
+	for _, task := range existing {
+		if err := gss.db.DeleteScheduledTask(task.ID); err != nil {
+			return err
+		}
+		removed++
 	}
 
-	if server.ContainerID != "" {
-		if dockerStatus, err := gss.docker.GetContainerStatus(server.ContainerID); err == nil && server.Status != dockerStatus {
-			server.Status, server.UpdatedAt = dockerStatus, time.Now()
-			gss.db.UpdateGameserver(server)
-		}
+	if created+updated+removed > 0 {
+		log.Info().Str("template_id", id).Str("tag", template.Tag).Int("created", created).Int("updated", updated).Int("removed", removed).Msg("Applied task template")
 	}
+	return nil
 }
 
-// GetGameserver retrieves a gameserver with populated fields and synced status
-func (gss *GameserverRepository) GetGameserver(id string) (*models.Gameserver, error) {
-	server, err := gss.db.GetGameserver(id)
+// applyTaskTemplates re-applies every task template, e.g. after a gameserver's tags change
+func (gss *GameserverRepository) applyTaskTemplates() {
+	templates, err := gss.db.ListTaskTemplates()
 	if err != nil {
-		return nil, err
+		log.Error().Err(err).Msg("Failed to list task templates")
+		return
+	}
+	for _, template := range templates {
+		if err := gss.ApplyTaskTemplate(template.ID); err != nil {
+			log.Error().Err(err).Str("template_id", template.ID).Msg("Failed to apply task template")
+		}
 	}
-	gss.populateGameFields(server)
-	gss.syncStatus(server)
-	return server, nil
 }
 
-// ListGameservers retrieves all gameservers with populated fields and synced status
-func (gss *GameserverRepository) ListGameservers() ([]*models.Gameserver, error) {
-	servers, err := gss.db.ListGameservers()
+// ListServerGroups retrieves all server groups with their members
+func (gss *GameserverRepository) ListServerGroups() ([]*models.ServerGroup, error) {
+	groups, err := gss.db.ListServerGroups()
 	if err != nil {
 		return nil, err
 	}
-	for _, server := range servers {
-		gss.populateGameFields(server)
-		gss.syncStatus(server)
+	for _, group := range groups {
+		if group.Members, err = gss.serverGroupMembers(group.ID); err != nil {
+			return nil, err
+		}
 	}
-	return servers, nil
+	return groups, nil
 }
 
-// StreamGameserverLogs returns a stream of gameserver logs
-func (gss *GameserverRepository) StreamGameserverLogs(id string) (io.ReadCloser, error) {
-	server, err := gss.db.GetGameserver(id)
+// GetServerGroup retrieves a server group with its members
+func (gss *GameserverRepository) GetServerGroup(id string) (*models.ServerGroup, error) {
+	group, err := gss.db.GetServerGroup(id)
 	if err != nil {
 		return nil, err
 	}
-	if server.ContainerID == "" {
-		return nil, &models.DatabaseError{Op: "stream_logs", Msg: "container not created yet", Err: nil}
+	if group.Members, err = gss.serverGroupMembers(id); err != nil {
+		return nil, err
 	}
-	return gss.docker.StreamContainerLogs(server.ContainerID)
+	return group, nil
 }
 
-// StreamGameserverStats returns a stream of gameserver statistics
-func (gss *GameserverRepository) StreamGameserverStats(id string) (io.ReadCloser, error) {
-	server, err := gss.db.GetGameserver(id)
+// serverGroupMembers loads a group's gameservers with live status, in start priority order
+func (gss *GameserverRepository) serverGroupMembers(groupID string) ([]*models.Gameserver, error) {
+	ids, err := gss.db.ListServerGroupMemberIDs(groupID)
 	if err != nil {
 		return nil, err
 	}
-	if server.ContainerID == "" {
-		return nil, &models.DatabaseError{Op: "stream_stats", Msg: "container not created yet", Err: nil}
+	var members []*models.Gameserver
+	for _, id := range ids {
+		server, err := gss.GetGameserver(id)
+		if err != nil {
+			log.Warn().Err(err).Str("group_id", groupID).Str("gameserver_id", id).Msg("Skipping missing server group member")
+			continue
+		}
+		members = append(members, server)
 	}
-	return gss.docker.StreamContainerStats(server.ContainerID)
+	sort.SliceStable(members, func(i, j int) bool { return members[i].StartPriority < members[j].StartPriority })
+	return members, nil
 }
 
-// ListGames returns all available games
-func (gss *GameserverRepository) ListGames() ([]*models.Game, error) {
-	return gss.db.ListGames()
-}
+// CreateServerGroup creates a server group containing the given gameservers
+func (gss *GameserverRepository) CreateServerGroup(group *models.ServerGroup, memberIDs []string) error {
+	if err := gss.validateGroupMembers(memberIDs); err != nil {
+		return err
+	}
 
-// GetGame returns a specific game by ID
-func (gss *GameserverRepository) GetGame(id string) (*models.Game, error) {
-	return gss.db.GetGame(id)
+	now := time.Now()
+	group.ID = models.GenerateID()
+	group.CreatedAt, group.UpdatedAt = now, now
+	if err := gss.db.CreateServerGroup(group); err != nil {
+		return err
+	}
+	return gss.db.SetServerGroupMembers(group.ID, memberIDs)
 }
 
-// CreateGame creates a new game configuration
-func (gss *GameserverRepository) CreateGame(game *models.Game) error {
-	now := time.Now()
-	game.CreatedAt, game.UpdatedAt = now, now
-	return gss.db.CreateGame(game)
+// UpdateServerGroup updates a server group and replaces its members
+func (gss *GameserverRepository) UpdateServerGroup(group *models.ServerGroup, memberIDs []string) error {
+	if err := gss.validateGroupMembers(memberIDs); err != nil {
+		return err
+	}
+
+	group.UpdatedAt = time.Now()
+	if err := gss.db.UpdateServerGroup(group); err != nil {
+		return err
+	}
+	return gss.db.SetServerGroupMembers(group.ID, memberIDs)
 }
 
-// UpdateGame updates an existing game configuration
-func (gss *GameserverRepository) UpdateGame(game *models.Game) error {
-	game.UpdatedAt = time.Now()
-	return gss.db.UpdateGame(game)
+// validateGroupMembers checks that every member ID refers to an existing gameserver
+func (gss *GameserverRepository) validateGroupMembers(memberIDs []string) error {
+	for _, id := range memberIDs {
+		if _, err := gss.db.GetGameserver(id); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// DeleteGame deletes a game if no gameservers are using it
-func (gss *GameserverRepository) DeleteGame(id string) error {
-	return gss.db.DeleteGame(id)
+// DeleteServerGroup deletes a server group. Its gameservers are left untouched.
+func (gss *GameserverRepository) DeleteServerGroup(id string) error {
+	return gss.db.DeleteServerGroup(id)
 }
 
-// ListMods returns all available mods
-func (gss *GameserverRepository) ListMods() ([]*models.Mod, error) {
-	return gss.db.ListMods()
+// StartServerGroup starts a group's stopped gameservers in priority order
+func (gss *GameserverRepository) StartServerGroup(id string) error {
+	members, err := gss.serverGroupMembers(id)
+	if err != nil {
+		return err
+	}
+	gss.startInPriorityOrder(members)
+	return nil
 }
 
-// GetModsForGame returns all mods available for a specific game
-func (gss *GameserverRepository) GetModsForGame(gameID string) ([]*models.Mod, error) {
-	return gss.db.GetModsForGame(gameID)
+// StopServerGroup stops a group's running gameservers in reverse priority order
+func (gss *GameserverRepository) StopServerGroup(id string) error {
+	members, err := gss.serverGroupMembers(id)
+	if err != nil {
+		return err
+	}
+	gss.stopInPriorityOrder(members)
+	return nil
 }
 
-// SaveModsForGame syncs mods for a game (deletes old, creates new)
-func (gss *GameserverRepository) SaveModsForGame(gameID string, mods []*models.Mod) error {
-	// Delete existing mods for this game
-	if err := gss.db.DeleteModsForGame(gameID); err != nil {
+// BackupServerGroup backs up each of a group's gameservers in priority order, continuing past failures
+func (gss *GameserverRepository) BackupServerGroup(id string) error {
+	members, err := gss.serverGroupMembers(id)
+	if err != nil {
 		return err
 	}
 
-	// Create new mods
-	for _, mod := range mods {
-		mod.GameID = gameID
-		if err := gss.db.CreateMod(mod); err != nil {
-			return err
+	var failed int
+	for _, server := range members {
+		if server.ContainerID == "" {
+			continue
+		}
+		if err := gss.CreateGameserverBackup(server.ID); err != nil {
+			log.Error().Err(err).Str("group_id", id).Str("gameserver_id", server.ID).Msg("Failed to back up gameserver during group backup")
+			failed++
 		}
 	}
-
+	if failed > 0 {
+		return &models.OperationError{Op: "backup_server_group", Msg: fmt.Sprintf("%d of %d gameserver backups failed", failed, len(members))}
+	}
 	return nil
 }
 
-// CountGameserversByGameID returns the count of gameservers using a specific game
-func (gss *GameserverRepository) CountGameserversByGameID(gameID string) (int64, error) {
-	return gss.db.CountGameserversByGameID(gameID)
+// CreateGameserverBackup creates a backup of a gameserver
+func (gss *GameserverRepository) CreateGameserverBackup(gameserverID string) error {
+	_, err := gss.BackupGameserver(gameserverID)
+	return err
 }
 
-// Scheduled Task Service Operations
+// BackupGameserver creates a backup in the gameserver's volume and copies it to each of its
+// other destinations, returning how each destination fared. Only a failure to create the
+// backup is returned as an error; the local backup is still good if a copy fails.
+func (gss *GameserverRepository) BackupGameserver(gameserverID string) ([]models.BackupDestinationResult, error) {
+	gameserver, err := gss.db.GetGameserver(gameserverID)
+	if err != nil {
+		return nil, err
+	}
 
-// CreateScheduledTask creates a new scheduled task
-func (gss *GameserverRepository) CreateScheduledTask(task *models.ScheduledTask) error {
-	now := time.Now()
-	task.CreatedAt, task.UpdatedAt = now, now
-	task.ID = models.GenerateID()
+	// Wait for a free backup slot so heavy tar jobs don't saturate disk IO
+	release := gss.acquireBackupSlot(gameserverID)
+	defer release()
+
+	// The pre hook can prepare external state, e.g. snapshot a database the game uses
+	event := backupHookEvent{GameserverID: gameserver.ID, GameserverName: gameserver.Name}
+	if gss.backupPreHookURL != "" {
+		event.Event = "backup.pre"
+		if err := gss.callBackupHook(gss.backupPreHookURL, event); err != nil {
+			log.Error().Err(err).Str("gameserver_id", gameserverID).Msg("Pre-backup hook failed, skipping backup")
+			return nil, err
+		}
+	}
+
+	// Create backup
+	backupFile, err := gss.docker.CreateBackup(gameserver.ContainerID, gameserver.BackupOptions())
 
-	// Validate and calculate initial next run time using robfig/cron
-	schedule, err := cron.ParseStandard(task.CronSchedule)
+	results := []models.BackupDestinationResult{{Destination: models.BackupDestinationLocal, Success: err == nil}}
 	if err != nil {
-		return &models.DatabaseError{
-			Op:  "parse_cron",
-			Msg: fmt.Sprintf("invalid cron schedule: %s", task.CronSchedule),
-			Err: err,
+		results[0].Error = err.Error()
+	} else {
+		results = append(results, gss.copyBackupOffsite(gameserver, backupFile)...)
+	}
+	gss.recordBackupResults(gameserverID, results)
+
+	if gss.backupPostHookURL != "" {
+		event.Event, event.BackupFile, event.Success, event.Destinations = "backup.post", backupFile, err == nil, results
+		if err != nil {
+			event.Error = err.Error()
+		}
+		if hookErr := gss.callBackupHook(gss.backupPostHookURL, event); hookErr != nil {
+			log.Warn().Err(hookErr).Str("gameserver_id", gameserverID).Msg("Post-backup hook failed")
 		}
 	}
-	nextRun := schedule.Next(now)
-	task.NextRun = &nextRun
+	if err != nil {
+		return results, err
+	}
 
-	return gss.db.CreateScheduledTask(task)
-}
+	// Clean up old backups if max_backups is set
+	err = gss.docker.CleanupOldBackups(gameserver.ContainerID, gameserver.MaxBackups)
+	if err != nil {
+		log.Error().Err(err).Str("gameserver_id", gameserverID).Msg("Failed to cleanup old backups")
+		// Don't return error for cleanup failure, backup creation was successful
+	}
 
-// GetScheduledTask retrieves a scheduled task by ID
-func (gss *GameserverRepository) GetScheduledTask(id string) (*models.ScheduledTask, error) {
-	return gss.db.GetScheduledTask(id)
+	return results, nil
 }
 
-// UpdateScheduledTask updates an existing scheduled task
-func (gss *GameserverRepository) UpdateScheduledTask(task *models.ScheduledTask) error {
-	task.UpdatedAt = time.Now()
-	// Clear next run time so scheduler will recalculate it
-	task.NextRun = nil
-	return gss.db.UpdateScheduledTask(task)
+// recordBackupResults saves how the latest backup fared at each destination, for the backups page
+func (gss *GameserverRepository) recordBackupResults(gameserverID string, results []models.BackupDestinationResult) {
+	// Reload so settings changed while the backup ran aren't overwritten
+	server, err := gss.db.GetGameserver(gameserverID)
+	if err != nil {
+		return
+	}
+	server.LastBackupResults = results
+	if err := gss.db.UpdateGameserver(server); err != nil {
+		log.Warn().Err(err).Str("gameserver_id", gameserverID).Msg("Failed to record backup results")
+	}
 }
 
-// DeleteScheduledTask deletes a scheduled task
-func (gss *GameserverRepository) DeleteScheduledTask(id string) error {
-	return gss.db.DeleteScheduledTask(id)
-}
+// UpdateBackupRetention changes how many backups a gameserver keeps and prunes any excess right away
+func (gss *GameserverRepository) UpdateBackupRetention(gameserverID string, maxBackups int) error {
+	server, err := gss.db.GetGameserver(gameserverID)
+	if err != nil {
+		return err
+	}
 
-// ListScheduledTasksForGameserver retrieves all scheduled tasks for a gameserver
-func (gss *GameserverRepository) ListScheduledTasksForGameserver(gameserverID string) ([]*models.ScheduledTask, error) {
-	return gss.db.ListScheduledTasksForGameserver(gameserverID)
+	server.MaxBackups = maxBackups
+	server.UpdatedAt = time.Now()
+	if err := gss.db.UpdateGameserver(server); err != nil {
+		return err
+	}
+
+	// Backups live in the container's volume, so they can only be pruned while it exists
+	if server.ContainerID != "" {
+		if err := gss.docker.CleanupOldBackups(server.ContainerID, maxBackups); err != nil {
+			log.Warn().Err(err).Str("gameserver_id", gameserverID).Msg("Failed to apply new backup limit")
+		}
+	}
+
+	return nil
 }
 
-// CreateGameserverBackup creates a backup of a gameserver
-func (gss *GameserverRepository) CreateGameserverBackup(gameserverID string) error {
-	gameserver, err := gss.db.GetGameserver(gameserverID)
+// VerifyGameserverBackup test-restores the latest backup into a throwaway container and
+// checks the server boots from it, without touching the live server
+func (gss *GameserverRepository) VerifyGameserverBackup(gameserverID string) error {
+	gameserver, err := gss.GetGameserver(gameserverID)
 	if err != nil {
 		return err
 	}
 
-	// Create backup
-	err = gss.docker.CreateBackup(gameserver.ContainerID, gameserver.Name)
+	// Extracting is as IO-heavy as taking a backup, so share the backup slots
+	release := gss.acquireBackupSlot(gameserverID)
+	defer release()
+
+	backupFilename, err := gss.docker.VerifyBackup(gameserver, gss.backupVerifyBootTime)
 	if err != nil {
+		log.Error().Err(err).Str("gameserver_id", gameserverID).Str("backup_file", backupFilename).Msg("Backup verification failed")
 		return err
 	}
 
-	// Clean up old backups if max_backups is set
-	err = gss.docker.CleanupOldBackups(gameserver.ContainerID, gameserver.MaxBackups)
-	if err != nil {
-		log.Error().Err(err).Str("gameserver_id", gameserverID).Msg("Failed to cleanup old backups")
-		// Don't return error for cleanup failure, backup creation was successful
+	log.Info().Str("gameserver_id", gameserverID).Str("backup_file", backupFilename).Msg("Backup verification passed")
+	return nil
+}
+
+// acquireBackupSlot blocks until a host-wide backup slot is free and returns its release func
+func (gss *GameserverRepository) acquireBackupSlot(gameserverID string) func() {
+	if gss.backupSlots == nil {
+		return func() {}
 	}
 
-	return nil
+	select {
+	case gss.backupSlots <- struct{}{}:
+	default:
+		log.Info().Str("gameserver_id", gameserverID).Int("max_concurrent", cap(gss.backupSlots)).Msg("Backup queued, waiting for a free slot")
+		gss.backupSlots <- struct{}{}
+	}
+
+	return func() { <-gss.backupSlots }
+}
+
+// acquireStartSlot blocks until a host-wide start slot is free and returns its release func
+func (gss *GameserverRepository) acquireStartSlot(gameserverID string) func() {
+	if gss.startSlots == nil {
+		return func() {}
+	}
+
+	select {
+	case gss.startSlots <- struct{}{}:
+	default:
+		log.Info().Str("gameserver_id", gameserverID).Int("max_concurrent", cap(gss.startSlots)).Msg("Start queued, waiting for a free slot")
+		gss.startSlots <- struct{}{}
+	}
+
+	return func() { <-gss.startSlots }
 }
 
 // RestoreGameserverBackup restores a gameserver from a backup
@@ -591,6 +2398,30 @@ func (gss *GameserverRepository) RestoreGameserverBackup(gameserverID, backupFil
 	return gss.docker.RestoreBackup(gameserver.ContainerID, backupFilename)
 }
 
+// ImportGameserverBackup stores an uploaded backup archive alongside the gameserver's own backups,
+// named like them with label appended and the extension of its compression, and returns the
+// filename it was given
+func (gss *GameserverRepository) ImportGameserverBackup(gameserverID, label, compression string, body io.Reader, size int64) (string, error) {
+	gameserver, err := gss.db.GetGameserver(gameserverID)
+	if err != nil {
+		return "", err
+	}
+	if gameserver.ContainerID == "" {
+		return "", &models.DatabaseError{Op: "import_backup", Msg: "gameserver has no container"}
+	}
+
+	// Importing is as IO-heavy as taking a backup, so share the backup slots
+	release := gss.acquireBackupSlot(gameserverID)
+	defer release()
+
+	backupFilename := models.BackupFilename(time.Now(), label, compression)
+	if err := gss.docker.ImportBackup(gameserver.ContainerID, backupFilename, body, size); err != nil {
+		return "", err
+	}
+	log.Info().Str("gameserver_id", gameserverID).Str("backup_file", backupFilename).Int64("size", size).Msg("Backup imported")
+	return backupFilename, nil
+}
+
 // ListGameserverBackups lists all backup files for a gameserver
 func (gss *GameserverRepository) ListGameserverBackups(gameserverID string) ([]*models.FileInfo, error) {
 	gameserver, err := gss.db.GetGameserver(gameserverID)
@@ -598,7 +2429,7 @@ func (gss *GameserverRepository) ListGameserverBackups(gameserverID string) ([]*
 		return nil, err
 	}
 
-	// List files in /data/backups and filter for .tar.gz files
+	// List files in /data/backups and filter for backup archives
 	files, err := gss.docker.ListFiles(gameserver.ContainerID, "/data/backups")
 	if err != nil {
 		return nil, err
@@ -607,7 +2438,7 @@ func (gss *GameserverRepository) ListGameserverBackups(gameserverID string) ([]*
 	// Filter for backup files
 	var backups []*models.FileInfo
 	for _, file := range files {
-		if !file.IsDir && strings.HasSuffix(strings.ToLower(file.Name), ".tar.gz") {
+		if !file.IsDir && models.BackupCompressionOf(file.Name) != "" {
 			backups = append(backups, file)
 		}
 	}
@@ -615,6 +2446,19 @@ func (gss *GameserverRepository) ListGameserverBackups(gameserverID string) ([]*
 	return backups, nil
 }
 
+// GetBackupUsage returns the disk space a gameserver's files and backups use. A server without a
+// container has nothing on disk yet.
+func (gss *GameserverRepository) GetBackupUsage(gameserverID string) (*models.DiskUsage, error) {
+	gameserver, err := gss.db.GetGameserver(gameserverID)
+	if err != nil {
+		return nil, err
+	}
+	if gameserver.ContainerID == "" {
+		return &models.DiskUsage{}, nil
+	}
+	return gss.docker.DiskUsage(gameserver.ContainerID)
+}
+
 // validateSystemMemory checks if the server's memory requirements fit within available system memory
 func (gss *GameserverRepository) validateSystemMemory(server *models.Gameserver) error {
 	systemInfo, err := models.GetSystemInfo()
@@ -623,11 +2467,11 @@ func (gss *GameserverRepository) validateSystemMemory(server *models.Gameserver)
 		return nil // Don't fail if we can't get system info
 	}
 
-	if server.MemoryMB > systemInfo.TotalMemoryMB {
+	if available := gss.allocatableMemoryMB(systemInfo); server.MemoryMB > available {
 		return &models.DatabaseError{
-			Op:  "validate_memory",
-			Msg: fmt.Sprintf("server memory (%d MB) exceeds total system memory (%d MB)", 
-				server.MemoryMB, systemInfo.TotalMemoryMB),
+			Op: "validate_memory",
+			Msg: fmt.Sprintf("server memory (%d MB) exceeds allocatable system memory (%d MB total - %d MB reserved = %d MB)",
+				server.MemoryMB, systemInfo.TotalMemoryMB, gss.memoryReserveMB, available),
 			Err: nil,
 		}
 	}
@@ -653,21 +2497,14 @@ func (gss *GameserverRepository) validateSystemMemoryForStart(server *models.Gam
 		}
 	}
 
-	// Calculate current memory usage from running servers only
-	currentMemoryUsage := 0
-	for _, existingServer := range servers {
-		// Only count running servers (transitional servers will become running)
-		if existingServer.Status == models.StatusRunning || existingServer.Status.IsTransitional() {
-			currentMemoryUsage += existingServer.MemoryMB
-		}
-	}
+	currentMemoryUsage := committedMemoryMB(servers)
 
-	// Check if starting this server would exceed total system memory
-	if currentMemoryUsage+server.MemoryMB > systemInfo.TotalMemoryMB {
+	// Check if starting this server would eat into the host's reserved memory
+	if available := gss.allocatableMemoryMB(systemInfo); currentMemoryUsage+server.MemoryMB > available {
 		return &models.DatabaseError{
-			Op:  "validate_memory",
-			Msg: fmt.Sprintf("starting server would exceed total system memory: %d MB (running) + %d MB (new) = %d MB > %d MB total",
-				currentMemoryUsage, server.MemoryMB, currentMemoryUsage+server.MemoryMB, systemInfo.TotalMemoryMB),
+			Op: "validate_memory",
+			Msg: fmt.Sprintf("starting server would exceed allocatable system memory: %d MB (running) + %d MB (new) = %d MB > %d MB (%d MB total - %d MB reserved)",
+				currentMemoryUsage, server.MemoryMB, currentMemoryUsage+server.MemoryMB, available, systemInfo.TotalMemoryMB, gss.memoryReserveMB),
 			Err: nil,
 		}
 	}
@@ -675,7 +2512,27 @@ func (gss *GameserverRepository) validateSystemMemoryForStart(server *models.Gam
 	return nil
 }
 
-// ExecuteScheduledTask executes a scheduled task (restart or backup)
+// committedMemoryMB totals the memory of servers that are running or about to be. Transitional
+// servers will become running, and paused ones keep their memory.
+func committedMemoryMB(servers []*models.Gameserver) int {
+	total := 0
+	for _, server := range servers {
+		if server.Status.ConsumesMemory() {
+			total += server.MemoryMB
+		}
+	}
+	return total
+}
+
+// allocatableMemoryMB returns the host memory gameservers may use, after the configured reserve
+func (gss *GameserverRepository) allocatableMemoryMB(systemInfo *models.SystemInfo) int {
+	if available := systemInfo.TotalMemoryMB - gss.memoryReserveMB; available > 0 {
+		return available
+	}
+	return 0
+}
+
+// ExecuteScheduledTask executes a scheduled task (restart, backup, backup verification or console commands)
 func (gss *GameserverRepository) ExecuteScheduledTask(task *models.ScheduledTask) error {
 	log.Info().Str("task_id", task.ID).Str("task_name", task.Name).Str("type", string(task.Type)).Msg("Executing scheduled task")
 
@@ -705,6 +2562,31 @@ func (gss *GameserverRepository) ExecuteScheduledTask(task *models.ScheduledTask
 			Msg("Executing scheduled backup")
 		return gss.CreateGameserverBackup(task.GameserverID)
 
+	case models.TaskTypeVerifyBackup:
+		// Runs in its own container, so the live server's status doesn't matter
+		return gss.VerifyGameserverBackup(task.GameserverID)
+
+	case models.TaskTypeCommand:
+		// Unlike restarts, a command that can't be delivered is a failed run rather than a skip
+		if gameserver.Status != models.StatusRunning {
+			return &models.DatabaseError{
+				Op:  "execute_scheduled_task",
+				Msg: fmt.Sprintf("gameserver is %s", gameserver.Status),
+				Err: models.ErrServerNotRunning,
+			}
+		}
+		commands := task.Commands()
+		if len(commands) == 0 {
+			return &models.DatabaseError{Op: "execute_scheduled_task", Msg: "command task has no command"}
+		}
+		for _, command := range commands {
+			if _, err := gss.SendGameserverCommand(task.GameserverID, command); err != nil {
+				return err
+			}
+			log.Info().Str("gameserver_id", task.GameserverID).Str("command", command).Msg("Sent scheduled command")
+		}
+		return nil
+
 	default:
 		return &models.DatabaseError{
 			Op:  "execute_scheduled_task",
@@ -713,4 +2595,3 @@ func (gss *GameserverRepository) ExecuteScheduledTask(task *models.ScheduledTask
 		}
 	}
 }
-
@@ -0,0 +1,220 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"0xkowalskidev/gameservers/models"
+	"0xkowalskidev/gameservers/testutil"
+)
+
+// newTestRepository creates a repository over a fresh database holding one running gameserver
+func newTestRepository(t *testing.T) (*GameserverRepository, *testutil.MockDockerManager, *models.Gameserver) {
+	t.Helper()
+	db, err := NewDatabaseManager(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	game := &models.Game{ID: "testgame", Name: "Test Game", Slug: "testgame", Image: "example/testgame:latest",
+		MinMemoryMB: 256, RecMemoryMB: 256}
+	if err := db.CreateGame(game); err != nil {
+		t.Fatal(err)
+	}
+	server := &models.Gameserver{ID: "gs-1", Name: "world", GameID: game.ID, MemoryMB: 256,
+		ContainerID: "container-1", Status: models.StatusRunning}
+	if err := db.CreateGameserver(server); err != nil {
+		t.Fatal(err)
+	}
+
+	docker := testutil.NewMockDockerManager()
+	docker.AddContainer("container-1", models.StatusRunning)
+	return NewGameserverRepository(db, docker, nil, RepositoryOptions{}), docker, server
+}
+
+func TestStopGameserverStopsBeforeRemoving(t *testing.T) {
+	tests := []struct {
+		name       string
+		exitCode   int
+		wantForced bool
+	}{
+		{"graceful", 0, false},
+		{"killed after the stop timeout", 137, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, docker, server := newTestRepository(t)
+			docker.StopExitCode = tt.exitCode
+
+			if err := repo.StopGameserver(server.ID); err != nil {
+				t.Fatalf("StopGameserver: %v", err)
+			}
+
+			stop := docker.CallIndex("StopContainer container-1")
+			remove := docker.CallIndex("RemoveContainer container-1")
+			if stop < 0 || remove < 0 || stop > remove {
+				t.Errorf("container must be stopped before it is removed, calls: %v", docker.Calls())
+			}
+
+			stopped, err := repo.GetGameserver(server.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stopped.Status != models.StatusStopped || stopped.ContainerID != "" {
+				t.Errorf("after stop: status %q, container %q; want stopped with no container", stopped.Status, stopped.ContainerID)
+			}
+			if stopped.LastStopForced != tt.wantForced {
+				t.Errorf("LastStopForced = %v, want %v", stopped.LastStopForced, tt.wantForced)
+			}
+		})
+	}
+}
+
+func TestUpdateGameserverPreservesLastStopForced(t *testing.T) {
+	repo, docker, server := newTestRepository(t)
+	docker.StopExitCode = 137
+	if err := repo.StopGameserver(server.ID); err != nil {
+		t.Fatalf("StopGameserver: %v", err)
+	}
+
+	// A settings form doesn't carry LastStopForced, so it arrives false
+	update := &models.Gameserver{ID: server.ID, Name: "renamed", GameID: server.GameID, MemoryMB: 512}
+	if err := repo.UpdateGameserver(update); err != nil {
+		t.Fatalf("UpdateGameserver: %v", err)
+	}
+
+	updated, err := repo.GetGameserver(server.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "renamed" {
+		t.Errorf("Name = %q, want the update applied", updated.Name)
+	}
+	if !updated.LastStopForced {
+		t.Error("UpdateGameserver cleared LastStopForced")
+	}
+}
+
+// startUntilWaitingReady starts server and waits for its startup to reach waiting_ready, where
+// the operation lock is released. With no query service the server never becomes ready.
+func startUntilWaitingReady(t *testing.T, repo *GameserverRepository, id string) {
+	t.Helper()
+	repo.readyInterval = time.Millisecond
+	if err := repo.StartGameserver(id); err != nil {
+		t.Fatalf("StartGameserver: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		server, err := repo.db.GetGameserver(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if server.Status == models.StatusWaitingReady {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("startup never reached waiting_ready, status %q", server.Status)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStopDuringWaitingReadyIsKept(t *testing.T) {
+	repo, _, server := newTestRepository(t)
+	startUntilWaitingReady(t, repo, server.ID)
+
+	if err := repo.StopGameserver(server.ID); err != nil {
+		t.Fatalf("StopGameserver during waiting_ready: %v", err)
+	}
+	// Give the readiness wait many ticks to notice the removed container
+	time.Sleep(50 * time.Millisecond)
+
+	stopped, err := repo.db.GetGameserver(server.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stopped.Status != models.StatusStopped || stopped.ContainerID != "" || stopped.StartupError != "" {
+		t.Errorf("after stop: status %q, container %q, startup error %q; want stopped with no container or error",
+			stopped.Status, stopped.ContainerID, stopped.StartupError)
+	}
+}
+
+func TestDeleteDuringWaitingReadyIsKept(t *testing.T) {
+	repo, _, server := newTestRepository(t)
+	startUntilWaitingReady(t, repo, server.ID)
+
+	if err := repo.DeleteGameserver(server.ID); err != nil {
+		t.Fatalf("DeleteGameserver during waiting_ready: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if deleted, err := repo.db.GetGameserver(server.ID); err == nil {
+		t.Errorf("deleted gameserver came back with status %q", deleted.Status)
+	}
+}
+
+func TestUpdateStartupStatusOnlyWhileWaitingReady(t *testing.T) {
+	repo, _, server := newTestRepository(t)
+
+	// The row is running, not waiting on a start
+	stale := *server
+	stale.Status = models.StatusError
+	stale.StartupError = "Container exited during startup"
+	if updated, err := repo.db.UpdateStartupStatus(&stale); err != nil || updated {
+		t.Errorf("UpdateStartupStatus on a running server = (%v, %v), want nothing written", updated, err)
+	}
+
+	server.Status = models.StatusWaitingReady
+	if err := repo.db.UpdateGameserver(server); err != nil {
+		t.Fatal(err)
+	}
+	if updated, err := repo.db.UpdateStartupStatus(&stale); err != nil || !updated {
+		t.Fatalf("UpdateStartupStatus while waiting_ready = (%v, %v), want the row updated", updated, err)
+	}
+	failed, err := repo.db.GetGameserver(server.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed.Status != models.StatusError || failed.StartupError != stale.StartupError || failed.Name != server.Name {
+		t.Errorf("after UpdateStartupStatus: %+v", failed)
+	}
+}
+
+func TestFailedPersistentStopResyncsStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		stateErr   bool
+		wantStatus models.GameserverStatus
+	}{
+		{"container still running", false, models.StatusRunning},
+		{"container state unknown", true, models.StatusError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, docker, server := newTestRepository(t)
+			repo.persistentContainers = true
+			docker.Errors["StopContainer"] = errors.New("daemon unavailable")
+			if tt.stateErr {
+				docker.Errors["GetContainerState"] = errors.New("daemon unavailable")
+			}
+
+			if err := repo.StopGameserver(server.ID); err == nil {
+				t.Fatal("StopGameserver succeeded, want the stop error")
+			}
+
+			after, err := repo.db.GetGameserver(server.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if after.Status != tt.wantStatus {
+				t.Errorf("status after a failed stop = %q, want %q", after.Status, tt.wantStatus)
+			}
+			if after.ContainerID != "container-1" {
+				t.Errorf("container = %q, want the persistent container kept", after.ContainerID)
+			}
+		})
+	}
+}
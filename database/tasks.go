@@ -40,7 +40,7 @@ func (dm *DatabaseManager) UpdateScheduledTask(task *models.ScheduledTask) error
 	return nil
 }
 
-// DeleteScheduledTask deletes a scheduled task by ID
+// DeleteScheduledTask deletes a scheduled task by ID, along with its execution history
 func (dm *DatabaseManager) DeleteScheduledTask(id string) error {
 	result := dm.db.Unscoped().Delete(&models.ScheduledTask{}, "id = ?", id)
 	if result.Error != nil {
@@ -49,9 +49,38 @@ func (dm *DatabaseManager) DeleteScheduledTask(id string) error {
 	if result.RowsAffected == 0 {
 		return &models.DatabaseError{Op: "delete_task", Msg: fmt.Sprintf("scheduled task %s not found", id), Err: nil}
 	}
+	if err := dm.db.Delete(&models.TaskExecution{}, "task_id = ?", id).Error; err != nil {
+		return &models.DatabaseError{Op: "delete_task", Msg: fmt.Sprintf("failed to delete history of scheduled task %s", id), Err: err}
+	}
 	return nil
 }
 
+// CreateTaskExecution records a run of a scheduled task, then prunes the task's history
+// down to its keep most recent runs (0 = keep all)
+func (dm *DatabaseManager) CreateTaskExecution(execution *models.TaskExecution, keep int) error {
+	if err := dm.db.Create(execution).Error; err != nil {
+		return &models.DatabaseError{Op: "create_task_execution", Msg: fmt.Sprintf("failed to record run of scheduled task %s", execution.TaskID), Err: err}
+	}
+	if keep <= 0 {
+		return nil
+	}
+
+	recent := dm.db.Model(&models.TaskExecution{}).Select("id").Where("task_id = ?", execution.TaskID).Order("started_at DESC").Limit(keep)
+	if err := dm.db.Where("task_id = ? AND id NOT IN (?)", execution.TaskID, recent).Delete(&models.TaskExecution{}).Error; err != nil {
+		return &models.DatabaseError{Op: "create_task_execution", Msg: fmt.Sprintf("failed to prune history of scheduled task %s", execution.TaskID), Err: err}
+	}
+	return nil
+}
+
+// ListTaskExecutionsForTask retrieves a scheduled task's most recent runs, newest first, up to limit
+func (dm *DatabaseManager) ListTaskExecutionsForTask(taskID string, limit int) ([]*models.TaskExecution, error) {
+	var executions []*models.TaskExecution
+	if err := dm.db.Where("task_id = ?", taskID).Order("started_at DESC").Limit(limit).Find(&executions).Error; err != nil {
+		return nil, &models.DatabaseError{Op: "list_task_executions", Msg: fmt.Sprintf("failed to query history of scheduled task %s", taskID), Err: err}
+	}
+	return executions, nil
+}
+
 // ListScheduledTasksForGameserver retrieves all scheduled tasks for a gameserver
 func (dm *DatabaseManager) ListScheduledTasksForGameserver(gameserverID string) ([]*models.ScheduledTask, error) {
 	var tasks []*models.ScheduledTask
@@ -69,3 +98,65 @@ func (dm *DatabaseManager) ListActiveScheduledTasks() ([]*models.ScheduledTask,
 	}
 	return tasks, nil
 }
+
+// ListScheduledTasksForTemplate retrieves all scheduled tasks managed by a task template
+func (dm *DatabaseManager) ListScheduledTasksForTemplate(templateID string) ([]*models.ScheduledTask, error) {
+	var tasks []*models.ScheduledTask
+	if err := dm.db.Where("template_id = ?", templateID).Find(&tasks).Error; err != nil {
+		return nil, &models.DatabaseError{Op: "list_template_tasks", Msg: "failed to query template tasks", Err: err}
+	}
+	return tasks, nil
+}
+
+// CreateTaskTemplate inserts a new task template into the database
+func (dm *DatabaseManager) CreateTaskTemplate(template *models.TaskTemplate) error {
+	if err := dm.db.Create(template).Error; err != nil {
+		return &models.DatabaseError{Op: "create_task_template", Msg: "failed to create task template", Err: err}
+	}
+	return nil
+}
+
+// GetTaskTemplate retrieves a task template by ID
+func (dm *DatabaseManager) GetTaskTemplate(id string) (*models.TaskTemplate, error) {
+	var template models.TaskTemplate
+	if err := dm.db.First(&template, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, &models.DatabaseError{Op: "get_task_template", Msg: fmt.Sprintf("task template %s not found", id), Err: nil}
+		}
+		return nil, &models.DatabaseError{Op: "get_task_template", Msg: fmt.Sprintf("failed to query task template %s", id), Err: err}
+	}
+	return &template, nil
+}
+
+// UpdateTaskTemplate updates an existing task template
+func (dm *DatabaseManager) UpdateTaskTemplate(template *models.TaskTemplate) error {
+	result := dm.db.Save(template)
+	if result.Error != nil {
+		return &models.DatabaseError{Op: "update_task_template", Msg: "failed to update task template", Err: result.Error}
+	}
+	if result.RowsAffected == 0 {
+		return &models.DatabaseError{Op: "update_task_template", Msg: fmt.Sprintf("task template %s not found", template.ID), Err: nil}
+	}
+	return nil
+}
+
+// DeleteTaskTemplate deletes a task template by ID
+func (dm *DatabaseManager) DeleteTaskTemplate(id string) error {
+	result := dm.db.Delete(&models.TaskTemplate{}, "id = ?", id)
+	if result.Error != nil {
+		return &models.DatabaseError{Op: "delete_task_template", Msg: "failed to delete task template", Err: result.Error}
+	}
+	if result.RowsAffected == 0 {
+		return &models.DatabaseError{Op: "delete_task_template", Msg: fmt.Sprintf("task template %s not found", id), Err: nil}
+	}
+	return nil
+}
+
+// ListTaskTemplates retrieves all task templates
+func (dm *DatabaseManager) ListTaskTemplates() ([]*models.TaskTemplate, error) {
+	var templates []*models.TaskTemplate
+	if err := dm.db.Order("tag ASC, name ASC").Find(&templates).Error; err != nil {
+		return nil, &models.DatabaseError{Op: "list_task_templates", Msg: "failed to query task templates", Err: err}
+	}
+	return templates, nil
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+func TestParseCronScheduleRejectsInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",            // Too few fields
+		"* * * * * *",        // Seconds aren't supported
+		"60 * * * *",         // Minute out of range
+		"0 24 * * *",         // Hour out of range
+		"0 0 0 * *",          // Days of the month start at 1
+		"0 0 * 13 *",         // Month out of range
+		"0 0 * * 8",          // Day of the week out of range
+		"0 0 * * 7",          // Sunday is 0, not 7
+		"*/0 * * * *",        // Zero step
+		"0 0 * * mon-funday", // Unknown name
+		"@reboot",            // Unsupported descriptor
+		"0 0 30 2 *",         // February never has a 30th
+		"0 0 31 4,6,9,11 *",  // None of these months has a 31st
+	} {
+		_, err := parseCronSchedule(expr)
+		if err == nil {
+			t.Errorf("parseCronSchedule(%q) succeeded, want an error", expr)
+			continue
+		}
+		if !errors.Is(err, models.ErrInvalidCronSchedule) {
+			t.Errorf("parseCronSchedule(%q) = %v, want ErrInvalidCronSchedule", expr, err)
+		}
+	}
+}
+
+func TestParseCronScheduleNextRuns(t *testing.T) {
+	// Tuesday
+	from := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		expr string
+		want []time.Time
+	}{
+		// Steps within a stepped range
+		{"*/20 9-17/4 * * *", []time.Time{at(10, 1, 9, 0), at(10, 1, 9, 20), at(10, 1, 9, 40), at(10, 1, 13, 0)}},
+		// Weekdays only, skipping the weekend
+		{"30 6 * * 1-5", []time.Time{at(10, 1, 6, 30), at(10, 2, 6, 30), at(10, 3, 6, 30), at(10, 4, 6, 30), at(10, 7, 6, 30)}},
+		// Day of month and day of week both restricted: either one matches
+		{"0 12 13 * 5", []time.Time{at(10, 4, 12, 0), at(10, 11, 12, 0), at(10, 13, 12, 0), at(10, 18, 12, 0)}},
+		// Day of week with a wildcard day of month: only the weekday matters
+		{"0 12 * * 0", []time.Time{at(10, 6, 12, 0), at(10, 13, 12, 0)}},
+		// The 31st skips the months that don't have one
+		{"0 0 31 * *", []time.Time{at(10, 31, 0, 0), at(12, 31, 0, 0)}},
+		// Sunday by name
+		{"0 0 * * SUN", []time.Time{at(10, 6, 0, 0)}},
+		{"@daily", []time.Time{at(10, 2, 0, 0), at(10, 3, 0, 0)}},
+	}
+	for _, tt := range tests {
+		schedule, err := parseCronSchedule(tt.expr)
+		if err != nil {
+			t.Errorf("parseCronSchedule(%q): %v", tt.expr, err)
+			continue
+		}
+		next := from
+		for i, want := range tt.want {
+			next = schedule.Next(next)
+			if !next.Equal(want) {
+				t.Errorf("%q run %d = %s, want %s", tt.expr, i+1, next.Format(time.RFC1123), want.Format(time.RFC1123))
+				break
+			}
+		}
+	}
+}
+
+func TestPreviewCronSchedule(t *testing.T) {
+	repo, _, _ := newTestRepository(t)
+
+	runs, err := repo.PreviewCronSchedule("*/15 * * * *", 5)
+	if err != nil {
+		t.Fatalf("PreviewCronSchedule: %v", err)
+	}
+	if len(runs) != 5 {
+		t.Fatalf("got %d runs, want 5", len(runs))
+	}
+	if !runs[0].After(time.Now()) {
+		t.Errorf("first run %s is not in the future", runs[0])
+	}
+	for i, run := range runs {
+		if run.Minute()%15 != 0 || run.Second() != 0 {
+			t.Errorf("run %d at %s is not on a quarter hour", i+1, run)
+		}
+		if i > 0 && run.Sub(runs[i-1]) != 15*time.Minute {
+			t.Errorf("runs %d and %d are %s apart, want 15m", i, i+1, run.Sub(runs[i-1]))
+		}
+	}
+
+	if _, err := repo.PreviewCronSchedule("0 0 30 2 *", 5); !errors.Is(err, models.ErrInvalidCronSchedule) {
+		t.Errorf("preview of a never-firing schedule = %v, want ErrInvalidCronSchedule", err)
+	}
+}
+
+func TestScheduledTaskRejectsInvalidSchedule(t *testing.T) {
+	repo, _, server := newTestRepository(t)
+
+	task := &models.ScheduledTask{Name: "Backup", GameserverID: server.ID, Type: models.TaskTypeBackup, CronSchedule: "0 3 * * *"}
+	if err := repo.CreateScheduledTask(task); err != nil {
+		t.Fatalf("CreateScheduledTask: %v", err)
+	}
+	if task.NextRun == nil || task.NextRun.IsZero() {
+		t.Error("created task has no next run")
+	}
+
+	task.CronSchedule = "0 3 * *"
+	if err := repo.UpdateScheduledTask(task); !errors.Is(err, models.ErrInvalidCronSchedule) {
+		t.Errorf("UpdateScheduledTask with a bad schedule = %v, want ErrInvalidCronSchedule", err)
+	}
+	bad := &models.ScheduledTask{Name: "Never", GameserverID: server.ID, Type: models.TaskTypeBackup, CronSchedule: "0 0 30 2 *"}
+	if err := repo.CreateScheduledTask(bad); !errors.Is(err, models.ErrInvalidCronSchedule) {
+		t.Errorf("CreateScheduledTask with a never-firing schedule = %v, want ErrInvalidCronSchedule", err)
+	}
+}
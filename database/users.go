@@ -0,0 +1,435 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+const (
+	// defaultSessionTTL is how long a login lasts when no session TTL is configured
+	defaultSessionTTL = 7 * 24 * time.Hour
+	// tokenTouchInterval limits how often a token's last use is written back, so authenticating
+	// doesn't cost a write on every request
+	tokenTouchInterval = time.Minute
+	// tokenPrefix marks the panel's API tokens, e.g. for secret scanners
+	tokenPrefix = "gs_"
+
+	minPasswordLength = 8
+	maxPasswordLength = 72 // bcrypt ignores anything longer
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{1,50}$`)
+
+// dummyPasswordHash is compared against when a username doesn't exist, so a failed login
+// takes as long whether or not the user exists
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+	return hash
+})
+
+// CreateUser inserts a new user
+func (dm *DatabaseManager) CreateUser(user *models.User) error {
+	if err := dm.db.Create(user).Error; err != nil {
+		return &models.DatabaseError{Op: "create_user", Msg: "failed to create user", Err: err}
+	}
+	return nil
+}
+
+// GetUser retrieves a user by ID
+func (dm *DatabaseManager) GetUser(id string) (*models.User, error) {
+	var user models.User
+	if err := dm.db.First(&user, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, &models.DatabaseError{Op: "get_user", Msg: fmt.Sprintf("user %s not found", id), Err: nil}
+		}
+		return nil, &models.DatabaseError{Op: "get_user", Msg: fmt.Sprintf("failed to query user %s", id), Err: err}
+	}
+	return &user, nil
+}
+
+// GetUserByUsername retrieves a user by username, returning nil if there is none
+func (dm *DatabaseManager) GetUserByUsername(username string) (*models.User, error) {
+	var user models.User
+	if err := dm.db.First(&user, "username = ?", username).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, &models.DatabaseError{Op: "get_user", Msg: fmt.Sprintf("failed to query user %s", username), Err: err}
+	}
+	return &user, nil
+}
+
+// ListUsers retrieves all users, ordered by username
+func (dm *DatabaseManager) ListUsers() ([]*models.User, error) {
+	var users []*models.User
+	if err := dm.db.Order("username ASC").Find(&users).Error; err != nil {
+		return nil, &models.DatabaseError{Op: "list_users", Msg: "failed to query users", Err: err}
+	}
+	return users, nil
+}
+
+// CountUsers returns how many users have the given role, or how many users there are when role is empty
+func (dm *DatabaseManager) CountUsers(role string) (int64, error) {
+	query := dm.db.Model(&models.User{})
+	if role != "" {
+		query = query.Where("role = ?", role)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return 0, &models.DatabaseError{Op: "count_users", Msg: "failed to count users", Err: err}
+	}
+	return count, nil
+}
+
+// UpdateUser saves changes to an existing user
+func (dm *DatabaseManager) UpdateUser(user *models.User) error {
+	if err := dm.db.Save(user).Error; err != nil {
+		return &models.DatabaseError{Op: "update_user", Msg: fmt.Sprintf("failed to update user %s", user.ID), Err: err}
+	}
+	return nil
+}
+
+// DeleteUser deletes a user along with their sessions and API tokens
+func (dm *DatabaseManager) DeleteUser(id string) error {
+	return dm.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.UserToken{}, "user_id = ?", id).Error; err != nil {
+			return &models.DatabaseError{Op: "delete_user", Msg: fmt.Sprintf("failed to delete tokens of user %s", id), Err: err}
+		}
+		result := tx.Delete(&models.User{}, "id = ?", id)
+		if result.Error != nil {
+			return &models.DatabaseError{Op: "delete_user", Msg: fmt.Sprintf("failed to delete user %s", id), Err: result.Error}
+		}
+		if result.RowsAffected == 0 {
+			return &models.DatabaseError{Op: "delete_user", Msg: fmt.Sprintf("user %s not found", id), Err: nil}
+		}
+		return nil
+	})
+}
+
+// CreateUserToken inserts a session or API token
+func (dm *DatabaseManager) CreateUserToken(token *models.UserToken) error {
+	if err := dm.db.Create(token).Error; err != nil {
+		return &models.DatabaseError{Op: "create_user_token", Msg: "failed to create token", Err: err}
+	}
+	return nil
+}
+
+// GetUserTokenByHash retrieves a token by the hash of its value, returning nil if there is none
+func (dm *DatabaseManager) GetUserTokenByHash(hash string) (*models.UserToken, error) {
+	var token models.UserToken
+	if err := dm.db.First(&token, "token_hash = ?", hash).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, &models.DatabaseError{Op: "get_user_token", Msg: "failed to query token", Err: err}
+	}
+	return &token, nil
+}
+
+// ListUserTokens retrieves a user's tokens of one kind, newest first
+func (dm *DatabaseManager) ListUserTokens(userID, kind string) ([]*models.UserToken, error) {
+	var tokens []*models.UserToken
+	if err := dm.db.Where("user_id = ? AND kind = ?", userID, kind).Order("created_at DESC").Find(&tokens).Error; err != nil {
+		return nil, &models.DatabaseError{Op: "list_user_tokens", Msg: fmt.Sprintf("failed to query tokens of user %s", userID), Err: err}
+	}
+	return tokens, nil
+}
+
+// TouchUserToken records when a token was last used
+func (dm *DatabaseManager) TouchUserToken(id string, at time.Time) error {
+	if err := dm.db.Model(&models.UserToken{}).Where("id = ?", id).Update("last_used_at", at).Error; err != nil {
+		return &models.DatabaseError{Op: "touch_user_token", Msg: "failed to update token", Err: err}
+	}
+	return nil
+}
+
+// DeleteUserToken deletes one of a user's tokens of the given kind
+func (dm *DatabaseManager) DeleteUserToken(userID, kind, id string) error {
+	result := dm.db.Delete(&models.UserToken{}, "id = ? AND user_id = ? AND kind = ?", id, userID, kind)
+	if result.Error != nil {
+		return &models.DatabaseError{Op: "delete_user_token", Msg: "failed to delete token", Err: result.Error}
+	}
+	if result.RowsAffected == 0 {
+		return &models.DatabaseError{Op: "delete_user_token", Msg: fmt.Sprintf("token %s not found", id), Err: nil}
+	}
+	return nil
+}
+
+// DeleteUserSessions deletes a user's login sessions, except the one with ID keepID if set
+func (dm *DatabaseManager) DeleteUserSessions(userID, keepID string) error {
+	query := dm.db.Where("user_id = ? AND kind = ?", userID, models.TokenSession)
+	if keepID != "" {
+		query = query.Where("id <> ?", keepID)
+	}
+	if err := query.Delete(&models.UserToken{}).Error; err != nil {
+		return &models.DatabaseError{Op: "delete_user_sessions", Msg: fmt.Sprintf("failed to delete sessions of user %s", userID), Err: err}
+	}
+	return nil
+}
+
+// BootstrapAdmin creates an admin account when there are no users yet, so a fresh install
+// can be signed in to. Without a password one is generated and logged once.
+func (gss *GameserverRepository) BootstrapAdmin(username, password string) error {
+	count, err := gss.db.CountUsers("")
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	generated := password == ""
+	if generated {
+		password, err = newToken("")
+		if err != nil {
+			return err
+		}
+		password = password[:20]
+	}
+	if _, err := gss.CreateUser(username, password, models.RoleAdmin); err != nil {
+		return err
+	}
+
+	if generated {
+		log.Warn().Str("username", username).Str("password", password).Msg("Created initial admin user with a generated password; sign in and change it")
+	} else {
+		log.Info().Str("username", username).Msg("Created initial admin user")
+	}
+	return nil
+}
+
+// CreateUser adds a user with a bcrypt-hashed password
+func (gss *GameserverRepository) CreateUser(username, password, role string) (*models.User, error) {
+	if !usernamePattern.MatchString(username) {
+		return nil, &models.OperationError{Op: "create_user", Msg: "username must be 1-50 letters, digits, '_', '.' or '-'", Err: models.ErrInvalidUser}
+	}
+	if role != models.RoleAdmin && role != models.RoleViewer {
+		return nil, &models.OperationError{Op: "create_user", Msg: fmt.Sprintf("role must be %s or %s", models.RoleAdmin, models.RoleViewer), Err: models.ErrInvalidUser}
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	gss.createMu.Lock()
+	defer gss.createMu.Unlock()
+
+	existing, err := gss.db.GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, &models.OperationError{Op: "create_user", Msg: fmt.Sprintf("username %q is already taken", username), Err: models.ErrUsernameTaken}
+	}
+
+	user := &models.User{
+		ID:           models.GenerateID(),
+		Username:     username,
+		PasswordHash: hash,
+		Role:         role,
+		CreatedAt:    time.Now(),
+	}
+	if err := gss.db.CreateUser(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ListUsers returns all users
+func (gss *GameserverRepository) ListUsers() ([]*models.User, error) {
+	return gss.db.ListUsers()
+}
+
+// DeleteUser removes a user and signs them out everywhere. The last admin can't be deleted.
+func (gss *GameserverRepository) DeleteUser(id string) error {
+	gss.createMu.Lock()
+	defer gss.createMu.Unlock()
+
+	user, err := gss.db.GetUser(id)
+	if err != nil {
+		return err
+	}
+	if user.IsAdmin() {
+		admins, err := gss.db.CountUsers(models.RoleAdmin)
+		if err != nil {
+			return err
+		}
+		if admins <= 1 {
+			return &models.OperationError{Op: "delete_user", Msg: "the last admin can't be deleted", Err: models.ErrLastAdmin}
+		}
+	}
+	return gss.db.DeleteUser(id)
+}
+
+// ChangePassword replaces a user's password after checking the current one, and signs out
+// their other sessions. keepSessionID is the session making the change, if any.
+func (gss *GameserverRepository) ChangePassword(userID, current, password, keepSessionID string) error {
+	user, err := gss.db.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(current)) != nil {
+		return &models.OperationError{Op: "change_password", Msg: "current password is incorrect", Err: models.ErrInvalidCredentials}
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash = hash
+	if err := gss.db.UpdateUser(user); err != nil {
+		return err
+	}
+	return gss.db.DeleteUserSessions(userID, keepSessionID)
+}
+
+// Login checks a username and password and starts a session, returning the session token
+// to hand to the browser along with its record
+func (gss *GameserverRepository) Login(username, password string) (string, *models.UserToken, error) {
+	user, err := gss.db.GetUserByUsername(username)
+	if err != nil {
+		return "", nil, err
+	}
+	if user == nil {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+		return "", nil, &models.OperationError{Op: "login", Msg: "invalid username or password", Err: models.ErrInvalidCredentials}
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return "", nil, &models.OperationError{Op: "login", Msg: "invalid username or password", Err: models.ErrInvalidCredentials}
+	}
+
+	now := time.Now()
+	expires := now.Add(gss.sessionTTL)
+	value, token, err := gss.issueToken(user.ID, models.TokenSession, "", &expires)
+	if err != nil {
+		return "", nil, err
+	}
+
+	user.LastLoginAt = &now
+	if err := gss.db.UpdateUser(user); err != nil {
+		log.Warn().Err(err).Str("user", user.Username).Msg("Failed to record login time")
+	}
+	log.Info().Str("user", user.Username).Msg("User signed in")
+	return value, token, nil
+}
+
+// Authenticate resolves a session or API token to its user. The token record is returned as
+// well, so a session can be signed out.
+func (gss *GameserverRepository) Authenticate(value string) (*models.User, *models.UserToken, error) {
+	invalid := &models.OperationError{Op: "authenticate", Msg: "invalid or expired token", Err: models.ErrInvalidCredentials}
+	if value == "" {
+		return nil, nil, invalid
+	}
+
+	token, err := gss.db.GetUserTokenByHash(hashToken(value))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	if token == nil || token.Expired(now) {
+		return nil, nil, invalid
+	}
+	user, err := gss.db.GetUser(token.UserID)
+	if err != nil {
+		return nil, nil, invalid
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= tokenTouchInterval {
+		if err := gss.db.TouchUserToken(token.ID, now); err != nil {
+			log.Warn().Err(err).Str("token_id", token.ID).Msg("Failed to record token use")
+		}
+	}
+	return user, token, nil
+}
+
+// Logout ends a session
+func (gss *GameserverRepository) Logout(session *models.UserToken) error {
+	return gss.db.DeleteUserToken(session.UserID, models.TokenSession, session.ID)
+}
+
+// CreateAPIToken issues a named API token for a user. The token's value is only available
+// now; afterwards just its prefix is kept for display.
+func (gss *GameserverRepository) CreateAPIToken(userID, name string) (string, *models.UserToken, error) {
+	if _, err := gss.db.GetUser(userID); err != nil {
+		return "", nil, err
+	}
+	return gss.issueToken(userID, models.TokenAPI, name, nil)
+}
+
+// ListAPITokens returns a user's API tokens
+func (gss *GameserverRepository) ListAPITokens(userID string) ([]*models.UserToken, error) {
+	return gss.db.ListUserTokens(userID, models.TokenAPI)
+}
+
+// RevokeAPIToken deletes one of a user's API tokens
+func (gss *GameserverRepository) RevokeAPIToken(userID, tokenID string) error {
+	return gss.db.DeleteUserToken(userID, models.TokenAPI, tokenID)
+}
+
+// issueToken generates a token, stores its hash and returns its value
+func (gss *GameserverRepository) issueToken(userID, kind, name string, expires *time.Time) (string, *models.UserToken, error) {
+	prefix := ""
+	if kind == models.TokenAPI {
+		prefix = tokenPrefix
+	}
+	value, err := newToken(prefix)
+	if err != nil {
+		return "", nil, err
+	}
+
+	token := &models.UserToken{
+		ID:        models.GenerateID(),
+		UserID:    userID,
+		Kind:      kind,
+		Name:      name,
+		TokenHash: hashToken(value),
+		CreatedAt: time.Now(),
+		ExpiresAt: expires,
+	}
+	if kind == models.TokenAPI {
+		token.Prefix = value[:len(tokenPrefix)+6]
+	}
+	if err := gss.db.CreateUserToken(token); err != nil {
+		return "", nil, err
+	}
+	return value, token, nil
+}
+
+// newToken returns 32 random bytes, hex encoded, after prefix
+func newToken(prefix string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", &models.OperationError{Op: "generate_token", Msg: "failed to generate token", Err: err}
+	}
+	return prefix + hex.EncodeToString(buf), nil
+}
+
+// hashToken returns the SHA-256 of a token's value. Tokens are random, so unlike passwords
+// they need no salt or slow hash.
+func hashToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashPassword checks a password's length and bcrypt-hashes it
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return "", &models.OperationError{Op: "hash_password", Msg: fmt.Sprintf("password must be %d-%d characters", minPasswordLength, maxPasswordLength), Err: models.ErrInvalidUser}
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", &models.OperationError{Op: "hash_password", Msg: "failed to hash password", Err: err}
+	}
+	return string(hash), nil
+}
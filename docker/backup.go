@@ -1,38 +1,165 @@
 package docker
 
 import (
+	"archive/tar"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/docker/docker/api/types/container"
 	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
 )
 
-// CreateBackup creates a backup of gameserver files
-func (d *DockerManager) CreateBackup(containerID, gameserverName string) error {
+// CreateBackup creates a backup of gameserver files and returns the backup's filename. A zstd
+// backup falls back to gzip when the game image has no zstd binary.
+func (d *DockerManager) CreateBackup(containerID string, opts models.BackupOptions) (string, error) {
+	for _, pattern := range opts.Excludes {
+		if err := models.ValidateBackupExclude(pattern); err != nil {
+			return "", &DockerError{Op: "create_backup", Msg: err.Error()}
+		}
+	}
+
 	// Generate timestamped backup filename
-	timestamp := time.Now().Format("2006-01-02_15-04-05")
-	backupFilename := fmt.Sprintf("backup-%s.tar.gz", timestamp)
+	now := time.Now()
+	backupFilename := models.BackupFilename(now, "", opts.Compression)
+
+	log.Info().Str("container_id", containerID).Str("backup_file", backupFilename).Strs("excludes", opts.Excludes).Msg("Creating backup")
+
+	// Create backup using tar inside the existing container, or alongside it if it's stopped
+	cmd := []string{"sh", "-c", backupScript(now, opts)}
+	output, err := d.runInContainer(containerID, cmd)
+	if err != nil {
+		return "", &DockerError{
+			Op:  "create_backup",
+			Msg: fmt.Sprintf("failed to create_backup in container %s", containerID),
+			Err: err,
+		}
+	}
 
-	log.Info().Str("container_id", containerID).Str("backup_file", backupFilename).Msg("Creating backup")
+	// The script prints the archive it wrote last, which differs from the planned name after a fallback
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if written := strings.TrimSpace(lines[len(lines)-1]); written != backupFilename && models.IsBackupFilename(written) {
+		log.Warn().Str("container_id", containerID).Str("backup_file", written).Msg("zstd isn't installed in the game image, backed up with gzip instead")
+		backupFilename = written
+	}
+
+	log.Info().Str("container_id", containerID).Str("backup_file", backupFilename).Msg("Backup created successfully")
+	return backupFilename, nil
+}
+
+// backupScript builds the shell script that archives /data/server into /data/backups with the
+// chosen compression and excludes, printing the filename of the archive it wrote
+func backupScript(t time.Time, opts models.BackupOptions) string {
+	// Excludes are given with and without ./ since tars differ in whether patterns match the member's prefix
+	var excludes strings.Builder
+	for _, pattern := range opts.Excludes {
+		pattern = strings.TrimPrefix(pattern, "./")
+		fmt.Fprintf(&excludes, " --exclude='%s' --exclude='./%s'", pattern, pattern)
+	}
+	tarArgs := excludes.String() + " -C /data/server ."
+
+	gzipName := models.BackupFilename(t, "", models.BackupCompressionGzip)
+	gzip := fmt.Sprintf("tar -czf '%s'%s && echo '%s'", gzipName, tarArgs, gzipName)
+
+	script := "mkdir -p /data/backups && cd /data/backups && "
+	switch opts.Compression {
+	case models.BackupCompressionNone:
+		name := models.BackupFilename(t, "", models.BackupCompressionNone)
+		return script + fmt.Sprintf("tar -cf '%s'%s && echo '%s'", name, tarArgs, name)
+	case models.BackupCompressionZstd:
+		// Archive first and compress after, since sh has no pipefail to catch a failing tar in a pipe
+		tarName := models.BackupFilename(t, "", models.BackupCompressionNone)
+		zstd := fmt.Sprintf("{ tar -cf '%s'%s && zstd -q --rm -T0 '%s' && echo '%s.zst'; } || { rm -f '%s'; exit 1; }",
+			tarName, tarArgs, tarName, tarName, tarName)
+		return script + fmt.Sprintf("if command -v zstd >/dev/null 2>&1; then %s; else %s; fi", zstd, gzip)
+	default:
+		return script + gzip
+	}
+}
 
-	// First ensure the backups directory exists
+// ImportBackup streams a backup archive of the given size into /data/backups, e.g. one
+// fetched from offsite storage, so it can then be restored like a local backup
+func (d *DockerManager) ImportBackup(containerID, backupFilename string, body io.Reader, size int64) error {
+	if backupFilename != filepath.Base(backupFilename) || !models.IsBackupFilename(backupFilename) {
+		return &DockerError{Op: "import_backup", Msg: fmt.Sprintf("invalid backup filename %q", backupFilename)}
+	}
 	if err := d.execCommandSimple(containerID, []string{"mkdir", "-p", "/data/backups"}, "create_backup_dir"); err != nil {
 		return err
 	}
 
-	// Create backup using tar inside the existing container
-	cmd := []string{"tar", "-czf", fmt.Sprintf("/data/backups/%s", backupFilename),
-		"-C", "/data/server", "."}
+	// Wrap the archive in a single-entry tar stream as CopyToContainer expects, without buffering it
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := tw.WriteHeader(&tar.Header{Name: backupFilename, Mode: 0644, Size: size, ModTime: time.Now()})
+		if err == nil {
+			_, err = io.CopyN(tw, body, size)
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
 
-	if err := d.execCommandSimple(containerID, cmd, "create_backup"); err != nil {
-		return err
+	if err := d.client.CopyToContainer(context.Background(), containerID, "/data/backups", pr, container.CopyToContainerOptions{}); err != nil {
+		pr.CloseWithError(err)
+		return &DockerError{
+			Op:  "import_backup",
+			Msg: fmt.Sprintf("failed to copy backup %s into container %s", backupFilename, containerID),
+			Err: err,
+		}
 	}
 
-	log.Info().Str("container_id", containerID).Str("backup_file", backupFilename).Msg("Backup created successfully")
+	log.Info().Str("container_id", containerID).Str("backup_file", backupFilename).Msg("Backup imported")
 	return nil
 }
 
+// runInContainer runs cmd against a container's files: exec'd into it while it runs, otherwise
+// in a throwaway container sharing its volumes, e.g. to back up a server that was just stopped
+func (d *DockerManager) runInContainer(containerID string, cmd []string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	info, err := d.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return "", d.wrapErr("inspect", fmt.Sprintf("failed to inspect container %s", containerID), err)
+	}
+	if info.State != nil && info.State.Running {
+		return d.exec(containerID, cmd)
+	}
+
+	name := fmt.Sprintf("%s-run-%s", d.namespace, strings.TrimPrefix(info.Name, "/"))
+	d.client.ContainerRemove(ctx, name, container.RemoveOptions{Force: true})
+
+	config := &container.Config{
+		Image:      info.Image,
+		User:       info.Config.User, // Files are written as the game's user, as with exec
+		Entrypoint: cmd,
+	}
+	hostConfig := &container.HostConfig{
+		VolumesFrom: []string{containerID},
+		NetworkMode: "none",
+	}
+	output, exitCode, err := d.runToCompletion(ctx, name, config, hostConfig)
+	if err != nil {
+		return "", err
+	}
+	if exitCode != 0 {
+		return output, &DockerError{
+			Op:  "run",
+			Msg: fmt.Sprintf("%s exited with code %d: %s", cmd[0], exitCode, output),
+		}
+	}
+	return output, nil
+}
+
 // CleanupOldBackups removes old backup files based on maxBackups limit
 func (d *DockerManager) CleanupOldBackups(containerID string, maxBackups int) error {
 	if maxBackups <= 0 {
@@ -43,8 +170,8 @@ func (d *DockerManager) CleanupOldBackups(containerID string, maxBackups int) er
 	log.Info().Str("container_id", containerID).Int("max_backups", maxBackups).Msg("Cleaning up old backups")
 
 	// List all backup files sorted by modification time (newest first)
-	cmd := []string{"sh", "-c", "find /data/backups -name '*.tar.gz' -type f -printf '%T@ %p\\n' | sort -nr | cut -d' ' -f2-"}
-	output, err := d.ExecCommand(containerID, cmd)
+	cmd := []string{"sh", "-c", "find /data/backups \\( -name '*.tar.gz' -o -name '*.tar.zst' -o -name '*.tar' \\) -type f -printf '%T@ %p\\n' | sort -nr | cut -d' ' -f2-"}
+	output, err := d.runInContainer(containerID, cmd)
 	if err != nil {
 		return &DockerError{
 			Op:  "list_backups",
@@ -68,7 +195,7 @@ func (d *DockerManager) CleanupOldBackups(containerID string, maxBackups int) er
 		}
 
 		log.Info().Str("container_id", containerID).Str("backup_file", file).Msg("Deleting old backup")
-		_, err := d.ExecCommand(containerID, []string{"rm", "-f", file})
+		_, err := d.runInContainer(containerID, []string{"rm", "-f", file})
 		if err != nil {
 			log.Error().Err(err).Str("container_id", containerID).Str("backup_file", file).Msg("Failed to delete old backup")
 			// Continue with other files even if one fails
@@ -79,32 +206,133 @@ func (d *DockerManager) CleanupOldBackups(containerID string, maxBackups int) er
 	return nil
 }
 
-// RestoreBackup restores a backup to the gameserver
+// DiskUsage measures the server files and backups in a container's /data with du, whether or
+// not the container is running. A directory that doesn't exist yet counts as empty.
+func (d *DockerManager) DiskUsage(containerID string) (*models.DiskUsage, error) {
+	cmd := []string{"sh", "-c", "du -sk /data/server /data/backups 2>/dev/null || true"}
+	output, err := d.runInContainer(containerID, cmd)
+	if err != nil {
+		return nil, &DockerError{
+			Op:  "disk_usage",
+			Msg: fmt.Sprintf("failed to measure disk usage in container %s", containerID),
+			Err: err,
+		}
+	}
+
+	// Each line is "<KiB>\t<path>"
+	usage := &models.DiskUsage{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		kib, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[1] {
+		case "/data/server":
+			usage.ServerBytes = kib * 1024
+		case "/data/backups":
+			usage.BackupsBytes = kib * 1024
+		}
+	}
+	return usage, nil
+}
+
+// restoreRollbackPrefix names the directories holding a server's files while a backup is
+// extracted over them. They sit on the same volume so setting the files aside is a cheap move,
+// and each restore gets its own so a copy left by an interrupted restore is never overwritten.
+const restoreRollbackPrefix = "/data/.pre-restore-"
+
+// RestoreBackup restores a backup to the gameserver. The archive is read in full before anything
+// is touched, and the current files are set aside rather than deleted, so a failed extraction
+// (corrupt archive, full disk) puts them back instead of leaving the server empty.
 func (d *DockerManager) RestoreBackup(containerID, backupFilename string) error {
 	log.Info().Str("container_id", containerID).Str("backup_file", backupFilename).Msg("Restoring backup")
 
-	// Create temporary directory for backups during restore
-	if err := d.execCommandSimple(containerID, []string{"mkdir", "-p", "/tmp/backups"}, "create_temp_dir"); err != nil {
+	checkCmd, err := backupTarCmd(backupFilename, "t", "> /dev/null")
+	if err != nil {
 		return err
 	}
-
-	// Clear server directory
-	if err := d.execCommandSimple(containerID, []string{"sh", "-c", "find /data/server -mindepth 1 -delete"}, "clear_server_dir"); err != nil {
+	extractCmd, err := backupTarCmd(backupFilename, "x", "-C /data/server")
+	if err != nil {
 		return err
 	}
 
+	// Every step runs to completion with no timeout: reading or extracting a large archive can
+	// take far longer than ExecCommand allows, and a step that gave up early could still be
+	// writing files while they are rolled back.
+
+	// Verify the archive reads to the end, which also catches a zstd backup on an image without zstd
+	if _, err := d.exec(containerID, checkCmd); err != nil {
+		return restoreError(backupFilename, "archive failed the integrity check, the server files were not changed", err)
+	}
+
+	// Set the current files aside. find ignores a failing mv, so check nothing was left behind.
+	rollbackDir := fmt.Sprintf("%s%d", restoreRollbackPrefix, time.Now().UnixNano())
+	moveAside := fmt.Sprintf("mkdir -p %s && find /data/server -mindepth 1 -maxdepth 1 -exec mv {} %s/ \\; && [ -z \"$(ls -A /data/server)\" ]", rollbackDir, rollbackDir)
+	if _, err := d.exec(containerID, []string{"sh", "-c", moveAside}); err != nil {
+		return d.rollbackRestore(containerID, backupFilename, rollbackDir, "failed to set the current server files aside", err)
+	}
+
 	// Extract the backup
-	backupPath := fmt.Sprintf("/data/backups/%s", backupFilename)
-	if err := d.execCommandSimple(containerID, []string{"tar", "-xzf", backupPath, "-C", "/data/server"}, "extract_backup"); err != nil {
-		return err
+	if _, err := d.exec(containerID, extractCmd); err != nil {
+		return d.rollbackRestore(containerID, backupFilename, rollbackDir, "failed to extract", err)
 	}
 
-	// Clean up temporary directory
-	_, err := d.ExecCommand(containerID, []string{"rm", "-rf", "/tmp/backups"})
-	if err != nil {
-		log.Warn().Err(err).Msg("Failed to clean up temporary backup directory")
+	// Only drop the old files once the backup is fully in place
+	if _, err := d.exec(containerID, []string{"rm", "-rf", rollbackDir}); err != nil {
+		log.Warn().Err(err).Str("container_id", containerID).Str("dir", rollbackDir).Msg("Failed to remove the pre-restore copy of the server files")
 	}
 
 	log.Info().Str("container_id", containerID).Str("backup_file", backupFilename).Msg("Backup restored successfully")
 	return nil
 }
+
+// rollbackRestore puts the files set aside in rollbackDir back into /data/server after a restore
+// step failed, and returns an error describing both the failure and whether the rollback worked
+func (d *DockerManager) rollbackRestore(containerID, backupFilename, rollbackDir, step string, stepErr error) error {
+	log.Error().Err(stepErr).Str("container_id", containerID).Str("backup_file", backupFilename).Msg("Restore failed, rolling back")
+
+	script := fmt.Sprintf("find /data/server -mindepth 1 -delete && find %s -mindepth 1 -maxdepth 1 -exec mv {} /data/server/ \\; && rmdir %s", rollbackDir, rollbackDir)
+	if _, err := d.exec(containerID, []string{"sh", "-c", script}); err != nil {
+		log.Error().Err(err).Str("container_id", containerID).Str("dir", rollbackDir).Msg("Failed to roll back restore")
+		return restoreError(backupFilename, fmt.Sprintf("%s and to roll back, the original server files are kept in %s", step, rollbackDir), errors.Join(stepErr, err))
+	}
+	return restoreError(backupFilename, step+", the original server files were put back", stepErr)
+}
+
+// restoreError describes a failed restore of backupFilename
+func restoreError(backupFilename, msg string, err error) error {
+	return &DockerError{
+		Op:  "restore_backup",
+		Msg: fmt.Sprintf("restoring %s: %s", backupFilename, msg),
+		Err: err,
+	}
+}
+
+// backupTarCmd returns the command that runs tar in mode (t to list, x to extract) on a backup
+// with the given trailing shell arguments, picking the decompression from the backup's extension
+func backupTarCmd(backupFilename, mode, args string) ([]string, error) {
+	if !models.IsBackupFilename(backupFilename) {
+		return nil, &DockerError{Op: "restore_backup", Msg: fmt.Sprintf("invalid backup filename %q", backupFilename)}
+	}
+	backupPath := "/data/backups/" + backupFilename
+
+	var script string
+	switch models.BackupCompressionOf(backupFilename) {
+	case models.BackupCompressionZstd:
+		script = "command -v zstd > /dev/null || { echo 'the game image has no zstd binary'; exit 1; }; "
+		if mode == "t" {
+			// zstd -t checks the frame checksums, which a piped tar can't see
+			script += fmt.Sprintf("zstd -q -t '%s' && ", backupPath)
+		}
+		script += fmt.Sprintf("zstd -dc '%s' | tar -%sf - %s", backupPath, mode, args)
+	case models.BackupCompressionNone:
+		script = fmt.Sprintf("tar -%sf '%s' %s", mode, backupPath, args)
+	default:
+		script = fmt.Sprintf("tar -%szf '%s' %s", mode, backupPath, args)
+	}
+	return []string{"sh", "-c", script}, nil
+}
@@ -0,0 +1,168 @@
+package docker
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Restore steps, told apart by their commands
+const (
+	stepVerify    = "verify"
+	stepMoveAside = "move aside"
+	stepExtract   = "extract"
+	stepDropCopy  = "drop copy"
+	stepRollback  = "rollback"
+)
+
+// restoreStep names the restore step cmd performs
+func restoreStep(cmd []string) string {
+	script := strings.Join(cmd, " ")
+	switch {
+	case strings.Contains(script, "tar -tzf"):
+		return stepVerify
+	case strings.Contains(script, "mkdir -p "+restoreRollbackPrefix):
+		return stepMoveAside
+	case strings.Contains(script, "tar -xzf"):
+		return stepExtract
+	case strings.HasPrefix(script, "rm -rf "+restoreRollbackPrefix):
+		return stepDropCopy
+	case strings.Contains(script, "find /data/server -mindepth 1 -delete"):
+		return stepRollback
+	}
+	return script
+}
+
+// fakeExec stands in for execToCompletion, failing the steps in fail. Failing steps take a
+// while to exit, so the order of the calls shows whether a step finished before the next began.
+type fakeExec struct {
+	mu    sync.Mutex
+	fail  map[string]bool
+	calls []string // "start STEP" and "end STEP"
+	cmds  [][]string
+}
+
+func (f *fakeExec) exec(containerID string, cmd []string) (string, error) {
+	step := restoreStep(cmd)
+	f.mu.Lock()
+	f.calls = append(f.calls, "start "+step)
+	f.cmds = append(f.cmds, cmd)
+	fail := f.fail[step]
+	f.mu.Unlock()
+
+	if fail {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "end "+step)
+	if fail {
+		return "", &DockerError{Op: "exec_failed", Msg: step + " failed"}
+	}
+	return "", nil
+}
+
+// rollbackDir returns the directory the files were set aside in, from the move-aside command
+func (f *fakeExec) rollbackDir(t *testing.T) string {
+	t.Helper()
+	for _, cmd := range f.cmds {
+		if restoreStep(cmd) == stepMoveAside {
+			return strings.Fields(strings.TrimPrefix(cmd[len(cmd)-1], "mkdir -p "))[0]
+		}
+	}
+	t.Fatal("files were never set aside")
+	return ""
+}
+
+func restoreWith(fail ...string) (*fakeExec, error) {
+	fake := &fakeExec{fail: make(map[string]bool)}
+	for _, step := range fail {
+		fake.fail[step] = true
+	}
+	d := &DockerManager{exec: fake.exec}
+	return fake, d.RestoreBackup("container-1", "backup-2024.tar.gz")
+}
+
+func assertCalls(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	var steps []string
+	for _, step := range want {
+		steps = append(steps, "start "+step, "end "+step)
+	}
+	if strings.Join(got, ", ") != strings.Join(steps, ", ") {
+		t.Errorf("calls = %v, want %v", got, steps)
+	}
+}
+
+func TestRestoreBackupSucceeds(t *testing.T) {
+	fake, err := restoreWith()
+	if err != nil {
+		t.Fatalf("RestoreBackup: %v", err)
+	}
+	assertCalls(t, fake.calls, stepVerify, stepMoveAside, stepExtract, stepDropCopy)
+	if dir := fake.rollbackDir(t); !strings.HasSuffix(strings.Join(fake.cmds[3], " "), dir) {
+		t.Errorf("dropped %v, want the copy in %s", fake.cmds[3], dir)
+	}
+}
+
+func TestRestoreBackupVerifyFailureLeavesFilesAlone(t *testing.T) {
+	fake, err := restoreWith(stepVerify)
+	if err == nil || !strings.Contains(err.Error(), "the server files were not changed") {
+		t.Fatalf("RestoreBackup = %v, want an integrity check error", err)
+	}
+	assertCalls(t, fake.calls, stepVerify)
+}
+
+func TestRestoreBackupRollsBackFailedSteps(t *testing.T) {
+	tests := []struct {
+		name      string
+		fail      []string
+		wantCalls []string
+		wantMsg   string
+	}{
+		{"move aside", []string{stepMoveAside}, []string{stepVerify, stepMoveAside, stepRollback},
+			"failed to set the current server files aside, the original server files were put back"},
+		{"extract", []string{stepExtract}, []string{stepVerify, stepMoveAside, stepExtract, stepRollback},
+			"failed to extract, the original server files were put back"},
+		{"extract and rollback", []string{stepExtract, stepRollback}, []string{stepVerify, stepMoveAside, stepExtract, stepRollback},
+			"failed to extract and to roll back, the original server files are kept in " + restoreRollbackPrefix},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, err := restoreWith(tt.fail...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Fatalf("RestoreBackup = %v, want %q", err, tt.wantMsg)
+			}
+			// Each failed step has exited before the rollback starts
+			assertCalls(t, fake.calls, tt.wantCalls...)
+
+			var dockerErr *DockerError
+			if !errors.As(err, &dockerErr) || dockerErr.Op != "restore_backup" {
+				t.Errorf("error = %#v, want a restore_backup DockerError", err)
+			}
+
+			// The rollback restores from the directory the files were moved to
+			dir := fake.rollbackDir(t)
+			rollback := strings.Join(fake.cmds[len(fake.cmds)-1], " ")
+			if !strings.Contains(rollback, "find "+dir+" ") || !strings.HasSuffix(rollback, "rmdir "+dir) {
+				t.Errorf("rollback %q doesn't restore from %s", rollback, dir)
+			}
+		})
+	}
+}
+
+func TestRestoreBackupRejectsBadFilenames(t *testing.T) {
+	fake := &fakeExec{}
+	d := &DockerManager{exec: fake.exec}
+	for _, name := range []string{"../server/world.tar.gz", "backup.zip", "it's.tar.gz"} {
+		if err := d.RestoreBackup("container-1", name); err == nil {
+			t.Errorf("RestoreBackup(%q) succeeded, want an error", name)
+		}
+	}
+	if len(fake.calls) != 0 {
+		t.Errorf("bad filenames ran %v, want nothing run", fake.calls)
+	}
+}
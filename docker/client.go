@@ -1,6 +1,9 @@
 package docker
 
 import (
+	"context"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/docker/client"
@@ -12,29 +15,71 @@ import (
 // DockerError is an alias for models.OperationError
 type DockerError = models.OperationError
 
+// Options holds Docker connection and container settings loaded from configuration
+type Options struct {
+	Socket         string        // Custom Docker socket (empty = Docker default)
+	APIVersion     string        // Pinned API version (empty = negotiate with daemon)
+	Namespace      string        // Prefix for container and volume names
+	StopTimeout    time.Duration // Grace period before a stopping container is killed
+	Podman         bool          // Force Podman compatibility mode (also auto-detected)
+	LogMaxSize     string        // Rotate container logs at this size, e.g. "10m" (empty = no rotation)
+	LogMaxFiles    int           // Rotated container log files to keep
+	SecretsAsFiles bool          // Pass password config vars as FILE__ references instead of plain env
+	Timezone       string        // Default TZ for containers whose gameserver doesn't set one (empty = image default, usually UTC)
+	DNS            []string      // Default DNS servers for containers whose gameserver doesn't set any (empty = Docker default)
+	ExtraHosts     []string      // /etc/hosts entries (host:ip) added to every container
+	MaxReadSize    int64         // Largest file ReadFile returns whole (0 = 10MB), matching the editor's limit
+}
+
+// defaultMaxReadSize caps ReadFile when no limit is configured
+const defaultMaxReadSize = 10 * 1024 * 1024
+
 // DockerManager manages Docker operations for gameservers
 type DockerManager struct {
-	client           *client.Client
-	namespace        string
-	stopTimeout      time.Duration
+	client         *client.Client
+	namespace      string
+	stopTimeout    time.Duration
+	podman         bool // Avoid Docker-only API calls unsupported by Podman's compat socket
+	logMaxSize     string
+	logMaxFiles    int
+	secretsAsFiles bool // Keep secrets out of docker inspect by copying them into /run/secrets
+	timezone       string
+	dns            []string
+	extraHosts     []string
+	maxReadSize    int64
+
+	pullMu sync.Mutex
+	pulls  map[string]*imagePull // In-flight pulls by image, shared by concurrent callers
+
+	// exec runs backup and restore steps in a running container; execToCompletion, or a fake in tests
+	exec func(containerID string, cmd []string) (string, error)
+	// run runs quick file manager commands such as resolving paths; ExecCommand, or a fake in tests
+	run func(containerID string, cmd []string) (string, error)
 }
 
 // NewDockerManager creates a new Docker manager instance
-func NewDockerManager(dockerSocket, namespace string, stopTimeout time.Duration) (*DockerManager, error) {
+func NewDockerManager(opts Options) (*DockerManager, error) {
 	log.Info().Msg("Connecting to Docker daemon")
 
-	opts := []client.Opt{
+	clientOpts := []client.Opt{
 		client.FromEnv,
-		client.WithAPIVersionNegotiation(),
+	}
+
+	// Pin the API version if configured, otherwise negotiate with the daemon
+	if opts.APIVersion != "" {
+		clientOpts = append(clientOpts, client.WithVersion(opts.APIVersion))
+		log.Info().Str("api_version", opts.APIVersion).Msg("Using pinned Docker API version")
+	} else {
+		clientOpts = append(clientOpts, client.WithAPIVersionNegotiation())
 	}
 
 	// Use custom docker socket if provided
-	if dockerSocket != "" {
-		opts = append(opts, client.WithHost(dockerSocket))
-		log.Info().Str("socket", dockerSocket).Msg("Using custom Docker socket")
+	if opts.Socket != "" {
+		clientOpts = append(clientOpts, client.WithHost(opts.Socket))
+		log.Info().Str("socket", opts.Socket).Msg("Using custom Docker socket")
 	}
 
-	cli, err := client.NewClientWithOpts(opts...)
+	cli, err := client.NewClientWithOpts(clientOpts...)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to create Docker client")
 		return nil, &DockerError{
@@ -44,12 +89,62 @@ func NewDockerManager(dockerSocket, namespace string, stopTimeout time.Duration)
 		}
 	}
 
-	log.Info().Str("namespace", namespace).Dur("stop_timeout", stopTimeout).Msg("Docker client connected successfully")
-	return &DockerManager{
-		client:      cli,
-		namespace:   namespace,
-		stopTimeout: stopTimeout,
-	}, nil
+	maxReadSize := opts.MaxReadSize
+	if maxReadSize <= 0 {
+		maxReadSize = defaultMaxReadSize
+	}
+
+	podman := opts.Podman || isPodman(cli)
+	if podman {
+		log.Info().Msg("Podman detected, enabling compatibility mode")
+	}
+
+	log.Info().Str("namespace", opts.Namespace).Dur("stop_timeout", opts.StopTimeout).Msg("Docker client connected successfully")
+	d := &DockerManager{
+		client:         cli,
+		namespace:      opts.Namespace,
+		stopTimeout:    opts.StopTimeout,
+		podman:         podman,
+		logMaxSize:     opts.LogMaxSize,
+		logMaxFiles:    opts.LogMaxFiles,
+		secretsAsFiles: opts.SecretsAsFiles,
+		timezone:       opts.Timezone,
+		dns:            opts.DNS,
+		extraHosts:     opts.ExtraHosts,
+		maxReadSize:    maxReadSize,
+		pulls:          make(map[string]*imagePull),
+	}
+	d.exec = d.execToCompletion
+	d.run = d.ExecCommand
+	return d, nil
+}
+
+// isPodman checks whether the daemon behind the socket is Podman's Docker-compatible API
+func isPodman(cli *client.Client) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	version, err := cli.ServerVersion(ctx)
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to get daemon version for runtime detection")
+		return false
+	}
+
+	for _, component := range version.Components {
+		if strings.Contains(strings.ToLower(component.Name), "podman") {
+			return true
+		}
+	}
+	return false
+}
+
+// Ping checks that the Docker daemon is reachable
+func (d *DockerManager) Ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := d.client.Ping(ctx)
+	return d.wrapErr("ping", "failed to reach Docker daemon", err)
 }
 
 // wrapErr creates a DockerError with the given operation, message, and wrapped error
@@ -0,0 +1,134 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/rs/zerolog/log"
+)
+
+// consoleInputScript forwards each line on stdin to the image's send-command.sh, so an
+// interactive session feeds the same command pipe as one-off commands
+const consoleInputScript = `while IFS= read -r line; do /data/scripts/send-command.sh "$line"; done`
+
+// consoleLogTail is how many earlier log lines an interactive console starts with
+const consoleLogTail = "100"
+
+// interactiveConsole is a bidirectional console stream: writes go to an exec session's
+// stdin, reads return the container's log output merged with the output of sent commands
+type interactiveConsole struct {
+	exec   types.HijackedResponse
+	logs   io.ReadCloser
+	output *io.PipeReader
+	cancel context.CancelFunc
+	once   sync.Once
+}
+
+func (c *interactiveConsole) Read(p []byte) (int, error) {
+	return c.output.Read(p)
+}
+
+func (c *interactiveConsole) Write(p []byte) (int, error) {
+	return c.exec.Conn.Write(p)
+}
+
+// Close ends the exec session by closing its stdin, and stops following the logs
+func (c *interactiveConsole) Close() error {
+	c.once.Do(func() {
+		c.exec.CloseWrite()
+		c.exec.Close()
+		c.logs.Close()
+		c.output.Close()
+		c.cancel()
+	})
+	return nil
+}
+
+// AttachInteractive opens an interactive console on a running container. Lines written to
+// the returned stream are sent as console commands; reading it follows the container's logs,
+// starting with the most recent lines, along with any output the commands print (e.g. RCON
+// replies). The stream ends when the container stops. Closing it ends the exec session.
+func (d *DockerManager) AttachInteractive(containerID string) (io.ReadWriteCloser, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	execID, err := d.client.ContainerExecCreate(ctx, containerID, container.ExecOptions{
+		Cmd:          []string{"/bin/sh", "-c", consoleInputScript},
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		cancel()
+		return nil, &DockerError{
+			Op:  "console_attach",
+			Msg: fmt.Sprintf("failed to create console exec for container %s", containerID),
+			Err: err,
+		}
+	}
+
+	exec, err := d.client.ContainerExecAttach(ctx, execID.ID, container.ExecAttachOptions{})
+	if err != nil {
+		cancel()
+		return nil, &DockerError{
+			Op:  "console_attach",
+			Msg: fmt.Sprintf("failed to attach to console exec for container %s", containerID),
+			Err: err,
+		}
+	}
+
+	logs, err := d.client.ContainerLogs(ctx, containerID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+		Tail:       consoleLogTail,
+		Timestamps: true,
+	})
+	if err != nil {
+		exec.Close()
+		cancel()
+		return nil, &DockerError{
+			Op:  "console_attach",
+			Msg: fmt.Sprintf("failed to follow logs for container %s", containerID),
+			Err: err,
+		}
+	}
+
+	// Merge logs and command output a line at a time; the stream ends with the logs
+	output, outputWriter := io.Pipe()
+	var writeMu sync.Mutex
+	go func() {
+		outputWriter.CloseWithError(forwardLines(outputWriter, &writeMu, logs))
+	}()
+	go forwardLines(outputWriter, &writeMu, exec.Reader)
+
+	log.Debug().Str("container_id", containerID).Str("exec_id", execID.ID).Msg("Interactive console attached")
+	return &interactiveConsole{exec: exec, logs: logs, output: output, cancel: cancel}, nil
+}
+
+// forwardLines demuxes a multiplexed (non-TTY) stream and writes it to out a whole line at
+// a time, so lines from different sources don't interleave mid-line
+func forwardLines(out io.Writer, mu *sync.Mutex, src io.Reader) error {
+	demuxed, demuxWriter := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(demuxWriter, demuxWriter, src)
+		demuxWriter.CloseWithError(err)
+	}()
+	defer demuxed.Close()
+
+	scanner := bufio.NewScanner(demuxed)
+	for scanner.Scan() {
+		mu.Lock()
+		_, err := fmt.Fprintln(out, scanner.Text())
+		mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -2,14 +2,20 @@ package docker
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/go-connections/nat"
 	"github.com/rs/zerolog/log"
 
@@ -31,8 +37,13 @@ func (d *DockerManager) CreateContainerWithCallback(server *models.Gameserver, c
 		callback(models.StatusPullingImage)
 	}
 
-	// Try to pull image if it doesn't exist locally
-	if err := d.pullImageIfNeeded(ctx, server.Image); err != nil {
+	// Try to pull image if it doesn't exist locally. A pinned digest never changes, so it
+	// is only pulled when missing; tagged images are updated when the registry has a newer one.
+	pull := d.pullImageIfNeeded
+	if models.ImagePinnedDigest(server.Image) != "" {
+		pull = d.pullImageIfMissing
+	}
+	if err := pull(ctx, server.Image); err != nil {
 		log.Warn().Err(err).Str("image", server.Image).Msg("Failed to pull Docker image, proceeding anyway")
 	}
 
@@ -42,24 +53,12 @@ func (d *DockerManager) CreateContainerWithCallback(server *models.Gameserver, c
 	}
 
 	// Prepare environment variables with automatic resource settings
-	env := make([]string, len(server.Environment))
-	copy(env, server.Environment)
-
-	// Automatically set MEMORY_MB for images that need it
-	if server.MemoryMB > 0 {
-		env = append(env, fmt.Sprintf("MEMORY_MB=%d", server.MemoryMB))
-	}
-
-	// Set ENABLED_MODS for mod support
-	if len(server.EnabledMods) > 0 {
-		env = append(env, fmt.Sprintf("ENABLED_MODS=%s", strings.Join(server.EnabledMods, ",")))
-	}
+	env, secrets := d.containerEnv(server)
 
-	// Set up port mappings
-	exposedPorts := make(nat.PortSet)
-	for _, portMapping := range server.PortMappings {
-		port := nat.Port(fmt.Sprintf("%d/%s", portMapping.ContainerPort, portMapping.Protocol))
-		exposedPorts[port] = struct{}{}
+	// Publish every port mapping, TCP and UDP alike, on its pre-assigned host port
+	exposedPorts, portBindings, err := containerPorts(server.PortMappings)
+	if err != nil {
+		return err
 	}
 
 	// Container configuration
@@ -68,49 +67,38 @@ func (d *DockerManager) CreateContainerWithCallback(server *models.Gameserver, c
 		Env:          env,
 		ExposedPorts: exposedPorts,
 		Labels: map[string]string{
-			"gameserver.id":   server.ID,
-			"gameserver.name": server.Name,
-			"gameserver.type": server.GameType,
+			"gameserver.id":          server.ID,
+			"gameserver.name":        server.Name,
+			"gameserver.type":        server.GameType,
+			"gameserver.config-hash": server.ContainerConfigHash(),
 		},
 	}
 
-	// Set up port bindings using pre-assigned ports
-	portBindings := make(nat.PortMap)
-	for _, portMapping := range server.PortMappings {
-		port := nat.Port(fmt.Sprintf("%d/%s", portMapping.ContainerPort, portMapping.Protocol))
-		if portMapping.HostPort == 0 {
-			return &DockerError{
-				Op:  "create",
-				Msg: fmt.Sprintf("port mapping for %s:%d has no assigned host port", portMapping.Protocol, portMapping.ContainerPort),
-				Err: nil,
-			}
-		}
-		hostPort := strconv.Itoa(portMapping.HostPort)
-		portBindings[port] = []nat.PortBinding{
-			{
-				HostIP:   "0.0.0.0",
-				HostPort: hostPort,
-			},
-		}
-	}
-
 	// Host configuration with resource constraints
 	hostConfig := &container.HostConfig{
-		PortBindings: portBindings,
-		RestartPolicy: container.RestartPolicy{
-			Name: "unless-stopped",
-		},
+		PortBindings:  portBindings,
+		RestartPolicy: containerRestartPolicy(server),
 	}
+	d.applyResourceLimits(hostConfig, server)
 
-	// Apply memory constraint (always required)
-	hostConfig.Memory = int64(server.MemoryMB) * 1024 * 1024 // Convert MB to bytes
+	// Kernel tuning and capabilities the game needs
+	hostConfig.Sysctls = server.Sysctls
+	hostConfig.CapAdd = server.CapAdd
+	if shmSizeMB := server.EffectiveShmSizeMB(); shmSizeMB > 0 {
+		hostConfig.ShmSize = int64(shmSizeMB) * 1024 * 1024
+	}
 
-	// Apply CPU constraint (optional - 0 means unlimited)
-	if server.CPUCores > 0 {
-		// Convert CPU cores to Docker's quota/period system
-		// 1 core = 100000 quota with 100000 period
-		hostConfig.CPUQuota = int64(server.CPUCores * 100000)
-		hostConfig.CPUPeriod = 100000
+	// Custom resolvers and /etc/hosts entries, for restricted or air-gapped networks
+	hostConfig.DNS = d.containerDNS(server)
+	hostConfig.ExtraHosts = d.containerExtraHosts(server)
+
+	// Rotate container logs so chatty servers can't fill the disk
+	if d.logMaxSize != "" {
+		logConfig := map[string]string{"max-size": d.logMaxSize}
+		if d.logMaxFiles > 0 {
+			logConfig["max-file"] = strconv.Itoa(d.logMaxFiles)
+		}
+		hostConfig.LogConfig = container.LogConfig{Type: "json-file", Config: logConfig}
 	}
 
 	// Create and mount auto-managed volume for data persistence
@@ -152,6 +140,14 @@ func (d *DockerManager) CreateContainerWithCallback(server *models.Gameserver, c
 		}
 	}
 
+	if len(secrets) > 0 {
+		if err := d.copySecrets(ctx, resp.ID, secrets); err != nil {
+			log.Error().Err(err).Str("gameserver_id", server.ID).Msg("Failed to copy secrets, removing container")
+			d.RemoveContainer(resp.ID)
+			return err
+		}
+	}
+
 	server.ContainerID = resp.ID
 	server.Status = models.StatusStopped
 	server.UpdatedAt = time.Now()
@@ -165,6 +161,151 @@ func (d *DockerManager) CreateContainerWithCallback(server *models.Gameserver, c
 	return nil
 }
 
+// containerEnv builds a server's container environment with automatic resource settings,
+// returning secrets separately when they are passed as files
+func (d *DockerManager) containerEnv(server *models.Gameserver) ([]string, map[string]string) {
+	env := make([]string, len(server.Environment))
+	copy(env, server.Environment)
+
+	// Automatically set MEMORY_MB for images that need it
+	if server.MemoryMB > 0 {
+		env = append(env, fmt.Sprintf("MEMORY_MB=%d", server.MemoryMB))
+	}
+
+	// Set ENABLED_MODS for mod support
+	if len(server.EnabledMods) > 0 {
+		env = append(env, fmt.Sprintf("ENABLED_MODS=%s", strings.Join(server.EnabledMods, ",")))
+	}
+
+	// Set TZ so game logs use the operator's local time, unless the env already sets it
+	if tz := d.containerTimezone(server); tz != "" && !hasEnvVar(env, "TZ") {
+		env = append(env, "TZ="+tz)
+	}
+
+	// Swap secrets for FILE__ references so their values don't show up in docker inspect, but
+	// only for images that load them; any other image would start without its passwords
+	if d.secretsAsFiles && server.GameSecretFiles {
+		return splitSecretEnv(env, server.SecretVars)
+	}
+	return env, nil
+}
+
+// containerPorts builds the exposed ports and host bindings for a gameserver's port mappings.
+// Each mapping is published for its own protocol (tcp when unset), so a game listening on
+// both TCP and UDP, or on several ports, is reachable on all of them. A container port may be
+// bound to more than one host port.
+func containerPorts(mappings []models.PortMapping) (nat.PortSet, nat.PortMap, error) {
+	exposedPorts := make(nat.PortSet)
+	portBindings := make(nat.PortMap)
+	for _, portMapping := range mappings {
+		protocol := strings.ToLower(portMapping.Protocol)
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		if protocol != "tcp" && protocol != "udp" {
+			return nil, nil, &DockerError{
+				Op:  "create",
+				Msg: fmt.Sprintf("port mapping %s has unsupported protocol %q", portMapping.Name, portMapping.Protocol),
+			}
+		}
+		if portMapping.HostPort == 0 {
+			return nil, nil, &DockerError{
+				Op:  "create",
+				Msg: fmt.Sprintf("port mapping for %s:%d has no assigned host port", protocol, portMapping.ContainerPort),
+			}
+		}
+
+		port := nat.Port(fmt.Sprintf("%d/%s", portMapping.ContainerPort, protocol))
+		binding := nat.PortBinding{HostIP: "0.0.0.0", HostPort: strconv.Itoa(portMapping.HostPort)}
+		exposedPorts[port] = struct{}{}
+		if !slices.Contains(portBindings[port], binding) {
+			portBindings[port] = append(portBindings[port], binding)
+		}
+	}
+	return exposedPorts, portBindings, nil
+}
+
+// containerRestartPolicy restarts a crashed game only if the gameserver asks for it. A clean
+// exit, e.g. a stop typed into the console, is never restarted.
+func containerRestartPolicy(server *models.Gameserver) container.RestartPolicy {
+	if !server.RestartOnCrash {
+		return container.RestartPolicy{Name: container.RestartPolicyDisabled}
+	}
+	return container.RestartPolicy{Name: container.RestartPolicyOnFailure, MaximumRetryCount: server.MaxRestartAttempts}
+}
+
+// containerTimezone returns the server's timezone, falling back to the configured default
+func (d *DockerManager) containerTimezone(server *models.Gameserver) string {
+	if server.Timezone != "" {
+		return server.Timezone
+	}
+	return d.timezone
+}
+
+// containerDNS returns the server's DNS servers, falling back to the configured default
+func (d *DockerManager) containerDNS(server *models.Gameserver) []string {
+	if len(server.DNS) > 0 {
+		return server.DNS
+	}
+	return d.dns
+}
+
+// containerExtraHosts returns the server's /etc/hosts entries followed by the configured
+// defaults, skipping defaults for hosts the server already maps
+func (d *DockerManager) containerExtraHosts(server *models.Gameserver) []string {
+	hosts := append([]string(nil), server.ExtraHosts...)
+	mapped := make(map[string]bool, len(hosts))
+	for _, entry := range hosts {
+		host, _, _ := strings.Cut(entry, ":")
+		mapped[host] = true
+	}
+	for _, entry := range d.extraHosts {
+		if host, _, _ := strings.Cut(entry, ":"); !mapped[host] {
+			hosts = append(hosts, entry)
+		}
+	}
+	return hosts
+}
+
+// hasEnvVar reports whether env contains an entry for name
+func hasEnvVar(env []string, name string) bool {
+	for _, entry := range env {
+		if strings.HasPrefix(entry, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// applyResourceLimits sets a server's memory, swap and CPU limits on a host config
+func (d *DockerManager) applyResourceLimits(hostConfig *container.HostConfig, server *models.Gameserver) {
+	// Apply memory constraint (always required)
+	hostConfig.Memory = int64(server.MemoryMB) * 1024 * 1024 // Convert MB to bytes
+
+	// Apply CPU constraint (optional - 0 means unlimited)
+	if server.CPUCores > 0 {
+		if d.podman {
+			// Rootless Podman rejects CPU period/quota, NanoCPUs is supported everywhere
+			hostConfig.NanoCPUs = int64(server.CPUCores * 1e9)
+		} else {
+			// Convert CPU cores to Docker's quota/period system
+			// 1 core = 100000 quota with 100000 period
+			hostConfig.CPUQuota = int64(server.CPUCores * 100000)
+			hostConfig.CPUPeriod = 100000
+		}
+	}
+
+	// Docker's MemorySwap is memory plus swap, so equal to Memory disables swap (0 = Docker default)
+	if server.SwapMB > 0 {
+		hostConfig.MemorySwap = hostConfig.Memory + int64(server.SwapMB)*1024*1024
+	} else if server.SwapMB < 0 {
+		hostConfig.MemorySwap = hostConfig.Memory
+	}
+
+	// Pin to specific host cores (optional - empty means any)
+	hostConfig.CpusetCpus = server.CPUSetCPUs
+}
+
 // StartContainer starts a Docker container
 func (d *DockerManager) StartContainer(containerID string) error {
 	ctx := context.Background()
@@ -200,6 +341,22 @@ func (d *DockerManager) StopContainer(containerID string) error {
 	return nil
 }
 
+// PauseContainer freezes all processes in a container, keeping its memory
+func (d *DockerManager) PauseContainer(containerID string) error {
+	if err := d.client.ContainerPause(context.Background(), containerID); err != nil {
+		return d.wrapErr("pause", fmt.Sprintf("failed to pause container %s", containerID), err)
+	}
+	return nil
+}
+
+// UnpauseContainer resumes a paused container
+func (d *DockerManager) UnpauseContainer(containerID string) error {
+	if err := d.client.ContainerUnpause(context.Background(), containerID); err != nil {
+		return d.wrapErr("unpause", fmt.Sprintf("failed to unpause container %s", containerID), err)
+	}
+	return nil
+}
+
 // RemoveContainer removes a Docker container
 func (d *DockerManager) RemoveContainer(containerID string) error {
 	ctx := context.Background()
@@ -220,37 +377,143 @@ func (d *DockerManager) RemoveContainer(containerID string) error {
 
 // GetContainerStatus returns the status of a container
 func (d *DockerManager) GetContainerStatus(containerID string) (models.GameserverStatus, error) {
+	state, err := d.GetContainerState(containerID)
+	if err != nil {
+		return models.StatusError, err
+	}
+	return state.Status, nil
+}
+
+// ContainerImageDigest returns the repo digest of the image a container runs, falling back
+// to the image ID for local builds that have never been pushed or pulled
+func (d *DockerManager) ContainerImageDigest(containerID string) (string, error) {
+	ctx := context.Background()
+
+	inspect, err := d.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return "", &DockerError{
+			Op:  "inspect",
+			Msg: fmt.Sprintf("failed to inspect container %s", containerID),
+			Err: err,
+		}
+	}
+
+	image, err := d.client.ImageInspect(ctx, inspect.Image)
+	if err != nil {
+		return "", &DockerError{
+			Op:  "image_inspect",
+			Msg: fmt.Sprintf("failed to inspect image %s", inspect.Image),
+			Err: err,
+		}
+	}
+	if len(image.RepoDigests) > 0 {
+		return image.RepoDigests[0], nil
+	}
+	return image.ID, nil
+}
+
+// InspectContainer returns Docker's full inspect output for a container, with secret env
+// values masked, for comparing what's actually running against the database
+func (d *DockerManager) InspectContainer(containerID string, secretVars []string) (json.RawMessage, error) {
+	inspect, err := d.client.ContainerInspect(context.Background(), containerID)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil, &DockerError{Op: "inspect", Msg: fmt.Sprintf("container %s does not exist", containerID), Err: models.ErrContainerNotFound}
+		}
+		return nil, &DockerError{
+			Op:  "inspect",
+			Msg: fmt.Sprintf("failed to inspect container %s", containerID),
+			Err: err,
+		}
+	}
+	if inspect.Config != nil {
+		inspect.Config.Env = models.MaskSecretEnv(inspect.Config.Env, secretVars)
+	}
+
+	data, err := json.Marshal(inspect)
+	if err != nil {
+		return nil, &DockerError{Op: "inspect", Msg: "failed to encode inspect output", Err: err}
+	}
+	return data, nil
+}
+
+// GetContainerState returns the status of a container along with its restart history
+func (d *DockerManager) GetContainerState(containerID string) (*models.ContainerState, error) {
 	ctx := context.Background()
 
 	inspect, err := d.client.ContainerInspect(ctx, containerID)
 	if err != nil {
-		return models.StatusError, &DockerError{
+		return nil, &DockerError{
 			Op:  "inspect",
 			Msg: fmt.Sprintf("failed to inspect container %s", containerID),
 			Err: err,
 		}
 	}
 
+	state := &models.ContainerState{RestartCount: inspect.RestartCount}
+	if inspect.Config != nil {
+		state.ConfigHash = inspect.Config.Labels["gameserver.config-hash"]
+	}
+	if inspect.State == nil {
+		state.Status = models.StatusError
+		return state, nil
+	}
+	if startedAt, err := time.Parse(time.RFC3339Nano, inspect.State.StartedAt); err == nil {
+		state.StartedAt = startedAt
+	}
+	state.ExitCode = inspect.State.ExitCode
+	state.OOMKilled = inspect.State.OOMKilled
+
 	switch inspect.State.Status {
 	case "running":
-		return models.StatusRunning, nil
+		state.Status = models.StatusRunning
 	case "exited":
-		return models.StatusStopped, nil
+		state.Status = models.StatusStopped
 	case "created":
-		return models.StatusStopped, nil
+		state.Status = models.StatusStopped
 	case "restarting":
-		return models.StatusStartingContainer, nil
+		state.Status = models.StatusStartingContainer
+	case "paused":
+		state.Status = models.StatusPaused
 	default:
-		return models.StatusError, nil
+		state.Status = models.StatusError
+	}
+	return state, nil
+}
+
+// GetContainerIP returns the container's IP address on its first attached network
+func (d *DockerManager) GetContainerIP(containerID string) (string, error) {
+	ctx := context.Background()
+
+	inspect, err := d.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return "", d.wrapErr("inspect", fmt.Sprintf("failed to inspect container %s", containerID), err)
+	}
+	if inspect.NetworkSettings == nil {
+		return "", &DockerError{Op: "inspect", Msg: fmt.Sprintf("container %s has no network settings", containerID)}
 	}
+
+	// Sort network names so the choice is stable when attached to several
+	names := make([]string, 0, len(inspect.NetworkSettings.Networks))
+	for name := range inspect.NetworkSettings.Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ep := inspect.NetworkSettings.Networks[name]; ep != nil && ep.IPAddress != "" {
+			return ep.IPAddress, nil
+		}
+	}
+	return "", &DockerError{Op: "inspect", Msg: fmt.Sprintf("container %s has no network IP address", containerID)}
 }
 
-// ListContainers returns a list of all gameserver containers
-func (d *DockerManager) ListContainers() ([]string, error) {
+// ListContainers returns every gameserver container in the panel's namespace, running or not
+func (d *DockerManager) ListContainers() ([]*models.ContainerSummary, error) {
 	ctx := context.Background()
 
 	filter := filters.NewArgs()
 	filter.Add("label", "gameserver.id")
+	filter.Add("name", "^/"+regexp.QuoteMeta(d.namespace)+"-")
 
 	containers, err := d.client.ContainerList(ctx, container.ListOptions{
 		All:     true,
@@ -264,10 +527,118 @@ func (d *DockerManager) ListContainers() ([]string, error) {
 		}
 	}
 
-	var result []string
+	var result []*models.ContainerSummary
 	for _, c := range containers {
-		result = append(result, c.ID)
+		summary := &models.ContainerSummary{
+			ID:      c.ID,
+			Image:   c.Image,
+			State:   string(c.State),
+			Created: time.Unix(c.Created, 0),
+			Labels:  c.Labels,
+		}
+		if len(c.Names) > 0 {
+			summary.Name = strings.TrimPrefix(c.Names[0], "/")
+		}
+
+		// Docker lists a published port once per host address (IPv4 and IPv6)
+		seen := make(map[string]bool)
+		for _, port := range c.Ports {
+			key := fmt.Sprintf("%d/%s", port.PrivatePort, port.Type)
+			if port.PublicPort == 0 || seen[key] {
+				continue
+			}
+			seen[key] = true
+			summary.Ports = append(summary.Ports, models.PortMapping{Protocol: port.Type, ContainerPort: int(port.PrivatePort), HostPort: int(port.PublicPort)})
+		}
+
+		for _, mount := range c.Mounts {
+			if mount.Destination != "/data" {
+				continue
+			}
+			summary.Volume = mount.Source
+			if mount.Name != "" {
+				summary.Volume = mount.Name
+			}
+		}
+		result = append(result, summary)
 	}
 
 	return result, nil
 }
+
+// InspectContainerSettings recovers the environment and resource limits a container was created
+// with. Variables set by the image itself are left out, so only the gameserver's own remain.
+func (d *DockerManager) InspectContainerSettings(containerID string) (*models.ContainerSettings, error) {
+	ctx := context.Background()
+
+	inspect, err := d.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return nil, &DockerError{
+			Op:  "inspect",
+			Msg: fmt.Sprintf("failed to inspect container %s", containerID),
+			Err: err,
+		}
+	}
+
+	imageEnv := make(map[string]bool)
+	image, err := d.client.ImageInspect(ctx, inspect.Image)
+	if err == nil && image.Config != nil {
+		for _, entry := range image.Config.Env {
+			imageEnv[entry] = true
+		}
+	} else if err != nil {
+		log.Warn().Err(err).Str("container_id", containerID).Msg("Failed to inspect container image, keeping its environment variables")
+	}
+
+	settings := &models.ContainerSettings{}
+	if inspect.Config != nil {
+		for _, entry := range inspect.Config.Env {
+			if !imageEnv[entry] {
+				settings.Env = append(settings.Env, entry)
+			}
+		}
+	}
+	if inspect.HostConfig != nil {
+		resources := inspect.HostConfig.Resources
+		settings.MemoryMB = int(resources.Memory / (1024 * 1024))
+		settings.CPUSetCPUs = resources.CpusetCpus
+		if resources.NanoCPUs > 0 {
+			settings.CPUCores = float64(resources.NanoCPUs) / 1e9
+		} else if resources.CPUQuota > 0 && resources.CPUPeriod > 0 {
+			settings.CPUCores = float64(resources.CPUQuota) / float64(resources.CPUPeriod)
+		}
+	}
+	return settings, nil
+}
+
+// WatchContainerEvents calls onEvent with the gameserver ID of each gameserver container that
+// starts, stops, dies, is paused or unpaused, or is removed. It blocks until ctx is cancelled
+// or the event stream fails.
+func (d *DockerManager) WatchContainerEvents(ctx context.Context, onEvent func(gameserverID string)) error {
+	filter := filters.NewArgs()
+	filter.Add("type", string(events.ContainerEventType))
+	filter.Add("label", "gameserver.id")
+	for _, action := range []events.Action{
+		events.ActionStart, events.ActionStop, events.ActionDie, events.ActionKill, events.ActionOOM,
+		events.ActionPause, events.ActionUnPause, events.ActionDestroy,
+	} {
+		filter.Add("event", string(action))
+	}
+
+	messages, errs := d.client.Events(ctx, events.ListOptions{Filters: filter})
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			if ctx.Err() != nil {
+				return nil
+			}
+			return &DockerError{Op: "events", Msg: "container event stream failed", Err: err}
+		case msg := <-messages:
+			if id := msg.Actor.Attributes["gameserver.id"]; id != "" {
+				onEvent(id)
+			}
+		}
+	}
+}
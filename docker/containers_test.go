@@ -0,0 +1,68 @@
+package docker
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+func TestContainerPortsMixedProtocols(t *testing.T) {
+	mappings := []models.PortMapping{
+		{Name: "game", Protocol: "tcp", ContainerPort: 25565, HostPort: 30001},
+		{Name: "game", Protocol: "udp", ContainerPort: 25565, HostPort: 30001},
+		{Name: "voice", Protocol: "UDP", ContainerPort: 24454, HostPort: 30002},
+		{Name: "rcon", Protocol: "", ContainerPort: 25575, HostPort: 30003},
+		// A duplicate binding is only made once
+		{Name: "game-again", Protocol: "tcp", ContainerPort: 25565, HostPort: 30001},
+	}
+
+	exposed, bindings, err := containerPorts(mappings)
+	if err != nil {
+		t.Fatalf("containerPorts: %v", err)
+	}
+
+	wantExposed := nat.PortSet{"25565/tcp": {}, "25565/udp": {}, "24454/udp": {}, "25575/tcp": {}}
+	if !reflect.DeepEqual(exposed, wantExposed) {
+		t.Errorf("PortSet = %v, want %v", exposed, wantExposed)
+	}
+	binding := func(hostPort string) []nat.PortBinding {
+		return []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: hostPort}}
+	}
+	wantBindings := nat.PortMap{
+		"25565/tcp": binding("30001"),
+		"25565/udp": binding("30001"),
+		"24454/udp": binding("30002"),
+		"25575/tcp": binding("30003"),
+	}
+	if !reflect.DeepEqual(bindings, wantBindings) {
+		t.Errorf("PortMap = %v, want %v", bindings, wantBindings)
+	}
+}
+
+func TestContainerPortsSamePortOnTwoHostPorts(t *testing.T) {
+	_, bindings, err := containerPorts([]models.PortMapping{
+		{Name: "query", Protocol: "udp", ContainerPort: 27015, HostPort: 30010},
+		{Name: "query-alt", Protocol: "udp", ContainerPort: 27015, HostPort: 30011},
+	})
+	if err != nil {
+		t.Fatalf("containerPorts: %v", err)
+	}
+	want := []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "30010"}, {HostIP: "0.0.0.0", HostPort: "30011"}}
+	if got := bindings["27015/udp"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("27015/udp bindings = %v, want %v", got, want)
+	}
+}
+
+func TestContainerPortsRejectsBadMappings(t *testing.T) {
+	for name, mapping := range map[string]models.PortMapping{
+		"unsupported protocol": {Name: "game", Protocol: "sctp", ContainerPort: 25565, HostPort: 30001},
+		"unassigned host port": {Name: "game", Protocol: "tcp", ContainerPort: 25565},
+	} {
+		if _, _, err := containerPorts([]models.PortMapping{mapping}); err == nil {
+			t.Errorf("%s: containerPorts succeeded, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package docker
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// extractTimeout bounds copying an extracted archive into a container
+const extractTimeout = 30 * time.Minute
+
+// strictServerOnlyValidation denies paths outside /data/server instead of redirecting them
+var strictServerOnlyValidation = pathValidation{
+	allowedPrefixes: []string{"/data/server"},
+}
+
+// ExtractArchive unpacks a zip, tar.gz or tar archive into destDir, which must lie within
+// /data/server. The entries are re-packed into a tar for the copy into the container, so the
+// image needs no unzip tool. Entries that would land outside destDir fail the extraction;
+// links and special files are skipped. maxSize caps the total unpacked size. Returns the
+// number of files extracted.
+func (d *DockerManager) ExtractArchive(containerID, destDir, name string, archive io.ReaderAt, size, maxSize int64) (int, error) {
+	format := models.ArchiveFormat(name)
+	if format == "" {
+		return 0, &DockerError{Op: "extract", Msg: fmt.Sprintf("%s is not a .zip, .tar.gz or .tar archive", name), Err: models.ErrInvalidArchive}
+	}
+
+	destDir, err := d.validatePath(destDir, strictServerOnlyValidation)
+	if err != nil {
+		return 0, err
+	}
+	destDir, err = d.resolvePath(containerID, destDir, strictServerOnlyValidation)
+	if err != nil {
+		return 0, err
+	}
+
+	// Stream the re-packed archive into the container as it is built
+	pr, pw := io.Pipe()
+	var files int
+	repacked := make(chan error, 1)
+	go func() {
+		tw := tar.NewWriter(pw)
+		var err error
+		if format == models.ArchiveZip {
+			files, err = repackZip(tw, archive, size, maxSize)
+		} else {
+			files, err = repackTar(tw, io.NewSectionReader(archive, 0, size), format == models.ArchiveTarGz, maxSize)
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+		repacked <- err
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), extractTimeout)
+	defer cancel()
+
+	// Extracted files belong to the container's user rather than root, so the game can modify them
+	copyErr := d.client.CopyToContainer(ctx, containerID, destDir, pr, container.CopyToContainerOptions{CopyUIDGID: true})
+	pr.Close()
+	// A bad archive is what failed the copy, so report it instead
+	if err := <-repacked; err != nil && !errors.Is(err, io.ErrClosedPipe) {
+		return 0, &DockerError{Op: "extract", Msg: fmt.Sprintf("failed to extract %s: %s", name, err), Err: models.ErrInvalidArchive}
+	}
+	if copyErr != nil {
+		return 0, &DockerError{
+			Op:  "extract",
+			Msg: fmt.Sprintf("failed to extract %s into %s", name, destDir),
+			Err: copyErr,
+		}
+	}
+
+	log.Info().Str("container_id", containerID).Str("archive", name).Str("dest", destDir).Int("files", files).Msg("Extracted archive")
+	return files, nil
+}
+
+// ExtractFile unpacks an archive already in the container into destDir, as ExtractArchive does.
+// The archive is staged in a temporary file on the host, since zips need random access.
+func (d *DockerManager) ExtractFile(containerID, archivePath, destDir string, maxSize int64) (int, error) {
+	archivePath, err := d.validatePath(archivePath, strictServerOnlyValidation)
+	if err != nil {
+		return 0, err
+	}
+	archivePath, err = d.resolvePath(containerID, archivePath, strictServerOnlyValidation)
+	if err != nil {
+		return 0, err
+	}
+
+	stream, err := d.copyFromContainer(containerID, archivePath)
+	if err != nil {
+		return 0, err
+	}
+	defer stream.Close()
+
+	tr := tar.NewReader(stream)
+	header, err := tr.Next()
+	if err != nil {
+		return 0, &DockerError{Op: "extract", Msg: fmt.Sprintf("failed to read %s", archivePath), Err: err}
+	}
+	if header.Typeflag != tar.TypeReg {
+		return 0, &DockerError{Op: "extract", Msg: fmt.Sprintf("%s is not a file", archivePath), Err: models.ErrInvalidArchive}
+	}
+	if header.Size > maxSize {
+		return 0, &DockerError{Op: "extract", Msg: fmt.Sprintf("%s is larger than the extraction limit", archivePath), Err: models.ErrInvalidArchive}
+	}
+
+	staged, err := os.CreateTemp("", "gameserver-extract-*")
+	if err != nil {
+		return 0, &DockerError{Op: "extract", Msg: "failed to stage archive", Err: err}
+	}
+	defer os.Remove(staged.Name())
+	defer staged.Close()
+
+	if _, err := io.Copy(staged, tr); err != nil {
+		return 0, &DockerError{Op: "extract", Msg: fmt.Sprintf("failed to copy %s out of the container", archivePath), Err: err}
+	}
+
+	return d.ExtractArchive(containerID, destDir, path.Base(archivePath), staged, header.Size, maxSize)
+}
+
+// repackZip copies the directories and regular files of a zip into tw
+func repackZip(tw *tar.Writer, archive io.ReaderAt, size, maxSize int64) (int, error) {
+	zr, err := zip.NewReader(archive, size)
+	if err != nil {
+		return 0, fmt.Errorf("not a valid zip file: %w", err)
+	}
+
+	files := 0
+	var total int64
+	for _, entry := range zr.File {
+		name, err := archiveEntryPath(entry.Name)
+		if err != nil {
+			return files, err
+		}
+		if name == "" {
+			continue
+		}
+
+		mode := entry.Mode()
+		switch {
+		case mode.IsDir():
+			if err := tw.WriteHeader(&tar.Header{Name: name + "/", Typeflag: tar.TypeDir, Mode: 0755, ModTime: entry.Modified}); err != nil {
+				return files, err
+			}
+			continue
+		case !mode.IsRegular():
+			continue
+		}
+
+		if entry.UncompressedSize64 > uint64(maxSize-total) {
+			return files, fmt.Errorf("archive contents are larger than the extraction limit")
+		}
+		entrySize := int64(entry.UncompressedSize64)
+		total += entrySize
+		rc, err := entry.Open()
+		if err != nil {
+			return files, fmt.Errorf("failed to read %s from zip: %w", entry.Name, err)
+		}
+		err = writeTarFile(tw, name, fileMode(mode.Perm()), entry.Modified, entrySize, rc)
+		rc.Close()
+		if err != nil {
+			return files, err
+		}
+		files++
+	}
+	return files, nil
+}
+
+// repackTar copies the directories and regular files of a (gzipped) tar into tw
+func repackTar(tw *tar.Writer, archive io.Reader, gzipped bool, maxSize int64) (int, error) {
+	if gzipped {
+		gz, err := gzip.NewReader(archive)
+		if err != nil {
+			return 0, fmt.Errorf("not a valid gzip file: %w", err)
+		}
+		defer gz.Close()
+		archive = gz
+	}
+
+	tr := tar.NewReader(archive)
+	files := 0
+	var total int64
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return files, fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		name, err := archiveEntryPath(header.Name)
+		if err != nil {
+			return files, err
+		}
+		if name == "" {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := tw.WriteHeader(&tar.Header{Name: name + "/", Typeflag: tar.TypeDir, Mode: 0755, ModTime: header.ModTime}); err != nil {
+				return files, err
+			}
+		case tar.TypeReg:
+			if header.Size > maxSize-total {
+				return files, fmt.Errorf("archive contents are larger than the extraction limit")
+			}
+			total += header.Size
+			if err := writeTarFile(tw, name, fileMode(os.FileMode(header.Mode).Perm()), header.ModTime, header.Size, tr); err != nil {
+				return files, err
+			}
+			files++
+		}
+	}
+}
+
+// writeTarFile writes one regular file of exactly size bytes read from r
+func writeTarFile(tw *tar.Writer, name string, mode int64, modTime time.Time, size int64, r io.Reader) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: mode, Size: size, ModTime: modTime}); err != nil {
+		return err
+	}
+	if _, err := io.CopyN(tw, r, size); err != nil {
+		return fmt.Errorf("failed to read %s from archive: %w", name, err)
+	}
+	return nil
+}
+
+// fileMode keeps only whether an extracted file is executable
+func fileMode(perm os.FileMode) int64 {
+	if perm&0111 != 0 {
+		return 0755
+	}
+	return 0644
+}
+
+// archiveEntryPath cleans an archive entry's name into a path relative to the extraction
+// directory. Returns "" for the directory itself, and an error for names that would escape it.
+func archiveEntryPath(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if path.IsAbs(name) {
+		return "", fmt.Errorf("archive entry %q has an absolute path", name)
+	}
+	cleaned := path.Clean(name)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("archive entry %q points outside the target directory", name)
+	}
+	if cleaned == "." {
+		return "", nil
+	}
+	return cleaned, nil
+}
@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"path/filepath"
@@ -13,6 +14,8 @@ import (
 	"time"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/rs/zerolog/log"
 
 	"0xkowalskidev/gameservers/models"
@@ -23,11 +26,23 @@ func (d *DockerManager) SendCommand(containerID string, command string) (string,
 	return d.ExecCommand(containerID, []string{"/data/scripts/send-command.sh", command})
 }
 
-// ExecCommand executes a command in a container and returns the output
+// ExecCommand executes a command in a container and returns the output. It gives up after 30
+// seconds; archive work that can take longer goes through execToCompletion.
 func (d *DockerManager) ExecCommand(containerID string, cmd []string) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
+	return d.execCommand(ctx, containerID, cmd)
+}
+
+// execToCompletion executes a command with no timeout, e.g. extracting a large backup. It only
+// returns once the command has exited, so a failed step is never still writing files while the
+// caller cleans up after it.
+func (d *DockerManager) execToCompletion(containerID string, cmd []string) (string, error) {
+	return d.execCommand(context.Background(), containerID, cmd)
+}
 
+// execCommand runs cmd in a container until it exits or ctx is done
+func (d *DockerManager) execCommand(ctx context.Context, containerID string, cmd []string) (string, error) {
 	execConfig := container.ExecOptions{
 		Cmd:          cmd,
 		AttachStdout: true,
@@ -66,17 +81,21 @@ func (d *DockerManager) ExecCommand(containerID string, cmd []string) (string, e
 	}
 
 	// Read output - use a buffer with deadline
+	// The exec stream is multiplexed (no TTY), so demux stdout/stderr into a single buffer
 	var output []byte
 	done := make(chan error, 1)
 	go func() {
-		var err error
-		output, err = io.ReadAll(resp.Reader)
+		var buf bytes.Buffer
+		_, err := stdcopy.StdCopy(&buf, &buf, resp.Reader)
+		output = buf.Bytes()
 		done <- err
 	}()
 
 	select {
 	case err := <-done:
 		if err != nil {
+			// The stream broke, not necessarily the command
+			d.waitForExec(ctx, execID.ID)
 			return "", &DockerError{
 				Op:  "exec_read",
 				Msg: fmt.Sprintf("failed to read exec output for container %s", containerID),
@@ -112,17 +131,37 @@ func (d *DockerManager) ExecCommand(containerID string, cmd []string) (string, e
 	return string(output), nil
 }
 
+// waitForExec polls an exec until it is no longer running, ctx is done or it can't be inspected
+func (d *DockerManager) waitForExec(ctx context.Context, execID string) {
+	for {
+		inspectResp, err := d.client.ContainerExecInspect(ctx, execID)
+		if err != nil || !inspectResp.Running {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
 // StreamContainerLogs returns a stream of container logs
-func (d *DockerManager) StreamContainerLogs(containerID string) (io.ReadCloser, error) {
+func (d *DockerManager) StreamContainerLogs(containerID string, opts models.LogStreamOptions) (io.ReadCloser, error) {
 	ctx := context.Background()
 
 	options := container.LogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
 		Follow:     true,
-		Tail:       "100",
+		Tail:       strconv.Itoa(opts.Tail),
 		Timestamps: true,
 	}
+	// Docker's since has nanosecond precision and is inclusive, so the line at Since is sent again
+	if !opts.Since.IsZero() {
+		options.Tail = "all"
+		options.Since = fmt.Sprintf("%d.%09d", opts.Since.Unix(), opts.Since.Nanosecond())
+	}
 
 	logs, err := d.client.ContainerLogs(ctx, containerID, options)
 	if err != nil {
@@ -136,6 +175,11 @@ func (d *DockerManager) StreamContainerLogs(containerID string) (io.ReadCloser,
 	return logs, nil
 }
 
+// ContainerLogTail returns the last lines of a container's combined stdout and stderr
+func (d *DockerManager) ContainerLogTail(containerID string, lines int) string {
+	return d.containerOutput(context.Background(), containerID, strconv.Itoa(lines))
+}
+
 // StreamContainerStats returns a stream of container statistics
 func (d *DockerManager) StreamContainerStats(containerID string) (io.ReadCloser, error) {
 	ctx := context.Background()
@@ -152,6 +196,58 @@ func (d *DockerManager) StreamContainerStats(containerID string) (io.ReadCloser,
 	return stats.Body, nil
 }
 
+// ContainerResourceUsage takes a single stats reading of a container. Docker samples CPU
+// twice for the reading, so this blocks for about a second.
+func (d *DockerManager) ContainerResourceUsage(containerID string) (*models.ResourceUsage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stats, err := d.client.ContainerStats(ctx, containerID, false)
+	if err != nil {
+		return nil, &DockerError{
+			Op:  "container_stats",
+			Msg: fmt.Sprintf("failed to get stats for container %s", containerID),
+			Err: err,
+		}
+	}
+	defer stats.Body.Close()
+
+	var v container.StatsResponse
+	if err := json.NewDecoder(stats.Body).Decode(&v); err != nil {
+		return nil, &DockerError{
+			Op:  "container_stats",
+			Msg: fmt.Sprintf("failed to decode stats for container %s", containerID),
+			Err: err,
+		}
+	}
+
+	cpuPercent := 0.0
+	cpuDelta := float64(v.CPUStats.CPUUsage.TotalUsage) - float64(v.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(v.CPUStats.SystemUsage) - float64(v.PreCPUStats.SystemUsage)
+	if systemDelta > 0 && cpuDelta > 0 {
+		onlineCPUs := float64(v.CPUStats.OnlineCPUs)
+		if onlineCPUs == 0 {
+			onlineCPUs = float64(len(v.CPUStats.CPUUsage.PercpuUsage))
+		}
+		if onlineCPUs == 0 {
+			onlineCPUs = 1
+		}
+		cpuPercent = cpuDelta / systemDelta * onlineCPUs * 100
+	}
+
+	// Page cache isn't memory the game is using
+	memUsage := v.MemoryStats.Usage
+	if cache, ok := v.MemoryStats.Stats["cache"]; ok && cache < memUsage {
+		memUsage -= cache
+	}
+
+	return &models.ResourceUsage{
+		CPUPercent:    cpuPercent,
+		MemoryUsedMB:  float64(memUsage) / 1024 / 1024,
+		MemoryLimitMB: float64(v.MemoryStats.Limit) / 1024 / 1024,
+	}, nil
+}
+
 // Path validation types and helpers
 type pathValidation struct {
 	allowedPrefixes []string
@@ -167,19 +263,37 @@ var (
 		allowedPrefixes: []string{"/data/server", "/data/backups"},
 		defaultPath:     "/data/server",
 	}
+	// strictServerAndBackupsValidation has no default, so paths outside the
+	// prefixes are denied instead of silently redirected (download/delete)
+	strictServerAndBackupsValidation = pathValidation{
+		allowedPrefixes: []string{"/data/server", "/data/backups"},
+	}
 )
 
+// hasAllowedPrefix checks if path is one of the prefixes or nested below one
+func hasAllowedPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 func (d *DockerManager) validatePath(path string, validation pathValidation) (string, error) {
 	// Handle empty paths
-	if path == "" || path == "/" {
+	if (path == "" || path == "/") && validation.defaultPath != "" {
 		return validation.defaultPath, nil
 	}
 
+	// Collapse any ".." segments before checking prefixes
+	if path != "" {
+		path = filepath.Clean(path)
+	}
+
 	// Check if path has any allowed prefix
-	for _, prefix := range validation.allowedPrefixes {
-		if strings.HasPrefix(path, prefix) {
-			return path, nil
-		}
+	if hasAllowedPrefix(path, validation.allowedPrefixes) {
+		return path, nil
 	}
 
 	// If no valid prefix found, return default or error based on context
@@ -194,9 +308,44 @@ func (d *DockerManager) validatePath(path string, validation pathValidation) (st
 	}
 }
 
+// resolvePath follows symlinks inside the container and ensures the real path
+// still lies within the allowed prefixes (e.g. a link to /etc/passwd is rejected)
+func (d *DockerManager) resolvePath(containerID string, path string, validation pathValidation) (string, error) {
+	output, err := d.run(containerID, []string{"readlink", "-f", "--", path})
+	if err != nil {
+		return "", &DockerError{
+			Op:  "resolve_path",
+			Msg: fmt.Sprintf("failed to resolve path %s", path),
+			Err: err,
+		}
+	}
+
+	resolved := strings.TrimSpace(output)
+	if resolved == "" || !hasAllowedPrefix(resolved, validation.allowedPrefixes) {
+		log.Warn().Str("path", path).Str("resolved", resolved).Str("container_id", containerID).Msg("Blocked path resolving outside allowed directories")
+		return "", &DockerError{
+			Op:  "resolve_path",
+			Msg: fmt.Sprintf("access denied: %s resolves outside %v", path, validation.allowedPrefixes),
+			Err: nil,
+		}
+	}
+
+	return resolved, nil
+}
+
+// resolveParentPath resolves symlinks in the parent directory only, so operations
+// on the entry itself (delete, overwrite) act on the link rather than its target
+func (d *DockerManager) resolveParentPath(containerID string, path string, validation pathValidation) (string, error) {
+	parent, err := d.resolvePath(containerID, filepath.Dir(path), validation)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(parent, filepath.Base(path)), nil
+}
+
 // execCommandSimple is a helper for simple exec operations that just need to run a command
 func (d *DockerManager) execCommandSimple(containerID string, cmd []string, operation string) error {
-	_, err := d.ExecCommand(containerID, cmd)
+	_, err := d.run(containerID, cmd)
 	if err != nil {
 		return &DockerError{
 			Op:  operation,
@@ -212,17 +361,51 @@ func (d *DockerManager) ListFiles(containerID string, path string) ([]*models.Fi
 	// Validate and normalize path
 	validPath, _ := d.validatePath(path, serverAndBackupsValidation)
 
-	// Use simple ls -la command
-	cmd := []string{"ls", "-la", validPath}
+	// Follow symlinked directories only if they stay within the allowed prefixes
+	validPath, err := d.resolvePath(containerID, validPath, serverAndBackupsValidation)
+	if err != nil {
+		return nil, err
+	}
+
+	// List with find -printf rather than ls, whose output varies by locale and can't be split reliably
+	cmd := []string{"find", validPath, "-mindepth", "1", "-maxdepth", "1", "-printf", listFilesFormat}
 
 	output, err := d.ExecCommand(containerID, cmd)
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse ls output and sort with context
+	// Parse the listing and sort with context
 	isBackupsPath := strings.Contains(validPath, "/backups")
-	return sortFiles(parseLsOutput(output, validPath), isBackupsPath), nil
+	return sortFiles(parseFindOutput(output, validPath), isBackupsPath), nil
+}
+
+// FilesModifiedSince returns the names of the regular files directly in dir modified after since
+func (d *DockerManager) FilesModifiedSince(containerID string, dir string, since time.Time) ([]string, error) {
+	validPath, err := d.validatePath(dir, serverOnlyValidation)
+	if err != nil {
+		return nil, err
+	}
+	validPath, err = d.resolvePath(containerID, validPath, serverOnlyValidation)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := d.ExecCommand(containerID, []string{
+		"find", validPath, "-maxdepth", "1", "-type", "f",
+		"-newermt", fmt.Sprintf("@%d", since.Unix()), "-printf", "%f\n",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, name := range strings.Split(output, "\n") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
 }
 
 // ReadFile reads a file from a container
@@ -233,9 +416,18 @@ func (d *DockerManager) ReadFile(containerID string, path string) ([]byte, error
 		return nil, err
 	}
 
+	// Resolve symlinks so a link pointing outside /data/server can't be read
+	path, err = d.resolvePath(containerID, path, serverOnlyValidation)
+	if err != nil {
+		return nil, err
+	}
+
 	// Use docker cp to safely read the file
 	reader, err := d.copyFromContainer(containerID, path)
 	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil, &DockerError{Op: "read_file", Msg: fmt.Sprintf("%s does not exist", path), Err: models.ErrFileNotFound}
+		}
 		return nil, err
 	}
 	defer reader.Close()
@@ -251,12 +443,11 @@ func (d *DockerManager) ReadFile(containerID string, path string) ([]byte, error
 		}
 	}
 
-	// Enforce size limit (10MB)
-	const maxSize = 10 * 1024 * 1024
-	if header.Size > maxSize {
+	// Enforce the configured size limit, larger files are read in parts with ReadFileRange
+	if header.Size > d.maxReadSize {
 		return nil, &DockerError{
 			Op:  "read_file",
-			Msg: fmt.Sprintf("file %s is too large (%d bytes, max %d bytes)", path, header.Size, maxSize),
+			Msg: fmt.Sprintf("file %s is too large (%d bytes, max %d bytes)", path, header.Size, d.maxReadSize),
 			Err: fmt.Errorf("file too large"),
 		}
 	}
@@ -276,6 +467,60 @@ func (d *DockerManager) ReadFile(containerID string, path string) ([]byte, error
 	return content[:n], nil
 }
 
+// ReadFileWithHash reads a file like ReadFile and also returns its content hash, which editors
+// send back on save to detect that the file changed underneath them
+func (d *DockerManager) ReadFileWithHash(containerID string, path string) ([]byte, string, error) {
+	content, err := d.ReadFile(containerID, path)
+	if err != nil {
+		return nil, "", err
+	}
+	return content, models.ContentHash(content), nil
+}
+
+// readRangeScript prints the size of file $1 on the first line, followed by up to $3 bytes
+// starting at byte offset $2, or the last $3 bytes when $2 is negative
+const readRangeScript = `size=$(($(wc -c < "$1"))) && echo "$size" && start=$2 && ` +
+	`if [ "$start" -lt 0 ]; then start=$((size > $3 ? size - $3 : 0)); fi && ` +
+	`tail -c +$((start + 1)) "$1" | head -c "$3"`
+
+// ReadFileRange reads up to limit bytes of a file starting at offset, for viewing files too large
+// to edit in parts. A negative offset reads the file's last limit bytes, starting at
+// max(size-limit, 0). It also returns the file's total size.
+func (d *DockerManager) ReadFileRange(containerID string, path string, offset, limit int64) ([]byte, int64, error) {
+	if limit <= 0 {
+		return nil, 0, &DockerError{Op: "read_file_range", Msg: fmt.Sprintf("invalid range %d+%d", offset, limit)}
+	}
+
+	// Validate path
+	_, err := d.validatePath(path, serverOnlyValidation)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Resolve symlinks so a link pointing outside /data/server can't be read
+	path, err = d.resolvePath(containerID, path, serverOnlyValidation)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cmd := []string{"sh", "-c", readRangeScript, "sh", path, strconv.FormatInt(offset, 10), strconv.FormatInt(limit, 10)}
+	output, err := d.ExecCommand(containerID, cmd)
+	if err != nil {
+		return nil, 0, &DockerError{
+			Op:  "read_file_range",
+			Msg: fmt.Sprintf("failed to read %d bytes at %d of file %s", limit, offset, path),
+			Err: err,
+		}
+	}
+
+	sizeLine, content, _ := strings.Cut(output, "\n")
+	size, err := strconv.ParseInt(strings.TrimSpace(sizeLine), 10, 64)
+	if err != nil {
+		return nil, 0, &DockerError{Op: "read_file_range", Msg: fmt.Sprintf("unexpected size %q of file %s", sizeLine, path), Err: err}
+	}
+	return []byte(content), size, nil
+}
+
 // WriteFile writes a file to a container
 func (d *DockerManager) WriteFile(containerID string, path string, content []byte) error {
 	// Validate path
@@ -284,9 +529,114 @@ func (d *DockerManager) WriteFile(containerID string, path string, content []byt
 		return err
 	}
 
+	// Ensure the target directory isn't a symlink escaping /data/server
+	path, err = d.resolveParentPath(containerID, path, serverOnlyValidation)
+	if err != nil {
+		return err
+	}
+
 	return d.copyToContainer(containerID, path, content)
 }
 
+// WriteMissingFiles writes files (keyed by path relative to /data/server) that don't exist yet.
+// Unlike WriteFile it works on created but not yet started containers, so config can be
+// seeded before the game process first reads it. Existing files are never overwritten.
+func (d *DockerManager) WriteMissingFiles(containerID string, files map[string][]byte) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	const root = "/data/server"
+
+	// Sort for deterministic tar ordering
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		if !filepath.IsLocal(path) {
+			return nil, &DockerError{
+				Op:  "validate_path",
+				Msg: fmt.Sprintf("access denied: %s must be a relative path within %s", path, root),
+			}
+		}
+		paths = append(paths, filepath.Clean(path))
+	}
+	sort.Strings(paths)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	seenDirs := make(map[string]bool)
+	var written []string
+
+	for _, path := range paths {
+		exists, err := d.pathExists(ctx, containerID, filepath.Join(root, path))
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			continue
+		}
+
+		// Add headers for missing parent directories so nested paths can be created
+		var dirs []string
+		for dir := filepath.Dir(path); dir != "." && !seenDirs[dir]; dir = filepath.Dir(dir) {
+			seenDirs[dir] = true
+			dirs = append([]string{dir}, dirs...)
+		}
+		for _, dir := range dirs {
+			exists, err := d.pathExists(ctx, containerID, filepath.Join(root, dir))
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				continue
+			}
+			if err := tw.WriteHeader(&tar.Header{Name: dir + "/", Typeflag: tar.TypeDir, Mode: 0755, ModTime: time.Now()}); err != nil {
+				return nil, d.wrapErr("create_tar", "failed to add directory to tar archive", err)
+			}
+		}
+
+		content := files[path]
+		if err := tw.WriteHeader(&tar.Header{Name: path, Mode: 0644, Size: int64(len(content)), ModTime: time.Now()}); err != nil {
+			return nil, d.wrapErr("create_tar", fmt.Sprintf("failed to add %s to tar archive", path), err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			return nil, d.wrapErr("create_tar", fmt.Sprintf("failed to add %s to tar archive", path), err)
+		}
+		written = append(written, path)
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, d.wrapErr("create_tar", "failed to finalize tar archive", err)
+	}
+	if len(written) == 0 {
+		return nil, nil
+	}
+
+	if err := d.client.CopyToContainer(ctx, containerID, root, &buf, container.CopyToContainerOptions{}); err != nil {
+		return nil, &DockerError{
+			Op:  "copy_to_container",
+			Msg: fmt.Sprintf("failed to copy files to container %s", containerID),
+			Err: err,
+		}
+	}
+
+	return written, nil
+}
+
+// pathExists reports whether a path exists in a container, running or not
+func (d *DockerManager) pathExists(ctx context.Context, containerID string, path string) (bool, error) {
+	_, err := d.client.ContainerStatPath(ctx, containerID, path)
+	if err == nil {
+		return true, nil
+	}
+	if errdefs.IsNotFound(err) {
+		return false, nil
+	}
+	return false, &DockerError{
+		Op:  "stat",
+		Msg: fmt.Sprintf("failed to stat %s in container %s", path, containerID),
+		Err: err,
+	}
+}
+
 // copyToContainer is a helper that creates a tar archive and copies it to the container
 func (d *DockerManager) copyToContainer(containerID string, path string, content []byte) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -318,18 +668,30 @@ func (d *DockerManager) copyToContainer(containerID string, path string, content
 // CreateDirectory creates a directory in a container
 func (d *DockerManager) CreateDirectory(containerID string, path string) error {
 	// Validate path
-	_, err := d.validatePath(path, serverOnlyValidation)
+	path, err := d.validatePath(path, strictServerOnlyValidation)
 	if err != nil {
 		return err
 	}
 
-	return d.execCommandSimple(containerID, []string{"mkdir", "-p", path}, "create_directory")
+	// Ensure the parent directory isn't a symlink escaping /data/server
+	path, err = d.resolveParentPath(containerID, path, strictServerOnlyValidation)
+	if err != nil {
+		return err
+	}
+
+	return d.execCommandSimple(containerID, []string{"mkdir", "-p", "--", path}, "create_directory")
 }
 
 // DeletePath deletes a file or directory in a container
 func (d *DockerManager) DeletePath(containerID string, path string) error {
 	// Validate path
-	_, err := d.validatePath(path, serverAndBackupsValidation)
+	path, err := d.validatePath(path, strictServerAndBackupsValidation)
+	if err != nil {
+		return err
+	}
+
+	// Resolve symlinked parents; a symlink itself is removed, never its target
+	path, err = d.resolveParentPath(containerID, path, strictServerAndBackupsValidation)
 	if err != nil {
 		return err
 	}
@@ -349,7 +711,13 @@ func (d *DockerManager) DeletePath(containerID string, path string) error {
 // DownloadFile downloads a file from a container
 func (d *DockerManager) DownloadFile(containerID string, path string) (io.ReadCloser, error) {
 	// Validate path
-	validPath, err := d.validatePath(path, serverAndBackupsValidation)
+	validPath, err := d.validatePath(path, strictServerAndBackupsValidation)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve symlinks so downloads can't escape the allowed directories
+	validPath, err = d.resolvePath(containerID, validPath, strictServerAndBackupsValidation)
 	if err != nil {
 		return nil, err
 	}
@@ -361,8 +729,8 @@ func (d *DockerManager) DownloadFile(containerID string, path string) (io.ReadCl
 
 // copyFromContainer handles the Docker API path conversion and copy operation
 func (d *DockerManager) copyFromContainer(containerID string, path string) (io.ReadCloser, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	// The copy streams for as long as the caller reads, so cancel when it closes rather than on a timer
+	ctx, cancel := context.WithCancel(context.Background())
 
 	// Use absolute path directly - Docker API can handle absolute paths
 	dockerPath := path
@@ -371,6 +739,7 @@ func (d *DockerManager) copyFromContainer(containerID string, path string) (io.R
 
 	reader, _, err := d.client.CopyFromContainer(ctx, containerID, dockerPath)
 	if err != nil {
+		cancel()
 		log.Error().Err(err).Str("docker_path", dockerPath).Str("container_id", containerID).Msg("Docker copy from container failed")
 		return nil, &DockerError{
 			Op:  "copy_from_container",
@@ -380,13 +749,30 @@ func (d *DockerManager) copyFromContainer(containerID string, path string) (io.R
 	}
 
 	log.Info().Str("docker_path", dockerPath).Str("container_id", containerID).Msg("Docker copy from container successful")
-	return reader, nil
+	return &cancelOnClose{ReadCloser: reader, cancel: cancel}, nil
+}
+
+// cancelOnClose releases a stream's context once the stream is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
 }
 
 // UploadFile uploads a file to a container
 func (d *DockerManager) UploadFile(containerID string, destPath string, reader io.Reader) error {
 	// Validate path
-	_, err := d.validatePath(destPath, serverOnlyValidation)
+	destPath, err := d.validatePath(destPath, strictServerOnlyValidation)
+	if err != nil {
+		return err
+	}
+
+	// Resolve symlinks so the upload can't land outside /data/server
+	destPath, err = d.resolvePath(containerID, destPath, strictServerOnlyValidation)
 	if err != nil {
 		return err
 	}
@@ -410,160 +796,83 @@ func (d *DockerManager) UploadFile(containerID string, destPath string, reader i
 // RenameFile renames a file in a container
 func (d *DockerManager) RenameFile(containerID string, oldPath string, newPath string) error {
 	// Validate both paths
-	_, err := d.validatePath(oldPath, serverOnlyValidation)
+	oldPath, err := d.validatePath(oldPath, strictServerOnlyValidation)
 	if err != nil {
 		return err
 	}
-	_, err = d.validatePath(newPath, serverOnlyValidation)
+	newPath, err = d.validatePath(newPath, strictServerOnlyValidation)
 	if err != nil {
 		return err
 	}
 
-	return d.execCommandSimple(containerID, []string{"mv", oldPath, newPath}, "rename_file")
+	// Resolve symlinked parents; a symlink itself is renamed, never its target
+	if oldPath, err = d.resolveParentPath(containerID, oldPath, strictServerOnlyValidation); err != nil {
+		return err
+	}
+	if newPath, err = d.resolveParentPath(containerID, newPath, strictServerOnlyValidation); err != nil {
+		return err
+	}
+
+	// -T renames onto newPath itself instead of moving into it when it is a directory, which
+	// could be a symlink leading out of /data/server
+	return d.execCommandSimple(containerID, []string{"mv", "-T", "--", oldPath, newPath}, "rename_file")
 }
 
 // Helper functions for file operations
 
-func parseLsOutput(output string, basePath string) []*models.FileInfo {
-	var files []*models.FileInfo
-	lines := strings.Split(strings.TrimSpace(output), "\n")
+// listFilesFormat is the find -printf format ListFiles parses: type, size, modification time in
+// Unix seconds, name and symlink target, each NUL terminated so no filename can break the parse
+const listFilesFormat = `%y\0%s\0%T@\0%f\0%l\0`
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "total") {
-			continue
-		}
+// listFilesFields is the number of fields listFilesFormat prints per entry
+const listFilesFields = 5
 
-		// Parse ls -la output
-		// Example: drwxr-xr-x 2 root root 4096 Jan 1 12:00 dirname
-		fields := strings.Fields(line)
-		if len(fields) < 9 {
-			continue
-		}
+// parseFindOutput parses the entries of a directory listed with listFilesFormat
+func parseFindOutput(output string, basePath string) []*models.FileInfo {
+	// Every field ends in a NUL, so whatever follows the last one belongs to a record cut short
+	fields := strings.Split(output, "\x00")
+	fields = fields[:len(fields)-1]
+	files := make([]*models.FileInfo, 0, len(fields)/listFilesFields)
 
-		// Get permissions and file type
-		perms := fields[0]
-		isDir := perms[0] == 'd'
-
-		// Get size
-		size, _ := strconv.ParseInt(fields[4], 10, 64)
-
-		// Get name (everything after the time fields)
-		// Fields: [0]perms [1]links [2]owner [3]group [4]size [5]month [6]day [7]time [8+]name
-		name := strings.Join(fields[8:], " ")
-
-		// Skip . and .. entries
-		if name == "." || name == ".." {
+	for i := 0; i+listFilesFields <= len(fields); i += listFilesFields {
+		kind, sizeField, mtimeField, name, linkTarget := fields[i], fields[i+1], fields[i+2], fields[i+3], fields[i+4]
+		if name == "" || name == "." || name == ".." {
 			continue
 		}
 
-		// Clean the filename
-		cleanName := cleanFilename(name)
-		if cleanName == "" {
-			continue
-		}
-
-		// Parse timestamp - for backup files, extract from filename; otherwise use ls output
-		var modTime time.Time
-		if strings.HasPrefix(cleanName, "backup-") && strings.HasSuffix(cleanName, ".tar.gz") {
-			// Extract timestamp from backup filename: backup-YYYY-MM-DD_HH-MM-SS.tar.gz
-			modTime = parseBackupTimestamp(cleanName)
-		} else {
-			// For other files, parse from ls output
-			modTime = parseFileTimestamp(fields[5], fields[6], fields[7])
-		}
-
-		file := &models.FileInfo{
-			Name:     cleanName,
-			Path:     filepath.Join(basePath, cleanName),
-			IsDir:    isDir,
-			Size:     size,
-			Modified: modTime.Format("2006-01-02 15:04:05"),
-		}
-
-		files = append(files, file)
+		size, _ := strconv.ParseInt(sizeField, 10, 64)
+		modTime := parseUnixTime(mtimeField)
+
+		files = append(files, &models.FileInfo{
+			Name:       name,
+			Path:       filepath.Join(basePath, name),
+			IsDir:      kind == "d",
+			IsSymlink:  kind == "l",
+			LinkTarget: linkTarget,
+			Size:       size,
+			Modified:   modTime.Format("2006-01-02 15:04:05"),
+			ModifiedAt: modTime,
+		})
 	}
 
 	return files
 }
 
-func parseBackupTimestamp(filename string) time.Time {
-	// Extract timestamp from backup filename: backup-YYYY-MM-DD_HH-MM-SS.tar.gz
-	// Remove "backup-" prefix and ".tar.gz" suffix
-	if !strings.HasPrefix(filename, "backup-") || !strings.HasSuffix(filename, ".tar.gz") {
-		return time.Now()
-	}
-
-	// Extract the timestamp part: YYYY-MM-DD_HH-MM-SS
-	timestampPart := filename[7 : len(filename)-7] // Remove "backup-" and ".tar.gz"
-
-	// Parse the timestamp: YYYY-MM-DD_HH-MM-SS
-	parsedTime, err := time.Parse("2006-01-02_15-04-05", timestampPart)
-	if err != nil {
-		// Fallback to current time if parsing fails
-		return time.Now()
-	}
-
-	return parsedTime
-}
-
-func parseFileTimestamp(month, day, timeOrYear string) time.Time {
-	// Parse month
-	monthMap := map[string]time.Month{
-		"Jan": time.January, "Feb": time.February, "Mar": time.March,
-		"Apr": time.April, "May": time.May, "Jun": time.June,
-		"Jul": time.July, "Aug": time.August, "Sep": time.September,
-		"Oct": time.October, "Nov": time.November, "Dec": time.December,
-	}
-
-	monthNum := monthMap[month]
-	if monthNum == 0 {
-		// Fallback to current time if parsing fails
-		return time.Now()
-	}
-
-	// Parse day
-	dayNum, err := strconv.Atoi(day)
+// parseUnixTime parses find's %T@, Unix seconds with a fractional part, e.g. 1714564800.1234567890
+func parseUnixTime(value string) time.Time {
+	secField, fracField, _ := strings.Cut(value, ".")
+	sec, err := strconv.ParseInt(secField, 10, 64)
 	if err != nil {
-		return time.Now()
+		return time.Time{}
 	}
 
-	now := time.Now()
-	currentYear := now.Year()
-
-	// Check if timeOrYear is a time (HH:MM) or year (YYYY)
-	if strings.Contains(timeOrYear, ":") {
-		// It's a time, assume current year
-		timeParts := strings.Split(timeOrYear, ":")
-		if len(timeParts) != 2 {
-			return time.Now()
-		}
-
-		hour, err1 := strconv.Atoi(timeParts[0])
-		minute, err2 := strconv.Atoi(timeParts[1])
-		if err1 != nil || err2 != nil {
-			return time.Now()
-		}
-
-		// Create date with current year
-		fileTime := time.Date(currentYear, monthNum, dayNum, hour, minute, 0, 0, time.UTC)
-
-		// If this date is in the future, it's probably from last year
-		if fileTime.After(now) {
-			fileTime = time.Date(currentYear-1, monthNum, dayNum, hour, minute, 0, 0, time.UTC)
-		}
-
-		return fileTime
-	} else {
-		// It's a year
-		year, err := strconv.Atoi(timeOrYear)
-		if err != nil {
-			return time.Now()
-		}
-
-		// Assume noon for files from previous years
-		return time.Date(year, monthNum, dayNum, 12, 0, 0, 0, time.UTC)
+	// Keep nanosecond precision, padding or cutting the fraction to 9 digits
+	var nsec int64
+	if fracField != "" {
+		fracField = (fracField + "000000000")[:9]
+		nsec, _ = strconv.ParseInt(fracField, 10, 64)
 	}
+	return time.Unix(sec, nsec)
 }
 
 func sortFiles(files []*models.FileInfo, isBackupsPath bool) []*models.FileInfo {
@@ -592,7 +901,7 @@ func sortFiles(files []*models.FileInfo, isBackupsPath bool) []*models.FileInfo
 	if isBackupsPath {
 		// Sort backups by modification time (newest first)
 		sort.Slice(regularFiles, func(i, j int) bool {
-			return regularFiles[i].Modified > regularFiles[j].Modified
+			return regularFiles[i].ModifiedAt.After(regularFiles[j].ModifiedAt)
 		})
 	} else {
 		// Sort files by size (largest first) for file manager
@@ -638,20 +947,3 @@ func createTarArchive(filename string, content []byte) (io.Reader, error) {
 
 	return &buf, nil
 }
-
-func cleanFilename(filename string) string {
-	// Simple cleaning - just remove obvious problematic characters
-	cleaned := strings.TrimSpace(filename)
-
-	// Skip empty names and parent directory references
-	if cleaned == "" || cleaned == "." || cleaned == ".." {
-		return ""
-	}
-
-	// Remove any null bytes or other control characters
-	cleaned = strings.ReplaceAll(cleaned, "\x00", "")
-	cleaned = strings.ReplaceAll(cleaned, "\r", "")
-	cleaned = strings.ReplaceAll(cleaned, "\n", "")
-
-	return cleaned
-}
@@ -0,0 +1,225 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidatePathStrictDeniesTraversal(t *testing.T) {
+	d := &DockerManager{}
+	denied := []string{
+		"",
+		"/",
+		"/etc/passwd",
+		"/data",
+		"/data/backups/../server/../../etc/passwd",
+		"/data/server/../../etc/passwd",
+		"/data/server/../backups/../../root/.ssh/id_rsa",
+		"/data/serverfoo/file",
+		"/data/backups-old/backup.tar.gz",
+		"data/server/../../etc/passwd",
+		"../data/server",
+	}
+	for _, path := range denied {
+		if got, err := d.validatePath(path, strictServerAndBackupsValidation); err == nil {
+			t.Errorf("validatePath(%q) = %q, want access denied", path, got)
+		}
+	}
+}
+
+func TestValidatePathStrictAllowsDataPaths(t *testing.T) {
+	d := &DockerManager{}
+	allowed := map[string]string{
+		"/data/server":                              "/data/server",
+		"/data/server/world/level.dat":              "/data/server/world/level.dat",
+		"/data/backups/backup.tar.gz":               "/data/backups/backup.tar.gz",
+		"/data/server/logs/../server.properties":    "/data/server/server.properties",
+		"/data/backups/../server/server.properties": "/data/server/server.properties",
+		"/data/server//plugins/./config.yml":        "/data/server/plugins/config.yml",
+	}
+	for path, want := range allowed {
+		got, err := d.validatePath(path, strictServerAndBackupsValidation)
+		if err != nil {
+			t.Errorf("validatePath(%q) returned error: %v", path, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("validatePath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestValidatePathDefaultRedirectsOutsidePrefixes(t *testing.T) {
+	d := &DockerManager{}
+	for _, path := range []string{"", "/", "/etc", "/data/backups/../../etc/passwd"} {
+		got, err := d.validatePath(path, serverAndBackupsValidation)
+		if err != nil {
+			t.Errorf("validatePath(%q) returned error: %v", path, err)
+			continue
+		}
+		if got != "/data/server" {
+			t.Errorf("validatePath(%q) = %q, want the default /data/server", path, got)
+		}
+	}
+}
+
+// findRecord formats one entry the way find -printf listFilesFormat does
+func findRecord(kind, size, mtime, name, target string) string {
+	return strings.Join([]string{kind, size, mtime, name, target}, "\x00") + "\x00"
+}
+
+func TestParseFindOutput(t *testing.T) {
+	output := findRecord("d", "4096", "1714564800.5", "world", "") +
+		findRecord("f", "1024", "1714564801.1234567890", "My Server (1).properties", "") +
+		findRecord("f", "12", "1714564802", "line one\nline two.txt", "") +
+		findRecord("f", "0", "1714564803.0", "  leading and trailing  ", "") +
+		findRecord("l", "21", "1714564804.25", "latest.log", "/data/server/logs/2024-05-01.log") +
+		findRecord("l", "9", "1714564805", "dangling", "../missing target")
+
+	files := parseFindOutput(output, "/data/server")
+
+	want := []struct {
+		name, target string
+		dir, symlink bool
+		size         int64
+		modified     time.Time
+	}{
+		{"world", "", true, false, 4096, time.Unix(1714564800, 500000000)},
+		{"My Server (1).properties", "", false, false, 1024, time.Unix(1714564801, 123456789)},
+		{"line one\nline two.txt", "", false, false, 12, time.Unix(1714564802, 0)},
+		{"  leading and trailing  ", "", false, false, 0, time.Unix(1714564803, 0)},
+		{"latest.log", "/data/server/logs/2024-05-01.log", false, true, 21, time.Unix(1714564804, 250000000)},
+		{"dangling", "../missing target", false, true, 9, time.Unix(1714564805, 0)},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("parsed %d entries, want %d: %+v", len(files), len(want), files)
+	}
+	for i, w := range want {
+		f := files[i]
+		if f.Name != w.name || f.Path != "/data/server/"+w.name || f.IsDir != w.dir || f.IsSymlink != w.symlink ||
+			f.LinkTarget != w.target || f.Size != w.size || !f.ModifiedAt.Equal(w.modified) {
+			t.Errorf("entry %d = %+v, want %+v", i, f, w)
+		}
+		if f.Modified != w.modified.Format("2006-01-02 15:04:05") {
+			t.Errorf("entry %d Modified = %q, want %q", i, f.Modified, w.modified.Format("2006-01-02 15:04:05"))
+		}
+	}
+}
+
+func TestParseFindOutputDropsTruncatedRecord(t *testing.T) {
+	complete := findRecord("f", "10", "1714564800", "server.properties", "")
+	for _, truncated := range []string{
+		"f",
+		"f\x0010\x00",
+		"f\x0010\x001714564800\x00ops.js",
+		// The final NUL is missing, so the target may be cut short too
+		"l\x0010\x001714564800\x00link\x00/data/server/wor",
+	} {
+		files := parseFindOutput(complete+truncated, "/data/server")
+		if len(files) != 1 || files[0].Name != "server.properties" {
+			t.Errorf("parseFindOutput with truncated record %q = %+v, want only the complete entry", truncated, files)
+		}
+	}
+}
+
+func TestParseFindOutputSkipsDotEntries(t *testing.T) {
+	output := findRecord("d", "4096", "1714564800", ".", "") + findRecord("d", "4096", "1714564800", "..", "") +
+		findRecord("f", "1", "1714564800", ".hidden", "")
+	files := parseFindOutput(output, "/data/server")
+	if len(files) != 1 || files[0].Name != ".hidden" {
+		t.Errorf("parsed %+v, want only .hidden", files)
+	}
+	if files := parseFindOutput("", "/data/server"); len(files) != 0 {
+		t.Errorf("empty output parsed to %+v", files)
+	}
+}
+
+// fakeRun stands in for ExecCommand: readlink -f resolves through links, and every other
+// command is recorded without running
+type fakeRun struct {
+	links map[string]string // Symlink path to its target
+	cmds  [][]string
+}
+
+func (f *fakeRun) run(containerID string, cmd []string) (string, error) {
+	if cmd[0] != "readlink" {
+		f.cmds = append(f.cmds, cmd)
+		return "", nil
+	}
+	path := cmd[len(cmd)-1]
+	for link, target := range f.links {
+		if path == link || strings.HasPrefix(path, link+"/") {
+			return target + strings.TrimPrefix(path, link) + "\n", nil
+		}
+	}
+	return path + "\n", nil
+}
+
+func newFakeRunManager() (*DockerManager, *fakeRun) {
+	fake := &fakeRun{links: map[string]string{
+		"/data/server/scripts": "/data/scripts",
+		"/data/server/root":    "/",
+		"/data/server/world2":  "/data/server/world",
+	}}
+	return &DockerManager{run: fake.run}, fake
+}
+
+func TestCreateDirectoryDeniesSymlinkEscape(t *testing.T) {
+	for _, path := range []string{"/data/server/scripts/evil", "/data/server/root/etc/cron.d", "/etc", "/data/server/../scripts/x"} {
+		d, fake := newFakeRunManager()
+		if err := d.CreateDirectory("c1", path); err == nil {
+			t.Errorf("CreateDirectory(%q) succeeded, want access denied", path)
+		}
+		if len(fake.cmds) != 0 {
+			t.Errorf("CreateDirectory(%q) ran %q", path, fake.cmds)
+		}
+	}
+
+	d, fake := newFakeRunManager()
+	if err := d.CreateDirectory("c1", "/data/server/world2/region"); err != nil {
+		t.Fatalf("CreateDirectory inside a link to /data/server: %v", err)
+	}
+	if want := []string{"mkdir", "-p", "--", "/data/server/world/region"}; len(fake.cmds) != 1 || strings.Join(fake.cmds[0], " ") != strings.Join(want, " ") {
+		t.Errorf("commands = %q, want %q", fake.cmds, want)
+	}
+}
+
+func TestUploadFileDeniesSymlinkEscape(t *testing.T) {
+	// The client is nil, so an upload that got past the checks would panic rather than pass
+	for _, path := range []string{"/data/server/scripts", "/data/server/root", "/data/server/scripts/sub", "/data"} {
+		d, _ := newFakeRunManager()
+		if err := d.UploadFile("c1", path, strings.NewReader("")); err == nil {
+			t.Errorf("UploadFile(%q) succeeded, want access denied", path)
+		}
+	}
+}
+
+func TestRenameFileDeniesSymlinkEscape(t *testing.T) {
+	tests := []struct{ oldPath, newPath string }{
+		{"/data/server/server.properties", "/data/server/scripts/send-command.sh"},
+		{"/data/server/root/etc/passwd", "/data/server/passwd"},
+		{"/data/server/scripts/send-command.sh", "/data/server/send-command.sh"},
+		{"/data/server/ops.json", "/etc/ops.json"},
+		{"/data/server", "/data/server-old"},
+	}
+	for _, tt := range tests {
+		d, fake := newFakeRunManager()
+		if err := d.RenameFile("c1", tt.oldPath, tt.newPath); err == nil {
+			t.Errorf("RenameFile(%q, %q) succeeded, want access denied", tt.oldPath, tt.newPath)
+		}
+		if len(fake.cmds) != 0 {
+			t.Errorf("RenameFile(%q, %q) ran %q", tt.oldPath, tt.newPath, fake.cmds)
+		}
+	}
+
+	// Renaming a link moves the link itself, and never into a directory it points at
+	d, fake := newFakeRunManager()
+	if err := d.RenameFile("c1", "/data/server/world2", "/data/server/world-link"); err != nil {
+		t.Fatalf("RenameFile of a link: %v", err)
+	}
+	want := "mv -T -- /data/server/world2 /data/server/world-link"
+	if len(fake.cmds) != 1 || strings.Join(fake.cmds[0], " ") != want {
+		t.Errorf("commands = %q, want %q", fake.cmds, want)
+	}
+}
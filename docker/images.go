@@ -1,19 +1,64 @@
 package docker
 
 import (
+	"archive/tar"
+	"bufio"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
+	"path"
 	"strings"
+	"time"
 
+	"github.com/docker/docker/api/types/build"
 	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/errdefs"
 	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
 )
 
-// pullImageIfNeeded implements a smart pull strategy that only pulls if there's a newer image
+// imagePull is an in-flight pullImageIfNeeded that concurrent callers for the same image wait on
+type imagePull struct {
+	done chan struct{}
+	err  error
+}
+
+// pullImageIfNeeded implements a smart pull strategy that only pulls if there's a newer image.
+// Concurrent calls for the same image share a single check and pull.
 func (d *DockerManager) pullImageIfNeeded(ctx context.Context, imageName string) error {
+	d.pullMu.Lock()
+	if pull, ok := d.pulls[imageName]; ok {
+		d.pullMu.Unlock()
+		log.Debug().Str("image", imageName).Msg("Image pull already in progress, waiting for it")
+		select {
+		case <-pull.done:
+			return pull.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	pull := &imagePull{done: make(chan struct{})}
+	d.pulls[imageName] = pull
+	d.pullMu.Unlock()
+
+	// Other callers depend on this pull, so it must outlive the caller that started it
+	pull.err = d.checkAndPullImage(context.WithoutCancel(ctx), imageName)
+
+	d.pullMu.Lock()
+	delete(d.pulls, imageName)
+	d.pullMu.Unlock()
+	close(pull.done)
+
+	return pull.err
+}
+
+// checkAndPullImage pulls imageName if the registry has a newer version than the local copy
+func (d *DockerManager) checkAndPullImage(ctx context.Context, imageName string) error {
 	log.Debug().Str("image", imageName).Msg("Checking if image pull is needed")
 
 	// Check if we should pull the image
@@ -32,6 +77,67 @@ func (d *DockerManager) pullImageIfNeeded(ctx context.Context, imageName string)
 	return d.pullImage(ctx, imageName)
 }
 
+// pullImageIfMissing pulls imageName only when there is no local copy
+func (d *DockerManager) pullImageIfMissing(ctx context.Context, imageName string) error {
+	if _, err := d.client.ImageInspect(ctx, imageName); err == nil {
+		log.Debug().Str("image", imageName).Msg("Pinned image present locally, skipping pull")
+		return nil
+	}
+	return d.pullImage(ctx, imageName)
+}
+
+// Bounds on registry calls made on request rather than during container creation
+const (
+	imageRegistryTimeout = time.Minute      // Digest lookup
+	imagePullTimeout     = 30 * time.Minute // Full pull of a large game image
+)
+
+// PullImage pulls the latest version of an image from its registry
+func (d *DockerManager) PullImage(imageName string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), imagePullTimeout)
+	defer cancel()
+	return d.pullImage(ctx, imageName)
+}
+
+// RemoteImageDigest returns the digest ("sha256:...") the registry currently serves for an
+// image, without pulling it
+func (d *DockerManager) RemoteImageDigest(imageName string) (string, error) {
+	if d.podman {
+		return "", &DockerError{Op: "remote_digest", Msg: "checking the registry for image updates isn't supported with Podman"}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), imageRegistryTimeout)
+	defer cancel()
+
+	digest, err := d.getRemoteImageDigest(ctx, imageName)
+	if err != nil {
+		return "", &DockerError{Op: "remote_digest", Msg: fmt.Sprintf("failed to look up %s in its registry", imageName), Err: err}
+	}
+	return digest, nil
+}
+
+// LocalImageDigest returns the registry digest ("sha256:...") of the local copy of an image.
+// Returns "" if the image hasn't been pulled, or was built locally.
+func (d *DockerManager) LocalImageDigest(imageName string) (string, error) {
+	inspect, err := d.client.ImageInspect(context.Background(), imageName)
+	if errdefs.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", &DockerError{Op: "image_inspect", Msg: fmt.Sprintf("failed to inspect image %s", imageName), Err: err}
+	}
+
+	repo := models.ImageRepository(imageName)
+	for _, repoDigest := range inspect.RepoDigests {
+		if models.ImageRepository(repoDigest) == repo {
+			return models.ImagePinnedDigest(repoDigest), nil
+		}
+	}
+	if len(inspect.RepoDigests) > 0 {
+		return models.ImagePinnedDigest(inspect.RepoDigests[0]), nil
+	}
+	return "", nil
+}
+
 // shouldPullImage determines if we should pull the image based on comparing local and remote digests
 func (d *DockerManager) shouldPullImage(ctx context.Context, imageName string) (bool, error) {
 	// First, check if the image exists locally
@@ -42,16 +148,20 @@ func (d *DockerManager) shouldPullImage(ctx context.Context, imageName string) (
 		return true, nil
 	}
 
-	// Get the local image digest
-	var localDigest string
-	if len(localImage.RepoDigests) > 0 {
-		localDigest = localImage.RepoDigests[0]
-		log.Debug().Str("image", imageName).Str("local_digest", localDigest).Msg("Found local image digest")
-	} else {
-		// No repo digest available (probably built locally), check by ID
-		log.Debug().Str("image", imageName).Str("local_id", localImage.ID).Msg("No repo digest found, using image ID")
-		localDigest = localImage.ID
+	// Images built locally (e.g. custom game images) have no registry to update from
+	if len(localImage.RepoDigests) == 0 {
+		log.Debug().Str("image", imageName).Str("local_id", localImage.ID).Msg("Image was built locally, skipping pull")
+		return false, nil
+	}
+
+	// Podman's compat API doesn't implement DistributionInspect; its pull only
+	// fetches changed layers, so just pull and let Podman decide
+	if d.podman {
+		log.Debug().Str("image", imageName).Msg("Podman mode, pulling to check for updates")
+		return true, nil
 	}
+	localDigest := localImage.RepoDigests[0]
+	log.Debug().Str("image", imageName).Str("local_digest", localDigest).Msg("Found local image digest")
 
 	// Get remote image digest
 	remoteDigest, err := d.getRemoteImageDigest(ctx, imageName)
@@ -125,3 +235,121 @@ func (d *DockerManager) pullImage(ctx context.Context, imageName string) error {
 	log.Info().Str("image", imageName).Msg("Successfully pulled Docker image")
 	return nil
 }
+
+// buildMessage is one line of the JSON progress stream returned by an image build
+type buildMessage struct {
+	Stream      string `json:"stream"`
+	Status      string `json:"status"`
+	Error       string `json:"error"`
+	ErrorDetail struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+}
+
+// BuildImage builds and tags an image from a Dockerfile, using the files of an optional tar
+// or tar.gz archive as the build context. A Dockerfile in the archive is replaced by the one
+// given. Returns the build output, which is also useful when the build fails.
+func (d *DockerManager) BuildImage(tag, dockerfile string, contextArchive io.Reader) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Hour)
+	defer cancel()
+
+	log.Info().Str("image", tag).Msg("Building Docker image")
+
+	// Stream the assembled context into the build rather than buffering it
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeBuildContext(pw, dockerfile, contextArchive))
+	}()
+	defer pr.Close()
+
+	resp, err := d.client.ImageBuild(ctx, pr, build.ImageBuildOptions{
+		Tags:        []string{tag},
+		Dockerfile:  "Dockerfile",
+		Remove:      true,
+		ForceRemove: true,
+		Labels:      map[string]string{"gameserver.custom-image": "true"},
+	})
+	if err != nil {
+		return "", &DockerError{
+			Op:  "build_image",
+			Msg: fmt.Sprintf("failed to build image %s", tag),
+			Err: err,
+		}
+	}
+	defer resp.Body.Close()
+
+	var output strings.Builder
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var msg buildMessage
+		if err := decoder.Decode(&msg); err == io.EOF {
+			break
+		} else if err != nil {
+			return output.String(), &DockerError{Op: "build_image", Msg: "failed to read build output", Err: err}
+		}
+
+		if msg.Error != "" {
+			output.WriteString(msg.Error + "\n")
+			return output.String(), &DockerError{
+				Op:  "build_image",
+				Msg: fmt.Sprintf("build of image %s failed: %s", tag, msg.Error),
+			}
+		}
+		if msg.Stream != "" {
+			output.WriteString(msg.Stream)
+		} else if msg.Status != "" {
+			output.WriteString(msg.Status + "\n")
+		}
+	}
+
+	log.Info().Str("image", tag).Msg("Successfully built Docker image")
+	return output.String(), nil
+}
+
+// writeBuildContext writes a build context tar to w: the entries of contextArchive (plain or
+// gzipped tar, may be nil) followed by the Dockerfile
+func writeBuildContext(w io.Writer, dockerfile string, contextArchive io.Reader) error {
+	tw := tar.NewWriter(w)
+
+	if contextArchive != nil {
+		source := bufio.NewReader(contextArchive)
+		if magic, _ := source.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+			gz, err := gzip.NewReader(source)
+			if err != nil {
+				return fmt.Errorf("failed to read build context: %w", err)
+			}
+			defer gz.Close()
+			contextArchive = gz
+		} else {
+			contextArchive = source
+		}
+
+		tr := tar.NewReader(contextArchive)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read build context: %w", err)
+			}
+			if path.Clean(strings.TrimPrefix(header.Name, "./")) == "Dockerfile" {
+				continue
+			}
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+			if _, err := io.Copy(tw, tr); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tw.WriteHeader(&tar.Header{Name: "Dockerfile", Mode: 0644, Size: int64(len(dockerfile)), ModTime: time.Now()}); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(tw, dockerfile); err != nil {
+		return err
+	}
+	return tw.Close()
+}
@@ -0,0 +1,169 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// secretsDir is where secret files are placed inside the container
+const secretsDir = "/run/secrets"
+
+// splitSecretEnv moves the named variables out of env, replacing each with a
+// FILE__NAME reference to the file that will hold its value
+func splitSecretEnv(env []string, secretVars []string) ([]string, map[string]string) {
+	if len(secretVars) == 0 {
+		return env, nil
+	}
+
+	isSecret := make(map[string]bool, len(secretVars))
+	for _, name := range secretVars {
+		isSecret[name] = true
+	}
+
+	result := make([]string, 0, len(env))
+	secrets := make(map[string]string)
+	for _, entry := range env {
+		name, value, ok := strings.Cut(entry, "=")
+		// Empty secrets stay as-is so scripts can still tell "unset" from "set"
+		if !ok || !isSecret[name] || value == "" {
+			result = append(result, entry)
+			continue
+		}
+		secrets[name] = value
+		result = append(result, fmt.Sprintf("FILE__%s=%s/%s", name, secretsDir, name))
+	}
+	return result, secrets
+}
+
+// copySecrets writes secret values into a created container's secrets directory, readable
+// only by the user the container runs as
+func (d *DockerManager) copySecrets(ctx context.Context, containerID string, secrets map[string]string) error {
+	uid, gid, err := d.containerUser(ctx, containerID)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	dir := path.Base(secretsDir)
+	if err := tw.WriteHeader(&tar.Header{Name: dir + "/", Typeflag: tar.TypeDir, Mode: 0500, Uid: uid, Gid: gid, ModTime: time.Now()}); err != nil {
+		return d.wrapErr("create_tar", "failed to add secrets directory to tar archive", err)
+	}
+	for name, value := range secrets {
+		if err := tw.WriteHeader(&tar.Header{Name: dir + "/" + name, Mode: 0400, Uid: uid, Gid: gid, Size: int64(len(value)), ModTime: time.Now()}); err != nil {
+			return d.wrapErr("create_tar", fmt.Sprintf("failed to add secret %s to tar archive", name), err)
+		}
+		if _, err := tw.Write([]byte(value)); err != nil {
+			return d.wrapErr("create_tar", fmt.Sprintf("failed to add secret %s to tar archive", name), err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return d.wrapErr("create_tar", "failed to finalize tar archive", err)
+	}
+
+	if err := d.client.CopyToContainer(ctx, containerID, path.Dir(secretsDir), &buf, container.CopyToContainerOptions{}); err != nil {
+		return &DockerError{
+			Op:  "copy_secrets",
+			Msg: fmt.Sprintf("failed to copy secrets to container %s", containerID),
+			Err: err,
+		}
+	}
+	return nil
+}
+
+// containerUser returns the uid and gid a created container runs as, looking up user and
+// group names in the container's own /etc/passwd and /etc/group
+func (d *DockerManager) containerUser(ctx context.Context, containerID string) (int, int, error) {
+	inspect, err := d.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return 0, 0, d.wrapErr("container_user", fmt.Sprintf("failed to inspect container %s", containerID), err)
+	}
+	spec := ""
+	if inspect.Config != nil {
+		spec = inspect.Config.User
+	}
+	if spec == "" {
+		return 0, 0, nil
+	}
+
+	// Both files are optional: numeric users don't need them
+	passwd, _ := d.readContainerFile(ctx, containerID, "/etc/passwd")
+	group, _ := d.readContainerFile(ctx, containerID, "/etc/group")
+	uid, gid, err := resolveUser(spec, string(passwd), string(group))
+	if err != nil {
+		return 0, 0, d.wrapErr("container_user", fmt.Sprintf("failed to resolve user of container %s", containerID), err)
+	}
+	return uid, gid, nil
+}
+
+// readContainerFile reads a small file from a container, which doesn't need to be running
+func (d *DockerManager) readContainerFile(ctx context.Context, containerID string, filePath string) ([]byte, error) {
+	reader, _, err := d.client.CopyFromContainer(ctx, containerID, filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	tr := tar.NewReader(reader)
+	if _, err := tr.Next(); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(io.LimitReader(tr, 1024*1024))
+}
+
+// resolveUser turns a container user spec (user, uid, user:group or uid:gid) into numeric ids
+// using the container's passwd and group files, the way Docker resolves them
+func resolveUser(spec string, passwd string, group string) (int, int, error) {
+	userPart, groupPart, hasGroup := strings.Cut(spec, ":")
+
+	uid, gid := -1, 0
+	if id, err := strconv.Atoi(userPart); err == nil {
+		uid = id
+	}
+	for _, line := range strings.Split(passwd, "\n") {
+		// name:password:uid:gid:gecos:home:shell
+		fields := strings.Split(line, ":")
+		if len(fields) < 4 {
+			continue
+		}
+		entryUID, err1 := strconv.Atoi(fields[2])
+		entryGID, err2 := strconv.Atoi(fields[3])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		if fields[0] == userPart || (uid >= 0 && entryUID == uid) {
+			uid, gid = entryUID, entryGID
+			break
+		}
+	}
+	if uid < 0 {
+		return 0, 0, fmt.Errorf("user %q not found in /etc/passwd", userPart)
+	}
+	if !hasGroup {
+		return uid, gid, nil
+	}
+
+	if id, err := strconv.Atoi(groupPart); err == nil {
+		return uid, id, nil
+	}
+	for _, line := range strings.Split(group, "\n") {
+		// name:password:gid:members
+		fields := strings.Split(line, ":")
+		if len(fields) >= 3 && fields[0] == groupPart {
+			if id, err := strconv.Atoi(fields[2]); err == nil {
+				return uid, id, nil
+			}
+		}
+	}
+	return 0, 0, fmt.Errorf("group %q not found in /etc/group", groupPart)
+}
@@ -0,0 +1,55 @@
+package docker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSecretEnv(t *testing.T) {
+	env := []string{"SERVER_NAME=My Server", "RCON_PASSWORD=hunter2", "PASSWORD=", "GSLT=abc"}
+
+	got, secrets := splitSecretEnv(env, []string{"RCON_PASSWORD", "PASSWORD", "GSLT"})
+
+	want := []string{"SERVER_NAME=My Server", "FILE__RCON_PASSWORD=/run/secrets/RCON_PASSWORD", "PASSWORD=", "FILE__GSLT=/run/secrets/GSLT"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("env = %v, want %v", got, want)
+	}
+	wantSecrets := map[string]string{"RCON_PASSWORD": "hunter2", "GSLT": "abc"}
+	if !reflect.DeepEqual(secrets, wantSecrets) {
+		t.Errorf("secrets = %v, want %v", secrets, wantSecrets)
+	}
+}
+
+func TestResolveUser(t *testing.T) {
+	passwd := "root:x:0:0:root:/root:/bin/bash\nsteam:x:1000:1000::/home/steam:/bin/bash\nminecraft:x:1001:50::/home/minecraft:/bin/sh\n"
+	group := "root:x:0:\nsteam:x:1000:\nstaff:x:50:minecraft\n"
+
+	tests := []struct {
+		spec     string
+		uid, gid int
+	}{
+		{"steam", 1000, 1000},
+		{"1000", 1000, 1000},
+		{"minecraft", 1001, 50},
+		{"steam:staff", 1000, 50},
+		{"steam:0", 1000, 0},
+		{"2000", 2000, 0},
+		{"2000:2000", 2000, 2000},
+	}
+	for _, tt := range tests {
+		uid, gid, err := resolveUser(tt.spec, passwd, group)
+		if err != nil {
+			t.Errorf("resolveUser(%q) returned error: %v", tt.spec, err)
+			continue
+		}
+		if uid != tt.uid || gid != tt.gid {
+			t.Errorf("resolveUser(%q) = %d:%d, want %d:%d", tt.spec, uid, gid, tt.uid, tt.gid)
+		}
+	}
+
+	for _, spec := range []string{"nobody", "steam:wheel"} {
+		if _, _, err := resolveUser(spec, passwd, group); err == nil {
+			t.Errorf("resolveUser(%q) succeeded, want an error", spec)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// verifyExtractScript extracts the newest backup from the live volume (mounted read-only
+// at /source) into the scratch volume and prints its filename first
+const verifyExtractScript = `latest=$(ls -t /source/backups/*.tar.gz /source/backups/*.tar.zst /source/backups/*.tar 2>/dev/null | head -n 1)
+[ -n "$latest" ] || { echo "no backups found"; exit 1; }
+basename "$latest"
+mkdir -p /data/server && case "$latest" in
+  *.tar.zst) zstd -dc "$latest" | tar -xf - -C /data/server ;;
+  *.tar) tar -xf "$latest" -C /data/server ;;
+  *) tar -xzf "$latest" -C /data/server ;;
+esac`
+
+// VerifyBackup restores a gameserver's latest backup into a throwaway volume and boots the
+// game image on it, requiring the server to stay up for bootTime. The live server's
+// container and volume are never written to. Returns the verified backup's filename.
+func (d *DockerManager) VerifyBackup(server *models.Gameserver, bootTime time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), bootTime+30*time.Minute)
+	defer cancel()
+
+	name := fmt.Sprintf("%s-verify-%s", d.namespace, server.Name)
+	scratchVolume := name + "-data"
+
+	// Clear leftovers from an interrupted verification
+	for _, leftover := range []string{name + "-extract", name} {
+		d.client.ContainerRemove(ctx, leftover, container.RemoveOptions{Force: true})
+	}
+
+	if err := d.pullImageIfNeeded(ctx, server.Image); err != nil {
+		log.Warn().Err(err).Str("image", server.Image).Msg("Failed to pull Docker image, proceeding anyway")
+	}
+	if err := d.CreateVolume(scratchVolume); err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := d.RemoveVolume(scratchVolume); err != nil {
+			log.Warn().Err(err).Str("volume", scratchVolume).Msg("Failed to remove backup verification volume")
+		}
+	}()
+
+	// Extract into the scratch volume
+	extractConfig := &container.Config{
+		Image:      server.Image,
+		Entrypoint: []string{"sh", "-c", verifyExtractScript},
+		Labels:     map[string]string{"gameserver.verify": server.ID},
+	}
+	extractHostConfig := &container.HostConfig{
+		Binds: []string{
+			fmt.Sprintf("%s:/source:ro", d.GetVolumeNameForServer(server)),
+			fmt.Sprintf("%s:/data", scratchVolume),
+		},
+		NetworkMode: "none",
+	}
+	output, exitCode, err := d.runToCompletion(ctx, name+"-extract", extractConfig, extractHostConfig)
+	if err != nil {
+		return "", err
+	}
+	backupFilename, _, _ := strings.Cut(strings.TrimSpace(output), "\n")
+	if exitCode != 0 {
+		return backupFilename, &DockerError{
+			Op:  "verify_backup",
+			Msg: fmt.Sprintf("failed to extract backup (exit code %d): %s", exitCode, strings.TrimSpace(output)),
+		}
+	}
+	log.Info().Str("gameserver_id", server.ID).Str("backup_file", backupFilename).Msg("Backup extracted for verification")
+
+	// Boot the game on the restored files, without published ports so it can't clash with the live server
+	env, secrets := d.containerEnv(server)
+	bootConfig := &container.Config{
+		Image:  server.Image,
+		Env:    env,
+		Labels: map[string]string{"gameserver.verify": server.ID},
+	}
+	bootHostConfig := &container.HostConfig{
+		Binds: []string{fmt.Sprintf("%s:/data", scratchVolume)},
+	}
+	d.applyResourceLimits(bootHostConfig, server)
+	bootHostConfig.Sysctls = server.Sysctls
+	bootHostConfig.CapAdd = server.CapAdd
+	bootHostConfig.ShmSize = int64(server.EffectiveShmSizeMB()) * 1024 * 1024
+
+	resp, err := d.client.ContainerCreate(ctx, bootConfig, bootHostConfig, nil, nil, name)
+	if err != nil {
+		return backupFilename, &DockerError{
+			Op:  "verify_backup",
+			Msg: fmt.Sprintf("failed to create verification container for server %s", server.Name),
+			Err: err,
+		}
+	}
+	defer d.RemoveContainer(resp.ID)
+
+	if len(secrets) > 0 {
+		if err := d.copySecrets(ctx, resp.ID, secrets); err != nil {
+			return backupFilename, err
+		}
+	}
+
+	waitCh, errCh := d.client.ContainerWait(ctx, resp.ID, container.WaitConditionNextExit)
+	if err := d.client.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return backupFilename, &DockerError{
+			Op:  "verify_backup",
+			Msg: fmt.Sprintf("failed to start verification container for server %s", server.Name),
+			Err: err,
+		}
+	}
+
+	select {
+	case <-time.After(bootTime):
+		log.Info().Str("gameserver_id", server.ID).Str("backup_file", backupFilename).Msg("Backup verified")
+		return backupFilename, nil
+	case result := <-waitCh:
+		return backupFilename, &DockerError{
+			Op:  "verify_backup",
+			Msg: fmt.Sprintf("server exited with code %d during verification: %s", result.StatusCode, d.containerOutput(ctx, resp.ID, "20")),
+		}
+	case err := <-errCh:
+		return backupFilename, d.wrapErr("verify_backup", "failed waiting on verification container", err)
+	}
+}
+
+// runToCompletion runs a one-off container and returns its output and exit code
+func (d *DockerManager) runToCompletion(ctx context.Context, name string, config *container.Config, hostConfig *container.HostConfig) (string, int64, error) {
+	resp, err := d.client.ContainerCreate(ctx, config, hostConfig, nil, nil, name)
+	if err != nil {
+		return "", 0, &DockerError{
+			Op:  "create",
+			Msg: fmt.Sprintf("failed to create container %s", name),
+			Err: err,
+		}
+	}
+	defer d.RemoveContainer(resp.ID)
+
+	waitCh, errCh := d.client.ContainerWait(ctx, resp.ID, container.WaitConditionNextExit)
+	if err := d.client.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return "", 0, &DockerError{
+			Op:  "start",
+			Msg: fmt.Sprintf("failed to start container %s", name),
+			Err: err,
+		}
+	}
+
+	select {
+	case result := <-waitCh:
+		return d.containerOutput(ctx, resp.ID, "all"), result.StatusCode, nil
+	case err := <-errCh:
+		return "", 0, d.wrapErr("wait", fmt.Sprintf("failed waiting on container %s", name), err)
+	}
+}
+
+// containerOutput returns a stopped container's combined stdout and stderr
+func (d *DockerManager) containerOutput(ctx context.Context, containerID string, tail string) string {
+	logs, err := d.client.ContainerLogs(ctx, containerID, container.LogsOptions{ShowStdout: true, ShowStderr: true, Tail: tail})
+	if err != nil {
+		return ""
+	}
+	defer logs.Close()
+
+	var buf bytes.Buffer
+	stdcopy.StdCopy(&buf, &buf, logs)
+	return strings.TrimSpace(buf.String())
+}
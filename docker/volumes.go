@@ -3,15 +3,24 @@ package docker
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/versions"
 	"github.com/docker/docker/api/types/volume"
 	"github.com/rs/zerolog/log"
 
 	"0xkowalskidev/gameservers/models"
 )
 
-// CreateVolume creates a Docker volume
+// CreateVolume creates a Docker volume. Host paths are left alone: Docker creates a missing
+// bind-mount directory itself.
 func (d *DockerManager) CreateVolume(volumeName string) error {
+	if models.IsHostPath(volumeName) {
+		return nil
+	}
 	ctx := context.Background()
 
 	// Check if volume already exists
@@ -61,13 +70,168 @@ func (d *DockerManager) RemoveVolume(volumeName string) error {
 	return nil
 }
 
-// GetVolumeNameForServer generates a volume name for a gameserver
+// PruneAnonymousVolumes removes unused anonymous volumes, such as those left behind by images
+// declaring VOLUME when containers are recreated. Volumes we manage are never pruned.
+// Returns the number of volumes removed and the bytes reclaimed.
+func (d *DockerManager) PruneAnonymousVolumes() (int, uint64, error) {
+	ctx := context.Background()
+
+	// Before API 1.42 (and on Podman) prune also removes unused named volumes, which may
+	// belong to other software on the host, so refuse rather than risk deleting them
+	if d.podman || versions.LessThan(d.client.ClientVersion(), "1.42") {
+		return 0, 0, &DockerError{
+			Op:  "prune_volumes",
+			Msg: fmt.Sprintf("anonymous-only volume prune needs Docker API 1.42+ (have %s, podman=%t)", d.client.ClientVersion(), d.podman),
+		}
+	}
+
+	filter := filters.NewArgs()
+	filter.Add("label!", "gameserver.managed")
+
+	report, err := d.client.VolumesPrune(ctx, filter)
+	if err != nil {
+		return 0, 0, &DockerError{
+			Op:  "prune_volumes",
+			Msg: "failed to prune anonymous volumes",
+			Err: err,
+		}
+	}
+
+	return len(report.VolumesDeleted), report.SpaceReclaimed, nil
+}
+
+// ListVolumes returns the volumes the panel created in its namespace, including those of
+// deleted gameservers that were kept
+func (d *DockerManager) ListVolumes() ([]*models.VolumeInfo, error) {
+	filter := filters.NewArgs()
+	filter.Add("label", "gameserver.managed=true")
+
+	resp, err := d.client.VolumeList(context.Background(), volume.ListOptions{Filters: filter})
+	if err != nil {
+		return nil, &DockerError{
+			Op:  "list_volumes",
+			Msg: "failed to list volumes",
+			Err: err,
+		}
+	}
+
+	var result []*models.VolumeInfo
+	for _, vol := range resp.Volumes {
+		if !strings.HasPrefix(vol.Name, d.namespace+"-") {
+			continue
+		}
+		result = append(result, &models.VolumeInfo{
+			Name:       vol.Name,
+			MountPoint: vol.Mountpoint,
+			Driver:     vol.Driver,
+			CreatedAt:  vol.CreatedAt,
+			Labels:     vol.Labels,
+		})
+	}
+	return result, nil
+}
+
+// GetVolumeNameForServer returns a gameserver's data volume: the adopted volume if one was
+// chosen at creation, otherwise one named after the server
 func (d *DockerManager) GetVolumeNameForServer(server *models.Gameserver) string {
+	if server.DataVolume != "" {
+		return server.DataVolume
+	}
 	return fmt.Sprintf("%s-%s-data", d.namespace, server.Name)
 }
 
-// GetVolumeInfo returns information about a Docker volume
+// VolumeHasServerData reports whether an existing volume holds gameserver files, by listing
+// /data/server from a throwaway container running image. Fails if the volume doesn't exist.
+func (d *DockerManager) VolumeHasServerData(volumeName, image string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	if _, err := d.client.VolumeInspect(ctx, volumeName); err != nil {
+		return false, &DockerError{
+			Op:  "inspect_volume",
+			Msg: fmt.Sprintf("volume %s not found", volumeName),
+			Err: err,
+		}
+	}
+
+	if err := d.pullImageIfNeeded(ctx, image); err != nil {
+		log.Warn().Err(err).Str("image", image).Msg("Failed to pull Docker image, proceeding anyway")
+	}
+
+	name := fmt.Sprintf("%s-inspect-%s", d.namespace, volumeName)
+	d.client.ContainerRemove(ctx, name, container.RemoveOptions{Force: true})
+
+	config := &container.Config{
+		Image:      image,
+		Entrypoint: []string{"sh", "-c", `[ -n "$(ls -A /data/server 2>/dev/null)" ]`},
+	}
+	hostConfig := &container.HostConfig{
+		Binds:       []string{fmt.Sprintf("%s:/data:ro", volumeName)},
+		NetworkMode: "none",
+	}
+	_, exitCode, err := d.runToCompletion(ctx, name, config, hostConfig)
+	if err != nil {
+		return false, err
+	}
+	return exitCode == 0, nil
+}
+
+// migrateDataScript copies /source into an empty /target, then compares checksums of every
+// file on both sides so a truncated or failed copy is caught before the server switches over
+const migrateDataScript = `if [ -n "$(ls -A /target 2>/dev/null)" ]; then echo "target is not empty"; exit 2; fi
+cp -a /source/. /target/ || exit 1
+(cd /source && find . -type f -exec cksum {} + | sort) > /tmp/source.sum || exit 1
+(cd /target && find . -type f -exec cksum {} + | sort) > /tmp/target.sum || exit 1
+[ "$(cksum < /tmp/source.sum)" = "$(cksum < /tmp/target.sum)" ] || { echo "copied files don't match the source"; exit 3; }
+echo "$(wc -l < /tmp/source.sum) files copied and verified"`
+
+// MigrateData copies a stopped gameserver's /data from one location to another, each either a
+// volume name or an absolute host path, and verifies the copy. The source is mounted
+// read-only and never modified; the target must be empty.
+func (d *DockerManager) MigrateData(source, target, image string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Hour)
+	defer cancel()
+
+	if err := d.pullImageIfNeeded(ctx, image); err != nil {
+		log.Warn().Err(err).Str("image", image).Msg("Failed to pull Docker image, proceeding anyway")
+	}
+	if err := d.CreateVolume(target); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-migrate-%d", d.namespace, time.Now().UnixNano())
+	config := &container.Config{
+		Image:      image,
+		User:       "0:0", // Preserve ownership whatever user the game image runs as
+		Entrypoint: []string{"sh", "-c", migrateDataScript},
+	}
+	hostConfig := &container.HostConfig{
+		Binds: []string{
+			fmt.Sprintf("%s:/source:ro", source),
+			fmt.Sprintf("%s:/target", target),
+		},
+		NetworkMode: "none",
+	}
+	output, exitCode, err := d.runToCompletion(ctx, name, config, hostConfig)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return &DockerError{
+			Op:  "migrate_data",
+			Msg: fmt.Sprintf("failed to copy data from %s to %s (exit code %d): %s", source, target, exitCode, output),
+		}
+	}
+
+	log.Info().Str("source", source).Str("target", target).Str("result", output).Msg("Gameserver data migrated")
+	return nil
+}
+
+// GetVolumeInfo returns information about a Docker volume, or the directory of a host path
 func (d *DockerManager) GetVolumeInfo(volumeName string) (*models.VolumeInfo, error) {
+	if models.IsHostPath(volumeName) {
+		return &models.VolumeInfo{Name: volumeName, MountPoint: volumeName, Driver: "host"}, nil
+	}
 	ctx := context.Background()
 
 	vol, err := d.client.VolumeInspect(ctx, volumeName)
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -45,6 +46,33 @@ func HandleError(w http.ResponseWriter, err error, context string) {
 	http.Error(w, httpErr.Message, httpErr.Status)
 }
 
+// HandleAPIError handles errors like HandleError, but answers with a JSON {"error": message}
+// body for API clients
+func HandleAPIError(w http.ResponseWriter, err error, context string) {
+	if err == nil {
+		return
+	}
+
+	httpErr, ok := err.(HTTPError)
+	if !ok {
+		httpErr = HTTPError{
+			Status:  http.StatusInternalServerError,
+			Message: "Internal server error",
+			Err:     err,
+		}
+	}
+
+	log.Error().
+		Err(httpErr.Err).
+		Str("context", context).
+		Int("status", httpErr.Status).
+		Msg(httpErr.Message)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.Status)
+	json.NewEncoder(w).Encode(map[string]string{"error": httpErr.Message})
+}
+
 // WrapError wraps an error with HTTP status and message
 func WrapError(err error, status int, message string) error {
 	if err == nil {
@@ -73,6 +101,38 @@ func NotFound(resource string) error {
 	}
 }
 
+// Unauthorized creates an error for requests that aren't signed in
+func Unauthorized(message string) error {
+	return HTTPError{
+		Status:  http.StatusUnauthorized,
+		Message: message,
+	}
+}
+
+// Forbidden creates an error for requests that are understood but not permitted
+func Forbidden(message string) error {
+	return HTTPError{
+		Status:  http.StatusForbidden,
+		Message: message,
+	}
+}
+
+// Conflict creates a conflict error for requests that clash with an operation in progress
+func Conflict(message string) error {
+	return HTTPError{
+		Status:  http.StatusConflict,
+		Message: message,
+	}
+}
+
+// TooLarge creates an error for request bodies over a size limit
+func TooLarge(message string) error {
+	return HTTPError{
+		Status:  http.StatusRequestEntityTooLarge,
+		Message: message,
+	}
+}
+
 // InternalError wraps an internal error
 func InternalError(err error, message string) error {
 	return HTTPError{
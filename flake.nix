@@ -19,6 +19,9 @@
           src = ./.;
           vendorHash = "sha256-ievFLcJ2jehliQCHtqVACigHmbG+zxPe3Q5WiGoR+TQ=";
 
+          # Reported in the footer and /admin/about, and recorded on the gameservers it touches
+          ldflags = [ "-X main.version=0.1.0+${self.shortRev or "dirty"}" ];
+
           # Required for SQLite (go-sqlite3 uses CGO)
           env.CGO_ENABLED = 1;
           nativeBuildInputs = [ pkgs.pkg-config ];
@@ -66,20 +69,20 @@
           if [ -z "$GAME" ]; then
             echo "Usage: build-image <game>"
             echo "Available games:"
-            ls -d images/*/ 2>/dev/null | xargs -n1 basename | sed 's/^/  /'
+            ls -d images/*/ 2>/dev/null | xargs -n1 basename | grep -vx common | sed 's/^/  /'
             exit 1
           fi
 
-          if [ ! -d "images/$GAME" ]; then
+          if [ "$GAME" = "common" ] || [ ! -d "images/$GAME" ]; then
             echo "Error: images/$GAME not found"
             exit 1
           fi
 
-          # Calculate checksum of image directory
-          CHECKSUM=$(find "images/$GAME" -type f | sort | xargs ${pkgs.coreutils}/bin/sha256sum | ${pkgs.coreutils}/bin/sha256sum | cut -d' ' -f1)
+          # Calculate checksum of image directory and the scripts shared by every image
+          CHECKSUM=$(find "images/$GAME" images/common -type f | sort | xargs ${pkgs.coreutils}/bin/sha256sum | ${pkgs.coreutils}/bin/sha256sum | cut -d' ' -f1)
 
           echo "Building $REGISTRY/$GAME:latest (checksum: $CHECKSUM)"
-          ${pkgs.docker}/bin/docker build "images/$GAME" \
+          ${pkgs.docker}/bin/docker build -f "images/$GAME/Dockerfile" images \
             --tag "$REGISTRY/$GAME:latest" \
             --label "gameservers.checksum=$CHECKSUM"
         '';
@@ -99,17 +102,17 @@
 
         build-images = pkgs.writeShellScriptBin "build-images" ''
           REGISTRY="registry.0xkowalski.dev/gameservers"
-          GAMES=$(ls -d images/*/ 2>/dev/null | xargs -n1 basename)
+          GAMES=$(ls -d images/*/ 2>/dev/null | xargs -n1 basename | grep -vx common)
 
           for GAME in $GAMES; do
-            CHECKSUM=$(find "images/$GAME" -type f | sort | xargs ${pkgs.coreutils}/bin/sha256sum | ${pkgs.coreutils}/bin/sha256sum | cut -d' ' -f1)
+            CHECKSUM=$(find "images/$GAME" images/common -type f | sort | xargs ${pkgs.coreutils}/bin/sha256sum | ${pkgs.coreutils}/bin/sha256sum | cut -d' ' -f1)
             EXISTING=$(${pkgs.docker}/bin/docker inspect "$REGISTRY/$GAME:latest" 2>/dev/null | ${pkgs.jq}/bin/jq -r '.[0].Config.Labels["gameservers.checksum"] // ""')
 
             if [ "$CHECKSUM" = "$EXISTING" ]; then
               echo "Skipping $GAME (unchanged)"
             else
               echo "Building $GAME..."
-              ${pkgs.docker}/bin/docker build "images/$GAME" \
+              ${pkgs.docker}/bin/docker build -f "images/$GAME/Dockerfile" images \
                 --tag "$REGISTRY/$GAME:latest" \
                 --label "gameservers.checksum=$CHECKSUM"
             fi
@@ -118,7 +121,7 @@
 
         push-images = pkgs.writeShellScriptBin "push-images" ''
           REGISTRY="registry.0xkowalski.dev/gameservers"
-          GAMES=$(ls -d images/*/ 2>/dev/null | xargs -n1 basename)
+          GAMES=$(ls -d images/*/ 2>/dev/null | xargs -n1 basename | grep -vx common)
 
           for GAME in $GAMES; do
             if ${pkgs.docker}/bin/docker image inspect "$REGISTRY/$GAME:latest" >/dev/null 2>&1; then
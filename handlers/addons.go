@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// addon is a plugin or mod jar in a server's addon directory
+type addon struct {
+	*models.FileInfo
+	NeedsRestart bool // Added or replaced since the server started
+}
+
+// GameserverAddons shows the plugins/mods panel for the server's addon directory
+func (h *Handlers) GameserverAddons(w http.ResponseWriter, r *http.Request) {
+	h.respondAddons(w, r, chi.URLParam(r, "id"), nil)
+}
+
+// UploadGameserverAddon adds a plugin or mod jar. A zip (e.g. a mod pack) has every jar
+// inside it extracted into the addon directory.
+func (h *Handlers) UploadGameserverAddon(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if err := h.parseUpload(w, r); err != nil {
+		HandleError(w, err, "upload_addon")
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		HandleError(w, BadRequest("No file provided"), "upload_addon")
+		return
+	}
+	defer file.Close()
+
+	if header.Size > h.maxUploadSize {
+		HandleError(w, BadRequest("File too large (max %s)", formatFileSize(h.maxUploadSize)), "upload_addon")
+		return
+	}
+
+	gameserver, ok := h.getGameserver(w, id)
+	if !ok {
+		return
+	}
+	dir := gameserver.AddonDir()
+	if dir == "" {
+		HandleError(w, BadRequest("Set SERVER_TYPE to paper, fabric or forge to manage plugins or mods"), "upload_addon")
+		return
+	}
+
+	var jars map[string][]byte
+	switch strings.ToLower(path.Ext(header.Filename)) {
+	case ".jar":
+		content, err := io.ReadAll(file)
+		if err != nil {
+			HandleError(w, InternalError(err, "Failed to read upload"), "upload_addon")
+			return
+		}
+		jars = map[string][]byte{path.Base(header.Filename): content}
+	case ".zip":
+		jars, err = extractJars(file, header.Size, h.maxUploadSize)
+		if err != nil {
+			HandleError(w, BadRequest("%s", err.Error()), "upload_addon")
+			return
+		}
+	default:
+		HandleError(w, BadRequest("Upload a .jar, or a .zip of jars"), "upload_addon")
+		return
+	}
+
+	archive, err := addonArchive(jars)
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to create archive"), "upload_addon")
+		return
+	}
+
+	dirPath := "/data/server/" + dir
+	if err := h.docker.CreateDirectory(gameserver.ContainerID, dirPath); err != nil {
+		HandleError(w, InternalError(err, "Failed to create "+dir+" directory"), "upload_addon")
+		return
+	}
+	if err := h.docker.UploadFile(gameserver.ContainerID, dirPath, archive); err != nil {
+		HandleError(w, InternalError(err, "Failed to upload "+dir), "upload_addon")
+		return
+	}
+
+	log.Info().Str("gameserver_id", id).Str("dir", dir).Int("jars", len(jars)).Str("upload", header.Filename).Msg("Uploaded addons")
+	h.respondAddons(w, r, id, nil)
+}
+
+// DeleteGameserverAddon removes a plugin or mod jar
+func (h *Handlers) DeleteGameserverAddon(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	name, err := h.requireQueryParam(r, "name")
+	if err != nil {
+		HandleError(w, err, "delete_addon")
+		return
+	}
+	if name != path.Base(name) || !strings.HasSuffix(strings.ToLower(name), ".jar") {
+		HandleError(w, BadRequest("invalid addon name %q", name), "delete_addon")
+		return
+	}
+
+	gameserver, ok := h.getGameserver(w, id)
+	if !ok {
+		return
+	}
+	dir := gameserver.AddonDir()
+	if dir == "" {
+		HandleError(w, BadRequest("This server doesn't load plugins or mods"), "delete_addon")
+		return
+	}
+
+	if err := h.docker.DeletePath(gameserver.ContainerID, "/data/server/"+dir+"/"+name); err != nil {
+		HandleError(w, InternalError(err, "Failed to delete "+name), "delete_addon")
+		return
+	}
+
+	log.Info().Str("gameserver_id", id).Str("dir", dir).Str("name", name).Msg("Deleted addon")
+
+	// A running server keeps a removed jar loaded until it restarts
+	var removed []string
+	if gameserver.Status == models.StatusRunning {
+		removed = []string{name}
+	}
+	h.respondAddons(w, r, id, removed)
+}
+
+// respondAddons writes the server's addon listing: the addons panel for HTMX requests, JSON otherwise.
+// removed lists jars deleted from the running server, which stay loaded until it restarts.
+func (h *Handlers) respondAddons(w http.ResponseWriter, r *http.Request, id string, removed []string) {
+	gameserver, ok := h.getGameserver(w, id)
+	if !ok {
+		return
+	}
+
+	dir := gameserver.AddonDir()
+	var addons []addon
+	var listErr error
+	if dir != "" {
+		addons, listErr = h.listAddons(gameserver, dir)
+		if listErr != nil {
+			log.Error().Err(listErr).Str("gameserver_id", id).Str("dir", dir).Msg("Failed to list addons")
+		}
+	}
+
+	if r.Header.Get("HX-Request") != "true" {
+		if listErr != nil {
+			HandleError(w, InternalError(listErr, "Failed to list "+dir), "list_addons")
+			return
+		}
+		h.jsonSuccess(w, map[string]interface{}{"dir": dir, "addons": addons, "removed": removed})
+		return
+	}
+
+	data := map[string]interface{}{
+		"Gameserver": gameserver,
+		"Dir":        dir,
+		"Addons":     addons,
+		"Removed":    removed,
+		"ListError":  listErr,
+	}
+	if err := h.tmpl.ExecuteTemplate(w, "server-addons.html", data); err != nil {
+		HandleError(w, InternalError(err, "Failed to render addons"), "list_addons")
+	}
+}
+
+// listAddons lists the jars in the server's addon directory, flagging those added since it started
+func (h *Handlers) listAddons(gameserver *models.Gameserver, dir string) ([]addon, error) {
+	dirPath := "/data/server/" + dir
+	files, err := h.docker.ListFiles(gameserver.ContainerID, dirPath)
+	if err != nil {
+		// Nothing installed yet
+		if strings.Contains(err.Error(), "No such file") {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	pending := h.addonsChangedSinceStart(gameserver, dirPath)
+
+	var addons []addon
+	for _, file := range files {
+		if file.IsDir || !strings.HasSuffix(strings.ToLower(file.Name), ".jar") {
+			continue
+		}
+		addons = append(addons, addon{FileInfo: file, NeedsRestart: pending[file.Name]})
+	}
+	return addons, nil
+}
+
+// addonsChangedSinceStart returns the jars in dirPath modified after a running server started,
+// which it hasn't loaded yet
+func (h *Handlers) addonsChangedSinceStart(gameserver *models.Gameserver, dirPath string) map[string]bool {
+	if gameserver.Status != models.StatusRunning {
+		return nil
+	}
+	state, err := h.docker.GetContainerState(gameserver.ContainerID)
+	if err != nil || state.StartedAt.IsZero() {
+		return nil
+	}
+
+	names, err := h.docker.FilesModifiedSince(gameserver.ContainerID, dirPath, state.StartedAt)
+	if err != nil {
+		log.Warn().Err(err).Str("gameserver_id", gameserver.ID).Msg("Failed to check for addons added since start")
+		return nil
+	}
+
+	pending := make(map[string]bool, len(names))
+	for _, name := range names {
+		pending[name] = true
+	}
+	return pending
+}
+
+// extractJars reads every .jar from a zip upload, flattening directories. The total
+// extracted size is capped at maxSize to guard against zip bombs.
+func extractJars(file io.ReaderAt, size, maxSize int64) (map[string][]byte, error) {
+	zr, err := zip.NewReader(file, size)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid zip file")
+	}
+
+	jars := make(map[string][]byte)
+	var total int64
+	for _, entry := range zr.File {
+		name := path.Base(entry.Name)
+		if entry.FileInfo().IsDir() || !strings.HasSuffix(strings.ToLower(name), ".jar") || strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		rc, err := entry.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from zip", entry.Name)
+		}
+		content, err := io.ReadAll(io.LimitReader(rc, maxSize-total+1))
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from zip", entry.Name)
+		}
+		total += int64(len(content))
+		if total > maxSize {
+			return nil, fmt.Errorf("zip contents too large (max %s)", formatFileSize(maxSize))
+		}
+		jars[name] = content
+	}
+
+	if len(jars) == 0 {
+		return nil, fmt.Errorf("zip contains no .jar files")
+	}
+	return jars, nil
+}
+
+// addonArchive packs jars into a tar for copying into the container
+func addonArchive(jars map[string][]byte) (io.Reader, error) {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for name, content := range jars {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+)
+
+// SchedulerState returns the task scheduler's view of its tasks as JSON: next and last runs,
+// results, and the task executing right now. Admins only.
+func (h *Handlers) SchedulerState(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdminRole(w, r, "Only admins can view the scheduler state") {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.scheduler.State())
+}
+
+// About reports the app's build version and runtime, for support requests and upgrade debugging
+func (h *Handlers) About(w http.ResponseWriter, r *http.Request) {
+	h.jsonSuccess(w, map[string]interface{}{
+		"version":    h.appVersion,
+		"go_version": runtime.Version(),
+		"platform":   runtime.GOOS + "/" + runtime.GOARCH,
+	})
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// fakeScheduler reports a fixed scheduler state
+type fakeScheduler struct{}
+
+func (fakeScheduler) State() *models.SchedulerState {
+	return &models.SchedulerState{CheckInterval: "1m0s"}
+}
+
+// adminRouter mounts the admin-only debugging routes the way main does
+func adminRouter(h *Handlers) http.Handler {
+	r := chi.NewRouter()
+	r.Get("/gameservers/{id}/inspect", h.InspectGameserver)
+	r.Get("/admin/scheduler", h.SchedulerState)
+	return r
+}
+
+func TestAdminDebugRoutesRequireAdmin(t *testing.T) {
+	h, _, _ := newTestHandlers(t, nil)
+	h.scheduler = fakeScheduler{}
+	router := adminRouter(h)
+
+	for _, path := range []string{"/gameservers/gs-1/inspect", "/admin/scheduler"} {
+		tests := []struct {
+			name       string
+			role       string // Empty for authentication disabled
+			wantStatus int
+		}{
+			{"viewer", models.RoleViewer, http.StatusForbidden},
+			{"admin", models.RoleAdmin, http.StatusOK},
+			{"auth disabled", "", http.StatusOK},
+		}
+		for _, tt := range tests {
+			t.Run(path+" "+tt.name, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, path, nil)
+				if tt.role != "" {
+					r = withUser(r, tt.role)
+				}
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, r)
+				if rec.Code != tt.wantStatus {
+					t.Errorf("GET %s as %s = %d (%s), want %d", path, tt.name, rec.Code, rec.Body.String(), tt.wantStatus)
+				}
+			})
+		}
+	}
+}
+
+func TestInspectGameserverViewerDoesNotInspect(t *testing.T) {
+	h, docker, _ := newTestHandlers(t, nil)
+
+	rec := httptest.NewRecorder()
+	adminRouter(h).ServeHTTP(rec, withUser(httptest.NewRequest(http.MethodGet, "/gameservers/gs-1/inspect", nil), models.RoleViewer))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("viewer inspect = %d, want 403", rec.Code)
+	}
+	if i := docker.CallIndex("InspectContainer"); i >= 0 {
+		t.Errorf("viewer request inspected the container, calls: %v", docker.Calls())
+	}
+}
+
+func TestInspectGameserverErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		inspectErr    error
+		wantStatus    int
+		wantContainer string // Expected container_error on success
+	}{
+		{"unknown gameserver", "/gameservers/missing/inspect", nil, http.StatusNotFound, ""},
+		{"missing container", "/gameservers/gs-1/inspect", fmt.Errorf("inspect: %w", models.ErrContainerNotFound), http.StatusOK, "inspect: container not found"},
+		{"docker failure", "/gameservers/gs-1/inspect", errors.New("cannot connect to the Docker daemon"), http.StatusInternalServerError, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, docker, _ := newTestHandlers(t, nil)
+			if tt.inspectErr != nil {
+				docker.Errors["InspectContainer"] = tt.inspectErr
+			}
+
+			rec := httptest.NewRecorder()
+			adminRouter(h).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("GET %s = %d (%s), want %d", tt.path, rec.Code, rec.Body.String(), tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var inspection models.GameserverInspection
+			if err := json.Unmarshal(rec.Body.Bytes(), &inspection); err != nil {
+				t.Fatalf("invalid JSON %q: %v", rec.Body.String(), err)
+			}
+			if inspection.ContainerError != tt.wantContainer {
+				t.Errorf("container_error = %q, want %q", inspection.ContainerError, tt.wantContainer)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// serverStatus is one entry of the aggregate status endpoint
+type serverStatus struct {
+	ID         string                  `json:"id"`
+	Name       string                  `json:"name"`
+	Game       string                  `json:"game"`
+	Status     models.GameserverStatus `json:"status"`
+	HostPort   int                     `json:"host_port,omitempty"`
+	Online     bool                    `json:"online"`
+	Players    int                     `json:"players"`
+	MaxPlayers int                     `json:"max_players"`
+	Map        string                  `json:"map,omitempty"`
+}
+
+// StatusAPI returns the status of every gameserver as a JSON array for external status pages
+func (h *Handlers) StatusAPI(w http.ResponseWriter, r *http.Request) {
+	gameservers, err := h.service.ListGameservers()
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to list gameservers"), "status_api")
+		return
+	}
+
+	results := h.queryStatuses(gameservers)
+
+	statuses := make([]serverStatus, len(gameservers))
+	for i, gameserver := range gameservers {
+		statuses[i] = serverStatus{
+			ID:     gameserver.ID,
+			Name:   gameserver.Name,
+			Game:   gameserver.GameType,
+			Status: gameserver.Status,
+		}
+		if port := gameserver.GetGamePort(); port != nil {
+			statuses[i].HostPort = port.HostPort
+		}
+		if info := results[gameserver.ID]; info != nil {
+			statuses[i].Online = info.Online
+			statuses[i].Players = info.Players
+			statuses[i].MaxPlayers = info.MaxPlayers
+			statuses[i].Map = info.Map
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statuses)
+}
+
+// queryStatuses queries running servers as one batch, keyed by gameserver ID, so a slow or dead
+// server doesn't stall the response. It returns nil without a query service.
+func (h *Handlers) queryStatuses(gameservers []*models.Gameserver) map[string]*models.QueryResult {
+	if h.queryService == nil {
+		return nil
+	}
+	games, err := h.service.ListGames()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list games for status queries")
+	}
+	gamesByID := make(map[string]*models.Game, len(games))
+	for _, game := range games {
+		gamesByID[game.ID] = game
+	}
+	return h.queryService.QueryStatuses(gameservers, gamesByID)
+}
+
+// attachQueryResults queries the running gameservers for list pages, setting each one's Query
+func (h *Handlers) attachQueryResults(gameservers []*models.Gameserver) {
+	results := h.queryStatuses(gameservers)
+	for _, gameserver := range gameservers {
+		if gameserver.Status == models.StatusRunning {
+			gameserver.Query = results[gameserver.ID]
+		}
+	}
+}
+
+// InspectGameserver returns the gameserver's database record next to Docker's inspect output
+// for its container, secrets masked, for debugging config drift. Admins only, since it shows
+// mounts, the full environment and resource limits.
+// GET /gameservers/{id}/inspect
+func (h *Handlers) InspectGameserver(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdminRole(w, r, "Only admins can inspect gameserver containers") {
+		return
+	}
+	id := chi.URLParam(r, "id")
+
+	inspection, err := h.service.InspectGameserver(id)
+	if errors.Is(err, models.ErrGameserverNotFound) {
+		HandleError(w, NotFound("Gameserver"), "inspect_gameserver")
+		return
+	}
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to inspect gameserver"), "inspect_gameserver")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(inspection)
+}
+
+// ValidateGameserverAPI dry-runs gameserver creation from the create form's fields and
+// reports the ports that would be allocated, without persisting anything
+func (h *Handlers) ValidateGameserverAPI(w http.ResponseWriter, r *http.Request) {
+	formData, err := h.parseGameserverForm(r)
+	if err != nil {
+		h.jsonSuccess(w, map[string]interface{}{"valid": false, "error": err.Error()})
+		return
+	}
+
+	server := newGameserverFromForm(formData)
+	warnings, err := h.service.ValidateGameserver(server)
+	if err != nil {
+		result := map[string]interface{}{"valid": false, "error": err.Error()}
+		var configErr *models.ConfigValidationError
+		if errors.As(err, &configErr) {
+			result["fields"] = configErr.Fields()
+		}
+		h.jsonSuccess(w, result)
+		return
+	}
+
+	h.jsonSuccess(w, map[string]interface{}{
+		"valid":         true,
+		"name":          server.Name,
+		"game_id":       server.GameID,
+		"memory_mb":     server.MemoryMB,
+		"cpu_cores":     server.CPUCores,
+		"port_mappings": server.PortMappings,
+		"warnings":      warnings,
+	})
+}
+
+// apiGameserver is a gameserver as returned by the JSON API. Environment values are left out
+// since they hold passwords.
+type apiGameserver struct {
+	ID             string                  `json:"id"`
+	Name           string                  `json:"name"`
+	GameID         string                  `json:"game_id"`
+	Game           string                  `json:"game"`
+	Status         models.GameserverStatus `json:"status"`
+	IsTransitional bool                    `json:"is_transitional"`
+	PortMappings   []models.PortMapping    `json:"port_mappings"`
+	MemoryMB       int                     `json:"memory_mb"`
+	CPUCores       float64                 `json:"cpu_cores"`
+	Tags           []string                `json:"tags"`
+	RestartCount   int                     `json:"restart_count"`
+	StartupError   string                  `json:"startup_error,omitempty"`
+	ImageDigest    string                  `json:"image_digest,omitempty"`
+	CreatedAt      time.Time               `json:"created_at"`
+	UpdatedAt      time.Time               `json:"updated_at"`
+}
+
+// newAPIGameserver converts a gameserver to its API representation
+func newAPIGameserver(gameserver *models.Gameserver) apiGameserver {
+	result := apiGameserver{
+		ID:             gameserver.ID,
+		Name:           gameserver.Name,
+		GameID:         gameserver.GameID,
+		Game:           gameserver.GameType,
+		Status:         gameserver.Status,
+		IsTransitional: gameserver.Status.IsTransitional(),
+		PortMappings:   gameserver.PortMappings,
+		MemoryMB:       gameserver.MemoryMB,
+		CPUCores:       gameserver.CPUCores,
+		Tags:           gameserver.Tags,
+		RestartCount:   gameserver.RestartCount,
+		StartupError:   gameserver.StartupError,
+		ImageDigest:    gameserver.ImageDigest,
+		CreatedAt:      gameserver.CreatedAt,
+		UpdatedAt:      gameserver.UpdatedAt,
+	}
+	if result.PortMappings == nil {
+		result.PortMappings = []models.PortMapping{}
+	}
+	if result.Tags == nil {
+		result.Tags = []string{}
+	}
+	return result
+}
+
+// writeJSON encodes v as the response body
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// ListGameserversAPI returns every gameserver as a JSON array
+// GET /api/v1/gameservers
+func (h *Handlers) ListGameserversAPI(w http.ResponseWriter, r *http.Request) {
+	gameservers, err := h.service.ListGameservers()
+	if err != nil {
+		HandleAPIError(w, InternalError(err, "Failed to list gameservers"), "api_list_gameservers")
+		return
+	}
+
+	results := make([]apiGameserver, len(gameservers))
+	for i, gameserver := range gameservers {
+		results[i] = newAPIGameserver(gameserver)
+	}
+	writeJSON(w, results)
+}
+
+// GetGameserverAPI returns one gameserver as JSON
+// GET /api/v1/gameservers/{id}
+func (h *Handlers) GetGameserverAPI(w http.ResponseWriter, r *http.Request) {
+	gameserver, err := h.service.GetGameserver(chi.URLParam(r, "id"))
+	if err != nil {
+		HandleAPIError(w, NotFound("Gameserver"), "api_get_gameserver")
+		return
+	}
+	writeJSON(w, newAPIGameserver(gameserver))
+}
+
+// GameserverActionAPI starts, stops or restarts a gameserver and returns it as JSON. Stop
+// responses also report whether the game shut down on its own.
+// POST /api/v1/gameservers/{id}/{action}
+func (h *Handlers) GameserverActionAPI(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	action := chi.URLParam(r, "action")
+	context := "api_" + action + "_gameserver"
+
+	var run func(string) error
+	switch action {
+	case "start":
+		run = h.service.StartGameserver
+	case "stop":
+		run = h.service.StopGameserver
+	case "restart":
+		run = h.service.RestartGameserver
+	default:
+		HandleAPIError(w, NotFound("Action"), "api_gameserver_action")
+		return
+	}
+
+	if _, err := h.service.GetGameserver(id); err != nil {
+		HandleAPIError(w, NotFound("Gameserver"), context)
+		return
+	}
+
+	log.Info().Str("gameserver_id", id).Str("action", action).Msg("Gameserver action requested via API")
+	err := run(id)
+	h.recordAudit(r, action, id, "api", err)
+	if err != nil {
+		if errors.Is(err, models.ErrOperationInProgress) {
+			HandleAPIError(w, Conflict("Another operation is already in progress for this gameserver"), context)
+			return
+		}
+		HandleAPIError(w, InternalError(err, "Failed to "+action+" gameserver"), context)
+		return
+	}
+
+	gameserver, err := h.service.GetGameserver(id)
+	if err != nil {
+		HandleAPIError(w, NotFound("Gameserver"), context)
+		return
+	}
+	result := map[string]interface{}{"gameserver": newAPIGameserver(gameserver)}
+	if action == "stop" {
+		result["graceful"] = !gameserver.LastStopForced
+	}
+	writeJSON(w, result)
+}
+
+// QueryGameserverAPI queries a running gameserver for its players and map. Servers that
+// aren't running, or don't answer, are reported offline rather than as an error.
+// GET /api/v1/gameservers/{id}/query
+func (h *Handlers) QueryGameserverAPI(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	gameserver, err := h.service.GetGameserver(id)
+	if err != nil {
+		HandleAPIError(w, NotFound("Gameserver"), "api_query_gameserver")
+		return
+	}
+
+	if gameserver.Status != models.StatusRunning || h.queryService == nil {
+		writeJSON(w, map[string]interface{}{"online": false, "status": gameserver.Status})
+		return
+	}
+
+	game, err := h.service.GetGame(gameserver.GameID)
+	if err != nil {
+		HandleAPIError(w, InternalError(err, "Failed to get game info"), "api_query_gameserver")
+		return
+	}
+
+	result, err := h.queryService.QueryStatus(gameserver, game)
+	if err != nil {
+		log.Debug().Err(err).Str("gameserver_id", id).Msg("Failed to query gameserver")
+		writeJSON(w, map[string]interface{}{"online": false, "status": gameserver.Status, "error": err.Error()})
+		return
+	}
+	writeJSON(w, result)
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// fakeQueryService answers every query with result
+type fakeQueryService struct {
+	result *models.QueryResult
+}
+
+func (f *fakeQueryService) QueryStatus(gameserver *models.Gameserver, game *models.Game) (*models.QueryResult, error) {
+	return f.result, nil
+}
+
+func (f *fakeQueryService) QueryStatuses(gameservers []*models.Gameserver, games map[string]*models.Game) map[string]*models.QueryResult {
+	results := make(map[string]*models.QueryResult)
+	for _, gameserver := range gameservers {
+		results[gameserver.ID] = f.result
+	}
+	return results
+}
+
+// apiRouter mounts the API routes the way main does
+func apiRouter(h *Handlers) http.Handler {
+	r := chi.NewRouter()
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Get("/gameservers", h.ListGameserversAPI)
+		r.Get("/gameservers/{id}", h.GetGameserverAPI)
+		r.Get("/gameservers/{id}/query", h.QueryGameserverAPI)
+		r.Post("/gameservers/{id}/{action}", h.GameserverActionAPI)
+	})
+	return r
+}
+
+// serveJSON performs a request and decodes the JSON response into v
+func serveJSON(t *testing.T, handler http.Handler, method, path string, wantStatus int, v interface{}) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	if rec.Code != wantStatus {
+		t.Fatalf("%s %s = %d (%s), want %d", method, path, rec.Code, rec.Body.String(), wantStatus)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("%s %s Content-Type = %q, want application/json", method, path, ct)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("%s %s returned invalid JSON %q: %v", method, path, rec.Body.String(), err)
+	}
+}
+
+// keysOf returns the sorted keys of a decoded JSON object
+func keysOf(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// assertAPIGameserver checks a decoded gameserver has exactly the API fields and no secrets
+func assertAPIGameserver(t *testing.T, got map[string]interface{}, wantStatus models.GameserverStatus) {
+	t.Helper()
+	wantKeys := []string{"cpu_cores", "created_at", "game", "game_id", "id", "image_digest", "is_transitional",
+		"memory_mb", "name", "port_mappings", "restart_count", "startup_error", "status", "tags", "updated_at"}
+	keys := keysOf(got)
+	for _, optional := range []string{"image_digest", "startup_error"} {
+		if _, ok := got[optional]; !ok {
+			keys = append(keys, optional)
+		}
+	}
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("gameserver keys = %v, want %v", keys, wantKeys)
+	}
+
+	if got["id"] != "gs-1" || got["name"] != "world" || got["game_id"] != "testgame" || got["status"] != string(wantStatus) {
+		t.Errorf("gameserver = %v, want gs-1 named world, game testgame, status %s", got, wantStatus)
+	}
+	if got["memory_mb"] != float64(1024) || got["cpu_cores"] != float64(2) {
+		t.Errorf("resources = %v MB, %v cores, want 1024 MB and 2 cores", got["memory_mb"], got["cpu_cores"])
+	}
+	wantPorts := []interface{}{map[string]interface{}{"name": "game", "protocol": "tcp", "container_port": float64(25565), "host_port": float64(30001)}}
+	if !reflect.DeepEqual(got["port_mappings"], wantPorts) {
+		t.Errorf("port_mappings = %v, want %v", got["port_mappings"], wantPorts)
+	}
+	if !reflect.DeepEqual(got["tags"], []interface{}{"survival"}) {
+		t.Errorf("tags = %v, want [survival]", got["tags"])
+	}
+}
+
+func TestListGameserversAPI(t *testing.T) {
+	h, _, _ := newTestHandlers(t, nil)
+
+	var list []map[string]interface{}
+	serveJSON(t, apiRouter(h), http.MethodGet, "/api/v1/gameservers", http.StatusOK, &list)
+	if len(list) != 1 {
+		t.Fatalf("got %d gameservers, want 1", len(list))
+	}
+	assertAPIGameserver(t, list[0], models.StatusRunning)
+}
+
+func TestGetGameserverAPI(t *testing.T) {
+	h, _, _ := newTestHandlers(t, nil)
+	router := apiRouter(h)
+
+	var gameserver map[string]interface{}
+	serveJSON(t, router, http.MethodGet, "/api/v1/gameservers/gs-1", http.StatusOK, &gameserver)
+	assertAPIGameserver(t, gameserver, models.StatusRunning)
+
+	var notFound map[string]interface{}
+	serveJSON(t, router, http.MethodGet, "/api/v1/gameservers/missing", http.StatusNotFound, &notFound)
+	if keys := keysOf(notFound); !reflect.DeepEqual(keys, []string{"error"}) {
+		t.Errorf("error body keys = %v, want [error]", keys)
+	}
+}
+
+func TestGameserverActionAPIStop(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		exitCode     int
+		wantGraceful bool
+	}{
+		{"graceful", 0, true},
+		{"killed", 137, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h, docker, _ := newTestHandlers(t, nil)
+			docker.StopExitCode = tt.exitCode
+
+			var result map[string]interface{}
+			serveJSON(t, apiRouter(h), http.MethodPost, "/api/v1/gameservers/gs-1/stop", http.StatusOK, &result)
+			if keys := keysOf(result); !reflect.DeepEqual(keys, []string{"gameserver", "graceful"}) {
+				t.Fatalf("stop response keys = %v, want [gameserver graceful]", keys)
+			}
+			if result["graceful"] != tt.wantGraceful {
+				t.Errorf("graceful = %v, want %v", result["graceful"], tt.wantGraceful)
+			}
+			gameserver, _ := result["gameserver"].(map[string]interface{})
+			assertAPIGameserver(t, gameserver, models.StatusStopped)
+
+			if audit := h.audit.(*fakeAudit); len(audit.entries) != 1 || audit.entries[0].Action != "stop" {
+				t.Errorf("audit entries = %+v, want one stop", audit.entries)
+			}
+		})
+	}
+}
+
+func TestGameserverActionAPIErrors(t *testing.T) {
+	h, _, _ := newTestHandlers(t, nil)
+	router := apiRouter(h)
+
+	for _, path := range []string{"/api/v1/gameservers/gs-1/explode", "/api/v1/gameservers/missing/start"} {
+		var body map[string]interface{}
+		serveJSON(t, router, http.MethodPost, path, http.StatusNotFound, &body)
+		if _, ok := body["error"].(string); !ok {
+			t.Errorf("POST %s body = %v, want an error message", path, body)
+		}
+	}
+}
+
+func TestQueryGameserverAPI(t *testing.T) {
+	query := &fakeQueryService{result: &models.QueryResult{Online: true, Name: "My World", Map: "world",
+		Version: "1.20.4", Players: 3, MaxPlayers: 20, Ping: 12}}
+	h, _, _ := newTestHandlers(t, query)
+	router := apiRouter(h)
+
+	var result map[string]interface{}
+	serveJSON(t, router, http.MethodGet, "/api/v1/gameservers/gs-1/query", http.StatusOK, &result)
+	want := map[string]interface{}{"online": true, "name": "My World", "map": "world", "version": "1.20.4",
+		"players": float64(3), "max_players": float64(20), "ping": float64(12)}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("query = %v, want %v", result, want)
+	}
+
+	// Stopped servers are reported offline with their status rather than queried
+	var stopped map[string]interface{}
+	serveJSON(t, router, http.MethodPost, "/api/v1/gameservers/gs-1/stop", http.StatusOK, &stopped)
+	var offline map[string]interface{}
+	serveJSON(t, router, http.MethodGet, "/api/v1/gameservers/gs-1/query", http.StatusOK, &offline)
+	if want := map[string]interface{}{"online": false, "status": "stopped"}; !reflect.DeepEqual(offline, want) {
+		t.Errorf("query of a stopped server = %v, want %v", offline, want)
+	}
+}
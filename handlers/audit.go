@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// recordAudit writes an audit log entry for an action taken by the request's user. err is
+// the action's outcome, so failed attempts are on record too.
+func (h *Handlers) recordAudit(r *http.Request, action, gameserverID, detail string, err error) {
+	entry := &models.AuditLog{
+		Action:       action,
+		GameserverID: gameserverID,
+		RequestID:    middleware.GetReqID(r.Context()),
+		RemoteAddr:   r.RemoteAddr,
+		Detail:       detail,
+	}
+	if user := CurrentUser(r); user != nil {
+		entry.Username = user.Username
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	h.audit.Record(entry)
+}
+
+// ListAuditLog shows a page of the audit log, optionally filtered to one gameserver
+// GET /audit?gameserver=ID&page=N
+func (h *Handlers) ListAuditLog(w http.ResponseWriter, r *http.Request) {
+	gameserverID := r.URL.Query().Get("gameserver")
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+	entries, err := h.audit.List(gameserverID, page)
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to list audit log"), "list_audit_log")
+		return
+	}
+	gameservers, err := h.service.ListGameservers()
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to list gameservers"), "list_audit_log")
+		return
+	}
+	names := make(map[string]string, len(gameservers))
+	for _, gameserver := range gameservers {
+		names[gameserver.ID] = gameserver.Name
+	}
+
+	h.render(w, r, "audit.html", map[string]interface{}{
+		"Audit":        entries,
+		"Gameservers":  gameservers,
+		"Names":        names,
+		"GameserverID": gameserverID,
+	})
+}
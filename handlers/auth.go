@@ -0,0 +1,438 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// sessionCookie holds the login session token set by the login form
+const sessionCookie = "gameserver_session"
+
+// maxTokenNameLength caps the label given to an API token
+const maxTokenNameLength = 100
+
+type authContextKey struct{}
+
+// authInfo is who a request is signed in as, and with which session or API token
+type authInfo struct {
+	user  *models.User
+	token *models.UserToken
+}
+
+// CurrentUser returns the user a request is signed in as, or nil when authentication is disabled
+func CurrentUser(r *http.Request) *models.User {
+	if info, ok := r.Context().Value(authContextKey{}).(*authInfo); ok {
+		return info.user
+	}
+	return nil
+}
+
+// currentToken returns the session or API token a request was authenticated with, if any
+func currentToken(r *http.Request) *models.UserToken {
+	if info, ok := r.Context().Value(authContextKey{}).(*authInfo); ok {
+		return info.token
+	}
+	return nil
+}
+
+// RequireAuth rejects requests that don't carry a valid session cookie or API token, except
+// for static assets and the login page. Viewers are limited to requests that don't change
+// anything, apart from managing their own account.
+func (h *Handlers) RequireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPublicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, token, err := h.service.Authenticate(requestToken(r))
+		if err != nil {
+			if !errors.Is(err, models.ErrInvalidCredentials) {
+				log.Error().Err(err).Str("path", r.URL.Path).Msg("Failed to authenticate request")
+			}
+			h.unauthorized(w, r)
+			return
+		}
+
+		if !user.CanAccess(r.Method) && !isAccountPath(r.URL.Path) {
+			log.Warn().Str("user", user.Username).Str("method", r.Method).Str("path", r.URL.Path).Msg("Denied request for read-only user")
+			h.authError(w, r, Forbidden("Your account has read-only access"), "authorize")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), authContextKey{}, &authInfo{user: user, token: token})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requestToken returns the bearer token of API clients, or else the browser's session cookie
+func requestToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, value, _ := strings.Cut(header, " ")
+		if strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(value)
+		}
+		return ""
+	}
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// unauthorized sends API clients a 401 and browsers to the login page, returning them to
+// the page they were on once signed in
+func (h *Handlers) unauthorized(w http.ResponseWriter, r *http.Request) {
+	if isAPIRequest(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="gameservers"`)
+		HandleAPIError(w, Unauthorized("Authentication required"), "authenticate")
+		return
+	}
+
+	next := r.URL.RequestURI()
+	if r.Header.Get("HX-Request") == "true" {
+		// A fragment's URL isn't a page to come back to, so use the page it was requested from
+		next = "/"
+		if current, err := url.Parse(r.Header.Get("HX-Current-URL")); err == nil && current.Path != "" {
+			next = current.RequestURI()
+		}
+		w.Header().Set("HX-Redirect", loginURL(next))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		HandleError(w, Unauthorized("Authentication required"), "authenticate")
+		return
+	}
+	http.Redirect(w, r, loginURL(next), http.StatusSeeOther)
+}
+
+// authError answers API clients in JSON and everyone else in plain text
+func (h *Handlers) authError(w http.ResponseWriter, r *http.Request, err error, context string) {
+	if isAPIRequest(r) {
+		HandleAPIError(w, err, context)
+		return
+	}
+	HandleError(w, err, context)
+}
+
+// LoginPage shows the sign-in form
+func (h *Handlers) LoginPage(w http.ResponseWriter, r *http.Request) {
+	h.renderLogin(w, http.StatusOK, r.URL.Query().Get("next"), "", "")
+}
+
+// Login checks a username and password and sets the session cookie
+func (h *Handlers) Login(w http.ResponseWriter, r *http.Request) {
+	if err := ParseForm(r); err != nil {
+		HandleError(w, err, "login_form")
+		return
+	}
+	username := strings.TrimSpace(r.FormValue("username"))
+	next := r.FormValue("next")
+
+	value, session, err := h.service.Login(username, r.FormValue("password"))
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidCredentials) {
+			log.Warn().Str("user", username).Str("remote_addr", r.RemoteAddr).Msg("Failed sign-in attempt")
+			h.renderLogin(w, http.StatusUnauthorized, next, username, "Invalid username or password")
+			return
+		}
+		HandleError(w, InternalError(err, "Failed to sign in"), "login")
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    value,
+		Path:     "/",
+		Expires:  *session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   h.secureCookies || r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, safeRedirect(next), http.StatusSeeOther)
+}
+
+// Logout ends the current session and clears its cookie
+func (h *Handlers) Logout(w http.ResponseWriter, r *http.Request) {
+	if token := currentToken(r); token != nil && token.Kind == models.TokenSession {
+		if err := h.service.Logout(token); err != nil {
+			log.Warn().Err(err).Msg("Failed to delete session")
+		}
+		log.Info().Str("user", CurrentUser(r).Username).Msg("User signed out")
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	if r.Header.Get("HX-Request") == "true" {
+		h.htmxRedirect(w, "/login")
+		return
+	}
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// ShowAccount displays the current user's account with their API tokens
+func (h *Handlers) ShowAccount(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.requireUser(w, r)
+	if !ok {
+		return
+	}
+	h.renderAccount(w, r, user, "")
+}
+
+// ChangePassword replaces the current user's password, signing out their other sessions
+func (h *Handlers) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.requireUser(w, r)
+	if !ok {
+		return
+	}
+	if err := ParseForm(r); err != nil {
+		HandleError(w, err, "change_password_form")
+		return
+	}
+	if r.FormValue("password") != r.FormValue("confirm") {
+		HandleError(w, BadRequest("New passwords don't match"), "change_password")
+		return
+	}
+
+	keep := ""
+	if token := currentToken(r); token != nil && token.Kind == models.TokenSession {
+		keep = token.ID
+	}
+	if err := h.service.ChangePassword(user.ID, r.FormValue("current"), r.FormValue("password"), keep); err != nil {
+		HandleError(w, userError(err, "Failed to change password"), "change_password")
+		return
+	}
+
+	log.Info().Str("user", user.Username).Msg("Changed password")
+	h.jsonSuccess(w, map[string]interface{}{"message": "Password changed"})
+}
+
+// CreateAPIToken issues an API token for the current user. Its value is shown only once.
+func (h *Handlers) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.requireUser(w, r)
+	if !ok {
+		return
+	}
+	if err := ParseForm(r); err != nil {
+		HandleError(w, err, "create_api_token_form")
+		return
+	}
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" || len(name) > maxTokenNameLength {
+		HandleError(w, BadRequest("Token name is required and must be at most %d characters", maxTokenNameLength), "create_api_token")
+		return
+	}
+
+	value, _, err := h.service.CreateAPIToken(user.ID, name)
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to create API token"), "create_api_token")
+		return
+	}
+
+	log.Info().Str("user", user.Username).Str("token_name", name).Msg("Created API token")
+	h.renderAccount(w, r, user, value)
+}
+
+// RevokeAPIToken deletes one of the current user's API tokens
+func (h *Handlers) RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.requireUser(w, r)
+	if !ok {
+		return
+	}
+	tokenID := chi.URLParam(r, "tokenId")
+	if err := h.service.RevokeAPIToken(user.ID, tokenID); err != nil {
+		HandleError(w, NotFound("API token"), "revoke_api_token")
+		return
+	}
+
+	log.Info().Str("user", user.Username).Str("token_id", tokenID).Msg("Revoked API token")
+	h.renderAccount(w, r, user, "")
+}
+
+// ListUsers displays all users with a form for adding one
+func (h *Handlers) ListUsers(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+	users, err := h.service.ListUsers()
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to list users"), "list_users")
+		return
+	}
+	h.render(w, r, "users.html", map[string]interface{}{
+		"Users":       users,
+		"CurrentUser": CurrentUser(r),
+	})
+}
+
+// CreateUser adds a user
+func (h *Handlers) CreateUser(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	if err := ParseForm(r); err != nil {
+		HandleError(w, err, "create_user_form")
+		return
+	}
+
+	user, err := h.service.CreateUser(strings.TrimSpace(r.FormValue("username")), r.FormValue("password"), r.FormValue("role"))
+	if err != nil {
+		HandleError(w, userError(err, "Failed to create user"), "create_user")
+		return
+	}
+
+	log.Info().Str("user", admin.Username).Str("new_user", user.Username).Str("role", user.Role).Msg("Created user")
+	h.htmxRedirect(w, "/admin/users")
+}
+
+// DeleteUser removes a user, signing them out everywhere
+func (h *Handlers) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	id := chi.URLParam(r, "userId")
+	if id == admin.ID {
+		HandleError(w, BadRequest("You can't delete your own account"), "delete_user")
+		return
+	}
+
+	if err := h.service.DeleteUser(id); err != nil {
+		HandleError(w, userError(err, "Failed to delete user"), "delete_user")
+		return
+	}
+
+	log.Info().Str("user", admin.Username).Str("deleted_user_id", id).Msg("Deleted user")
+	h.htmxRedirect(w, "/admin/users")
+}
+
+// requireUser returns the signed-in user, rejecting the request when authentication is disabled
+func (h *Handlers) requireUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	user := CurrentUser(r)
+	if user == nil {
+		HandleError(w, NotFound("Page"), "require_user")
+		return nil, false
+	}
+	return user, true
+}
+
+// requireAdmin returns the signed-in user if they are an admin
+func (h *Handlers) requireAdmin(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	user, ok := h.requireUser(w, r)
+	if !ok {
+		return nil, false
+	}
+	if !user.IsAdmin() {
+		HandleError(w, Forbidden("Only admins can manage users"), "require_admin")
+		return nil, false
+	}
+	return user, true
+}
+
+// requireAdminRole rejects signed-in users who aren't admins with message. Unlike requireAdmin
+// it lets everyone through when authentication is disabled.
+func (h *Handlers) requireAdminRole(w http.ResponseWriter, r *http.Request, message string) bool {
+	if user := CurrentUser(r); user != nil && !user.IsAdmin() {
+		HandleError(w, Forbidden(message), "require_admin")
+		return false
+	}
+	return true
+}
+
+// renderAccount renders the account page, showing a newly created API token's value if set
+func (h *Handlers) renderAccount(w http.ResponseWriter, r *http.Request, user *models.User, newToken string) {
+	tokens, err := h.service.ListAPITokens(user.ID)
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to list API tokens"), "list_api_tokens")
+		return
+	}
+	data := map[string]interface{}{
+		"User":     user,
+		"Tokens":   tokens,
+		"NewToken": newToken,
+	}
+	if r.Header.Get("HX-Request") == "true" && r.Method != http.MethodGet {
+		if err := h.tmpl.ExecuteTemplate(w, "api-tokens.html", data); err != nil {
+			HandleError(w, InternalError(err, "Failed to render template"), "render_template")
+		}
+		return
+	}
+	h.render(w, r, "account.html", data)
+}
+
+// renderLogin renders the standalone sign-in page
+func (h *Handlers) renderLogin(w http.ResponseWriter, status int, next, username, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := h.tmpl.ExecuteTemplate(w, "login.html", map[string]interface{}{
+		"Next":     safeRedirect(next),
+		"Username": username,
+		"Error":    message,
+	}); err != nil {
+		log.Error().Err(err).Msg("Failed to render login page")
+	}
+}
+
+// userError maps user and credential errors to a 400, with their message
+func userError(err error, message string) error {
+	var opErr *models.OperationError
+	switch {
+	case errors.Is(err, models.ErrInvalidUser), errors.Is(err, models.ErrInvalidCredentials), errors.Is(err, models.ErrLastAdmin):
+		if errors.As(err, &opErr) {
+			return BadRequest("%s", opErr.Msg)
+		}
+	case errors.Is(err, models.ErrUsernameTaken):
+		if errors.As(err, &opErr) {
+			return Conflict(opErr.Msg)
+		}
+	}
+	return InternalError(err, message)
+}
+
+// isPublicPath reports whether a path is reachable without signing in
+func isPublicPath(path string) bool {
+	return path == "/login" || strings.HasPrefix(path, "/static/")
+}
+
+// isAccountPath reports whether a path manages the caller's own account, which read-only users may do
+func isAccountPath(path string) bool {
+	return path == "/logout" || path == "/account" || strings.HasPrefix(path, "/account/")
+}
+
+// isAPIRequest reports whether a request comes from an API client rather than the browser UI
+func isAPIRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/api/") || r.Header.Get("Authorization") != ""
+}
+
+// loginURL returns the login page, set to return to next afterwards
+func loginURL(next string) string {
+	if next == "" || next == "/" {
+		return "/login"
+	}
+	return "/login?next=" + url.QueryEscape(next)
+}
+
+// safeRedirect returns next if it is a path on this site, so the login form can't be used to
+// send people elsewhere
+func safeRedirect(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
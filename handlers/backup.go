@@ -1,11 +1,19 @@
 package handlers
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
 )
 
 // RestoreGameserverBackup restores a gameserver from a backup
@@ -17,6 +25,11 @@ func (h *Handlers) RestoreGameserverBackup(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if !isValidBackupFilename(backupFilename) {
+		HandleError(w, BadRequest("invalid backup filename"), "restore_backup")
+		return
+	}
+
 	gameserver, ok := h.getGameserver(w, id)
 	if !ok {
 		return
@@ -24,7 +37,9 @@ func (h *Handlers) RestoreGameserverBackup(w http.ResponseWriter, r *http.Reques
 
 	log.Info().Str("gameserver_id", id).Str("backup_filename", backupFilename).Msg("Restoring backup")
 
-	if err := h.service.RestoreGameserverBackup(gameserver.ID, backupFilename); err != nil {
+	err = h.service.RestoreGameserverBackup(gameserver.ID, backupFilename)
+	h.recordAudit(r, models.AuditRestore, gameserver.ID, backupFilename, err)
+	if err != nil {
 		HandleError(w, InternalError(err, "Failed to restore backup"), "restore_backup")
 		return
 	}
@@ -32,18 +47,59 @@ func (h *Handlers) RestoreGameserverBackup(w http.ResponseWriter, r *http.Reques
 	w.WriteHeader(http.StatusOK)
 }
 
-// CreateGameserverBackup creates a new backup
+// RestoreOffsiteBackup downloads a backup from an offsite destination and restores the gameserver from it
+func (h *Handlers) RestoreOffsiteBackup(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	backupFilename, err := h.requireQueryParam(r, "backup")
+	if err != nil {
+		HandleError(w, err, "restore_offsite_backup")
+		return
+	}
+	destination, err := h.requireQueryParam(r, "destination")
+	if err != nil {
+		HandleError(w, err, "restore_offsite_backup")
+		return
+	}
+
+	if !isValidBackupFilename(backupFilename) {
+		HandleError(w, BadRequest("invalid backup filename"), "restore_offsite_backup")
+		return
+	}
+
+	gameserver, ok := h.getGameserver(w, id)
+	if !ok {
+		return
+	}
+
+	log.Info().Str("gameserver_id", id).Str("destination", destination).Str("backup_filename", backupFilename).Msg("Restoring offsite backup")
+
+	err = h.service.RestoreOffsiteBackup(gameserver.ID, destination, backupFilename)
+	h.recordAudit(r, models.AuditRestore, gameserver.ID, destination+": "+backupFilename, err)
+	if err != nil {
+		if errors.Is(err, models.ErrUnknownBackupDestination) {
+			HandleError(w, BadRequest("unknown backup destination %q", destination), "restore_offsite_backup")
+			return
+		}
+		HandleError(w, InternalError(err, "Failed to restore offsite backup"), "restore_offsite_backup")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// CreateGameserverBackup creates a new backup, responding with the outcome at each destination
 func (h *Handlers) CreateGameserverBackup(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
 	log.Info().Str("gameserver_id", id).Msg("Creating backup")
 
-	if err := h.service.CreateGameserverBackup(id); err != nil {
+	results, err := h.service.BackupGameserver(id)
+	if err != nil {
 		HandleError(w, InternalError(err, "Failed to create backup"), "create_backup")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	h.jsonSuccess(w, map[string]interface{}{"destinations": results})
 }
 
 // ListGameserverBackups displays all backups for a gameserver
@@ -61,13 +117,7 @@ func (h *Handlers) ListGameserverBackups(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	data := map[string]interface{}{
-		"Gameserver":   gameserver,
-		"Backups":      backups,
-		"GameserverID": id,
-		"BackupCount":  len(backups),
-		"MaxBackups":   gameserver.MaxBackups,
-	}
+	data := backupListData(gameserver, backups)
 
 	// Special case: if targeting #backup-list specifically, return just the list
 	target := r.Header.Get("HX-Target")
@@ -78,9 +128,99 @@ func (h *Handlers) ListGameserverBackups(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Show the backup schedule alongside retention so both can be managed in one place
+	tasks, err := h.service.ListScheduledTasksForGameserver(id)
+	if err != nil {
+		log.Warn().Err(err).Str("gameserver_id", id).Msg("Failed to list backup tasks")
+	}
+	var backupTasks []*models.ScheduledTask
+	for _, task := range tasks {
+		if task.Type == models.TaskTypeBackup {
+			backupTasks = append(backupTasks, task)
+		}
+	}
+	data["BackupTasks"] = backupTasks
+	data["MaxUploadSize"] = h.maxUploadSize
+
+	if h.service.OffsiteEnabled() {
+		data["OffsiteEnabled"] = true
+		data["BackupDestinations"] = h.service.BackupDestinations()
+		data["OffsiteDestinations"] = h.listOffsiteDestinations(gameserver)
+	}
+
 	h.renderGameserver(w, r, gameserver, "backups", "gameserver-backups.html", data)
 }
 
+// UpdateBackupSettings updates a gameserver's backup retention limit
+func (h *Handlers) UpdateBackupSettings(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := ParseForm(r); err != nil {
+		HandleError(w, err, "update_backup_settings")
+		return
+	}
+
+	maxBackups, err := strconv.Atoi(r.FormValue("max_backups"))
+	if err != nil || maxBackups < 0 {
+		HandleError(w, BadRequest("max_backups must be a non-negative number"), "update_backup_settings")
+		return
+	}
+
+	log.Info().Str("gameserver_id", id).Int("max_backups", maxBackups).Msg("Updating backup retention")
+
+	if err := h.service.UpdateBackupRetention(id, maxBackups); err != nil {
+		HandleError(w, InternalError(err, "Failed to update backup settings"), "update_backup_settings")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// UpdateBackupDestinations sets which destinations a gameserver's backups are stored at
+func (h *Handlers) UpdateBackupDestinations(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := ParseForm(r); err != nil {
+		HandleError(w, err, "update_backup_destinations")
+		return
+	}
+
+	destinations := r.Form["destinations"]
+	log.Info().Str("gameserver_id", id).Strs("destinations", destinations).Msg("Updating backup destinations")
+
+	if err := h.service.UpdateBackupDestinations(id, destinations); err != nil {
+		if errors.Is(err, models.ErrUnknownBackupDestination) {
+			HandleError(w, BadRequest("%s", err.Error()), "update_backup_destinations")
+			return
+		}
+		HandleError(w, InternalError(err, "Failed to update backup destinations"), "update_backup_destinations")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// offsiteDestination is one offsite destination's backups of a gameserver, for the backups page
+type offsiteDestination struct {
+	Name    string
+	Backups []*models.OffsiteBackup
+	Error   string
+}
+
+// listOffsiteDestinations lists the gameserver's backups at each destination it's copied to
+func (h *Handlers) listOffsiteDestinations(gameserver *models.Gameserver) []offsiteDestination {
+	var destinations []offsiteDestination
+	for _, name := range h.service.OffsiteDestinations(gameserver) {
+		destination := offsiteDestination{Name: name}
+		backups, err := h.service.ListOffsiteBackups(gameserver.ID, name)
+		if err != nil {
+			log.Warn().Err(err).Str("gameserver_id", gameserver.ID).Str("destination", name).Msg("Failed to list offsite backups")
+			destination.Error = "Could not reach " + name
+		}
+		destination.Backups = backups
+		destinations = append(destinations, destination)
+	}
+	return destinations
+}
+
 // DeleteGameserverBackup deletes a backup file
 func (h *Handlers) DeleteGameserverBackup(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -90,6 +230,11 @@ func (h *Handlers) DeleteGameserverBackup(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if !isValidBackupFilename(backupFilename) {
+		HandleError(w, BadRequest("invalid backup filename"), "delete_backup")
+		return
+	}
+
 	gameserver, ok := h.getGameserver(w, id)
 	if !ok {
 		return
@@ -99,27 +244,154 @@ func (h *Handlers) DeleteGameserverBackup(w http.ResponseWriter, r *http.Request
 
 	// Delete the backup file from /data/backups
 	backupPath := fmt.Sprintf("/data/backups/%s", backupFilename)
-	if err := h.docker.DeletePath(gameserver.ContainerID, backupPath); err != nil {
+	err = h.docker.DeletePath(gameserver.ContainerID, backupPath)
+	h.recordAudit(r, models.AuditBackupDelete, gameserver.ID, backupFilename, err)
+	if err != nil {
 		HandleError(w, InternalError(err, "Failed to delete backup"), "delete_backup")
 		return
 	}
 
 	// Return updated backup list for HTMX swap
-	backups, err := h.service.ListGameserverBackups(id)
+	h.respondBackupList(w, gameserver, "delete_backup")
+}
+
+// UploadGameserverBackup imports a backup archive, e.g. one downloaded from another host, so it
+// can be restored like the server's own backups. It's renamed to the usual backup-<timestamp>
+// form, keeping an optional label from the form after the timestamp.
+func (h *Handlers) UploadGameserverBackup(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if err := h.parseUpload(w, r); err != nil {
+		HandleError(w, err, "upload_backup")
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		HandleError(w, BadRequest("No file provided"), "upload_backup")
+		return
+	}
+	defer file.Close()
+
+	if header.Size > h.maxUploadSize {
+		HandleError(w, BadRequest("File too large (max %s)", formatFileSize(h.maxUploadSize)), "upload_backup")
+		return
+	}
+	name := strings.ToLower(header.Filename)
+	if strings.HasSuffix(name, ".tgz") {
+		name = strings.TrimSuffix(name, ".tgz") + ".tar.gz"
+	}
+	compression := models.BackupCompressionOf(name)
+	if compression == "" {
+		HandleError(w, BadRequest("Upload a .tar.gz, .tar.zst or .tar backup archive"), "upload_backup")
+		return
+	}
+	if !hasArchiveMagic(file, compression) {
+		HandleError(w, BadRequest("%s is not a %s archive", header.Filename, compression), "upload_backup")
+		return
+	}
+
+	gameserver, ok := h.getGameserver(w, id)
+	if !ok {
+		return
+	}
+
+	backupFilename, err := h.service.ImportGameserverBackup(id, r.FormValue("label"), compression, file, header.Size)
 	if err != nil {
-		HandleError(w, InternalError(err, "Failed to list backup files"), "delete_backup")
+		HandleError(w, InternalError(err, "Failed to import backup"), "upload_backup")
 		return
 	}
+	log.Info().Str("gameserver_id", id).Str("upload", header.Filename).Str("backup_filename", backupFilename).Msg("Uploaded backup")
+
+	h.respondBackupList(w, gameserver, "upload_backup")
+}
+
+// hasArchiveMagic reports whether an upload starts with the magic bytes of a backup with the
+// given compression, rewinding it afterwards. An uncompressed tar carries "ustar" at offset 257.
+func hasArchiveMagic(file io.ReadSeeker, compression string) bool {
+	var magic []byte
+	offset := 0
+	switch compression {
+	case models.BackupCompressionGzip:
+		magic = []byte{0x1f, 0x8b}
+	case models.BackupCompressionZstd:
+		magic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	default:
+		magic, offset = []byte("ustar"), 257
+	}
+
+	head := make([]byte, offset+len(magic))
+	_, err := io.ReadFull(file, head)
+	if _, seekErr := file.Seek(0, io.SeekStart); seekErr != nil {
+		return false
+	}
+	return err == nil && bytes.Equal(head[offset:], magic)
+}
 
-	data := map[string]interface{}{
+// respondBackupList renders the backup list partial, for HTMX swaps after the backups change
+func (h *Handlers) respondBackupList(w http.ResponseWriter, gameserver *models.Gameserver, op string) {
+	backups, err := h.service.ListGameserverBackups(gameserver.ID)
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to list backup files"), op)
+		return
+	}
+	if err := h.tmpl.ExecuteTemplate(w, "backup-list.html", backupListData(gameserver, backups)); err != nil {
+		HandleError(w, InternalError(err, "Failed to render backup list"), op)
+	}
+}
+
+// backupListData is the template data for the backup list partial
+func backupListData(gameserver *models.Gameserver, backups []*models.FileInfo) map[string]interface{} {
+	return map[string]interface{}{
 		"Gameserver":   gameserver,
 		"Backups":      backups,
-		"GameserverID": id,
+		"GameserverID": gameserver.ID,
 		"BackupCount":  len(backups),
+		"BackupBytes":  totalFileSize(backups),
 		"MaxBackups":   gameserver.MaxBackups,
 	}
+}
+
+// GameserverDiskUsage reports how much disk a gameserver's files and backups use: a summary
+// fragment for HTMX requests, JSON otherwise. Measuring can take a while on large worlds, so
+// pages load it separately.
+func (h *Handlers) GameserverDiskUsage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	gameserver, ok := h.getGameserver(w, id)
+	if !ok {
+		return
+	}
 
-	if err := h.tmpl.ExecuteTemplate(w, "backup-list.html", data); err != nil {
-		HandleError(w, InternalError(err, "Failed to render backup list"), "delete_backup")
+	usage, err := h.service.GetBackupUsage(id)
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to measure disk usage"), "disk_usage")
+		return
 	}
+
+	if r.Header.Get("HX-Request") != "true" {
+		h.jsonSuccess(w, map[string]interface{}{
+			"server_bytes":  usage.ServerBytes,
+			"backups_bytes": usage.BackupsBytes,
+			"total_bytes":   usage.TotalBytes(),
+		})
+		return
+	}
+
+	data := map[string]interface{}{"Gameserver": gameserver, "Usage": usage}
+	if err := h.tmpl.ExecuteTemplate(w, "disk-usage.html", data); err != nil {
+		HandleError(w, InternalError(err, "Failed to render disk usage"), "disk_usage")
+	}
+}
+
+// totalFileSize adds up the sizes of files, e.g. everything in the backups directory
+func totalFileSize(files []*models.FileInfo) int64 {
+	var total int64
+	for _, file := range files {
+		total += file.Size
+	}
+	return total
+}
+
+// isValidBackupFilename ensures a backup name is a plain archive filename with no path components
+func isValidBackupFilename(name string) bool {
+	return name == filepath.Base(name) && models.IsBackupFilename(name)
 }
@@ -3,37 +3,93 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"0xkowalskidev/gameservers/database"
 	"0xkowalskidev/gameservers/models"
-	"github.com/0xkowalskidev/gameserverquery/protocol"
+	"0xkowalskidev/gameservers/services"
 )
 
 // QueryServiceInterface defines the interface for game server queries
 type QueryServiceInterface interface {
-	QueryGameserver(gameserver *models.Gameserver, game *models.Game) (*protocol.ServerInfo, error)
+	QueryStatus(gameserver *models.Gameserver, game *models.Game) (*models.QueryResult, error)
+	QueryStatuses(gameservers []*models.Gameserver, games map[string]*models.Game) map[string]*models.QueryResult
+}
+
+// VersionServiceInterface defines the interface for listing available game versions
+type VersionServiceInterface interface {
+	ListVersions(game *models.Game) (*models.GameVersions, error)
+}
+
+// SchedulerInterface exposes the task scheduler's internal state
+type SchedulerInterface interface {
+	State() *models.SchedulerState
+}
+
+// StatusFeedInterface pushes gameserver status changes to the dashboard
+type StatusFeedInterface interface {
+	Subscribe() (<-chan models.StatusUpdate, func())
+	Snapshot() []models.StatusUpdate
+}
+
+// AuditInterface records and lists who triggered destructive and lifecycle actions
+type AuditInterface interface {
+	Record(entry *models.AuditLog)
+	List(gameserverID string, page int) (*models.AuditPage, error)
 }
 
 // Error handling functions - imported from main package
 var (
-	HandleError   func(w http.ResponseWriter, err error, context string)
-	NotFound      func(resource string) error
-	BadRequest    func(format string, args ...interface{}) error
-	InternalError func(err error, message string) error
-	ParseForm     func(r *http.Request) error
-	RequireMethod func(r *http.Request, method string) error
+	HandleError    func(w http.ResponseWriter, err error, context string)
+	HandleAPIError func(w http.ResponseWriter, err error, context string)
+	NotFound       func(resource string) error
+	BadRequest     func(format string, args ...interface{}) error
+	InternalError  func(err error, message string) error
+	Conflict       func(message string) error
+	Unauthorized   func(message string) error
+	Forbidden      func(message string) error
+	TooLarge       func(message string) error
+	ParseForm      func(r *http.Request) error
+	RequireMethod  func(r *http.Request, method string) error
 )
 
+// multipartOverhead allows for multipart boundaries and other form fields on top of an upload's file
+const multipartOverhead = 1 << 20
+
+// volumeNamePattern matches the names Docker accepts for volumes
+var volumeNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]+$`)
+
+// imageTagPattern matches an image tag or sha256 digest, or nothing for the game default
+var imageTagPattern = regexp.MustCompile(`^([a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127}|sha256:[a-f0-9]{64})?$`)
+
 // Layout data for wrapping content in layout.html
 type LayoutData struct {
 	Content   template.HTML
 	Title     string
-	ActiveNav string // "dashboard" | "gameservers" | "games"
+	ActiveNav string       // "dashboard" | "gameservers" | "groups" | "games" | "task-templates" | "audit"
+	User      *models.User // Signed-in user (nil when authentication is disabled)
+}
+
+// Options holds tunable handler settings loaded from configuration
+type Options struct {
+	MaxFileEditSize    int64    // Files larger than this open read-only, a part at a time
+	MaxUploadSize      int64    // Maximum multipart upload size
+	FileTailSize       int64    // Bytes loaded at a time from files too large to edit, and kept of large compressed files
+	MaxExtractSize     int64    // Total unpacked size allowed when extracting an archive
+	EditableExtensions []string // Extra editable extensions layered on top of the defaults
+
+	StreamReconnectTimeout time.Duration // How long log/stats streams wait for a replacement container (0 = don't re-attach)
+
+	AppVersion string // Build version reported by /admin/about
+
+	SecureCookies bool // Mark the session cookie Secure even for requests that don't look like HTTPS
 }
 
 // Handlers contains all HTTP handlers and their dependencies
@@ -43,18 +99,43 @@ type Handlers struct {
 	tmpl            *template.Template
 	maxFileEditSize int64
 	maxUploadSize   int64
+	fileTailSize    int64
+	maxExtractSize  int64
 	queryService    QueryServiceInterface
+	versionService  VersionServiceInterface
+	scheduler       SchedulerInterface
+	statusFeed      StatusFeedInterface
+	audit           AuditInterface
+
+	streamReconnectTimeout time.Duration
+	streamWait             func(time.Duration) <-chan time.Time // Paces re-attach checks; time.After outside tests
+	appVersion             string
+	secureCookies          bool
+
+	editableExtensions map[string]bool
 }
 
 // New creates a new handlers instance
-func New(service *database.GameserverRepository, docker models.DockerManagerInterface, tmpl *template.Template, maxFileEditSize, maxUploadSize int64, queryService QueryServiceInterface) *Handlers {
+func New(service *database.GameserverRepository, docker models.DockerManagerInterface, tmpl *template.Template, queryService QueryServiceInterface, versionService VersionServiceInterface, scheduler SchedulerInterface, statusFeed StatusFeedInterface, audit AuditInterface, opts Options) *Handlers {
 	return &Handlers{
-		service:         service,
-		docker:          docker,
-		tmpl:            tmpl,
-		maxFileEditSize: maxFileEditSize,
-		maxUploadSize:   maxUploadSize,
-		queryService:    queryService,
+		service:            service,
+		docker:             docker,
+		tmpl:               tmpl,
+		maxFileEditSize:    opts.MaxFileEditSize,
+		maxUploadSize:      opts.MaxUploadSize,
+		fileTailSize:       opts.FileTailSize,
+		maxExtractSize:     opts.MaxExtractSize,
+		queryService:       queryService,
+		versionService:     versionService,
+		scheduler:          scheduler,
+		statusFeed:         statusFeed,
+		audit:              audit,
+		editableExtensions: buildEditableExtensions(opts.EditableExtensions),
+
+		streamReconnectTimeout: opts.StreamReconnectTimeout,
+		streamWait:             time.After,
+		appVersion:             opts.AppVersion,
+		secureCookies:          opts.SecureCookies,
 	}
 }
 
@@ -163,6 +244,7 @@ func (h *Handlers) generateLayoutData(r *http.Request, content template.HTML) La
 
 	layout := LayoutData{
 		Content: content,
+		User:    CurrentUser(r),
 	}
 
 	switch {
@@ -179,6 +261,21 @@ func (h *Handlers) generateLayoutData(r *http.Request, content template.HTML) La
 		default:
 			layout.Title = "Gameserver Control Panel"
 		}
+	case strings.HasPrefix(path, "/groups"):
+		layout.Title = "Server Groups"
+		layout.ActiveNav = "groups"
+	case strings.HasPrefix(path, "/task-templates"):
+		layout.Title = "Task Templates"
+		layout.ActiveNav = "task-templates"
+	case path == "/audit":
+		layout.Title = "Audit Log"
+		layout.ActiveNav = "audit"
+	case path == "/account":
+		layout.Title = "Account"
+	case path == "/admin/users":
+		layout.Title = "Users"
+	case path == "/admin/orphans":
+		layout.Title = "Orphaned Containers"
 	case strings.HasPrefix(path, "/games"):
 		layout.ActiveNav = "games"
 		switch {
@@ -198,14 +295,35 @@ func (h *Handlers) generateLayoutData(r *http.Request, content template.HTML) La
 
 // GameserverFormData represents parsed gameserver form data
 type GameserverFormData struct {
-	Name         string
-	GameID       string
-	MemoryMB     int
-	CPUCores     float64
-	MaxBackups   int
-	Environment  []string
-	EnabledMods  []string
-	PortMappings []models.PortMapping // Manual port mappings (empty = auto allocate)
+	Name          string
+	GameID        string
+	MemoryMB      int
+	CPUCores      float64
+	MaxBackups    int
+	BackupOnStop  bool
+	StartPriority int
+	ShmSizeMB     int    // 0 = game default
+	Timezone      string // Empty = panel default
+	DataVolume    string // Existing volume to adopt on create (empty = new volume)
+	ImageOverride string // Image used instead of the game's (empty = game image)
+	ImageTag      string // Tag or digest applied to the game's image (empty = game default)
+	Environment   []string
+	Tags          []string
+	CommandAllow  []string // Console command patterns permitted (empty = all)
+	CommandDeny   []string // Console command patterns rejected
+	EnabledMods   []string
+	PortMappings  []models.PortMapping // Manual port mappings (empty = auto allocate)
+	DNS           []string             // Resolver IPs (empty = panel default)
+	ExtraHosts    []string             // host:ip entries for the container's /etc/hosts
+
+	BackupCompression string   // gzip, zstd or none
+	BackupExcludes    []string // Globs left out of backups
+
+	RestartOnCrash     bool
+	MaxRestartAttempts int // 0 = unlimited
+
+	CPUSetCPUs string // Host cores, e.g. 0-3 (empty = any)
+	SwapMB     int    // 0 = Docker default, -1 = no swap
 }
 
 // parseGameserverForm parses and validates gameserver form data
@@ -223,6 +341,11 @@ func (h *Handlers) parseGameserverForm(r *http.Request) (*GameserverFormData, er
 	memoryGB, _ := strconv.ParseFloat(r.FormValue("memory_gb"), 64)
 	cpuCores, _ := strconv.ParseFloat(r.FormValue("cpu_cores"), 64)
 	maxBackups, _ := strconv.Atoi(r.FormValue("max_backups"))
+	startPriority, _ := strconv.Atoi(r.FormValue("start_priority"))
+	shmSizeMB, _ := strconv.Atoi(r.FormValue("shm_size_mb"))
+	maxRestartAttempts, _ := strconv.Atoi(r.FormValue("max_restart_attempts"))
+	swapMB, _ := strconv.Atoi(r.FormValue("swap_mb"))
+	cpusetCPUs := strings.ReplaceAll(r.FormValue("cpuset_cpus"), " ", "")
 
 	memoryMB := int(memoryGB * 1024)
 	if memoryMB <= 0 {
@@ -231,16 +354,90 @@ func (h *Handlers) parseGameserverForm(r *http.Request) (*GameserverFormData, er
 	if maxBackups <= 0 {
 		maxBackups = 7
 	}
+	if shmSizeMB < 0 {
+		shmSizeMB = 0
+	}
+	if maxRestartAttempts < 0 {
+		maxRestartAttempts = 0
+	}
 
-	// Parse environment variables
+	// Parse environment variables from the config var fields and advanced area. API clients
+	// may still post the whole environment as KEY=VALUE lines.
 	var validEnv []string
-	for _, line := range strings.Split(r.FormValue("environment"), "\n") {
-		line = strings.TrimSpace(line)
-		if line != "" && strings.Contains(line, "=") {
-			validEnv = append(validEnv, line)
+	if _, raw := r.Form["environment"]; raw {
+		for _, line := range strings.Split(r.FormValue("environment"), "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" && strings.Contains(line, "=") {
+				validEnv = append(validEnv, line)
+			}
+		}
+	} else {
+		game, err := h.service.GetGame(gameID)
+		if err != nil {
+			return nil, err
+		}
+		if validEnv, err = services.ParseEnvironmentForm(game, r.Form); err != nil {
+			return nil, BadRequest("%s", err.Error())
+		}
+	}
+
+	timezone := strings.TrimSpace(r.FormValue("timezone"))
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return nil, BadRequest("unknown timezone %q", timezone)
 		}
 	}
 
+	dataVolume := strings.TrimSpace(r.FormValue("data_volume"))
+	if dataVolume != "" && !volumeNamePattern.MatchString(dataVolume) {
+		return nil, BadRequest("invalid volume name %q", dataVolume)
+	}
+
+	imageOverride := strings.TrimSpace(r.FormValue("image_override"))
+	if strings.ContainsAny(imageOverride, " \t\n") {
+		return nil, BadRequest("invalid image %q", imageOverride)
+	}
+	imageTag := strings.TrimSpace(r.FormValue("image_tag"))
+	if !imageTagPattern.MatchString(imageTag) {
+		return nil, BadRequest("invalid image tag %q: use a tag like 1.20.4 or a digest like sha256:...", imageTag)
+	}
+
+	dns := strings.Fields(strings.ReplaceAll(r.FormValue("dns"), ",", " "))
+	for _, server := range dns {
+		if err := models.ValidateDNSServer(server); err != nil {
+			return nil, BadRequest("%s", err.Error())
+		}
+	}
+	extraHosts := strings.Fields(r.FormValue("extra_hosts"))
+	for _, entry := range extraHosts {
+		if err := models.ValidateExtraHost(entry); err != nil {
+			return nil, BadRequest("%s", err.Error())
+		}
+	}
+
+	backupCompression := r.FormValue("backup_compression")
+	if backupCompression == "" {
+		backupCompression = models.BackupCompressionGzip
+	}
+	if !models.IsBackupCompression(backupCompression) {
+		return nil, BadRequest("invalid backup compression %q", backupCompression)
+	}
+	var backupExcludes []string
+	for _, pattern := range strings.Split(r.FormValue("backup_excludes"), "\n") {
+		pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "./")
+		if pattern == "" {
+			continue
+		}
+		if err := models.ValidateBackupExclude(pattern); err != nil {
+			return nil, BadRequest("%s", err.Error())
+		}
+		backupExcludes = append(backupExcludes, pattern)
+	}
+
+	tags := parseTags(r.FormValue("tags"))
+	commandAllow := parseCommandPatterns(r.FormValue("command_allow"))
+	commandDeny := parseCommandPatterns(r.FormValue("command_deny"))
+
 	// Parse enabled mods (checkboxes)
 	var enabledMods []string
 	if mods, ok := r.Form["mods"]; ok {
@@ -267,11 +464,89 @@ func (h *Handlers) parseGameserverForm(r *http.Request) (*GameserverFormData, er
 
 	return &GameserverFormData{
 		Name: name, GameID: gameID, MemoryMB: memoryMB,
-		CPUCores: cpuCores, MaxBackups: maxBackups, Environment: validEnv,
-		EnabledMods: enabledMods, PortMappings: portMappings,
+		CPUCores: cpuCores, MaxBackups: maxBackups, BackupOnStop: r.FormValue("backup_on_stop") == "true", StartPriority: startPriority, ShmSizeMB: shmSizeMB, Timezone: timezone, DataVolume: dataVolume, ImageOverride: imageOverride, ImageTag: imageTag, Environment: validEnv,
+		Tags: tags, CommandAllow: commandAllow, CommandDeny: commandDeny, EnabledMods: enabledMods, PortMappings: portMappings,
+		DNS: dns, ExtraHosts: extraHosts,
+		BackupCompression: backupCompression, BackupExcludes: backupExcludes,
+		RestartOnCrash: r.FormValue("restart_on_crash") == "true", MaxRestartAttempts: maxRestartAttempts,
+		CPUSetCPUs: cpusetCPUs, SwapMB: swapMB,
 	}, nil
 }
 
+// parseCommandPatterns splits a comma or newline separated list of console command patterns
+func parseCommandPatterns(value string) []string {
+	var patterns []string
+	for _, pattern := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' }) {
+		pattern = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(pattern), "/"))
+		if pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// parseTags splits a comma separated tag list, lowercasing and dropping duplicates
+func parseTags(value string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// parseTaskTemplateForm parses and validates task template form data
+func (h *Handlers) parseTaskTemplateForm(r *http.Request) (*models.TaskTemplate, error) {
+	if err := ParseForm(r); err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	tag := strings.ToLower(strings.TrimSpace(r.FormValue("tag")))
+	taskType := models.TaskType(strings.TrimSpace(r.FormValue("type")))
+	cronSchedule := strings.TrimSpace(r.FormValue("cron_schedule"))
+
+	if name == "" || tag == "" || taskType == "" || cronSchedule == "" {
+		return nil, BadRequest("name, tag, type and cron_schedule are required")
+	}
+	if !taskType.IsValid() {
+		return nil, BadRequest("invalid task type: %s", taskType)
+	}
+	command, err := parseTaskCommand(r, taskType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TaskTemplate{Name: name, Tag: tag, Type: taskType, Command: command, CronSchedule: cronSchedule}, nil
+}
+
+// parseServerGroupForm parses and validates server group form data, returning the selected member IDs
+func (h *Handlers) parseServerGroupForm(r *http.Request) (*models.ServerGroup, []string, error) {
+	if err := ParseForm(r); err != nil {
+		return nil, nil, err
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		return nil, nil, BadRequest("name is required")
+	}
+
+	var memberIDs []string
+	seen := make(map[string]bool)
+	for _, id := range r.Form["members"] {
+		if id = strings.TrimSpace(id); id != "" && !seen[id] {
+			seen[id] = true
+			memberIDs = append(memberIDs, id)
+		}
+	}
+
+	return &models.ServerGroup{Name: name, Description: strings.TrimSpace(r.FormValue("description"))}, memberIDs, nil
+}
+
 // parseScheduledTaskForm parses and validates scheduled task form data
 func (h *Handlers) parseScheduledTaskForm(r *http.Request, gameserverID string) (*models.ScheduledTask, error) {
 	if err := ParseForm(r); err != nil {
@@ -287,16 +562,33 @@ func (h *Handlers) parseScheduledTaskForm(r *http.Request, gameserverID string)
 	}
 
 	parsedType := models.TaskType(taskType)
-	if parsedType != models.TaskTypeRestart && parsedType != models.TaskTypeBackup {
+	if !parsedType.IsValid() {
 		return nil, BadRequest("invalid task type: %s", taskType)
 	}
+	command, err := parseTaskCommand(r, parsedType)
+	if err != nil {
+		return nil, err
+	}
 
 	return &models.ScheduledTask{
-		GameserverID: gameserverID, Name: name, Type: parsedType,
+		GameserverID: gameserverID, Name: name, Type: parsedType, Command: command,
 		Status: models.TaskStatusActive, CronSchedule: cronSchedule,
 	}, nil
 }
 
+// parseTaskCommand reads a command task's console commands from the form, one per line.
+// Other task types don't keep a command.
+func parseTaskCommand(r *http.Request, taskType models.TaskType) (string, error) {
+	if taskType != models.TaskTypeCommand {
+		return "", nil
+	}
+	commands := models.SplitTaskCommand(r.FormValue("command"))
+	if len(commands) == 0 {
+		return "", BadRequest("command is required for command tasks")
+	}
+	return strings.Join(commands, "\n"), nil
+}
+
 // updateTaskFromForm updates task from form data
 func (h *Handlers) updateTaskFromForm(task *models.ScheduledTask, r *http.Request) error {
 	if err := ParseForm(r); err != nil {
@@ -310,12 +602,23 @@ func (h *Handlers) updateTaskFromForm(task *models.ScheduledTask, r *http.Reques
 
 	if taskType != "" {
 		parsedType := models.TaskType(taskType)
-		if parsedType != models.TaskTypeRestart && parsedType != models.TaskTypeBackup {
+		if !parsedType.IsValid() {
 			return BadRequest("invalid task type: %s", taskType)
 		}
 		task.Type = parsedType
 	}
 
+	// Keep a command task's command unless the form changes it
+	if task.Type != models.TaskTypeCommand {
+		task.Command = ""
+	} else if _, ok := r.Form["command"]; ok || task.Command == "" {
+		command, err := parseTaskCommand(r, task.Type)
+		if err != nil {
+			return err
+		}
+		task.Command = command
+	}
+
 	if status != "" {
 		parsedStatus := models.TaskStatus(status)
 		if parsedStatus != models.TaskStatusActive && parsedStatus != models.TaskStatusDisabled {
@@ -354,7 +657,6 @@ func (h *Handlers) validateFormFields(r *http.Request, fields ...string) error {
 	return nil
 }
 
-
 // formatFileSize formats file size in human readable format
 func formatFileSize(size int64) string {
 	const unit = 1024
@@ -369,6 +671,26 @@ func formatFileSize(size int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
 }
 
+// parseUpload parses a multipart upload with the request body capped at the upload limit, so an
+// oversized upload is rejected as it arrives instead of after it has been spooled to disk
+func (h *Handlers) parseUpload(w http.ResponseWriter, r *http.Request) error {
+	limit := h.maxUploadSize + multipartOverhead
+	tooLarge := TooLarge(fmt.Sprintf("File too large (max %s)", formatFileSize(h.maxUploadSize)))
+	if r.ContentLength > limit {
+		return tooLarge
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	if err := r.ParseMultipartForm(h.maxUploadSize); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return tooLarge
+		}
+		return BadRequest("Invalid upload format")
+	}
+	return nil
+}
+
 // JSON response helpers
 func (h *Handlers) jsonError(w http.ResponseWriter, message string) {
 	w.Header().Set("Content-Type", "application/json")
@@ -379,3 +701,16 @@ func (h *Handlers) jsonSuccess(w http.ResponseWriter, data map[string]interface{
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(data)
 }
+
+// configError writes a 400 with the message and per-field problems of a config validation
+// failure, reporting whether err was one
+func (h *Handlers) configError(w http.ResponseWriter, err error) bool {
+	var configErr *models.ConfigValidationError
+	if !errors.As(err, &configErr) {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": "Invalid configuration: " + configErr.Error(), "fields": configErr.Fields()})
+	return true
+}
@@ -2,15 +2,45 @@ package handlers
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// A closed stream checks for the gameserver's container after streamReconnectInterval, backing
+// off to maxStreamReconnectInterval while the server stays down
+const (
+	streamReconnectInterval    = 2 * time.Second
+	maxStreamReconnectInterval = 15 * time.Second
+)
+
+// statusStreamKeepalive is how often an idle dashboard status stream sends a comment so proxies keep it open
+const statusStreamKeepalive = 30 * time.Second
+
+// Log history sent when a log stream opens: the default, and the most lines that may be requested
+const (
+	defaultLogTail = 100
+	maxLogTail     = 5000
+)
+
+// Metrics chart windows: the default, and the longest that may be requested
+const (
+	defaultMetricsWindow = time.Hour
+	maxMetricsWindow     = 7 * 24 * time.Hour
 )
 
 // GameserverConsole displays the console interface
@@ -35,6 +65,10 @@ func (h *Handlers) SendGameserverCommand(w http.ResponseWriter, r *http.Request)
 	log.Info().Str("gameserver_id", id).Str("command", command).Msg("Sending console command")
 
 	output, err := h.service.SendGameserverCommand(id, command)
+	if errors.Is(err, models.ErrCommandBlocked) {
+		HandleError(w, Forbidden(err.Error()), "send_command")
+		return
+	}
 	if err != nil {
 		HandleError(w, InternalError(err, "Failed to send console command"), "send_command")
 		return
@@ -44,10 +78,141 @@ func (h *Handlers) SendGameserverCommand(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(map[string]string{"output": output})
 }
 
-// GameserverLogs streams gameserver logs via Server-Sent Events
+// consoleMessage is a message on the interactive console WebSocket. The client sends
+// "command" messages; the server sends "log" lines and "error"s for rejected commands.
+type consoleMessage struct {
+	Type string `json:"type"`
+	Data string `json:"data"`
+}
+
+// GameserverConsoleSocket serves an interactive console over WebSocket, carrying both
+// command input and log output. Connections to a server that isn't running are closed
+// with code 4000 and a reason saying so.
+func (h *Handlers) GameserverConsoleSocket(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, ok := h.getGameserver(w, id); !ok {
+		return
+	}
+
+	ws, err := upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+
+	session, err := h.service.AttachGameserverConsole(id)
+	if errors.Is(err, models.ErrServerNotRunning) {
+		ws.close(wsCloseNotRunning, "gameserver is not running")
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Str("gameserver_id", id).Msg("Failed to attach console")
+		ws.close(wsCloseInternal, "failed to attach console")
+		return
+	}
+	defer session.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go forwardConsoleOutput(ws, session, done)
+
+	for {
+		messageType, payload, err := ws.readMessage()
+		if err != nil {
+			ws.conn.Close()
+			return
+		}
+		if messageType != websocket.TextMessage {
+			continue
+		}
+
+		var msg consoleMessage
+		if err := json.Unmarshal(payload, &msg); err != nil || msg.Type != "command" {
+			writeConsoleMessage(ws, "error", "expected a command message")
+			continue
+		}
+		// The socket is opened with a GET, so read-only users get this far and may watch the output
+		if user := CurrentUser(r); user != nil && !user.IsAdmin() {
+			writeConsoleMessage(ws, "error", "your account has read-only access")
+			continue
+		}
+
+		var opErr *models.OperationError
+		err = session.Send(msg.Data)
+		switch {
+		case err == nil:
+		case errors.Is(err, models.ErrCommandBlocked), errors.As(err, &opErr):
+			writeConsoleMessage(ws, "error", err.Error())
+		default:
+			log.Error().Err(err).Str("gameserver_id", id).Msg("Failed to send console command")
+			ws.close(wsCloseInternal, "failed to send command")
+			return
+		}
+	}
+}
+
+// forwardConsoleOutput sends console output lines to the client, pinging it while idle so
+// proxies keep the connection open. When the gameserver stops, the socket is closed.
+func forwardConsoleOutput(ws *wsConn, output io.Reader, done <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(statusStreamKeepalive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				ws.ping()
+			}
+		}
+	}()
+
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		if err := writeConsoleMessage(ws, "log", scanner.Text()); err != nil {
+			return
+		}
+	}
+
+	select {
+	case <-done:
+	default:
+		ws.close(wsCloseGoingAway, "gameserver stopped")
+	}
+}
+
+// writeConsoleMessage sends a console message to the client
+func writeConsoleMessage(ws *wsConn, msgType, data string) error {
+	payload, err := json.Marshal(consoleMessage{Type: msgType, Data: data})
+	if err != nil {
+		return err
+	}
+	return ws.writeText(payload)
+}
+
+// GameserverLogs streams gameserver logs via Server-Sent Events. Each line's id is its Docker
+// timestamp in Unix nanoseconds, so a reconnecting client's Last-Event-ID resumes after the last
+// line it got instead of receiving the tail again. ?tail= sets how many lines of history are sent.
 func (h *Handlers) GameserverLogs(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
+	opts := models.LogStreamOptions{Tail: defaultLogTail}
+	if value := r.URL.Query().Get("tail"); value != "" {
+		tail, err := strconv.Atoi(value)
+		if err != nil || tail < 0 {
+			HandleError(w, BadRequest("tail must be a number of lines"), "gameserver_logs")
+			return
+		}
+		opts.Tail = min(tail, maxLogTail)
+	}
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		if nanos, err := strconv.ParseInt(lastID, 10, 64); err == nil && nanos > 0 {
+			opts.Since = time.Unix(0, nanos)
+		}
+	}
+
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
@@ -59,21 +224,59 @@ func (h *Handlers) GameserverLogs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logs, err := h.service.StreamGameserverLogs(id)
-	if err != nil {
-		log.Error().Err(err).Str("gameserver_id", id).Msg("Failed to stream logs")
-		fmt.Fprintf(w, "event: error\ndata: Failed to stream logs: %v\n\n", err)
-		flusher.Flush()
-		return
+	// Re-attach when the container is replaced (e.g. on restart) so the console stays live,
+	// resuming after the last line sent. A restart event marks each re-attach for the UI.
+	lastSent := opts.Since
+	for attached := false; ; attached = true {
+		if !lastSent.IsZero() {
+			opts.Since = lastSent
+		}
+		logs, err := h.service.StreamGameserverLogs(id, opts)
+		switch {
+		case err != nil && !attached:
+			log.Error().Err(err).Str("gameserver_id", id).Msg("Failed to stream logs")
+			fmt.Fprintf(w, "event: error\ndata: Failed to stream logs: %v\n\n", err)
+			flusher.Flush()
+			return
+		case err != nil:
+			// The container may have been replaced again meanwhile, so keep waiting for it
+			log.Debug().Err(err).Str("gameserver_id", id).Msg("Failed to re-attach log stream")
+		default:
+			if attached {
+				fmt.Fprintf(w, "event: restart\ndata: %s\n\n", time.Now().Format(time.RFC3339))
+				flusher.Flush()
+			}
+			release := closeOnDone(r.Context(), logs)
+			lastSent = writeLogEvents(w, flusher, logs, lastSent)
+			release()
+		}
+
+		if !h.awaitContainer(r.Context(), id) {
+			return
+		}
+		log.Debug().Str("gameserver_id", id).Msg("Re-attaching log stream")
 	}
-	defer logs.Close()
+}
 
+// writeLogEvents forwards container log lines as SSE events until the stream ends,
+// skipping lines at or before lastSent that a resumed stream replays
+func writeLogEvents(w http.ResponseWriter, flusher http.Flusher, logs io.Reader, lastSent time.Time) time.Time {
 	scanner := bufio.NewScanner(logs)
 	for scanner.Scan() {
 		line := scanner.Text()
 		if len(line) > 8 {
 			cleanLine := line[8:]
 			if strings.TrimSpace(cleanLine) != "" {
+				if timestamp, _, ok := strings.Cut(cleanLine, " "); ok {
+					if t, err := time.Parse(time.RFC3339Nano, timestamp); err == nil {
+						if !t.After(lastSent) {
+							continue
+						}
+						lastSent = t
+						fmt.Fprintf(w, "id: %d\n", t.UnixNano())
+					}
+				}
+
 				// Escape HTML to prevent XSS
 				cleanLine = template.HTMLEscapeString(cleanLine)
 				fmt.Fprintf(w, "event: log\ndata: <div class=\"whitespace-pre-wrap break-all\">%s</div>\n\n", cleanLine)
@@ -81,6 +284,7 @@ func (h *Handlers) GameserverLogs(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
+	return lastSent
 }
 
 // GameserverStats streams gameserver statistics via Server-Sent Events
@@ -98,15 +302,29 @@ func (h *Handlers) GameserverStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stats, err := h.service.StreamGameserverStats(id)
-	if err != nil {
-		log.Error().Err(err).Str("gameserver_id", id).Msg("Failed to stream stats")
-		fmt.Fprintf(w, "event: error\ndata: Failed to stream stats: %v\n\n", err)
-		flusher.Flush()
-		return
+	// Re-attach when the container is replaced (e.g. on restart) so stats keep updating
+	for {
+		stats, err := h.service.StreamGameserverStats(id)
+		if err != nil {
+			log.Error().Err(err).Str("gameserver_id", id).Msg("Failed to stream stats")
+			fmt.Fprintf(w, "event: error\ndata: Failed to stream stats: %v\n\n", err)
+			flusher.Flush()
+			return
+		}
+
+		release := closeOnDone(r.Context(), stats)
+		writeStatsEvents(w, flusher, stats)
+		release()
+
+		if !h.awaitContainer(r.Context(), id) {
+			return
+		}
+		log.Debug().Str("gameserver_id", id).Msg("Re-attaching stats stream")
 	}
-	defer stats.Close()
+}
 
+// writeStatsEvents forwards container stats as SSE events until the stream ends
+func writeStatsEvents(w http.ResponseWriter, flusher http.Flusher, stats io.Reader) {
 	scanner := bufio.NewScanner(stats)
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -165,3 +383,79 @@ func (h *Handlers) GameserverStats(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+// GameserverMetrics returns recorded CPU and memory use over ?window= (default 1h) as JSON
+// points for a chart
+func (h *Handlers) GameserverMetrics(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	window := defaultMetricsWindow
+	if raw := r.URL.Query().Get("window"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < time.Minute || parsed > maxMetricsWindow {
+			HandleError(w, BadRequest("window must be a duration between 1m and 168h, e.g. 1h"), "gameserver_metrics")
+			return
+		}
+		window = parsed
+	}
+
+	points, step, err := h.service.GetGameserverMetrics(id, window)
+	if err != nil {
+		HandleError(w, NotFound("Gameserver"), "gameserver_metrics")
+		return
+	}
+
+	h.jsonSuccess(w, map[string]interface{}{
+		"window_seconds": int64(window.Seconds()),
+		"step_seconds":   int64(step.Seconds()),
+		"points":         points,
+	})
+}
+
+// closeOnDone closes a stream when the client disconnects so a blocked read returns.
+// The returned func closes the stream and stops watching.
+func closeOnDone(ctx context.Context, stream io.Closer) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			stream.Close()
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+		stream.Close()
+	}
+}
+
+// awaitContainer waits for a gameserver whose stream closed to be running again, checking less
+// often the longer it's down, and returns false if the client left, the gameserver is gone or
+// the timeout passed
+func (h *Handlers) awaitContainer(ctx context.Context, id string) bool {
+	if h.streamReconnectTimeout <= 0 {
+		return false
+	}
+
+	interval := streamReconnectInterval
+	deadline := time.After(h.streamReconnectTimeout)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-deadline:
+			return false
+		case <-h.streamWait(interval):
+		}
+		interval = min(interval*2, maxStreamReconnectInterval)
+
+		gameserver, err := h.service.GetGameserver(id)
+		if err != nil {
+			return false
+		}
+		if gameserver.ContainerID != "" && gameserver.Status == models.StatusRunning {
+			return true
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// dockerLogLine formats a log line as Docker streams it: an 8 byte stream header, then the
+// timestamp and the text
+func dockerLogLine(timestamp time.Time, text string) string {
+	return "\x01\x00\x00\x00\x00\x00\x00\x20" + timestamp.Format(time.RFC3339Nano) + " " + text + "\n"
+}
+
+// streamLogs runs GameserverLogs for gs-1 until ctx is cancelled and returns the response body
+func streamLogs(ctx context.Context, h *Handlers) string {
+	r := chi.NewRouter()
+	r.Get("/{id}/logs", h.GameserverLogs)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/gs-1/logs", nil).WithContext(ctx))
+	return rec.Body.String()
+}
+
+func TestGameserverLogsReattachesAfterRestart(t *testing.T) {
+	h, docker, db := newTestHandlers(t, nil)
+	h.streamReconnectTimeout = time.Minute
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	var sinces []time.Time
+	docker.StreamContainerLogsFunc = func(containerID string, opts models.LogStreamOptions) (io.ReadCloser, error) {
+		mu.Lock()
+		sinces = append(sinces, opts.Since)
+		mu.Unlock()
+		logs := map[string]string{
+			"container-1": dockerLogLine(start, "Starting server") + dockerLogLine(start.Add(time.Second), "Done (3.2s)!"),
+			// Docker's since is inclusive, so the last line sent is replayed
+			"container-2": dockerLogLine(start.Add(time.Second), "Done (3.2s)!") + dockerLogLine(start.Add(time.Minute), "Starting again"),
+		}
+		return io.NopCloser(strings.NewReader(logs[containerID])), nil
+	}
+
+	setServer := func(status models.GameserverStatus, containerID string) {
+		server, err := db.GetGameserver("gs-1")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		server.Status, server.ContainerID = status, containerID
+		if err := db.UpdateGameserver(server); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// The first stream ends as the server goes down; it's back after the fifth check, and the
+	// request ends once the second stream does
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var waits []time.Duration
+	h.streamWait = func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		switch len(waits) {
+		case 1:
+			setServer(models.StatusStopped, "")
+		case 5:
+			setServer(models.StatusRunning, "container-2")
+		case 6:
+			cancel()
+			return nil
+		}
+		fired := make(chan time.Time, 1)
+		fired <- time.Now()
+		return fired
+	}
+
+	body := streamLogs(ctx, h)
+
+	wantWaits := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 15 * time.Second, 15 * time.Second, 2 * time.Second}
+	if !reflect.DeepEqual(waits, wantWaits) {
+		t.Errorf("waits = %v, want %v", waits, wantWaits)
+	}
+	if len(sinces) != 2 || !sinces[0].IsZero() || !sinces[1].Equal(start.Add(time.Second)) {
+		t.Errorf("streams opened with since %v, want the second to resume after the last line sent", sinces)
+	}
+
+	var events []string
+	for _, event := range strings.Split(strings.TrimSpace(body), "\n\n") {
+		switch {
+		case strings.HasPrefix(event, "event: restart"):
+			events = append(events, "restart")
+		case strings.Contains(event, "event: log"):
+			text := event[strings.Index(event, "Z ")+2 : strings.LastIndex(event, "</div>")]
+			events = append(events, text)
+		default:
+			events = append(events, event)
+		}
+	}
+	wantEvents := []string{"Starting server", "Done (3.2s)!", "restart", "Starting again"}
+	if !reflect.DeepEqual(events, wantEvents) {
+		t.Errorf("events = %q, want %q", events, wantEvents)
+	}
+	if !strings.Contains(body, fmt.Sprintf("id: %d\n", start.Add(time.Minute).UnixNano())) {
+		t.Errorf("events carry no Docker timestamp ids:\n%s", body)
+	}
+}
+
+func TestGameserverLogsGivesUpWhenServerIsDeleted(t *testing.T) {
+	h, docker, db := newTestHandlers(t, nil)
+	h.streamReconnectTimeout = time.Minute
+	docker.Logs["container-1"] = dockerLogLine(time.Now(), "Stopping server")
+
+	var waits int
+	h.streamWait = func(d time.Duration) <-chan time.Time {
+		waits++
+		switch waits {
+		case 1:
+			server, _ := db.GetGameserver("gs-1")
+			server.Status, server.ContainerID = models.StatusStopped, ""
+			if err := db.UpdateGameserver(server); err != nil {
+				t.Error(err)
+			}
+		case 2:
+			if err := db.DeleteGameserver("gs-1"); err != nil {
+				t.Error(err)
+			}
+		}
+		fired := make(chan time.Time, 1)
+		fired <- time.Now()
+		return fired
+	}
+
+	body := streamLogs(context.Background(), h)
+	if waits != 2 {
+		t.Errorf("waited %d times, want to stop checking once the server is gone", waits)
+	}
+	if strings.Count(body, "event: log") != 1 || strings.Contains(body, "event: restart") {
+		t.Errorf("body = %q, want the one line and no re-attach", body)
+	}
+}
+
+func TestGameserverLogsWithoutReconnect(t *testing.T) {
+	h, docker, _ := newTestHandlers(t, nil)
+	docker.Logs["container-1"] = dockerLogLine(time.Now(), "Hello")
+	h.streamWait = func(d time.Duration) <-chan time.Time {
+		t.Errorf("waited %s with re-attaching disabled", d)
+		return nil
+	}
+
+	if body := streamLogs(context.Background(), h); strings.Count(body, "event: log") != 1 {
+		t.Errorf("body = %q, want the one line", body)
+	}
+}
@@ -3,16 +3,21 @@ package handlers
 import (
 	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
 )
 
 // GameserverFiles displays the file manager interface
@@ -47,6 +52,11 @@ func (h *Handlers) BrowseGameserverFiles(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	h.renderFileBrowser(w, gameserver, path)
+}
+
+// renderFileBrowser renders the file listing partial for path
+func (h *Handlers) renderFileBrowser(w http.ResponseWriter, gameserver *models.Gameserver, path string) {
 	files, err := h.docker.ListFiles(gameserver.ContainerID, path)
 	if err != nil {
 		HandleError(w, InternalError(err, "Failed to list files"), "browse_files")
@@ -88,8 +98,20 @@ func (h *Handlers) GameserverFileContent(w http.ResponseWriter, r *http.Request)
 	// Sanitize path
 	path = sanitizePath(path)
 
-	// Check if file is editable
-	if !isEditableFile(path) {
+	// Parts of files too large to edit are loaded by offset, or from their end with offset=end,
+	// which is where log files open by default
+	offsetParam := r.URL.Query().Get("offset")
+	tail := offsetParam == "end" || (offsetParam == "" && filepath.Ext(path) == ".log")
+	offset, _ := strconv.ParseInt(offsetParam, 10, 64)
+	if offset < 0 {
+		offset = 0
+	}
+
+	// Check if file is editable (extensionless files are sniffed after reading).
+	// Gzipped text files, such as rotated logs, are decompressed and shown read-only
+	compressed := h.isCompressedTextFile(path)
+	sniff := h.needsContentSniff(path)
+	if !compressed && !sniff && !h.isEditableFile(path) {
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"Path":      path,
 			"Content":   "",
@@ -138,22 +160,59 @@ func (h *Handlers) GameserverFileContent(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Read file content with size limit
-	if header.Size > h.maxFileEditSize {
+	if compressed {
+		h.compressedFileContent(w, path, tarReader)
+		return
+	}
+
+	// Files over the edit limit open read-only, a part at a time
+	if header.Size > h.maxFileEditSize || offset > 0 {
+		if tail {
+			offset = -1
+		}
+		h.rangeFileContent(w, gameserver.ContainerID, path, offset, sniff)
+		return
+	}
+
+	// Read content
+	content := make([]byte, header.Size)
+	_, err = io.ReadFull(tarReader, content)
+	if err != nil {
+		log.Error().Err(err).Str("path", path).Msg("Failed to read file content")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"Path":      path,
 			"Content":   "",
 			"Supported": false,
-			"Error":     fmt.Sprintf("File too large to edit (max %s)", formatFileSize(h.maxFileEditSize)),
+			"Error":     "Failed to read file content",
 		})
 		return
 	}
 
-	// Read content
-	content := make([]byte, header.Size)
-	_, err = io.ReadFull(tarReader, content)
+	if sniff && !isTextContent(content) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"Path":      path,
+			"Content":   "",
+			"Supported": false,
+		})
+		return
+	}
+
+	// Success response, with the hash the editor posts back on save
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"Path":      path,
+		"Content":   string(content),
+		"Supported": true,
+		"Hash":      models.ContentHash(content),
+	})
+}
+
+// rangeFileContent responds with up to fileTailSize bytes of a large file starting at offset, or
+// its last fileTailSize bytes for a negative offset, in read-only mode. Truncated and NextOffset
+// tell the editor whether and where to load more; Tail marks a part read from the end.
+func (h *Handlers) rangeFileContent(w http.ResponseWriter, containerID, path string, offset int64, sniff bool) {
+	content, size, err := h.docker.ReadFileRange(containerID, path, offset, h.fileTailSize)
 	if err != nil {
-		log.Error().Err(err).Str("path", path).Msg("Failed to read file content")
+		log.Error().Err(err).Str("path", path).Int64("offset", offset).Msg("Failed to read file range")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"Path":      path,
 			"Content":   "",
@@ -163,14 +222,138 @@ func (h *Handlers) GameserverFileContent(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Success response
+	tail := offset < 0
+	if tail {
+		offset = max(size-h.fileTailSize, 0)
+		// Drop the partial first line left by starting mid-file
+		if idx := bytes.IndexByte(content, '\n'); idx >= 0 && offset > 0 {
+			content = content[idx+1:]
+			offset += int64(idx + 1)
+		}
+	}
+
+	// End the part at a line break so loading more doesn't split lines or characters
+	next := offset + int64(len(content))
+	if next < size {
+		if idx := bytes.LastIndexByte(content, '\n'); idx >= 0 {
+			content = content[:idx+1]
+			next = offset + int64(idx+1)
+		}
+	}
+
+	if sniff && (offset == 0 || tail) && !isTextContent(content) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"Path":      path,
+			"Content":   "",
+			"Supported": false,
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"Path":       path,
+		"Content":    string(content),
+		"Supported":  true,
+		"ReadOnly":   true,
+		"Truncated":  next < size,
+		"Tail":       tail,
+		"Size":       size,
+		"Offset":     offset,
+		"NextOffset": next,
+		"Notice": fmt.Sprintf("File is too large to edit (%s, max %s) and opens read-only, %s at a time.",
+			formatFileSize(size), formatFileSize(h.maxFileEditSize), formatFileSize(h.fileTailSize)),
+	})
+}
+
+// maxDecompressedSize caps how much of a gzipped file is decompressed, so a
+// compression bomb can't keep the request busy indefinitely
+const maxDecompressedSize = 1 << 30
+
+// compressedFileContent decompresses a gzipped text file and responds with it in
+// read-only mode, keeping only the last fileTailSize bytes when it's over the edit limit
+func (h *Handlers) compressedFileContent(w http.ResponseWriter, path string, r io.Reader) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		log.Error().Err(err).Str("path", path).Msg("Failed to open gzip stream")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"Path":      path,
+			"Content":   "",
+			"Supported": false,
+			"Error":     "File is not valid gzip",
+		})
+		return
+	}
+	defer gz.Close()
+
+	tail := &tailBuffer{limit: int(h.maxFileEditSize)}
+	if _, err := io.Copy(tail, io.LimitReader(gz, maxDecompressedSize)); err != nil {
+		log.Error().Err(err).Str("path", path).Msg("Failed to decompress file")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"Path":      path,
+			"Content":   "",
+			"Supported": false,
+			"Error":     "Failed to decompress file",
+		})
+		return
+	}
+
+	content := tail.Bytes()
+	if !isTextContent(content) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"Path":      path,
+			"Content":   "",
+			"Supported": false,
+		})
+		return
+	}
+
+	notice := "Compressed file, shown read-only."
+	if tail.total > h.maxFileEditSize {
+		if int64(len(content)) > h.fileTailSize {
+			content = content[int64(len(content))-h.fileTailSize:]
+		}
+		// Drop the partial first line left by cutting mid-file
+		if idx := bytes.IndexByte(content, '\n'); idx >= 0 {
+			content = content[idx+1:]
+		}
+		notice = fmt.Sprintf("Compressed file is too large to show in full (%s decompressed). Showing the last %s read-only.",
+			formatFileSize(tail.total), formatFileSize(int64(len(content))))
+	}
+
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"Path":      path,
 		"Content":   string(content),
 		"Supported": true,
+		"ReadOnly":  true,
+		"Notice":    notice,
 	})
 }
 
+// tailBuffer is an io.Writer that keeps only the last limit bytes written
+type tailBuffer struct {
+	buf   []byte
+	limit int
+	total int64
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.total += int64(len(p))
+	t.buf = append(t.buf, p...)
+	// Compact occasionally rather than on every write
+	if len(t.buf) > 2*t.limit {
+		t.buf = append(t.buf[:0], t.buf[len(t.buf)-t.limit:]...)
+	}
+	return len(p), nil
+}
+
+// Bytes returns the last limit bytes written
+func (t *tailBuffer) Bytes() []byte {
+	if len(t.buf) > t.limit {
+		return t.buf[len(t.buf)-t.limit:]
+	}
+	return t.buf
+}
+
 // SaveGameserverFile saves file content (JSON API)
 func (h *Handlers) SaveGameserverFile(w http.ResponseWriter, r *http.Request) {
 	// Set content type early
@@ -214,7 +397,7 @@ func (h *Handlers) SaveGameserverFile(w http.ResponseWriter, r *http.Request) {
 	path = sanitizePath(path)
 
 	// Verify it's an editable file
-	if !isEditableFile(path) {
+	if !h.isEditableFile(path) && !h.needsContentSniff(path) {
 		w.WriteHeader(http.StatusForbidden)
 		json.NewEncoder(w).Encode(map[string]string{
 			"status": "error",
@@ -246,6 +429,52 @@ func (h *Handlers) SaveGameserverFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The hash of the content the editor loaded is required, unless force=true overwrites on purpose
+	force := r.FormValue("force") == "true"
+	hash := r.FormValue("hash")
+	if !force && hash == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"status": "error",
+			"error":  "Missing content hash, reload the file and try again",
+		})
+		return
+	}
+
+	// Re-read the file to detect changes made since it was loaded, e.g. in another tab
+	current, currentHash, err := h.docker.ReadFileWithHash(gameserver.ContainerID, path)
+	if err != nil && !errors.Is(err, models.ErrFileNotFound) {
+		log.Error().Err(err).Str("path", path).Msg("Failed to read current file")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"status": "error",
+			"error":  "Failed to read current file",
+		})
+		return
+	}
+	if !force && currentHash != hash {
+		log.Warn().Str("id", id).Str("path", path).Msg("Rejected save of a file that changed since it was loaded")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{
+			"status":  "conflict",
+			"error":   "File changed since it was loaded",
+			"content": string(current),
+			"hash":    currentHash,
+		})
+		return
+	}
+
+	// With preview=true, return the changes for review instead of writing them
+	if r.FormValue("preview") == "true" {
+		diff := unifiedDiff(path, string(current), content)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":  "preview",
+			"changed": diff != "",
+			"diff":    diff,
+		})
+		return
+	}
+
 	// Write file
 	if err := h.docker.WriteFile(gameserver.ContainerID, path, contentBytes); err != nil {
 		log.Error().Err(err).Str("path", path).Msg("Failed to write file")
@@ -257,9 +486,10 @@ func (h *Handlers) SaveGameserverFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Success response
+	// Success response, with the new hash for further edits
 	json.NewEncoder(w).Encode(map[string]string{
 		"status": "saved",
+		"hash":   models.ContentHash(contentBytes),
 	})
 }
 
@@ -369,7 +599,9 @@ func (h *Handlers) DeleteGameserverFile(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := h.docker.DeletePath(gameserver.ContainerID, path); err != nil {
+	err = h.docker.DeletePath(gameserver.ContainerID, path)
+	h.recordAudit(r, models.AuditFileDelete, gameserver.ID, path, err)
+	if err != nil {
 		HandleError(w, InternalError(err, "Failed to delete file/directory"), "delete_file")
 		return
 	}
@@ -410,9 +642,8 @@ func (h *Handlers) RenameGameserverFile(w http.ResponseWriter, r *http.Request)
 func (h *Handlers) UploadGameserverFile(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	// Parse multipart form with configurable limit
-	if err := r.ParseMultipartForm(h.maxUploadSize); err != nil {
-		HandleError(w, BadRequest("Invalid upload format"), "upload_file")
+	if err := h.parseUpload(w, r); err != nil {
+		HandleError(w, err, "upload_file")
 		return
 	}
 
@@ -442,6 +673,20 @@ func (h *Handlers) UploadGameserverFile(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Unpack archives in place of uploading them as-is
+	if r.FormValue("extract") == "true" {
+		if models.ArchiveFormat(header.Filename) == "" {
+			HandleError(w, BadRequest("Only .zip, .tar.gz and .tar archives can be extracted"), "upload_file")
+			return
+		}
+		if _, err := h.docker.ExtractArchive(gameserver.ContainerID, destPath, header.Filename, file, header.Size, h.maxExtractSize); err != nil {
+			HandleError(w, extractError(err), "upload_file")
+			return
+		}
+		h.renderFileBrowser(w, gameserver, destPath)
+		return
+	}
+
 	// Create a tar archive for the file
 	buf := new(bytes.Buffer)
 	tw := tar.NewWriter(buf)
@@ -478,8 +723,49 @@ func (h *Handlers) UploadGameserverFile(w http.ResponseWriter, r *http.Request)
 	h.BrowseGameserverFiles(w, r)
 }
 
+// ExtractGameserverFile unpacks an archive already on the server, into its own directory
+// unless a destination is given
+func (h *Handlers) ExtractGameserverFile(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := h.validateFormFields(r, "path"); err != nil {
+		HandleError(w, err, "extract_file")
+		return
+	}
+
+	path := sanitizePath(r.FormValue("path"))
+	if models.ArchiveFormat(path) == "" {
+		HandleError(w, BadRequest("Only .zip, .tar.gz and .tar archives can be extracted"), "extract_file")
+		return
+	}
+	dest := filepath.Dir(path)
+	if r.FormValue("dest") != "" {
+		dest = sanitizePath(r.FormValue("dest"))
+	}
+
+	gameserver, ok := h.getGameserver(w, id)
+	if !ok {
+		return
+	}
+
+	if _, err := h.docker.ExtractFile(gameserver.ContainerID, path, dest, h.maxExtractSize); err != nil {
+		HandleError(w, extractError(err), "extract_file")
+		return
+	}
+
+	h.renderFileBrowser(w, gameserver, dest)
+}
+
 // Helper functions
 
+// extractError reports archives that can't be extracted as a bad request
+func extractError(err error) error {
+	var opErr *models.OperationError
+	if errors.Is(err, models.ErrInvalidArchive) && errors.As(err, &opErr) {
+		return BadRequest("%s", opErr.Msg)
+	}
+	return InternalError(err, "Failed to extract archive")
+}
+
 func sanitizePath(path string) string {
 	// Clean the path
 	path = filepath.Clean(path)
@@ -526,81 +812,122 @@ func sanitizePath(path string) string {
 	return path
 }
 
-func isEditableFile(filename string) bool {
-	// Get file extension
-	ext := strings.ToLower(filepath.Ext(filename))
+// defaultEditableExtensions is the built-in whitelist of editable file extensions
+var defaultEditableExtensions = map[string]bool{
+	".txt":          true,
+	".json":         true,
+	".xml":          true,
+	".yaml":         true,
+	".yml":          true,
+	".toml":         true,
+	".ini":          true,
+	".conf":         true,
+	".config":       true,
+	".cfg":          true,
+	".properties":   true,
+	".log":          true,
+	".md":           true,
+	".js":           true,
+	".ts":           true,
+	".html":         true,
+	".htm":          true,
+	".css":          true,
+	".scss":         true,
+	".less":         true,
+	".sql":          true,
+	".sh":           true,
+	".bash":         true,
+	".bat":          true,
+	".cmd":          true,
+	".ps1":          true,
+	".py":           true,
+	".go":           true,
+	".java":         true,
+	".c":            true,
+	".cpp":          true,
+	".h":            true,
+	".hpp":          true,
+	".cs":           true,
+	".php":          true,
+	".rb":           true,
+	".pl":           true,
+	".r":            true,
+	".lua":          true,
+	".dockerfile":   true,
+	".dockerignore": true,
+	".gitignore":    true,
+	".env":          true,
+	".example":      true,
+}
 
-	// Whitelist of editable file extensions
-	editableExtensions := map[string]bool{
-		".txt":          true,
-		".json":         true,
-		".xml":          true,
-		".yaml":         true,
-		".yml":          true,
-		".toml":         true,
-		".ini":          true,
-		".conf":         true,
-		".config":       true,
-		".cfg":          true,
-		".properties":   true,
-		".log":          true,
-		".md":           true,
-		".js":           true,
-		".ts":           true,
-		".html":         true,
-		".htm":          true,
-		".css":          true,
-		".scss":         true,
-		".less":         true,
-		".sql":          true,
-		".sh":           true,
-		".bash":         true,
-		".bat":          true,
-		".cmd":          true,
-		".ps1":          true,
-		".py":           true,
-		".go":           true,
-		".java":         true,
-		".c":            true,
-		".cpp":          true,
-		".h":            true,
-		".hpp":          true,
-		".cs":           true,
-		".php":          true,
-		".rb":           true,
-		".pl":           true,
-		".r":            true,
-		".lua":          true,
-		".dockerfile":   true,
-		".dockerignore": true,
-		".gitignore":    true,
-		".env":          true,
-		".example":      true,
-		"":              true, // Files without extension (like README, LICENSE)
-	}
-
-	// Special cases for files without extension that are typically text
-	if ext == "" {
-		baseName := strings.ToLower(filepath.Base(filename))
-		textFiles := map[string]bool{
-			"readme":       true,
-			"license":      true,
-			"changelog":    true,
-			"authors":      true,
-			"contributors": true,
-			"copying":      true,
-			"install":      true,
-			"news":         true,
-			"todo":         true,
-			"makefile":     true,
-			"dockerfile":   true,
-			"vagrantfile":  true,
-		}
+// knownTextFiles are extensionless filenames that are always treated as text
+var knownTextFiles = map[string]bool{
+	"readme":       true,
+	"license":      true,
+	"changelog":    true,
+	"authors":      true,
+	"contributors": true,
+	"copying":      true,
+	"install":      true,
+	"news":         true,
+	"todo":         true,
+	"makefile":     true,
+	"dockerfile":   true,
+	"vagrantfile":  true,
+}
 
-		if textFiles[baseName] {
-			return true
+// buildEditableExtensions layers admin-configured extensions on top of the defaults
+func buildEditableExtensions(extra []string) map[string]bool {
+	extensions := make(map[string]bool, len(defaultEditableExtensions)+len(extra))
+	for ext := range defaultEditableExtensions {
+		extensions[ext] = true
+	}
+	for _, ext := range extra {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
 		}
+		extensions[ext] = true
+	}
+	return extensions
+}
+
+// isEditableFile checks the file against the editable extension whitelist
+func (h *Handlers) isEditableFile(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == "" {
+		return knownTextFiles[strings.ToLower(filepath.Base(filename))]
+	}
+	return h.editableExtensions[ext]
+}
+
+// isCompressedTextFile reports whether a file is gzipped text that can be viewed,
+// e.g. latest.log.gz or a rotated server.log.1.gz
+func (h *Handlers) isCompressedTextFile(filename string) bool {
+	lower := strings.ToLower(filename)
+	if !strings.HasSuffix(lower, ".gz") {
+		return false
 	}
+	inner := strings.TrimSuffix(lower, ".gz")
+	return h.isEditableFile(inner) || filepath.Ext(inner) == "" || strings.Contains(filepath.Base(inner), ".log.")
+}
+
+// needsContentSniff reports whether a file has no extension and must be sniffed to decide if it's text
+func (h *Handlers) needsContentSniff(filename string) bool {
+	return filepath.Ext(filename) == "" && !h.isEditableFile(filename)
+}
 
-	return editableExtensions[ext]
+// isTextContent sniffs content to decide if it's safe to open in the text editor
+func isTextContent(content []byte) bool {
+	sample := content
+	if len(sample) > 8192 {
+		sample = sample[:8192]
+	}
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return false
+	}
+	return utf8.Valid(sample) || strings.HasPrefix(http.DetectContentType(sample), "text/")
 }
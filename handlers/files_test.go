@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"0xkowalskidev/gameservers/models"
+	"0xkowalskidev/gameservers/testutil"
+)
+
+func TestSanitizePathStaysInsideDataDirs(t *testing.T) {
+	tests := map[string]string{
+		"":                              "/data/server",
+		"/":                             "/data/server",
+		"..":                            "/data/server",
+		"../../etc/passwd":              "/data/server",
+		"/data/server/../../etc/passwd": "/data/server/etc/passwd",
+		"/data/backups/../server/../../etc/passwd": "/data/server/etc/passwd",
+		"world/level.dat":                          "/data/server/world/level.dat",
+		"/data/server/world/../server.properties":  "/data/server/server.properties",
+		"/data/backups/backup.tar.gz":              "/data/backups/backup.tar.gz",
+	}
+	for path, want := range tests {
+		if got := sanitizePath(path); got != want {
+			t.Errorf("sanitizePath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+const propertiesPath = "/data/server/server.properties"
+
+// saveFile posts the file editor's save form and decodes the JSON reply
+func saveFile(t *testing.T, h *Handlers, form url.Values) (int, map[string]interface{}) {
+	t.Helper()
+	r := chi.NewRouter()
+	r.Post("/{id}/files/save", h.SaveGameserverFile)
+
+	req := httptest.NewRequest(http.MethodPost, "/gs-1/files/save", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("save returned invalid JSON %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, body
+}
+
+// newFileEditHandlers creates handlers whose gameserver has a server.properties to edit
+func newFileEditHandlers(t *testing.T) (*Handlers, *testutil.MockDockerManager) {
+	t.Helper()
+	h, docker, _ := newTestHandlers(t, nil)
+	h.maxFileEditSize = 1024 * 1024
+	docker.SetFile(propertiesPath, []byte("motd=Original\n"))
+	return h, docker
+}
+
+func TestSaveFileRejectsStaleHash(t *testing.T) {
+	h, docker := newFileEditHandlers(t)
+	loaded := models.ContentHash([]byte("motd=Original\n"))
+
+	// Two tabs load the file; the first one saves
+	status, body := saveFile(t, h, url.Values{"path": {propertiesPath}, "content": {"motd=First\n"}, "hash": {loaded}})
+	if status != http.StatusOK || body["status"] != "saved" {
+		t.Fatalf("first save = %d %v, want saved", status, body)
+	}
+	if body["hash"] != models.ContentHash([]byte("motd=First\n")) {
+		t.Errorf("first save hash = %v, want the hash of the saved content", body["hash"])
+	}
+
+	// The second tab still has the original hash, for a save or a preview
+	for _, preview := range []string{"false", "true"} {
+		status, body = saveFile(t, h, url.Values{"path": {propertiesPath}, "content": {"motd=Second\n"}, "hash": {loaded}, "preview": {preview}})
+		if status != http.StatusConflict {
+			t.Fatalf("stale save (preview=%s) = %d %v, want 409", preview, status, body)
+		}
+		want := map[string]interface{}{
+			"status":  "conflict",
+			"error":   "File changed since it was loaded",
+			"content": "motd=First\n",
+			"hash":    models.ContentHash([]byte("motd=First\n")),
+		}
+		for key, value := range want {
+			if body[key] != value {
+				t.Errorf("conflict %s = %v, want %v", key, body[key], value)
+			}
+		}
+	}
+
+	if content, _ := docker.File(propertiesPath); string(content) != "motd=First\n" {
+		t.Errorf("file = %q, want the first save kept", content)
+	}
+}
+
+func TestSaveFileForceOverwrites(t *testing.T) {
+	h, docker := newFileEditHandlers(t)
+	docker.SetFile(propertiesPath, []byte("motd=Changed elsewhere\n"))
+
+	stale := models.ContentHash([]byte("motd=Original\n"))
+	status, body := saveFile(t, h, url.Values{"path": {propertiesPath}, "content": {"motd=Mine\n"}, "hash": {stale}, "force": {"true"}})
+	if status != http.StatusOK || body["status"] != "saved" {
+		t.Fatalf("forced save = %d %v, want saved", status, body)
+	}
+	if content, _ := docker.File(propertiesPath); string(content) != "motd=Mine\n" {
+		t.Errorf("file = %q, want the forced content", content)
+	}
+
+	// Without a hash only a forced save is accepted
+	status, body = saveFile(t, h, url.Values{"path": {propertiesPath}, "content": {"motd=Blind\n"}})
+	if status != http.StatusBadRequest {
+		t.Errorf("save without a hash = %d %v, want 400", status, body)
+	}
+	status, _ = saveFile(t, h, url.Values{"path": {propertiesPath}, "content": {"motd=Blind\n"}, "force": {"true"}})
+	if status != http.StatusOK {
+		t.Errorf("forced save without a hash = %d, want 200", status)
+	}
+}
+
+// largeFileContent fetches a file's content the way the editor does, with an optional offset
+func largeFileContent(t *testing.T, h *Handlers, path, offset string) map[string]interface{} {
+	t.Helper()
+	target := "/gameservers/gs-1/files/content?path=" + url.QueryEscape(path)
+	if offset != "" {
+		target += "&offset=" + offset
+	}
+	r := chi.NewRouter()
+	r.Get("/gameservers/{id}/files/content", h.GameserverFileContent)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("content returned invalid JSON %q: %v", rec.Body.String(), err)
+	}
+	if body["Supported"] != true || body["ReadOnly"] != true {
+		t.Fatalf("content = %v, want a supported read-only part", body)
+	}
+	return body
+}
+
+// numberedLines returns count lines of the form "line 0001\n", 10 bytes each
+func numberedLines(count int) string {
+	var b strings.Builder
+	for i := 1; i <= count; i++ {
+		fmt.Fprintf(&b, "line %04d\n", i)
+	}
+	return b.String()
+}
+
+func TestLargeFileContentParts(t *testing.T) {
+	h, docker, _ := newTestHandlers(t, nil)
+	h.maxFileEditSize = 100
+	h.fileTailSize = 45
+	content := numberedLines(20) // 200 bytes
+	docker.SetFile("/data/server/latest.log", []byte(content))
+	docker.SetFile("/data/server/world.json", []byte(content))
+
+	tests := []struct {
+		name, path, offset   string
+		wantContent          string
+		wantTail             bool
+		wantOffset, wantNext float64
+	}{
+		// Parts end at a line break: 45 bytes hold four whole lines
+		{"other files open at the start", "/data/server/world.json", "", "line 0001\nline 0002\nline 0003\nline 0004\n", false, 0, 40},
+		{"load more continues forward", "/data/server/world.json", "40", "line 0005\nline 0006\nline 0007\nline 0008\n", false, 40, 80},
+		// The last 45 bytes start mid-line, so the partial line is dropped
+		{"log files open at the end", "/data/server/latest.log", "", "line 0017\nline 0018\nline 0019\nline 0020\n", true, 160, 200},
+		{"any file can jump to the end", "/data/server/world.json", "end", "line 0017\nline 0018\nline 0019\nline 0020\n", true, 160, 200},
+		{"log files can be read from the start", "/data/server/latest.log", "0", "line 0001\nline 0002\nline 0003\nline 0004\n", false, 0, 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := largeFileContent(t, h, tt.path, tt.offset)
+			if body["Content"] != tt.wantContent {
+				t.Errorf("Content = %q, want %q", body["Content"], tt.wantContent)
+			}
+			if body["Tail"] != tt.wantTail || body["Offset"] != tt.wantOffset || body["NextOffset"] != tt.wantNext {
+				t.Errorf("Tail, Offset, NextOffset = %v, %v, %v; want %v, %v, %v",
+					body["Tail"], body["Offset"], body["NextOffset"], tt.wantTail, tt.wantOffset, tt.wantNext)
+			}
+			if body["Size"] != float64(len(content)) || body["Truncated"] != (tt.wantNext < float64(len(content))) {
+				t.Errorf("Size, Truncated = %v, %v", body["Size"], body["Truncated"])
+			}
+		})
+	}
+}
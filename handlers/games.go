@@ -1,11 +1,17 @@
 package handlers
 
 import (
+	"io"
 	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"text/template"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
 
 	"0xkowalskidev/gameservers/models"
 )
@@ -65,6 +71,63 @@ func (h *Handlers) ShowGame(w http.ResponseWriter, r *http.Request) {
 	h.render(w, r, "game-details.html", data)
 }
 
+// BuildGameImage builds a custom image for a game from a submitted Dockerfile and optional
+// context archive, and switches the game to it. The build panel is re-rendered with the output.
+func (h *Handlers) BuildGameImage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := h.parseUpload(w, r); err != nil {
+		HandleError(w, err, "build_game_image")
+		return
+	}
+
+	dockerfile := r.FormValue("dockerfile")
+	if strings.TrimSpace(dockerfile) == "" {
+		HandleError(w, BadRequest("dockerfile is required"), "build_game_image")
+		return
+	}
+
+	// The context archive is optional; without one the build only sees the Dockerfile
+	var contextArchive io.Reader
+	if file, header, err := r.FormFile("context"); err == nil {
+		defer file.Close()
+		if header.Size > h.maxUploadSize {
+			HandleError(w, BadRequest("Build context too large (max %s)", formatFileSize(h.maxUploadSize)), "build_game_image")
+			return
+		}
+		contextArchive = file
+	}
+
+	log.Info().Str("game_id", id).Bool("has_context", contextArchive != nil).Msg("Building custom game image")
+
+	image, output, err := h.service.BuildGameImage(id, dockerfile, contextArchive)
+	if r.Header.Get("HX-Request") != "true" {
+		if err != nil {
+			HandleError(w, InternalError(err, "Failed to build image"), "build_game_image")
+			return
+		}
+		h.jsonSuccess(w, map[string]interface{}{"image": image, "output": output})
+		return
+	}
+
+	game, ok := h.getGame(w, id)
+	if !ok {
+		return
+	}
+	data := map[string]interface{}{
+		"Game":        game,
+		"Dockerfile":  dockerfile,
+		"BuildOutput": output,
+		"BuiltImage":  image,
+	}
+	if err != nil {
+		log.Error().Err(err).Str("game_id", id).Msg("Custom image build failed")
+		data["BuildError"] = err.Error()
+	}
+	if err := h.tmpl.ExecuteTemplate(w, "game-image-build.html", data); err != nil {
+		HandleError(w, InternalError(err, "Failed to render build result"), "build_game_image")
+	}
+}
+
 // EditGame shows the edit game form
 func (h *Handlers) EditGame(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -128,6 +191,7 @@ func (h *Handlers) UpdateGame(w http.ResponseWriter, r *http.Request) {
 	// Preserve ID and created timestamp
 	game.ID = id
 	game.CreatedAt = existingGame.CreatedAt
+	game.Customized = true // Keep hand edits when built-in games are reseeded
 
 	if err := h.service.UpdateGame(game); err != nil {
 		HandleError(w, InternalError(err, "Failed to update game"), "update_game")
@@ -144,6 +208,21 @@ func (h *Handlers) UpdateGame(w http.ResponseWriter, r *http.Request) {
 	h.htmxRedirect(w, "/games/"+id)
 }
 
+// ReseedGames adds new built-in games and refreshes uncustomized ones, e.g. after an upgrade
+func (h *Handlers) ReseedGames(w http.ResponseWriter, r *http.Request) {
+	result, err := h.service.ReseedGames()
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to reseed games"), "reseed_games")
+		return
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		h.htmxRedirect(w, "/games")
+		return
+	}
+	h.jsonSuccess(w, map[string]interface{}{"added": result.Added, "updated": result.Updated, "skipped": result.Skipped})
+}
+
 // DeleteGame deletes a game
 func (h *Handlers) DeleteGame(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -168,6 +247,36 @@ func (h *Handlers) DeleteGame(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// GameVersions returns the selectable versions for a game as JSON
+func (h *Handlers) GameVersions(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	game, ok := h.getGame(w, id)
+	if !ok {
+		return
+	}
+
+	if h.versionService == nil {
+		h.jsonSuccess(w, map[string]interface{}{"supported": false})
+		return
+	}
+
+	versions, err := h.versionService.ListVersions(game)
+	if err != nil {
+		h.jsonError(w, "Failed to fetch versions")
+		return
+	}
+	if versions == nil {
+		h.jsonSuccess(w, map[string]interface{}{"supported": false})
+		return
+	}
+
+	h.jsonSuccess(w, map[string]interface{}{
+		"supported": true,
+		"variable":  versions.Variable,
+		"versions":  versions.Versions,
+	})
+}
+
 // getGame is a helper function to get a game with error handling
 func (h *Handlers) getGame(w http.ResponseWriter, id string) (*models.Game, bool) {
 	game, err := h.service.GetGame(id)
@@ -209,26 +318,117 @@ func (h *Handlers) parseGameForm(r *http.Request) (*models.Game, error) {
 		recMemoryMB = minMemoryMB
 	}
 
+	// CPU guidance is optional, 0 means none
+	minCPUCores, _ := strconv.ParseFloat(r.FormValue("min_cpu_cores"), 64)
+	recCPUCores, _ := strconv.ParseFloat(r.FormValue("rec_cpu_cores"), 64)
+	if minCPUCores < 0 {
+		minCPUCores = 0
+	}
+	if recCPUCores < minCPUCores {
+		recCPUCores = minCPUCores
+	}
+
+	// Parse container tuning
+	sysctls, err := parseSysctls(r.FormValue("sysctls"))
+	if err != nil {
+		return nil, err
+	}
+	capAdd, err := parseCapabilities(r.FormValue("cap_add"))
+	if err != nil {
+		return nil, err
+	}
+
+	shmSizeMB, _ := strconv.Atoi(r.FormValue("shm_size_mb"))
+	if shmSizeMB < 0 {
+		shmSizeMB = 0
+	}
+
+	stopCommand := strings.TrimSpace(r.FormValue("stop_command"))
+	stopGraceSecs, _ := strconv.Atoi(r.FormValue("stop_grace_secs"))
+	if stopGraceSecs < 0 {
+		stopGraceSecs = 0
+	}
+
 	// Parse port mappings
 	portMappings := parsePortMappings(r)
 
 	// Parse config vars
-	configVars := parseConfigVars(r)
+	configVars, err := parseConfigVars(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse config templates
+	configTemplates, err := parseConfigTemplates(r)
+	if err != nil {
+		return nil, err
+	}
 
 	return &models.Game{
-		ID:            id,
-		Name:          name,
-		Slug:          slug,
-		Image:         image,
-		IconPath:      iconPath,
-		GridImagePath: gridImagePath,
-		MinMemoryMB:   minMemoryMB,
-		RecMemoryMB:   recMemoryMB,
-		PortMappings:  portMappings,
-		ConfigVars:    configVars,
+		ID:              id,
+		Name:            name,
+		Slug:            slug,
+		Image:           image,
+		IconPath:        iconPath,
+		GridImagePath:   gridImagePath,
+		MinMemoryMB:     minMemoryMB,
+		RecMemoryMB:     recMemoryMB,
+		MinCPUCores:     minCPUCores,
+		RecCPUCores:     recCPUCores,
+		Sysctls:         sysctls,
+		CapAdd:          capAdd,
+		ShmSizeMB:       shmSizeMB,
+		StopCommand:     stopCommand,
+		StopGraceSecs:   stopGraceSecs,
+		SecretFiles:     r.FormValue("secret_files") == "true",
+		PortMappings:    portMappings,
+		ConfigVars:      configVars,
+		ConfigTemplates: configTemplates,
 	}, nil
 }
 
+var (
+	sysctlKeyPattern  = regexp.MustCompile(`^[a-z0-9_]+(\.[a-z0-9_\-]+)+$`)
+	capabilityPattern = regexp.MustCompile(`^[A-Z_]+$`)
+)
+
+// parseSysctls parses one key=value sysctl per line
+func parseSysctls(input string) (map[string]string, error) {
+	sysctls := make(map[string]string)
+	for _, line := range strings.Split(input, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || value == "" || !sysctlKeyPattern.MatchString(key) {
+			return nil, BadRequest("invalid sysctl %q, expected key=value such as net.core.rmem_max=26214400", line)
+		}
+		sysctls[key] = value
+	}
+	if len(sysctls) == 0 {
+		return nil, nil
+	}
+	return sysctls, nil
+}
+
+// parseCapabilities parses a comma-separated list of Linux capabilities, with or without the CAP_ prefix
+func parseCapabilities(input string) ([]string, error) {
+	var caps []string
+	for _, capability := range strings.Split(input, ",") {
+		capability = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(capability)), "CAP_")
+		if capability == "" {
+			continue
+		}
+		if !capabilityPattern.MatchString(capability) {
+			return nil, BadRequest("invalid capability %q", capability)
+		}
+		caps = append(caps, capability)
+	}
+	return caps, nil
+}
+
 // parsePortMappings parses port mappings from form data
 func parsePortMappings(r *http.Request) []models.PortMapping {
 	var portMappings []models.PortMapping
@@ -242,7 +442,7 @@ func parsePortMappings(r *http.Request) []models.PortMapping {
 		}
 
 		protocolKey := "port_mappings[" + strconv.Itoa(i) + "].protocol"
-		protocol := strings.TrimSpace(r.FormValue(protocolKey))
+		protocol := strings.ToLower(strings.TrimSpace(r.FormValue(protocolKey)))
 		if protocol == "" {
 			protocol = "tcp"
 		}
@@ -263,8 +463,8 @@ func parsePortMappings(r *http.Request) []models.PortMapping {
 	return portMappings
 }
 
-// parseConfigVars parses config vars from form data
-func parseConfigVars(r *http.Request) []models.ConfigVar {
+// parseConfigVars parses and validates config vars from form data
+func parseConfigVars(r *http.Request) ([]models.ConfigVar, error) {
 	var configVars []models.ConfigVar
 
 	// Get all config var indices
@@ -296,7 +496,36 @@ func parseConfigVars(r *http.Request) []models.ConfigVar {
 		descriptionKey := "config_vars[" + strconv.Itoa(i) + "].description"
 		description := strings.TrimSpace(r.FormValue(descriptionKey))
 
-		configVars = append(configVars, models.ConfigVar{
+		appliesLiveKey := "config_vars[" + strconv.Itoa(i) + "].applies_live"
+		appliesLive := r.FormValue(appliesLiveKey) == "true" || r.FormValue(appliesLiveKey) == "on"
+
+		liveCommandKey := "config_vars[" + strconv.Itoa(i) + "].live_command"
+		liveCommand := strings.TrimSpace(r.FormValue(liveCommandKey))
+
+		allowedKey := "config_vars[" + strconv.Itoa(i) + "].allowed"
+		var allowed []string
+		for _, value := range strings.Split(r.FormValue(allowedKey), ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				allowed = append(allowed, value)
+			}
+		}
+
+		minValue, err := parseOptionalFloat(r.FormValue("config_vars[" + strconv.Itoa(i) + "].min"))
+		if err != nil {
+			return nil, BadRequest("%s: min must be a number", name)
+		}
+		maxValue, err := parseOptionalFloat(r.FormValue("config_vars[" + strconv.Itoa(i) + "].max"))
+		if err != nil {
+			return nil, BadRequest("%s: max must be a number", name)
+		}
+		if minValue != nil && maxValue != nil && *minValue > *maxValue {
+			return nil, BadRequest("%s: min is greater than max", name)
+		}
+
+		patternKey := "config_vars[" + strconv.Itoa(i) + "].pattern"
+		pattern := strings.TrimSpace(r.FormValue(patternKey))
+
+		configVar := models.ConfigVar{
 			Name:        name,
 			DisplayName: displayName,
 			Type:        varType,
@@ -304,10 +533,78 @@ func parseConfigVars(r *http.Request) []models.ConfigVar {
 			Required:    required,
 			Default:     defaultValue,
 			Description: description,
+			AppliesLive: appliesLive,
+			LiveCommand: liveCommand,
+			Allowed:     allowed,
+			Min:         minValue,
+			Max:         maxValue,
+			Pattern:     pattern,
+		}
+		if _, err := configVar.CompilePattern(); err != nil {
+			return nil, BadRequest("%s: invalid pattern: %v", name, err)
+		}
+		if message := configVar.CheckValue(defaultValue); message != "" {
+			return nil, BadRequest("%s: default value %s", name, message)
+		}
+		configVars = append(configVars, configVar)
+	}
+
+	return configVars, nil
+}
+
+// parseOptionalFloat parses a float form value, returning nil when it's empty
+func parseOptionalFloat(value string) (*float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &number, nil
+}
+
+// parseConfigTemplates parses and validates config templates from form data
+func parseConfigTemplates(r *http.Request) ([]models.ConfigTemplate, error) {
+	var configTemplates []models.ConfigTemplate
+
+	// Rows can be removed client-side, so collect the indices actually submitted
+	var indices []int
+	for key := range r.Form {
+		if idx, ok := strings.CutPrefix(key, "config_templates["); ok {
+			if idx, ok := strings.CutSuffix(idx, "].path"); ok {
+				if i, err := strconv.Atoi(idx); err == nil {
+					indices = append(indices, i)
+				}
+			}
+		}
+	}
+	sort.Ints(indices)
+
+	for _, i := range indices {
+		pathKey := "config_templates[" + strconv.Itoa(i) + "].path"
+		path := strings.TrimSpace(r.FormValue(pathKey))
+		if path == "" {
+			continue // Row left blank
+		}
+		if !filepath.IsLocal(path) {
+			return nil, BadRequest("config template path %q must be relative to /data/server", path)
+		}
+
+		contentKey := "config_templates[" + strconv.Itoa(i) + "].content"
+		content := r.FormValue(contentKey)
+		if _, err := template.New(path).Parse(content); err != nil {
+			return nil, BadRequest("config template %s is invalid: %v", path, err)
+		}
+
+		configTemplates = append(configTemplates, models.ConfigTemplate{
+			Path:    filepath.Clean(path),
+			Content: content,
 		})
 	}
 
-	return configVars
+	return configTemplates, nil
 }
 
 // parseMods parses mods from form data
@@ -2,12 +2,19 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog/log"
 
 	"0xkowalskidev/gameservers/models"
+	"0xkowalskidev/gameservers/services"
 )
 
 // DashboardData represents the data for the dashboard page
@@ -16,6 +23,32 @@ type DashboardData struct {
 	SystemInfo         *models.SystemInfo
 	CurrentMemoryUsage int
 	RunningServers     int
+	Onboarding         *OnboardingData // Set on first run, when no gameservers exist yet
+}
+
+// OnboardingData holds the first-run checklist shown on an empty dashboard
+type OnboardingData struct {
+	DockerConnected bool
+	DockerError     string
+	GamesAvailable  int
+	AuthEnabled     bool // Whether visitors must sign in; CurrentUser is nil with auth disabled
+}
+
+// buildOnboarding runs the first-run checks so misconfiguration surfaces before the first action fails
+func (h *Handlers) buildOnboarding(r *http.Request) *OnboardingData {
+	onboarding := &OnboardingData{DockerConnected: true, AuthEnabled: CurrentUser(r) != nil}
+
+	if err := h.docker.Ping(); err != nil {
+		log.Warn().Err(err).Msg("Docker connectivity check failed")
+		onboarding.DockerConnected = false
+		onboarding.DockerError = err.Error()
+	}
+
+	if games, err := h.service.ListGames(); err == nil {
+		onboarding.GamesAvailable = len(games)
+	}
+
+	return onboarding
 }
 
 // IndexGameservers lists all gameservers with resource usage statistics
@@ -42,31 +75,60 @@ func (h *Handlers) IndexGameservers(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	h.attachQueryResults(gameservers)
+
 	data := DashboardData{
 		Gameservers:        gameservers,
 		SystemInfo:         systemInfo,
 		CurrentMemoryUsage: currentMemoryUsage,
 		RunningServers:     runningServers,
 	}
+	if len(gameservers) == 0 {
+		data.Onboarding = h.buildOnboarding(r)
+	}
 
 	h.render(w, r, "index.html", data)
 }
 
+// gameserversPerPage is how many gameservers the list page shows at once
+const gameserversPerPage = 20
+
 // GameserversListData represents the data for the gameservers list page
 type GameserversListData struct {
-	Gameservers []*models.Gameserver
+	*models.GameserverPage
 }
 
 // ListGameservers shows the gameservers list page
 func (h *Handlers) ListGameservers(w http.ResponseWriter, r *http.Request) {
-	gameservers, err := h.service.ListGameservers()
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	pageNum, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || pageNum < 1 {
+		pageNum = 1
+	}
+
+	page, err := h.service.ListGameserversPage(query, pageNum, gameserversPerPage)
 	if err != nil {
 		HandleError(w, InternalError(err, "Failed to list gameservers"), "list_gameservers")
 		return
 	}
+	// Past the end, e.g. after deleting the last server on a page: show the last page instead
+	if page.Page > page.TotalPages() {
+		if page, err = h.service.ListGameserversPage(query, page.TotalPages(), gameserversPerPage); err != nil {
+			HandleError(w, InternalError(err, "Failed to list gameservers"), "list_gameservers")
+			return
+		}
+	}
+
+	h.attachQueryResults(page.Gameservers)
 
-	data := GameserversListData{
-		Gameservers: gameservers,
+	data := GameserversListData{GameserverPage: page}
+
+	// Searching and paging swap just the list, keeping the search box focused
+	if r.Header.Get("HX-Target") == "gameserver-list" {
+		if err := h.tmpl.ExecuteTemplate(w, "gameserver-list.html", data); err != nil {
+			HandleError(w, InternalError(err, "Failed to render gameserver list"), "list_gameservers")
+		}
+		return
 	}
 
 	h.render(w, r, "gameservers.html", data)
@@ -80,7 +142,12 @@ func (h *Handlers) ShowGameserver(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.renderGameserver(w, r, gameserver, "overview", "gameserver-details.html", nil)
+	notes, err := h.service.ListServerNotes(id)
+	if err != nil {
+		log.Warn().Err(err).Str("gameserver_id", id).Msg("Failed to list server notes")
+	}
+
+	h.renderGameserver(w, r, gameserver, "overview", "gameserver-details.html", map[string]interface{}{"Notes": notes})
 }
 
 // NewGameserver shows the create gameserver form
@@ -95,7 +162,12 @@ func (h *Handlers) NewGameserver(w http.ResponseWriter, r *http.Request) {
 		HandleError(w, InternalError(err, "Failed to list mods"), "new_gameserver")
 		return
 	}
-	h.render(w, r, "new-gameserver.html", map[string]interface{}{"Games": games, "Mods": mods})
+	// New servers start from each game's defaults
+	environments := make(map[string]services.EnvironmentForm, len(games))
+	for _, game := range games {
+		environments[game.ID] = services.MergeEnvironment(game, nil)
+	}
+	h.render(w, r, "new-gameserver.html", map[string]interface{}{"Games": games, "Mods": mods, "Environments": environments})
 }
 
 // EditGameserver shows the edit gameserver form
@@ -118,14 +190,218 @@ func (h *Handlers) EditGameserver(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	game, err := h.service.GetGame(gameserver.GameID)
+	if err != nil {
+		HandleError(w, err, "edit_gameserver")
+		return
+	}
+
 	data := map[string]interface{}{
-		"Games": games,
-		"Mods":  mods,
+		"Games":        games,
+		"Mods":         mods,
+		"Environments": map[string]services.EnvironmentForm{game.ID: services.MergeEnvironment(game, gameserver.Environment)},
 	}
 
 	h.renderGameserver(w, r, gameserver, "edit", "edit-gameserver.html", data)
 }
 
+// GameDefaultsDiff returns the game config defaults missing from a gameserver's environment as JSON
+func (h *Handlers) GameDefaultsDiff(w http.ResponseWriter, r *http.Request) {
+	missing, err := h.service.MissingGameDefaults(chi.URLParam(r, "id"))
+	if err != nil {
+		h.jsonError(w, "Failed to compare with game defaults")
+		return
+	}
+	h.jsonSuccess(w, map[string]interface{}{"missing": missing})
+}
+
+// ApplyGameDefaults merges missing game config defaults into a gameserver's environment
+func (h *Handlers) ApplyGameDefaults(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	applied, err := h.service.ApplyGameDefaults(id)
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to apply game defaults"), "apply_game_defaults")
+		return
+	}
+	log.Info().Str("gameserver_id", id).Int("applied", len(applied)).Msg("Applied game defaults")
+	h.htmxRedirect(w, "/gameservers/"+id+"/edit")
+}
+
+// RotateSecret replaces a password env var with a new random value, restarting a running
+// server to apply it when restart=true. The new value is returned once and not logged.
+func (h *Handlers) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	name := r.URL.Query().Get("var")
+	if name == "" {
+		HandleError(w, BadRequest("var is required"), "rotate_secret")
+		return
+	}
+
+	secret, err := h.service.RotateSecret(id, name)
+	if err != nil {
+		if errors.Is(err, models.ErrSecretNotRotatable) {
+			HandleError(w, BadRequest("%s", err.Error()), "rotate_secret")
+			return
+		}
+		HandleError(w, InternalError(err, "Failed to rotate secret"), "rotate_secret")
+		return
+	}
+
+	restarted := false
+	if r.URL.Query().Get("restart") == "true" {
+		gameserver, err := h.service.GetGameserver(id)
+		if err == nil && gameserver.Status == models.StatusRunning {
+			if err := h.service.RestartGameserver(id); err != nil {
+				h.lifecycleError(w, err, "Secret rotated but failed to restart gameserver", "rotate_secret")
+				return
+			}
+			restarted = true
+		}
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	h.jsonSuccess(w, map[string]interface{}{
+		"var":       strings.ToUpper(strings.TrimSpace(name)),
+		"value":     secret,
+		"restarted": restarted,
+	})
+}
+
+// MigrateGameserverData copies a stopped gameserver's data to another volume or host path
+// and switches the server over to it
+func (h *Handlers) MigrateGameserverData(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	target := strings.TrimSpace(r.FormValue("target"))
+	if models.IsHostPath(target) {
+		if target == "/" || path.Clean(target) != target {
+			HandleError(w, BadRequest("invalid host path %q", target), "migrate_data")
+			return
+		}
+	} else if !volumeNamePattern.MatchString(target) {
+		HandleError(w, BadRequest("target must be a volume name or an absolute host path"), "migrate_data")
+		return
+	}
+
+	log.Info().Str("gameserver_id", id).Str("target", target).Msg("Migrating gameserver data")
+
+	if err := h.service.MigrateGameserverData(id, target); err != nil {
+		if errors.Is(err, models.ErrServerNotStopped) {
+			HandleError(w, Conflict("Stop the gameserver before migrating its data"), "migrate_data")
+			return
+		}
+		h.lifecycleError(w, err, "Failed to migrate gameserver data", "migrate_data")
+		return
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		h.htmxRedirect(w, "/gameservers/"+id)
+		return
+	}
+	h.jsonSuccess(w, map[string]interface{}{"data_location": target})
+}
+
+// CloneGameserver creates a new gameserver with another's configuration, optionally copying
+// its data. POST /gameservers/{id}/clone with an optional name and copy_data=true
+func (h *Handlers) CloneGameserver(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	name := strings.TrimSpace(r.FormValue("name"))
+	copyData := r.FormValue("copy_data") == "true"
+
+	log.Info().Str("gameserver_id", id).Str("name", name).Bool("copy_data", copyData).Msg("Cloning gameserver")
+
+	clone, err := h.service.CloneGameserver(id, name, copyData)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrServerNotStopped):
+			HandleError(w, Conflict("Stop the gameserver before cloning it with its data"), "clone_gameserver")
+		case errors.Is(err, models.ErrNameTaken):
+			HandleError(w, Conflict("A gameserver with this name already exists"), "clone_gameserver")
+		default:
+			if h.configError(w, err) {
+				return
+			}
+			h.lifecycleError(w, err, "Failed to clone gameserver", "clone_gameserver")
+		}
+		return
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		h.htmxRedirect(w, "/gameservers/"+clone.ID)
+		return
+	}
+	h.jsonSuccess(w, map[string]interface{}{"id": clone.ID, "name": clone.Name})
+}
+
+// RollbackImage pins a gameserver to the image it ran before its last image change and restarts it
+// POST /gameservers/{id}/rollback-image
+func (h *Handlers) RollbackImage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	image, restarted, err := h.service.RollbackImage(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoPreviousImage) {
+			HandleError(w, Conflict("This gameserver has no previous image to roll back to"), "rollback_image")
+			return
+		}
+		h.lifecycleError(w, err, "Failed to roll back gameserver image", "rollback_image")
+		return
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		h.htmxRedirect(w, "/gameservers/"+id)
+		return
+	}
+	h.jsonSuccess(w, map[string]interface{}{"image": image, "restarted": restarted})
+}
+
+// CheckImageUpdate reports whether the registry has a newer version of the image a gameserver follows
+// POST /gameservers/{id}/check-update
+func (h *Handlers) CheckImageUpdate(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	gameserver, ok := h.getGameserver(w, id)
+	if !ok {
+		return
+	}
+
+	check, err := h.service.CheckImageUpdate(id)
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to check for an image update"), "check_image_update")
+		return
+	}
+
+	if r.Header.Get("HX-Request") != "true" {
+		h.jsonSuccess(w, map[string]interface{}{"check": check})
+		return
+	}
+	data := map[string]interface{}{"Gameserver": gameserver, "Check": check}
+	if err := h.tmpl.ExecuteTemplate(w, "image-update.html", data); err != nil {
+		HandleError(w, InternalError(err, "Failed to render image update check"), "check_image_update")
+	}
+}
+
+// UpdateImage pulls the latest version of a gameserver's image, re-pinning a pinned server to it.
+// A running server keeps its current image until it restarts.
+// POST /gameservers/{id}/update-image
+func (h *Handlers) UpdateImage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	gameserver, ok := h.getGameserver(w, id)
+	if !ok {
+		return
+	}
+
+	check, err := h.service.UpdateImage(id)
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to update image"), "update_image")
+		return
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		h.htmxRedirect(w, "/gameservers/"+id)
+		return
+	}
+	h.jsonSuccess(w, map[string]interface{}{"check": check, "restart_required": gameserver.Status == models.StatusRunning})
+}
+
 // CreateGameserver creates a new gameserver
 func (h *Handlers) CreateGameserver(w http.ResponseWriter, r *http.Request) {
 	formData, err := h.parseGameserverForm(r)
@@ -134,22 +410,19 @@ func (h *Handlers) CreateGameserver(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	server := &models.Gameserver{
-		ID:           models.GenerateID(),
-		Name:         formData.Name,
-		GameID:       formData.GameID,
-		MemoryMB:     formData.MemoryMB,
-		CPUCores:     formData.CPUCores,
-		MaxBackups:   formData.MaxBackups,
-		Environment:  formData.Environment,
-		EnabledMods:  formData.EnabledMods,
-		PortMappings: formData.PortMappings,
-	}
+	server := newGameserverFromForm(formData)
 
 	log.Info().Str("gameserver_id", server.ID).Str("name", server.Name).Int("memory_mb", formData.MemoryMB).Float64("cpu_cores", formData.CPUCores).Msg("Creating gameserver")
 
 	if err := h.service.CreateGameserver(server); err != nil {
-		HandleError(w, InternalError(err, "Failed to create gameserver"), "create_gameserver")
+		if errors.Is(err, models.ErrNameTaken) {
+			HandleError(w, Conflict("A gameserver with this name already exists"), "create_gameserver")
+			return
+		}
+		if h.configError(w, err) {
+			return
+		}
+		HandleError(w, resourcesError(err, "Failed to create gameserver"), "create_gameserver")
 		return
 	}
 
@@ -158,6 +431,61 @@ func (h *Handlers) CreateGameserver(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// lifecycleError reports a failed start/stop/restart/delete, answering 409 when the
+// gameserver is busy with another operation
+func (h *Handlers) lifecycleError(w http.ResponseWriter, err error, message, context string) {
+	if errors.Is(err, models.ErrOperationInProgress) {
+		HandleError(w, Conflict("Another operation is already in progress for this gameserver"), context)
+		return
+	}
+	HandleError(w, InternalError(err, message), context)
+}
+
+// resourcesError reports a CPU set or swap limit the host can't apply as a bad request with the reason
+func resourcesError(err error, message string) error {
+	var opErr *models.OperationError
+	if errors.Is(err, models.ErrInvalidResources) && errors.As(err, &opErr) {
+		return BadRequest("%s", opErr.Msg)
+	}
+	return InternalError(err, message)
+}
+
+// newGameserverFromForm builds a not-yet-created gameserver from submitted form data
+func newGameserverFromForm(formData *GameserverFormData) *models.Gameserver {
+	return &models.Gameserver{
+		ID:            models.GenerateID(),
+		Name:          formData.Name,
+		GameID:        formData.GameID,
+		MemoryMB:      formData.MemoryMB,
+		CPUCores:      formData.CPUCores,
+		MaxBackups:    formData.MaxBackups,
+		BackupOnStop:  formData.BackupOnStop,
+		StartPriority: formData.StartPriority,
+		ShmSizeMB:     formData.ShmSizeMB,
+		Timezone:      formData.Timezone,
+		DataVolume:    formData.DataVolume,
+		ImageOverride: formData.ImageOverride,
+		ImageTag:      formData.ImageTag,
+		Environment:   formData.Environment,
+		Tags:          formData.Tags,
+		CommandAllow:  formData.CommandAllow,
+		CommandDeny:   formData.CommandDeny,
+		EnabledMods:   formData.EnabledMods,
+		PortMappings:  formData.PortMappings,
+		DNS:           formData.DNS,
+		ExtraHosts:    formData.ExtraHosts,
+
+		BackupCompression: formData.BackupCompression,
+		BackupExcludes:    formData.BackupExcludes,
+
+		RestartOnCrash:     formData.RestartOnCrash,
+		MaxRestartAttempts: formData.MaxRestartAttempts,
+
+		CPUSetCPUs: formData.CPUSetCPUs,
+		SwapMB:     formData.SwapMB,
+	}
+}
+
 // UpdateGameserver updates an existing gameserver
 func (h *Handlers) UpdateGameserver(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -175,24 +503,62 @@ func (h *Handlers) UpdateGameserver(w http.ResponseWriter, r *http.Request) {
 	}
 
 	server := &models.Gameserver{
-		ID:           id,
-		Name:         formData.Name,
-		GameID:       formData.GameID,
-		MemoryMB:     formData.MemoryMB,
-		CPUCores:     formData.CPUCores,
-		MaxBackups:   formData.MaxBackups,
-		Environment:  formData.Environment,
-		EnabledMods:  formData.EnabledMods,
-		PortMappings: existingServer.PortMappings, // Preserve existing port allocations
+		ID:            id,
+		Name:          formData.Name,
+		GameID:        formData.GameID,
+		MemoryMB:      formData.MemoryMB,
+		CPUCores:      formData.CPUCores,
+		MaxBackups:    formData.MaxBackups,
+		BackupOnStop:  formData.BackupOnStop,
+		StartPriority: formData.StartPriority,
+		ShmSizeMB:     formData.ShmSizeMB,
+		Timezone:      formData.Timezone,
+		ImageOverride: formData.ImageOverride,
+		ImageTag:      formData.ImageTag,
+		Environment:   formData.Environment,
+		Tags:          formData.Tags,
+		CommandAllow:  formData.CommandAllow,
+		CommandDeny:   formData.CommandDeny,
+		EnabledMods:   formData.EnabledMods,
+		PortMappings:  existingServer.PortMappings, // Preserve existing port allocations
+		DNS:           formData.DNS,
+		ExtraHosts:    formData.ExtraHosts,
+
+		BackupCompression: formData.BackupCompression,
+		BackupExcludes:    formData.BackupExcludes,
+
+		RestartOnCrash:     formData.RestartOnCrash,
+		MaxRestartAttempts: formData.MaxRestartAttempts,
+
+		CPUSetCPUs: formData.CPUSetCPUs,
+		SwapMB:     formData.SwapMB,
 	}
 
 	log.Info().Str("gameserver_id", server.ID).Str("name", server.Name).Int("memory_mb", formData.MemoryMB).Float64("cpu_cores", formData.CPUCores).Msg("Updating gameserver")
 
 	if err := h.service.UpdateGameserver(server); err != nil {
-		HandleError(w, InternalError(err, "Failed to update gameserver"), "update_gameserver")
+		if errors.Is(err, models.ErrNameTaken) {
+			HandleError(w, Conflict("A gameserver with this name already exists"), "update_gameserver")
+			return
+		}
+		if h.configError(w, err) {
+			return
+		}
+		HandleError(w, resourcesError(err, "Failed to update gameserver"), "update_gameserver")
 		return
 	}
 
+	// Push changes that don't need a restart straight to the running server
+	if r.FormValue("apply_live") == "true" {
+		applied, err := h.service.ApplyLiveConfig(id, existingServer.Environment)
+		if err != nil {
+			log.Error().Err(err).Str("gameserver_id", id).Msg("Failed to apply config changes live")
+		}
+		if len(applied) > 0 {
+			w.Header().Set("X-Live-Applied", strings.Join(applied, ", "))
+		}
+	}
+
 	h.htmxRedirect(w, "/"+id)
 }
 
@@ -201,10 +567,90 @@ func (h *Handlers) StartGameserver(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	log.Info().Str("gameserver_id", id).Msg("Starting gameserver")
 
-	if err := h.service.StartGameserver(id); err != nil {
-		HandleError(w, InternalError(err, "Failed to start gameserver"), "start_gameserver")
+	err := h.service.StartGameserver(id)
+	h.recordAudit(r, models.AuditStart, id, "", err)
+	if err != nil {
+		h.lifecycleError(w, err, "Failed to start gameserver", "start_gameserver")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// StartAllGameservers starts all stopped gameservers in priority order
+func (h *Handlers) StartAllGameservers(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("Starting all gameservers")
+	h.recordAudit(r, models.AuditStart, "", "all gameservers", nil)
+
+	// Groups wait for each other to become ready, which can take minutes
+	go func() {
+		if err := h.service.StartAllGameservers(); err != nil {
+			log.Error().Err(err).Msg("Failed to start all gameservers")
+		}
+	}()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// BulkGameserverAction starts, stops or restarts the selected gameservers, responding once
+// each has been handled with a per-server result so the UI can show which failed
+func (h *Handlers) BulkGameserverAction(w http.ResponseWriter, r *http.Request) {
+	if err := ParseForm(r); err != nil {
+		HandleError(w, err, "bulk_action")
+		return
+	}
+
+	action := r.FormValue("action")
+	var ids []string
+	seen := make(map[string]bool)
+	for _, id := range r.Form["ids"] {
+		if id = strings.TrimSpace(id); id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		HandleError(w, BadRequest("select at least one gameserver"), "bulk_action")
+		return
+	}
+
+	log.Info().Str("action", action).Strs("gameserver_ids", ids).Msg("Running bulk action")
+	results, err := h.service.BulkGameserverAction(action, ids)
+	if errors.Is(err, models.ErrUnknownBulkAction) {
+		HandleError(w, BadRequest("action must be start, stop or restart"), "bulk_action")
 		return
 	}
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to run bulk action"), "bulk_action")
+		return
+	}
+
+	failed := 0
+	for _, result := range results {
+		if !result.OK {
+			failed++
+		}
+		if result.Skipped == "" {
+			var resultErr error
+			if result.Error != "" {
+				resultErr = errors.New(result.Error)
+			}
+			h.recordAudit(r, action, result.ID, "bulk action", resultErr)
+		}
+	}
+	h.jsonSuccess(w, map[string]interface{}{"action": action, "results": results, "failed": failed})
+}
+
+// StopAllGameservers stops all running gameservers in reverse priority order
+func (h *Handlers) StopAllGameservers(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("Stopping all gameservers")
+	h.recordAudit(r, models.AuditStop, "", "all gameservers", nil)
+
+	go func() {
+		if err := h.service.StopAllGameservers(); err != nil {
+			log.Error().Err(err).Msg("Failed to stop all gameservers")
+		}
+	}()
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -212,8 +658,42 @@ func (h *Handlers) StartGameserver(w http.ResponseWriter, r *http.Request) {
 // StopGameserver stops a gameserver
 func (h *Handlers) StopGameserver(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	if err := h.service.StopGameserver(id); err != nil {
-		HandleError(w, InternalError(err, "Failed to stop gameserver"), "stop_gameserver")
+	err := h.service.StopGameserver(id)
+	h.recordAudit(r, models.AuditStop, id, "", err)
+	if err != nil {
+		h.lifecycleError(w, err, "Failed to stop gameserver", "stop_gameserver")
+		return
+	}
+
+	// Tell the caller whether the game shut down on its own or had to be killed
+	gameserver, ok := h.getGameserver(w, id)
+	if !ok {
+		return
+	}
+	h.jsonSuccess(w, map[string]interface{}{"graceful": !gameserver.LastStopForced})
+}
+
+// PauseGameserver freezes a running gameserver
+func (h *Handlers) PauseGameserver(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	log.Info().Str("gameserver_id", id).Msg("Pausing gameserver")
+	err := h.service.PauseGameserver(id)
+	h.recordAudit(r, models.AuditPause, id, "", err)
+	if err != nil {
+		h.lifecycleError(w, err, "Failed to pause gameserver", "pause_gameserver")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// UnpauseGameserver resumes a paused gameserver
+func (h *Handlers) UnpauseGameserver(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	log.Info().Str("gameserver_id", id).Msg("Unpausing gameserver")
+	err := h.service.UnpauseGameserver(id)
+	h.recordAudit(r, models.AuditUnpause, id, "", err)
+	if err != nil {
+		h.lifecycleError(w, err, "Failed to unpause gameserver", "unpause_gameserver")
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -222,8 +702,10 @@ func (h *Handlers) StopGameserver(w http.ResponseWriter, r *http.Request) {
 // RestartGameserver restarts a gameserver
 func (h *Handlers) RestartGameserver(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	if err := h.service.RestartGameserver(id); err != nil {
-		HandleError(w, InternalError(err, "Failed to restart gameserver"), "restart_gameserver")
+	err := h.service.RestartGameserver(id)
+	h.recordAudit(r, models.AuditRestart, id, "", err)
+	if err != nil {
+		h.lifecycleError(w, err, "Failed to restart gameserver", "restart_gameserver")
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -232,8 +714,15 @@ func (h *Handlers) RestartGameserver(w http.ResponseWriter, r *http.Request) {
 // DestroyGameserver deletes a gameserver
 func (h *Handlers) DestroyGameserver(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	if err := h.service.DeleteGameserver(id); err != nil {
-		HandleError(w, InternalError(err, "Failed to delete gameserver"), "destroy_gameserver")
+	// Keep the name on record, since the gameserver won't be around to look it up
+	name := ""
+	if gameserver, err := h.service.GetGameserver(id); err == nil {
+		name = gameserver.Name
+	}
+	err := h.service.DeleteGameserver(id)
+	h.recordAudit(r, models.AuditDelete, id, name, err)
+	if err != nil {
+		h.lifecycleError(w, err, "Failed to delete gameserver", "destroy_gameserver")
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -252,9 +741,58 @@ func (h *Handlers) StatusPartial(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"status":         gameserver.Status,
 		"isTransitional": gameserver.Status.IsTransitional(),
+		"restartCount":   gameserver.RestartCount,
 	})
 }
 
+// StatusStream pushes gameserver status changes to the dashboard via Server-Sent Events,
+// starting with the current status of every gameserver
+func (h *Handlers) StatusStream(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		HandleError(w, InternalError(nil, "Streaming unsupported"), "status_stream")
+		return
+	}
+
+	updates, unsubscribe := h.statusFeed.Subscribe()
+	defer unsubscribe()
+
+	for _, update := range h.statusFeed.Snapshot() {
+		writeStatusEvent(w, update)
+	}
+	flusher.Flush()
+
+	keepalive := time.NewTicker(statusStreamKeepalive)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			writeStatusEvent(w, update)
+		}
+		flusher.Flush()
+	}
+}
+
+// writeStatusEvent writes a status change as an SSE event
+func writeStatusEvent(w http.ResponseWriter, update models.StatusUpdate) {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: status\ndata: %s\n\n", data)
+}
+
 // QueryGameserver returns JSON query data for client-side polling
 func (h *Handlers) QueryGameserver(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -286,7 +824,7 @@ func (h *Handlers) QueryGameserver(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	serverInfo, err := h.queryService.QueryGameserver(gameserver, game)
+	result, err := h.queryService.QueryStatus(gameserver, game)
 	if err != nil {
 		log.Debug().Err(err).Str("gameserver_id", id).Msg("Failed to query gameserver")
 		h.jsonSuccess(w, map[string]interface{}{
@@ -296,11 +834,6 @@ func (h *Handlers) QueryGameserver(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Return the server info as JSON
-	h.jsonSuccess(w, map[string]interface{}{
-		"online":  serverInfo.Online,
-		"players": serverInfo.Players,
-		"map":     serverInfo.Map,
-		"ping":    serverInfo.Ping,
-	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
 }
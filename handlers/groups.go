@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// ListServerGroups displays all server groups with a form for adding one
+func (h *Handlers) ListServerGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.service.ListServerGroups()
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to list server groups"), "list_server_groups")
+		return
+	}
+	gameservers, err := h.service.ListGameservers()
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to list gameservers"), "list_server_groups")
+		return
+	}
+
+	h.render(w, r, "server-groups.html", map[string]interface{}{
+		"Groups":      groups,
+		"Gameservers": gameservers,
+	})
+}
+
+// ShowServerGroup displays a group's members with group-level actions and its edit form
+func (h *Handlers) ShowServerGroup(w http.ResponseWriter, r *http.Request) {
+	group, err := h.service.GetServerGroup(chi.URLParam(r, "id"))
+	if err != nil {
+		HandleError(w, NotFound("Server group"), "show_server_group")
+		return
+	}
+	gameservers, err := h.service.ListGameservers()
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to list gameservers"), "show_server_group")
+		return
+	}
+
+	h.render(w, r, "server-group.html", map[string]interface{}{
+		"Group":       group,
+		"Gameservers": gameservers,
+	})
+}
+
+// CreateServerGroup creates a server group
+func (h *Handlers) CreateServerGroup(w http.ResponseWriter, r *http.Request) {
+	group, memberIDs, err := h.parseServerGroupForm(r)
+	if err != nil {
+		HandleError(w, err, "create_server_group_form")
+		return
+	}
+
+	log.Info().Str("name", group.Name).Int("members", len(memberIDs)).Msg("Creating server group")
+
+	if err := h.service.CreateServerGroup(group, memberIDs); err != nil {
+		HandleError(w, InternalError(err, "Failed to create server group"), "create_server_group")
+		return
+	}
+	h.htmxRedirect(w, "/groups/"+group.ID)
+}
+
+// UpdateServerGroup updates a server group's details and members
+func (h *Handlers) UpdateServerGroup(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	existing, err := h.service.GetServerGroup(id)
+	if err != nil {
+		HandleError(w, NotFound("Server group"), "update_server_group")
+		return
+	}
+
+	group, memberIDs, err := h.parseServerGroupForm(r)
+	if err != nil {
+		HandleError(w, err, "update_server_group_form")
+		return
+	}
+	group.ID, group.CreatedAt = existing.ID, existing.CreatedAt
+
+	log.Info().Str("group_id", id).Str("name", group.Name).Int("members", len(memberIDs)).Msg("Updating server group")
+
+	if err := h.service.UpdateServerGroup(group, memberIDs); err != nil {
+		HandleError(w, InternalError(err, "Failed to update server group"), "update_server_group")
+		return
+	}
+	h.htmxRedirect(w, "/groups/"+id)
+}
+
+// DeleteServerGroup deletes a server group, leaving its gameservers in place
+func (h *Handlers) DeleteServerGroup(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteServerGroup(chi.URLParam(r, "id")); err != nil {
+		HandleError(w, InternalError(err, "Failed to delete server group"), "delete_server_group")
+		return
+	}
+	h.htmxRedirect(w, "/groups")
+}
+
+// StartServerGroup starts a group's gameservers in priority order
+func (h *Handlers) StartServerGroup(w http.ResponseWriter, r *http.Request) {
+	h.runGroupAction(w, r, "start", h.service.StartServerGroup)
+}
+
+// StopServerGroup stops a group's gameservers in reverse priority order
+func (h *Handlers) StopServerGroup(w http.ResponseWriter, r *http.Request) {
+	h.runGroupAction(w, r, "stop", h.service.StopServerGroup)
+}
+
+// BackupServerGroup backs up each of a group's gameservers
+func (h *Handlers) BackupServerGroup(w http.ResponseWriter, r *http.Request) {
+	h.runGroupAction(w, r, "backup", h.service.BackupServerGroup)
+}
+
+// runGroupAction runs a group-wide action in the background, since priority groups wait
+// for each other and backups queue for slots, which can take minutes
+func (h *Handlers) runGroupAction(w http.ResponseWriter, r *http.Request, action string, run func(id string) error) {
+	id := chi.URLParam(r, "id")
+	group, err := h.service.GetServerGroup(id)
+	if err != nil {
+		HandleError(w, NotFound("Server group"), action+"_server_group")
+		return
+	}
+	if action == models.AuditStart || action == models.AuditStop {
+		h.recordAudit(r, action, "", "group "+group.Name, nil)
+	}
+
+	log.Info().Str("group_id", id).Str("action", action).Msg("Running server group action")
+	go func() {
+		if err := run(id); err != nil {
+			log.Error().Err(err).Str("group_id", id).Str("action", action).Msg("Server group action failed")
+		}
+	}()
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"0xkowalskidev/gameservers/database"
+	"0xkowalskidev/gameservers/models"
+	"0xkowalskidev/gameservers/testutil"
+)
+
+// testHTTPError stands in for the main package's HTTPError
+type testHTTPError struct {
+	status  int
+	message string
+}
+
+func (e testHTTPError) Error() string { return e.message }
+
+// stubErrorHandlers installs the error functions main normally provides, answering the way
+// main's do: plain text for pages and a JSON {"error": message} body for the API
+func stubErrorHandlers(t *testing.T) {
+	t.Helper()
+	handleError, handleAPIError := HandleError, HandleAPIError
+	notFound, badRequest, internalError, conflict, forbidden := NotFound, BadRequest, InternalError, Conflict, Forbidden
+	t.Cleanup(func() {
+		HandleError, HandleAPIError = handleError, handleAPIError
+		NotFound, BadRequest, InternalError, Conflict, Forbidden = notFound, badRequest, internalError, conflict, forbidden
+	})
+
+	asHTTPError := func(err error) testHTTPError {
+		var httpErr testHTTPError
+		if !errors.As(err, &httpErr) {
+			httpErr = testHTTPError{http.StatusInternalServerError, "Internal server error"}
+		}
+		return httpErr
+	}
+	HandleError = func(w http.ResponseWriter, err error, context string) {
+		httpErr := asHTTPError(err)
+		http.Error(w, httpErr.message, httpErr.status)
+	}
+	HandleAPIError = func(w http.ResponseWriter, err error, context string) {
+		httpErr := asHTTPError(err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(httpErr.status)
+		json.NewEncoder(w).Encode(map[string]string{"error": httpErr.message})
+	}
+	NotFound = func(resource string) error { return testHTTPError{http.StatusNotFound, resource + " not found"} }
+	BadRequest = func(format string, args ...interface{}) error {
+		return testHTTPError{http.StatusBadRequest, fmt.Sprintf(format, args...)}
+	}
+	InternalError = func(err error, message string) error { return testHTTPError{http.StatusInternalServerError, message} }
+	Conflict = func(message string) error { return testHTTPError{http.StatusConflict, message} }
+	Forbidden = func(message string) error { return testHTTPError{http.StatusForbidden, message} }
+}
+
+// fakeAudit keeps recorded entries in memory
+type fakeAudit struct {
+	mu      sync.Mutex
+	entries []*models.AuditLog
+}
+
+func (a *fakeAudit) Record(entry *models.AuditLog) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, entry)
+}
+
+func (a *fakeAudit) List(gameserverID string, page int) (*models.AuditPage, error) {
+	return &models.AuditPage{}, nil
+}
+
+// newTestHandlers creates handlers over a fresh database holding one running gameserver,
+// backed by the mock Docker manager
+func newTestHandlers(t *testing.T, queryService QueryServiceInterface) (*Handlers, *testutil.MockDockerManager, *database.DatabaseManager) {
+	t.Helper()
+	stubErrorHandlers(t)
+
+	db, err := database.NewDatabaseManager(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	game := &models.Game{ID: "testgame", Name: "Test Game", Slug: "testgame", Image: "example/testgame:latest",
+		MinMemoryMB: 256, RecMemoryMB: 256}
+	if err := db.CreateGame(game); err != nil {
+		t.Fatal(err)
+	}
+	server := &models.Gameserver{ID: "gs-1", Name: "world", GameID: game.ID, MemoryMB: 1024, CPUCores: 2,
+		ContainerID: "container-1", Status: models.StatusRunning, Tags: []string{"survival"},
+		Environment:  []string{"ADMIN_PASSWORD=hunter2"},
+		PortMappings: []models.PortMapping{{Name: "game", Protocol: "tcp", ContainerPort: 25565, HostPort: 30001}}}
+	if err := db.CreateGameserver(server); err != nil {
+		t.Fatal(err)
+	}
+
+	docker := testutil.NewMockDockerManager()
+	docker.AddContainer("container-1", models.StatusRunning)
+	repo := database.NewGameserverRepository(db, docker, nil, database.RepositoryOptions{})
+	return New(repo, docker, nil, queryService, nil, nil, nil, &fakeAudit{}, Options{}), docker, db
+}
+
+// withUser signs r in as a user with role
+func withUser(r *http.Request, role string) *http.Request {
+	user := &models.User{ID: "user-1", Username: role, Role: role}
+	return r.WithContext(context.WithValue(r.Context(), authContextKey{}, &authInfo{user: user}))
+}
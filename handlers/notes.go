@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// Limits on server note fields
+const (
+	maxNoteLength   = 4000
+	maxAuthorLength = 100
+)
+
+// ListServerNotes returns a gameserver's notes: the notes panel for HTMX requests, JSON otherwise
+func (h *Handlers) ListServerNotes(w http.ResponseWriter, r *http.Request) {
+	h.respondServerNotes(w, r, chi.URLParam(r, "id"))
+}
+
+// CreateServerNote adds a note to a gameserver's log
+func (h *Handlers) CreateServerNote(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	note := strings.TrimSpace(r.FormValue("note"))
+	author := strings.TrimSpace(r.FormValue("author"))
+	if user := CurrentUser(r); author == "" && user != nil {
+		author = user.Username
+	}
+	if note == "" {
+		HandleError(w, BadRequest("note is required"), "create_server_note")
+		return
+	}
+	if utf8.RuneCountInString(note) > maxNoteLength {
+		HandleError(w, BadRequest("note must be at most %d characters", maxNoteLength), "create_server_note")
+		return
+	}
+	if utf8.RuneCountInString(author) > maxAuthorLength {
+		HandleError(w, BadRequest("author must be at most %d characters", maxAuthorLength), "create_server_note")
+		return
+	}
+
+	if _, err := h.service.AddServerNote(id, note, author); err != nil {
+		HandleError(w, InternalError(err, "Failed to add note"), "create_server_note")
+		return
+	}
+	h.respondServerNotes(w, r, id)
+}
+
+// DeleteServerNote removes a note from a gameserver's log
+func (h *Handlers) DeleteServerNote(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	noteID := chi.URLParam(r, "noteId")
+
+	log.Info().Str("gameserver_id", id).Str("note_id", noteID).Msg("Deleting server note")
+
+	if err := h.service.DeleteServerNote(id, noteID); err != nil {
+		HandleError(w, NotFound("Note"), "delete_server_note")
+		return
+	}
+	h.respondServerNotes(w, r, id)
+}
+
+// respondServerNotes writes a gameserver's current notes
+func (h *Handlers) respondServerNotes(w http.ResponseWriter, r *http.Request, id string) {
+	gameserver, ok := h.getGameserver(w, id)
+	if !ok {
+		return
+	}
+	notes, err := h.service.ListServerNotes(id)
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to list notes"), "list_server_notes")
+		return
+	}
+
+	if r.Header.Get("HX-Request") != "true" {
+		h.jsonSuccess(w, map[string]interface{}{"notes": notes})
+		return
+	}
+	data := map[string]interface{}{"Gameserver": gameserver, "Notes": notes}
+	if err := h.tmpl.ExecuteTemplate(w, "server-notes.html", data); err != nil {
+		HandleError(w, InternalError(err, "Failed to render notes"), "list_server_notes")
+	}
+}
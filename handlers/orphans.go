@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// requireOrphanAdmin rejects signed-in users who aren't admins. With authentication disabled
+// everyone may manage orphans, like the other admin actions.
+func (h *Handlers) requireOrphanAdmin(w http.ResponseWriter, r *http.Request) bool {
+	return h.requireAdminRole(w, r, "Only admins can manage orphaned containers and volumes")
+}
+
+// ListOrphans shows the gameserver containers and volumes Docker has but the database doesn't
+// account for (HTML page, or JSON for API clients)
+func (h *Handlers) ListOrphans(w http.ResponseWriter, r *http.Request) {
+	if !h.requireOrphanAdmin(w, r) {
+		return
+	}
+	state, err := h.service.ReconcileDockerState()
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to compare Docker with the database"), "list_orphans")
+		return
+	}
+
+	if r.Header.Get("HX-Request") != "true" && r.Header.Get("Accept") == "application/json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(state)
+		return
+	}
+	h.render(w, r, "orphans.html", map[string]interface{}{"State": state})
+}
+
+// AdoptOrphanContainer recreates the gameserver record of an orphaned container
+func (h *Handlers) AdoptOrphanContainer(w http.ResponseWriter, r *http.Request) {
+	if !h.requireOrphanAdmin(w, r) {
+		return
+	}
+	containerID := chi.URLParam(r, "containerId")
+
+	server, err := h.service.AdoptOrphanContainer(containerID)
+	gameserverID := ""
+	if server != nil {
+		gameserverID = server.ID
+	}
+	h.recordAudit(r, models.AuditAdopt, gameserverID, "container "+shortContainerID(containerID), err)
+	if err != nil {
+		if h.configError(w, err) {
+			return
+		}
+		HandleError(w, orphanError(err, "Failed to adopt container"), "adopt_orphan_container")
+		return
+	}
+	h.htmxRedirect(w, "/"+server.ID)
+}
+
+// RemoveOrphanContainer removes an orphaned container, and its data volume with ?volume=true
+func (h *Handlers) RemoveOrphanContainer(w http.ResponseWriter, r *http.Request) {
+	if !h.requireOrphanAdmin(w, r) {
+		return
+	}
+	containerID := chi.URLParam(r, "containerId")
+	removeVolume := r.URL.Query().Get("volume") == "true"
+
+	err := h.service.RemoveOrphanContainer(containerID, removeVolume)
+	detail := "container " + shortContainerID(containerID)
+	if removeVolume {
+		detail += " and its volume"
+	}
+	h.recordAudit(r, models.AuditOrphanRemove, "", detail, err)
+	if err != nil {
+		HandleError(w, orphanError(err, "Failed to remove container"), "remove_orphan_container")
+		return
+	}
+	h.htmxRedirect(w, "/admin/orphans")
+}
+
+// RemoveOrphanVolume deletes a managed volume no gameserver or container uses
+func (h *Handlers) RemoveOrphanVolume(w http.ResponseWriter, r *http.Request) {
+	if !h.requireOrphanAdmin(w, r) {
+		return
+	}
+	name := chi.URLParam(r, "name")
+
+	err := h.service.RemoveOrphanVolume(name)
+	h.recordAudit(r, models.AuditOrphanRemove, "", "volume "+name, err)
+	if err != nil {
+		HandleError(w, orphanError(err, "Failed to remove volume"), "remove_orphan_volume")
+		return
+	}
+	h.htmxRedirect(w, "/admin/orphans")
+}
+
+// orphanError reports a failed orphan action: 404 once it's no longer orphaned, 409 when an
+// adopted name is taken and 400 when the container or its settings can't be adopted
+func orphanError(err error, message string) error {
+	var opErr *models.OperationError
+	switch {
+	case errors.Is(err, models.ErrOrphanNotFound):
+		return NotFound("Orphaned container or volume")
+	case errors.Is(err, models.ErrNameTaken):
+		return Conflict("A gameserver with this container's name already exists")
+	case errors.Is(err, models.ErrCannotAdopt):
+		if errors.As(err, &opErr) {
+			return BadRequest("%s", opErr.Msg)
+		}
+	}
+	return resourcesError(err, message)
+}
+
+// shortContainerID abbreviates a container ID the way the Docker CLI shows it
+func shortContainerID(id string) string {
+	return id[:min(len(id), 12)]
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// ListPlayerLists returns a gameserver's whitelist and ban lists: the players panel for HTMX
+// requests, JSON otherwise
+func (h *Handlers) ListPlayerLists(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	lists, err := h.service.ListPlayerLists(id)
+	if err != nil {
+		HandleError(w, NotFound("Gameserver"), "list_player_lists")
+		return
+	}
+	h.respondPlayerLists(w, r, id, lists)
+}
+
+// ModifyPlayerList adds a player to or removes one from a whitelist or ban list via the console
+// POST /gameservers/{id}/players/{list} with form fields player and action (add or remove)
+func (h *Handlers) ModifyPlayerList(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	listName := chi.URLParam(r, "list")
+	if err := h.validateFormFields(r, "player", "action"); err != nil {
+		HandleError(w, err, "modify_player_list")
+		return
+	}
+	player := strings.TrimSpace(r.FormValue("player"))
+	action := r.FormValue("action")
+	if action != "add" && action != "remove" {
+		HandleError(w, BadRequest("action must be add or remove"), "modify_player_list")
+		return
+	}
+
+	lists, err := h.service.ModifyPlayerList(id, listName, player, action == "add")
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUnknownPlayerList):
+			HandleError(w, NotFound("Player list"), "modify_player_list")
+		case errors.Is(err, models.ErrInvalidPlayer):
+			HandleError(w, BadRequest("%s", err.Error()), "modify_player_list")
+		case errors.Is(err, models.ErrCommandBlocked):
+			HandleError(w, Forbidden(err.Error()), "modify_player_list")
+		default:
+			HandleError(w, InternalError(err, "Failed to update player list"), "modify_player_list")
+		}
+		return
+	}
+	h.respondPlayerLists(w, r, id, lists)
+}
+
+// respondPlayerLists writes a gameserver's player lists
+func (h *Handlers) respondPlayerLists(w http.ResponseWriter, r *http.Request, id string, lists []*models.PlayerListEntries) {
+	if r.Header.Get("HX-Request") != "true" {
+		h.jsonSuccess(w, map[string]interface{}{"lists": lists})
+		return
+	}
+
+	gameserver, ok := h.getGameserver(w, id)
+	if !ok {
+		return
+	}
+	data := map[string]interface{}{"Gameserver": gameserver, "Lists": lists}
+	if err := h.tmpl.ExecuteTemplate(w, "server-players.html", data); err != nil {
+		HandleError(w, InternalError(err, "Failed to render player lists"), "list_player_lists")
+	}
+}
@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
 )
 
 // ListGameserverTasks displays all scheduled tasks for a gameserver
@@ -47,8 +52,10 @@ func (h *Handlers) CreateGameserverTask(w http.ResponseWriter, r *http.Request)
 
 	log.Info().Str("gameserver_id", id).Str("task_name", task.Name).Str("type", string(task.Type)).Str("cron", task.CronSchedule).Msg("Creating scheduled task")
 
-	if err := h.service.CreateScheduledTask(task); err != nil {
-		HandleError(w, InternalError(err, "Failed to create scheduled task"), "create_task")
+	err = h.service.CreateScheduledTask(task)
+	h.recordAudit(r, models.AuditTaskModify, id, "created task "+task.Name, err)
+	if err != nil {
+		HandleError(w, scheduleError(err, "Failed to create scheduled task"), "create_task")
 		return
 	}
 	h.htmxRedirect(w, fmt.Sprintf("/%s/tasks", id))
@@ -92,22 +99,193 @@ func (h *Handlers) UpdateGameserverTask(w http.ResponseWriter, r *http.Request)
 
 	log.Info().Str("task_id", taskID).Str("task_name", task.Name).Msg("Updating scheduled task")
 
-	if err := h.service.UpdateScheduledTask(task); err != nil {
-		HandleError(w, InternalError(err, "Failed to update scheduled task"), "update_task")
+	err = h.service.UpdateScheduledTask(task)
+	h.recordAudit(r, models.AuditTaskModify, id, "updated task "+task.Name, err)
+	if err != nil {
+		HandleError(w, scheduleError(err, "Failed to update scheduled task"), "update_task")
 		return
 	}
 
 	h.htmxRedirect(w, fmt.Sprintf("/%s/tasks", id))
 }
 
+// PreviewTaskSchedule returns the next run times for a cron expression as JSON
+func (h *Handlers) PreviewTaskSchedule(w http.ResponseWriter, r *http.Request) {
+	cronSchedule := strings.TrimSpace(r.URL.Query().Get("cron"))
+	if cronSchedule == "" {
+		h.jsonSuccess(w, map[string]interface{}{"valid": false})
+		return
+	}
+
+	runs, err := h.service.PreviewCronSchedule(cronSchedule, 5)
+	if err != nil {
+		var opErr *models.OperationError
+		message := err.Error()
+		if errors.As(err, &opErr) {
+			message = opErr.Msg
+		}
+		h.jsonSuccess(w, map[string]interface{}{"valid": false, "error": message})
+		return
+	}
+
+	h.jsonSuccess(w, map[string]interface{}{"valid": true, "runs": runs})
+}
+
+// scheduleError reports an invalid cron schedule as a bad request with the parser's reason
+func scheduleError(err error, message string) error {
+	var opErr *models.OperationError
+	if errors.Is(err, models.ErrInvalidCronSchedule) && errors.As(err, &opErr) {
+		return BadRequest("%s", opErr.Msg)
+	}
+	return InternalError(err, message)
+}
+
 // DeleteGameserverTask deletes a scheduled task
 func (h *Handlers) DeleteGameserverTask(w http.ResponseWriter, r *http.Request) {
 	taskID := chi.URLParam(r, "taskId")
+	detail := "deleted task " + taskID
+	if task, err := h.service.GetScheduledTask(taskID); err == nil {
+		detail = "deleted task " + task.Name
+	}
 
-	if err := h.service.DeleteScheduledTask(taskID); err != nil {
+	err := h.service.DeleteScheduledTask(taskID)
+	h.recordAudit(r, models.AuditTaskModify, chi.URLParam(r, "id"), detail, err)
+	if err != nil {
 		HandleError(w, err, "delete_task")
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// taskHistoryRuns is how many recent runs the task history shows
+const taskHistoryRuns = 20
+
+// GameserverTaskHistory shows a scheduled task's most recent runs with their durations and errors
+func (h *Handlers) GameserverTaskHistory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	taskID := chi.URLParam(r, "taskId")
+
+	task, executions, err := h.service.ListTaskExecutions(id, taskID, taskHistoryRuns)
+	if err != nil {
+		HandleError(w, NotFound("Task"), "task_history")
+		return
+	}
+
+	if r.Header.Get("HX-Request") != "true" {
+		h.jsonSuccess(w, map[string]interface{}{"task": task, "executions": executions})
+		return
+	}
+	data := map[string]interface{}{"Task": task, "Executions": executions}
+	if err := h.tmpl.ExecuteTemplate(w, "task-history.html", data); err != nil {
+		HandleError(w, InternalError(err, "Failed to render task history"), "task_history")
+	}
+}
+
+// ListTaskTemplates displays all task templates with a form for adding one
+func (h *Handlers) ListTaskTemplates(w http.ResponseWriter, r *http.Request) {
+	h.renderTaskTemplates(w, r, nil)
+}
+
+// EditTaskTemplate displays the task templates page with one template loaded into the form
+func (h *Handlers) EditTaskTemplate(w http.ResponseWriter, r *http.Request) {
+	template, err := h.service.GetTaskTemplate(chi.URLParam(r, "id"))
+	if err != nil {
+		HandleError(w, NotFound("Task template"), "edit_task_template")
+		return
+	}
+	h.renderTaskTemplates(w, r, template)
+}
+
+// renderTaskTemplates renders the task templates page, editing template if set
+func (h *Handlers) renderTaskTemplates(w http.ResponseWriter, r *http.Request, template *models.TaskTemplate) {
+	templates, err := h.service.ListTaskTemplates()
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to list task templates"), "list_task_templates")
+		return
+	}
+
+	// Offer the tags already in use as suggestions
+	gameservers, err := h.service.ListGameservers()
+	if err != nil {
+		HandleError(w, InternalError(err, "Failed to list gameservers"), "list_task_templates")
+		return
+	}
+	var tags []string
+	seen := make(map[string]bool)
+	for _, gameserver := range gameservers {
+		for _, tag := range gameserver.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+
+	h.render(w, r, "task-templates.html", map[string]interface{}{
+		"Templates": templates,
+		"Template":  template,
+		"Tags":      tags,
+	})
+}
+
+// CreateTaskTemplate creates a task template and applies it to matching gameservers
+func (h *Handlers) CreateTaskTemplate(w http.ResponseWriter, r *http.Request) {
+	template, err := h.parseTaskTemplateForm(r)
+	if err != nil {
+		HandleError(w, err, "create_task_template_form")
+		return
+	}
+
+	log.Info().Str("name", template.Name).Str("tag", template.Tag).Str("type", string(template.Type)).Str("cron", template.CronSchedule).Msg("Creating task template")
+
+	if err := h.service.CreateTaskTemplate(template); err != nil {
+		HandleError(w, scheduleError(err, "Failed to create task template"), "create_task_template")
+		return
+	}
+	h.htmxRedirect(w, "/task-templates")
+}
+
+// UpdateTaskTemplate updates a task template and re-applies it
+func (h *Handlers) UpdateTaskTemplate(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	existing, err := h.service.GetTaskTemplate(id)
+	if err != nil {
+		HandleError(w, NotFound("Task template"), "update_task_template")
+		return
+	}
+
+	template, err := h.parseTaskTemplateForm(r)
+	if err != nil {
+		HandleError(w, err, "update_task_template_form")
+		return
+	}
+	template.ID, template.CreatedAt = existing.ID, existing.CreatedAt
+
+	log.Info().Str("template_id", id).Str("name", template.Name).Str("tag", template.Tag).Msg("Updating task template")
+
+	if err := h.service.UpdateTaskTemplate(template); err != nil {
+		HandleError(w, scheduleError(err, "Failed to update task template"), "update_task_template")
+		return
+	}
+	h.htmxRedirect(w, "/task-templates")
+}
+
+// ApplyTaskTemplate re-syncs a template's tasks with the gameservers carrying its tag
+func (h *Handlers) ApplyTaskTemplate(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.ApplyTaskTemplate(chi.URLParam(r, "id")); err != nil {
+		HandleError(w, InternalError(err, "Failed to apply task template"), "apply_task_template")
+		return
+	}
+	h.htmxRedirect(w, "/task-templates")
+}
+
+// DeleteTaskTemplate deletes a task template and the tasks it manages
+func (h *Handlers) DeleteTaskTemplate(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteTaskTemplate(chi.URLParam(r, "id")); err != nil {
+		HandleError(w, InternalError(err, "Failed to delete task template"), "delete_task_template")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+func TestScheduleErrorMapsInvalidSchedulesToBadRequest(t *testing.T) {
+	stubErrorHandlers(t)
+
+	invalid := &models.DatabaseError{Op: "parse_cron", Msg: `cron schedule "0 0 30 2 *" never runs`, Err: models.ErrInvalidCronSchedule}
+	var httpErr testHTTPError
+	if err := scheduleError(invalid, "Failed to create scheduled task"); !errors.As(err, &httpErr) || httpErr.status != http.StatusBadRequest {
+		t.Errorf("invalid schedule gave %v, want a 400", err)
+	}
+
+	failed := &models.DatabaseError{Op: "create_scheduled_task", Msg: "failed to create scheduled task", Err: errors.New("disk full")}
+	if err := scheduleError(failed, "Failed to create scheduled task"); !errors.As(err, &httpErr) || httpErr.status != http.StatusInternalServerError {
+		t.Errorf("database failure gave %v, want a 500", err)
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// The interactive console exchanges small JSON text messages over gorilla/websocket. No
+// extensions or subprotocols are negotiated.
+
+// websocketMaxMessage caps incoming messages; console commands are single lines
+const websocketMaxMessage = 64 * 1024
+
+// websocketWriteTimeout bounds each frame write so a stalled client can't block the writer
+const websocketWriteTimeout = 10 * time.Second
+
+// WebSocket close codes
+const (
+	wsCloseGoingAway  = websocket.CloseGoingAway
+	wsCloseInternal   = websocket.CloseInternalServerErr
+	wsCloseNotRunning = 4000 // Application code: the gameserver isn't running
+)
+
+// websocketUpgrader performs the handshake. Its default origin check rejects cross-origin
+// requests so other sites can't drive the console; failures are answered like any other error.
+var websocketUpgrader = websocket.Upgrader{
+	Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+		var err error
+		switch status {
+		case http.StatusForbidden:
+			err = Forbidden("cross-origin WebSocket connections are not allowed")
+		case http.StatusInternalServerError:
+			err = InternalError(reason, "WebSocket upgrade unsupported")
+		default:
+			err = BadRequest("%s", reason.Error())
+		}
+		HandleError(w, err, "websocket_upgrade")
+	},
+}
+
+// wsConn is a server-side WebSocket connection. Writes are safe for concurrent use;
+// reads must come from a single goroutine.
+type wsConn struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+}
+
+// upgradeWebSocket performs the WebSocket handshake and takes over the connection. On
+// failure the error response has already been written.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	conn, err := websocketUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Oversized messages are answered with close code 1009 and fail the read
+	conn.SetReadLimit(websocketMaxMessage)
+	return &wsConn{conn: conn}, nil
+}
+
+// readMessage returns the next text or binary message. Pings are answered and the client's
+// close frame is acknowledged along the way; the latter surfaces as a *websocket.CloseError.
+func (c *wsConn) readMessage() (messageType int, payload []byte, err error) {
+	return c.conn.ReadMessage()
+}
+
+// writeText sends a text message
+func (c *wsConn) writeText(data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// ping sends a ping so proxies keep an idle connection open
+func (c *wsConn) ping() error {
+	return c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(websocketWriteTimeout))
+}
+
+// close sends a close frame with a code and reason, then closes the connection
+func (c *wsConn) close(code int, reason string) {
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(websocketWriteTimeout))
+	c.conn.Close()
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// websocketServer upgrades every request and hands the connection to serve
+func websocketServer(t *testing.T, serve func(ws *wsConn)) string {
+	t.Helper()
+	stubErrorHandlers(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgradeWebSocket(w, r)
+		if err != nil {
+			return
+		}
+		serve(ws)
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func dialWebSocket(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	client, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return client
+}
+
+func TestWebSocketEchoesTextMessages(t *testing.T) {
+	url := websocketServer(t, func(ws *wsConn) {
+		for {
+			messageType, payload, err := ws.readMessage()
+			if err != nil {
+				return
+			}
+			if messageType == websocket.TextMessage {
+				ws.writeText(payload)
+			}
+		}
+	})
+	client := dialWebSocket(t, url)
+
+	// A large message arrives in several frames and must come back whole
+	for _, message := range []string{`{"type":"command","data":"list"}`, strings.Repeat("x", 40000)} {
+		if err := client.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		messageType, payload, err := client.ReadMessage()
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if messageType != websocket.TextMessage || string(payload) != message {
+			t.Errorf("echo = (%d, %d bytes), want a text message of %d bytes", messageType, len(payload), len(message))
+		}
+	}
+}
+
+func TestWebSocketCloseSendsCodeAndReason(t *testing.T) {
+	url := websocketServer(t, func(ws *wsConn) {
+		ws.close(wsCloseNotRunning, "gameserver is not running")
+	})
+	client := dialWebSocket(t, url)
+
+	_, _, err := client.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		t.Fatalf("read error = %v, want a close frame", err)
+	}
+	if closeErr.Code != wsCloseNotRunning || closeErr.Text != "gameserver is not running" {
+		t.Errorf("close = (%d, %q), want (%d, %q)", closeErr.Code, closeErr.Text, wsCloseNotRunning, "gameserver is not running")
+	}
+}
+
+func TestWebSocketRejectsOversizedMessages(t *testing.T) {
+	readErr := make(chan error, 1)
+	url := websocketServer(t, func(ws *wsConn) {
+		_, _, err := ws.readMessage()
+		readErr <- err
+	})
+	client := dialWebSocket(t, url)
+
+	client.WriteMessage(websocket.TextMessage, make([]byte, websocketMaxMessage+1))
+	_, _, err := client.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		t.Errorf("client read error = %v, want close code %d", err, websocket.CloseMessageTooBig)
+	}
+	if err := <-readErr; !errors.Is(err, websocket.ErrReadLimit) {
+		t.Errorf("server read error = %v, want the read limit error", err)
+	}
+}
+
+func TestWebSocketUpgradeRejectsBadRequests(t *testing.T) {
+	url := websocketServer(t, func(ws *wsConn) { ws.close(websocket.CloseNormalClosure, "") })
+
+	// Cross-origin pages must not be able to drive the console
+	header := http.Header{"Origin": {"http://evil.example"}}
+	_, resp, err := websocket.DefaultDialer.Dial(url, header)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("cross-origin dial = %v, want 403", err)
+	}
+
+	// Plain HTTP requests are not upgraded
+	resp, err = http.Get("http" + strings.TrimPrefix(url, "ws"))
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("plain GET status = %d, want 400", resp.StatusCode)
+	}
+}
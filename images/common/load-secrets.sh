@@ -0,0 +1,13 @@
+#!/bin/bash
+# Shared by every image: sourced by start.sh and send-command.sh to load secrets the panel
+# passes as FILE__NAME=/run/secrets/NAME into NAME, so their values stay out of docker inspect
+
+for secret_var in $(compgen -e | grep '^FILE__'); do
+    secret_file="${!secret_var}"
+    if [ -r "$secret_file" ]; then
+        export "${secret_var#FILE__}=$(cat "$secret_file")"
+    else
+        echo "Warning: can't read ${secret_var#FILE__} from $secret_file" >&2
+    fi
+done
+unset secret_var secret_file
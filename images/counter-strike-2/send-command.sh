@@ -1,5 +1,8 @@
 #!/bin/bash
 
+# Load secrets passed as files (see images/common/load-secrets.sh)
+. /data/scripts/load-secrets.sh
+
 RCON_HOST="localhost"
 RCON_PASSWORD="${RCON_PASSWORD}"
 COMMAND="$1"
@@ -1,4 +1,8 @@
 #!/bin/bash
+
+# Load secrets passed as files (see images/common/load-secrets.sh)
+. /data/scripts/load-secrets.sh
+
 set -e
 set -m
 
@@ -0,0 +1,48 @@
+package minecraft
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// TestServerTypesBoot builds the image and boots a server of each SERVER_TYPE, waiting for the
+// "Done (...)! For help" line every server software prints once the world is loaded
+func TestServerTypesBoot(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping image integration test in short mode")
+	}
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	for _, serverType := range []string{"vanilla", "paper", "fabric", "forge"} {
+		t.Run(serverType, func(t *testing.T) {
+			ctx := context.Background()
+			container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+				ContainerRequest: testcontainers.ContainerRequest{
+					FromDockerfile: testcontainers.FromDockerfile{
+						// Images build from images/ so they can copy common/load-secrets.sh
+						Context:    "..",
+						Dockerfile: "minecraft/Dockerfile",
+						KeepImage:  true,
+					},
+					Env: map[string]string{
+						"EULA":              "true",
+						"SERVER_TYPE":       serverType,
+						"MINECRAFT_VERSION": "1.20.4",
+						"MEMORY_MB":         "2048",
+					},
+					ExposedPorts: []string{"25565/tcp"},
+					WaitingFor:   wait.ForLog("For help, type").WithStartupTimeout(10 * time.Minute),
+				},
+				Started: true,
+			})
+			testcontainers.CleanupContainer(t, container)
+			if err != nil {
+				t.Fatalf("%s server did not boot: %v", serverType, err)
+			}
+		})
+	}
+}
@@ -1,5 +1,8 @@
 #!/bin/bash
 
+# Load secrets passed as files (see images/common/load-secrets.sh)
+. /data/scripts/load-secrets.sh
+
 PIPE_PATH="/tmp/command-fifo"
 
 # Send command to the pipe
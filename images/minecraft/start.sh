@@ -1,17 +1,26 @@
 #!/bin/bash
 
+# Load secrets passed as files (see images/common/load-secrets.sh)
+. /data/scripts/load-secrets.sh
+
 # Enable job control for signal handling
 set -m
 
 # Change to server directory
 cd /data/server
 
-# Resolved version (set by download_server)
+# Resolved version (set by resolve_version)
 RESOLVED_VERSION=""
 
+# Arguments that launch the server (set by the download_* functions)
+SERVER_ARGS=(-jar server.jar)
+
 # Minimum supported version (first version with server JAR in Mojang's manifest)
 MIN_VERSION="1.2.5"
 
+# Server software: vanilla, paper, fabric or forge
+SERVER_TYPE=$(echo "${SERVER_TYPE:-vanilla}" | tr '[:upper:]' '[:lower:]')
+
 # Function to get correct Java path for Minecraft version
 get_java_for_version() {
     local mc_version="$1"
@@ -36,53 +45,60 @@ get_java_for_version() {
     fi
 }
 
-# Function to download Minecraft server JAR
-download_server() {
-    local version="${MINECRAFT_VERSION:-latest}"
-    local jar_name="minecraft_server_${version}.jar"
-
-    echo "[$(date)] Checking Minecraft server version: $version" >&2
-
-    # If we already have this version, use it
-    if [ -f "$jar_name" ]; then
-        echo "[$(date)] Using existing server JAR: $jar_name" >&2
-        ln -sf "$jar_name" server.jar
-        RESOLVED_VERSION="$version"
+# Fetch Mojang's version manifest into MANIFEST (only once)
+MANIFEST=""
+fetch_manifest() {
+    if [ -n "$MANIFEST" ]; then
         return 0
     fi
-
-    # Fetch version manifest
     echo "[$(date)] Fetching Minecraft version manifest..." >&2
-    if ! manifest=$(curl -s https://launchermeta.mojang.com/mc/game/version_manifest_v2.json); then
+    if ! MANIFEST=$(curl -sf https://launchermeta.mojang.com/mc/game/version_manifest_v2.json); then
         echo "[$(date)] ERROR: Failed to fetch version manifest!" >&2
         exit 1
     fi
+}
+
+# Function to turn MINECRAFT_VERSION ("latest" or a release) into RESOLVED_VERSION
+resolve_version() {
+    local version="${MINECRAFT_VERSION:-latest}"
 
-    # If version is "latest", get the latest release version
     if [ "$version" = "latest" ]; then
+        fetch_manifest
         # Extract latest release version using jq
-        version=$(echo "$manifest" | jq -r '.latest.release')
+        version=$(echo "$MANIFEST" | jq -r '.latest.release')
         if [ -z "$version" ] || [ "$version" = "null" ]; then
             echo "[$(date)] ERROR: Could not determine latest version!" >&2
             exit 1
         fi
         echo "[$(date)] Latest release version is: $version" >&2
-        jar_name="minecraft_server_${version}.jar"
-
-        # Check again if we have this specific version
-        if [ -f "$jar_name" ]; then
-            echo "[$(date)] Using existing server JAR: $jar_name" >&2
-            ln -sf "$jar_name" server.jar
-            RESOLVED_VERSION="$version"
-            return 0
-        fi
     fi
 
-    # Store resolved version
     RESOLVED_VERSION="$version"
+}
+
+# Function to point server.jar at a downloaded JAR and drop old ones matching a pattern (keep last 3)
+use_jar() {
+    local jar_name="$1" pattern="$2"
+    ln -sf "$jar_name" server.jar
+    ls -t $pattern 2>/dev/null | tail -n +4 | xargs -r rm -f
+}
+
+# Function to download the vanilla Minecraft server JAR
+download_vanilla() {
+    local version="$RESOLVED_VERSION"
+    local jar_name="minecraft_server_${version}.jar"
+
+    # If we already have this version, use it
+    if [ -f "$jar_name" ]; then
+        echo "[$(date)] Using existing server JAR: $jar_name" >&2
+        ln -sf "$jar_name" server.jar
+        return 0
+    fi
+
+    fetch_manifest
 
     # Get the version manifest URL using jq
-    version_url=$(echo "$manifest" | jq -r ".versions[] | select(.id == \"$version\") | .url")
+    version_url=$(echo "$MANIFEST" | jq -r ".versions[] | select(.id == \"$version\") | .url")
 
     if [ -z "$version_url" ] || [ "$version_url" = "null" ]; then
         echo "[$(date)] ERROR: Version $version not found in manifest!" >&2
@@ -114,27 +130,168 @@ download_server() {
     echo "[$(date)] Downloading Minecraft server $version..." >&2
     if curl -f -L -o "$jar_name" "$server_url"; then
         echo "[$(date)] Successfully downloaded $jar_name" >&2
+        use_jar "$jar_name" "minecraft_server_*.jar"
+    else
+        echo "[$(date)] ERROR: Failed to download server JAR from $server_url!" >&2
+        exit 1
+    fi
+}
+
+# Function to download the latest Paper build for the version
+download_paper() {
+    local version="$RESOLVED_VERSION"
+    local api="https://api.papermc.io/v2/projects/paper/versions/$version"
+    local existing build jar_name
+
+    existing=$(ls -t paper_server_"${version}"_*.jar 2>/dev/null | head -1)
+
+    echo "[$(date)] Fetching latest Paper build for $version..." >&2
+    build=$(curl -sf "$api/builds" | jq -r '(.builds | map(select(.channel == "default")) | last // empty) // .builds[-1] | .build' 2>/dev/null)
+    if [ -z "$build" ] || [ "$build" = "null" ]; then
+        if [ -n "$existing" ]; then
+            echo "[$(date)] WARNING: Could not reach the Paper API, using existing $existing" >&2
+            ln -sf "$existing" server.jar
+            return 0
+        fi
+        echo "[$(date)] ERROR: No Paper build found for version $version!" >&2
+        exit 1
+    fi
+
+    jar_name="paper_server_${version}_${build}.jar"
+    if [ -f "$jar_name" ]; then
+        echo "[$(date)] Using existing server JAR: $jar_name" >&2
         ln -sf "$jar_name" server.jar
+        return 0
+    fi
 
-        # Clean up old versions (keep last 3)
-        ls -t minecraft_server_*.jar 2>/dev/null | tail -n +4 | xargs -r rm -f
-        echo "[$(date)] Cleaned up old server JARs" >&2
+    echo "[$(date)] Downloading Paper $version build $build..." >&2
+    if curl -f -L -o "$jar_name" "$api/builds/$build/downloads/paper-${version}-${build}.jar"; then
+        echo "[$(date)] Successfully downloaded $jar_name" >&2
+        use_jar "$jar_name" "paper_server_*.jar"
     else
-        echo "[$(date)] ERROR: Failed to download server JAR from $server_url!" >&2
+        rm -f "$jar_name"
+        echo "[$(date)] ERROR: Failed to download Paper $version build $build!" >&2
         exit 1
     fi
 }
 
-# Download/update server JAR
-download_server
+# Function to download the Fabric server launcher (it fetches the vanilla JAR itself on first run)
+download_fabric() {
+    local version="$RESOLVED_VERSION"
+    local meta="https://meta.fabricmc.net/v2/versions"
+    local existing loader installer jar_name
+
+    existing=$(ls -t fabric_server_"${version}"_*.jar 2>/dev/null | head -1)
+
+    echo "[$(date)] Fetching latest Fabric loader for $version..." >&2
+    loader=$(curl -sf "$meta/loader/$version" | jq -r '.[0].loader.version // empty' 2>/dev/null)
+    installer=$(curl -sf "$meta/installer" | jq -r '.[0].version // empty' 2>/dev/null)
+    if [ -z "$loader" ] || [ -z "$installer" ]; then
+        if [ -n "$existing" ]; then
+            echo "[$(date)] WARNING: Could not reach Fabric meta, using existing $existing" >&2
+            ln -sf "$existing" server.jar
+            return 0
+        fi
+        echo "[$(date)] ERROR: No Fabric loader found for version $version!" >&2
+        exit 1
+    fi
 
-# Select correct Java version based on Minecraft version
+    jar_name="fabric_server_${version}_${loader}.jar"
+    if [ -f "$jar_name" ]; then
+        echo "[$(date)] Using existing server JAR: $jar_name" >&2
+        ln -sf "$jar_name" server.jar
+        return 0
+    fi
+
+    echo "[$(date)] Downloading Fabric server $version (loader $loader, installer $installer)..." >&2
+    if curl -f -L -o "$jar_name" "$meta/loader/$version/$loader/$installer/server/jar"; then
+        echo "[$(date)] Successfully downloaded $jar_name" >&2
+        use_jar "$jar_name" "fabric_server_*.jar"
+    else
+        rm -f "$jar_name"
+        echo "[$(date)] ERROR: Failed to download Fabric server for $version!" >&2
+        exit 1
+    fi
+}
+
+# Function to install Forge with its installer (needs JAVA_HOME set for the version)
+download_forge() {
+    local version="$RESOLVED_VERSION"
+    local forge_version full installer args_file
+
+    echo "[$(date)] Fetching recommended Forge build for $version..." >&2
+    forge_version=$(curl -sf https://files.minecraftforge.net/net/minecraftforge/forge/promotions_slim.json \
+        | jq -r --arg v "$version" '.promos[$v + "-recommended"] // .promos[$v + "-latest"] // empty' 2>/dev/null)
+    if [ -z "$forge_version" ]; then
+        # Fall back to whatever was installed last for this version
+        forge_version=$(cat ".forge_${version}" 2>/dev/null)
+        if [ -z "$forge_version" ]; then
+            echo "[$(date)] ERROR: No Forge build found for version $version!" >&2
+            exit 1
+        fi
+        echo "[$(date)] WARNING: Could not reach the Forge API, using installed Forge $forge_version" >&2
+    fi
+    full="${version}-${forge_version}"
+
+    if [ "$(cat ".forge_${version}" 2>/dev/null)" != "$forge_version" ]; then
+        installer="forge-${full}-installer.jar"
+        echo "[$(date)] Downloading Forge installer $full..." >&2
+        if ! curl -f -L -o "$installer" "https://maven.minecraftforge.net/net/minecraftforge/forge/${full}/${installer}"; then
+            rm -f "$installer"
+            echo "[$(date)] ERROR: Failed to download Forge installer $full!" >&2
+            exit 1
+        fi
+
+        echo "[$(date)] Installing Forge $full..." >&2
+        if ! "$JAVA_HOME/bin/java" -jar "$installer" --installServer >&2; then
+            echo "[$(date)] ERROR: Forge installer failed!" >&2
+            exit 1
+        fi
+        rm -f "$installer" "${installer}.log"
+        echo "$forge_version" > ".forge_${version}"
+        echo "[$(date)] Successfully installed Forge $full" >&2
+    else
+        echo "[$(date)] Using installed Forge $full" >&2
+    fi
+
+    # Forge 1.17+ launches through an args file; older versions ship a runnable JAR
+    args_file="libraries/net/minecraftforge/forge/${full}/unix_args.txt"
+    if [ -f "$args_file" ]; then
+        SERVER_ARGS=("@$args_file")
+        return 0
+    fi
+    for jar_name in "forge-${full}.jar" "forge-${full}-universal.jar" "forge-${full}-shim.jar"; do
+        if [ -f "$jar_name" ]; then
+            ln -sf "$jar_name" server.jar
+            return 0
+        fi
+    done
+    echo "[$(date)] ERROR: Could not find the installed Forge server for $full!" >&2
+    exit 1
+}
+
+resolve_version
+echo "[$(date)] Server type: $SERVER_TYPE, Minecraft version: $RESOLVED_VERSION" >&2
+
+# Select correct Java version based on Minecraft version (the Forge installer needs it too)
 JAVA_HOME=$(get_java_for_version "$RESOLVED_VERSION")
 export JAVA_HOME
 export PATH="$JAVA_HOME/bin:$PATH"
 echo "[$(date)] Using Java from: $JAVA_HOME" >&2
 echo "[$(date)] Java version: $($JAVA_HOME/bin/java -version 2>&1 | head -1)" >&2
 
+# Download/update the server for the chosen type
+case "$SERVER_TYPE" in
+    vanilla) download_vanilla ;;
+    paper) download_paper ;;
+    fabric) download_fabric ;;
+    forge) download_forge ;;
+    *)
+        echo "[$(date)] ERROR: Unknown SERVER_TYPE '$SERVER_TYPE' (expected vanilla, paper, fabric or forge)" >&2
+        exit 1
+        ;;
+esac
+
 # Create EULA file based on environment variable
 echo "eula=${EULA}" > eula.txt
 
@@ -213,7 +370,7 @@ echo "[$(date)] Memory: ${MEMORY_MB}MB" >&2
 # Start server in background and get PID
 while true; do
   cat $PIPE_PATH
-done | "$JAVA_HOME/bin/java" -Xms${MEMORY_MB}M -Xmx${MEMORY_MB}M $AIKAR_FLAGS -jar server.jar nogui &
+done | "$JAVA_HOME/bin/java" -Xms${MEMORY_MB}M -Xmx${MEMORY_MB}M $AIKAR_FLAGS "${SERVER_ARGS[@]}" nogui &
 SERVER_PID=$!
 
 # Wait for server process
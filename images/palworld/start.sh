@@ -1,5 +1,8 @@
 #!/bin/bash
 
+# Load secrets passed as files (see images/common/load-secrets.sh)
+. /data/scripts/load-secrets.sh
+
 # --- Update Server ---
 echo "-> Updating Palworld server via SteamCMD..."
 steamcmd +force_install_dir /data/server +login anonymous +app_update 2394010 validate +quit
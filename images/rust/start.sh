@@ -1,4 +1,8 @@
 #!/bin/bash
+
+# Load secrets passed as files (see images/common/load-secrets.sh)
+. /data/scripts/load-secrets.sh
+
 # Set up library path for Rust server
 export LD_LIBRARY_PATH=$LD_LIBRARY_PATH:/data/server/RustDedicated_Data/Plugins:/data/server/RustDedicated_Data/Plugins/x86_64
 
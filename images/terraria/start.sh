@@ -1,5 +1,8 @@
 #!/bin/bash
 
+# Load secrets passed as files (see images/common/load-secrets.sh)
+. /data/scripts/load-secrets.sh
+
 # Default values if environment variables are not set
 : "${MAXPLAYERS:=8}"
 : "${PASSWORD:=}"
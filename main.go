@@ -10,10 +10,12 @@ import (
 	"os"
 	"os/signal"
 	"reflect"
+	"runtime/debug"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Validate timezones even on hosts without zoneinfo installed
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -23,7 +25,9 @@ import (
 	"0xkowalskidev/gameservers/database"
 	"0xkowalskidev/gameservers/docker"
 	"0xkowalskidev/gameservers/handlers"
+	"0xkowalskidev/gameservers/models"
 	"0xkowalskidev/gameservers/services"
+	"0xkowalskidev/gameservers/storage"
 )
 
 //go:embed templates/*.html
@@ -32,31 +36,116 @@ var templateFiles embed.FS
 //go:embed static/*
 var staticFiles embed.FS
 
+// version is the app's build version, set at build time with -ldflags "-X main.version=1.2.3"
+var version = "dev"
+
 // Config holds all configuration for the application
 type Config struct {
 	// Server Configuration
-	Host          string
-	Port          int
-	PublicAddress string // Public IP/domain for gameserver connection details
+	Host            string
+	Port            int
+	PublicAddress   string // Public IP/domain for gameserver connection details
 	ShutdownTimeout time.Duration
 
+	// HTTP server limits. Streams and long-running operations are exempt from WriteTimeout.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration // Whole request including the body, so it bounds upload time
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration // How long keep-alive connections wait for the next request
+	MaxHeaderBytes    int
+
 	// Database Configuration
-	DatabasePath string
+	DatabasePath    string
+	CleanupInterval time.Duration // How often orphaned rows are purged and the DB vacuumed (0 = disabled)
 
 	// Docker Configuration
 	DockerSocket         string
+	DockerAPIVersion     string // Pin Docker API version (empty = negotiate)
+	PodmanCompat         bool   // Force Podman compatibility mode (auto-detected otherwise)
 	ContainerNamespace   string
+	VolumePruneInterval  time.Duration // How often dangling anonymous volumes are pruned (0 = disabled)
 	ContainerStopTimeout time.Duration
+	ContainerLogMaxSize  string // Rotate container logs at this size (empty = no rotation)
+	ContainerLogMaxFiles int    // Rotated container log files to keep
+	SecretsAsFiles       bool   // Pass password config vars to containers as files rather than env
+	DefaultTimezone      string // TZ injected into containers whose gameserver doesn't set one
+	PersistentContainers bool   // Stop rather than remove containers, recreating only on config change
+
+	ContainerDNS        []string // Default DNS servers for containers (empty = Docker default)
+	ContainerExtraHosts []string // host:ip entries added to every container's /etc/hosts
+
+	// Query Configuration
+	QueryHost        string        // Address published gameserver ports are queried on
+	QueryContainerIP bool          // Query containers on their network IP instead of published ports
+	QueryTimeout     time.Duration // How long one query may take before the server counts as offline
+	QueryConcurrency int           // Servers queried at once for list views and the status API
+	QueryListTimeout time.Duration // Per-server query limit for list views and the status API
+
+	// Scheduler Configuration
+	TaskMaxRetries   int           // Retries for a failed scheduled task before waiting for its next run
+	TaskRetryDelay   time.Duration // Delay between retries
+	TaskHistoryLimit int           // Runs kept in each task's execution history (0 = all)
+
+	// Backup Configuration
+	MaxConcurrentBackups int           // Host-wide limit on simultaneous backups (0 = unlimited)
+	BackupVerifyBootTime time.Duration // How long a test-restored backup must keep the server up
+	BackupPreHookURL     string        // Webhook called before each backup; failure aborts it (empty = none)
+	BackupPostHookURL    string        // Webhook called after each backup with the filename (empty = none)
+	BackupHookTimeout    time.Duration // How long a backup webhook call may take
+
+	// Host path backup destination: a directory on the host, e.g. a second disk (disabled when empty)
+	BackupHostPath string
+
+	// Offsite Backups (S3-compatible, disabled unless an endpoint is set)
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3Prefix    string // Key prefix for all objects in the bucket
+	S3PathStyle bool   // Address the bucket as endpoint/bucket (MinIO and most self-hosted stores)
+
+	// Resource Limits
+	MemoryReserveMB     int // Host memory kept free for the OS and this app when starting servers
+	MaxConcurrentStarts int // Host-wide limit on servers pulling, creating and starting containers at once (0 = unlimited)
+
+	// Startup Probe
+	StartupTimeout       time.Duration // How long a started server has to respond to queries
+	StartupFailOnTimeout bool          // Mark servers that don't respond in time as failed (otherwise running)
+
+	// Crash Loop Detection
+	CrashLoopRestarts int           // Restarts that count as a crash loop (0 = disabled)
+	CrashLoopWindow   time.Duration // Uptime below which a restarted server is still unstable
+	StopCrashLooping  bool          // Stop crash-looping containers
 
 	// File System Limits
-	MaxFileEditSize int64
-	MaxUploadSize   int64
+	MaxFileEditSize    int64
+	MaxUploadSize      int64
+	FileTailSize       int64    // Bytes loaded at a time, read-only, for files over MaxFileEditSize
+	MaxExtractSize     int64    // Total size of the files an archive may extract to
+	EditableExtensions []string // Extra extensions editable in the file manager (on top of defaults)
+
+	// Streaming Configuration
+	StreamReconnectTimeout time.Duration // How long log/stats streams wait to re-attach after a container is replaced
+	StatusRefreshInterval  time.Duration // How often the dashboard status feed resyncs every gameserver (0 = Docker events only)
+
+	// Metrics Configuration
+	MetricsInterval  time.Duration // How often running servers' CPU and memory use is sampled (0 = disabled)
+	MetricsRetention time.Duration // How long samples are kept
+
+	// Authentication
+	AuthEnabled   bool          // Require signing in (disable only behind an authenticating proxy)
+	AdminUsername string        // Admin created on first run, when there are no users
+	AdminPassword string        `json:"-"` // Password for that admin (empty = generate one and log it)
+	SessionTTL    time.Duration // How long a login session lasts
+	SecureCookies bool          // Always mark the session cookie Secure (it is anyway over HTTPS)
 }
 
 func main() {
 	// Load configuration
 	config := loadConfig()
-	log.Info().Interface("config", config).Msg("Configuration loaded")
+	appVersion := buildVersion()
+	log.Info().Interface("config", config).Str("version", appVersion).Msg("Configuration loaded")
 
 	// Setup logging
 	log.Logger = log.Output(zerolog.ConsoleWriter{
@@ -75,36 +164,137 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to initialize database")
 	}
 	defer db.Close()
+	db.SetAppVersion(appVersion)
 	log.Info().Msg("Database initialized successfully")
 
 	// Initialize Docker manager
-	dockerManager, err := docker.NewDockerManager(config.DockerSocket, config.ContainerNamespace, config.ContainerStopTimeout)
+	dockerManager, err := docker.NewDockerManager(docker.Options{
+		Socket:         config.DockerSocket,
+		APIVersion:     config.DockerAPIVersion,
+		Namespace:      config.ContainerNamespace,
+		StopTimeout:    config.ContainerStopTimeout,
+		Podman:         config.PodmanCompat,
+		LogMaxSize:     config.ContainerLogMaxSize,
+		LogMaxFiles:    config.ContainerLogMaxFiles,
+		SecretsAsFiles: config.SecretsAsFiles,
+		Timezone:       config.DefaultTimezone,
+		DNS:            config.ContainerDNS,
+		ExtraHosts:     config.ContainerExtraHosts,
+		MaxReadSize:    config.MaxFileEditSize,
+	})
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to initialize Docker manager")
 	}
 	log.Info().Msg("Docker manager initialized successfully")
 
 	// Initialize query service
-	queryService := services.NewQueryService()
+	queryService := services.NewQueryService(services.QueryOptions{
+		Host:        config.QueryHost,
+		ContainerIP: config.QueryContainerIP,
+		Resolver:    dockerManager,
+		Timeout:     config.QueryTimeout,
+		Concurrency: config.QueryConcurrency,
+		ListTimeout: config.QueryListTimeout,
+	})
 	log.Info().Msg("Query service initialized")
 
 	// Initialize gameserver repository
-	gameserverRepo := database.NewGameserverRepository(db, dockerManager, queryService)
+	// Initialize the configured offsite backup destinations
+	backupStores := make(map[string]models.BackupStore)
+	if config.BackupHostPath != "" {
+		dirStore, err := storage.NewDirStore(config.BackupHostPath)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to configure host path backup destination")
+		}
+		backupStores["host"] = dirStore
+		log.Info().Str("path", config.BackupHostPath).Msg("Host path backups enabled")
+	}
+	if config.S3Endpoint != "" {
+		s3Store, err := storage.NewS3Store(storage.S3Options{
+			Endpoint:  config.S3Endpoint,
+			Region:    config.S3Region,
+			Bucket:    config.S3Bucket,
+			AccessKey: config.S3AccessKey,
+			SecretKey: config.S3SecretKey,
+			Prefix:    config.S3Prefix,
+			PathStyle: config.S3PathStyle,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to configure offsite backup storage")
+		}
+		backupStores["s3"] = s3Store
+		log.Info().Str("endpoint", config.S3Endpoint).Str("bucket", config.S3Bucket).Msg("Offsite backups enabled")
+	}
+
+	gameserverRepo := database.NewGameserverRepository(db, dockerManager, queryService, database.RepositoryOptions{
+		MaxConcurrentBackups: config.MaxConcurrentBackups,
+		CrashLoopRestarts:    config.CrashLoopRestarts,
+		CrashLoopWindow:      config.CrashLoopWindow,
+		StopCrashLooping:     config.StopCrashLooping,
+		PersistentContainers: config.PersistentContainers,
+		BackupVerifyBootTime: config.BackupVerifyBootTime,
+		MemoryReserveMB:      config.MemoryReserveMB,
+		MaxConcurrentStarts:  config.MaxConcurrentStarts,
+		BackupPreHookURL:     config.BackupPreHookURL,
+		BackupPostHookURL:    config.BackupPostHookURL,
+		BackupHookTimeout:    config.BackupHookTimeout,
+		StartupTimeout:       config.StartupTimeout,
+		StartupFailOnTimeout: config.StartupFailOnTimeout,
+		SessionTTL:           config.SessionTTL,
+
+		BackupStores: backupStores,
+	})
 	log.Info().Msg("Gameserver repository initialized")
 
+	if config.AuthEnabled {
+		if err := gameserverRepo.BootstrapAdmin(config.AdminUsername, config.AdminPassword); err != nil {
+			log.Fatal().Err(err).Msg("Failed to create initial admin user")
+		}
+	} else {
+		log.Warn().Msg("Authentication disabled; anyone who can reach the panel has full access")
+	}
+
 	// Initialize and start task scheduler
-	taskScheduler := services.NewTaskScheduler(db, gameserverRepo)
+	taskScheduler := services.NewTaskScheduler(db, gameserverRepo, services.SchedulerOptions{
+		MaxRetries:   config.TaskMaxRetries,
+		RetryDelay:   config.TaskRetryDelay,
+		HistoryLimit: config.TaskHistoryLimit,
+	})
 	taskScheduler.Start()
 	log.Info().Msg("Task scheduler started")
 
 	// Ensure scheduler is stopped when application exits
 	defer taskScheduler.Stop()
 
+	// Periodically purge data left behind by deleted gameservers
+	dataCleaner := services.NewDataCleaner(db, config.CleanupInterval)
+	dataCleaner.Start()
+	defer dataCleaner.Stop()
+
+	// Optionally prune anonymous volumes that recreated containers leave behind
+	volumePruner := services.NewVolumePruner(dockerManager, config.VolumePruneInterval)
+	volumePruner.Start()
+	defer volumePruner.Stop()
+
+	// Record resource usage history for the metrics charts
+	metricsCollector := services.NewMetricsCollector(db, dockerManager, config.MetricsInterval, config.MetricsRetention)
+	metricsCollector.Start()
+	defer metricsCollector.Stop()
+
+	// Push gameserver status changes to the dashboard
+	statusFeed := services.NewStatusFeed(gameserverRepo, dockerManager, config.StatusRefreshInterval)
+	statusFeed.Start()
+	defer statusFeed.Stop()
+
 	// Parse html templates with custom functions
 	tmpl, err := template.New("").Funcs(template.FuncMap{
 		"formatFileSize": formatFileSize,
 		"cronToHuman":    cronToHuman,
+		"cronPresets":    func() []models.SchedulePreset { return models.SchedulePresets },
+		"appVersion":     func() string { return appVersion },
 		"publicAddress":  func() string { return config.PublicAddress },
+		"connectAddress": func(g *models.Gameserver) string { return g.ConnectAddress(config.PublicAddress) },
+		"add":            func(a, b int) int { return a + b },
 		"sub":            func(a, b int) int { return a - b },
 		"mul": func(a, b interface{}) float64 {
 			aVal, bVal := toFloat64(a), toFloat64(b)
@@ -150,14 +340,31 @@ func main() {
 
 	// Set up error handling functions for handlers
 	handlers.HandleError = HandleError
+	handlers.HandleAPIError = HandleAPIError
 	handlers.NotFound = NotFound
 	handlers.BadRequest = BadRequest
 	handlers.InternalError = InternalError
+	handlers.Conflict = Conflict
+	handlers.Unauthorized = Unauthorized
+	handlers.Forbidden = Forbidden
+	handlers.TooLarge = TooLarge
 	handlers.ParseForm = ParseForm
 	handlers.RequireMethod = RequireMethod
 
 	// Initialize handlers
-	handlerInstance := handlers.New(gameserverRepo, dockerManager, tmpl, config.MaxFileEditSize, config.MaxUploadSize, queryService)
+	handlerInstance := handlers.New(gameserverRepo, dockerManager, tmpl, queryService, services.NewVersionService(), taskScheduler, statusFeed, services.NewAuditService(db), handlers.Options{
+		MaxFileEditSize:    config.MaxFileEditSize,
+		MaxUploadSize:      config.MaxUploadSize,
+		FileTailSize:       config.FileTailSize,
+		MaxExtractSize:     config.MaxExtractSize,
+		EditableExtensions: config.EditableExtensions,
+
+		StreamReconnectTimeout: config.StreamReconnectTimeout,
+
+		AppVersion: appVersion,
+
+		SecureCookies: config.SecureCookies,
+	})
 
 	// Chi HTTP Server
 	r := chi.NewRouter()
@@ -184,9 +391,25 @@ func main() {
 		})
 	})
 
+	// Everything but static assets and the login page needs a signed-in user or API token
+	if config.AuthEnabled {
+		r.Use(handlerInstance.RequireAuth)
+	}
+
 	// Static
 	r.Handle("/static/*", http.StripPrefix("/static", http.FileServer(http.FS(staticFS))))
 
+	// Authentication
+	r.Get("/login", handlerInstance.LoginPage)
+	r.Post("/login", handlerInstance.Login)
+	r.Post("/logout", handlerInstance.Logout)
+	r.Route("/account", func(r chi.Router) {
+		r.Get("/", handlerInstance.ShowAccount)
+		r.Post("/password", handlerInstance.ChangePassword)
+		r.Post("/tokens", handlerInstance.CreateAPIToken)
+		r.Delete("/tokens/{tokenId}", handlerInstance.RevokeAPIToken)
+	})
+
 	// Routes
 	r.Get("/", handlerInstance.IndexGameservers)
 
@@ -195,40 +418,94 @@ func main() {
 		r.Get("/", handlerInstance.ListGameservers)
 		r.Post("/", handlerInstance.CreateGameserver)
 		r.Get("/new", handlerInstance.NewGameserver)
+		r.Post("/start-all", handlerInstance.StartAllGameservers)
+		r.Post("/stop-all", handlerInstance.StopAllGameservers)
+		r.With(withoutWriteTimeout).Post("/bulk", handlerInstance.BulkGameserverAction)
+		r.With(withoutWriteTimeout).Get("/status-stream", handlerInstance.StatusStream)
 		r.Get("/{id}", handlerInstance.ShowGameserver)
 		r.Get("/{id}/edit", handlerInstance.EditGameserver)
 		r.Put("/{id}", handlerInstance.UpdateGameserver)
+		r.Get("/{id}/game-defaults", handlerInstance.GameDefaultsDiff)
+		r.Post("/{id}/game-defaults", handlerInstance.ApplyGameDefaults)
+		r.Post("/{id}/rotate-secret", handlerInstance.RotateSecret)
+		r.With(withoutWriteTimeout).Post("/{id}/migrate-data", handlerInstance.MigrateGameserverData)
+		r.With(withoutWriteTimeout).Post("/{id}/clone", handlerInstance.CloneGameserver)
+		r.Post("/{id}/rollback-image", handlerInstance.RollbackImage)
+		r.With(withoutWriteTimeout).Post("/{id}/check-update", handlerInstance.CheckImageUpdate)
+		r.With(withoutWriteTimeout).Post("/{id}/update-image", handlerInstance.UpdateImage)
+		r.Get("/{id}/inspect", handlerInstance.InspectGameserver)
+		r.Get("/{id}/notes", handlerInstance.ListServerNotes)
+		r.Post("/{id}/notes", handlerInstance.CreateServerNote)
+		r.Delete("/{id}/notes/{noteId}", handlerInstance.DeleteServerNote)
 		r.Post("/{id}/start", handlerInstance.StartGameserver)
 		r.Post("/{id}/stop", handlerInstance.StopGameserver)
 		r.Post("/{id}/restart", handlerInstance.RestartGameserver)
+		r.Post("/{id}/pause", handlerInstance.PauseGameserver)
+		r.Post("/{id}/unpause", handlerInstance.UnpauseGameserver)
 		r.Post("/{id}/console", handlerInstance.SendGameserverCommand)
 		r.Delete("/{id}", handlerInstance.DestroyGameserver)
 		r.Get("/{id}/console", handlerInstance.GameserverConsole)
-		r.Get("/{id}/logs", handlerInstance.GameserverLogs)
-		r.Get("/{id}/stats", handlerInstance.GameserverStats)
+		r.With(withoutWriteTimeout).Get("/{id}/console/ws", handlerInstance.GameserverConsoleSocket)
+		r.With(withoutWriteTimeout).Get("/{id}/logs", handlerInstance.GameserverLogs)
+		r.With(withoutWriteTimeout).Get("/{id}/stats", handlerInstance.GameserverStats)
+		r.Get("/{id}/metrics", handlerInstance.GameserverMetrics)
 		r.Get("/{id}/query", handlerInstance.QueryGameserver)
 		r.Get("/{id}/status", handlerInstance.StatusPartial)
 		r.Get("/{id}/tasks", handlerInstance.ListGameserverTasks)
 		r.Get("/{id}/tasks/new", handlerInstance.NewGameserverTask)
+		r.Get("/{id}/tasks/preview", handlerInstance.PreviewTaskSchedule)
 		r.Post("/{id}/tasks", handlerInstance.CreateGameserverTask)
 		r.Get("/{id}/tasks/{taskId}/edit", handlerInstance.EditGameserverTask)
 		r.Put("/{id}/tasks/{taskId}", handlerInstance.UpdateGameserverTask)
 		r.Delete("/{id}/tasks/{taskId}", handlerInstance.DeleteGameserverTask)
-		r.Post("/{id}/restore", handlerInstance.RestoreGameserverBackup)
-		r.Post("/{id}/backup", handlerInstance.CreateGameserverBackup)
+		r.Get("/{id}/tasks/{taskId}/history", handlerInstance.GameserverTaskHistory)
+		r.With(withoutWriteTimeout).Post("/{id}/restore", handlerInstance.RestoreGameserverBackup)
+		r.With(withoutWriteTimeout).Post("/{id}/backup", handlerInstance.CreateGameserverBackup)
 		r.Get("/{id}/backups", handlerInstance.ListGameserverBackups)
+		r.With(withoutWriteTimeout).Get("/{id}/disk-usage", handlerInstance.GameserverDiskUsage)
+		r.Put("/{id}/backups/settings", handlerInstance.UpdateBackupSettings)
+		r.Put("/{id}/backups/destinations", handlerInstance.UpdateBackupDestinations)
 		r.Delete("/{id}/backups/delete", handlerInstance.DeleteGameserverBackup)
+		r.With(withoutWriteTimeout).Post("/{id}/backups/upload", handlerInstance.UploadGameserverBackup)
+		r.With(withoutWriteTimeout).Post("/{id}/restore-offsite", handlerInstance.RestoreOffsiteBackup)
 
 		// File manager routes
 		r.Get("/{id}/files", handlerInstance.GameserverFiles)
 		r.Get("/{id}/files/browse", handlerInstance.BrowseGameserverFiles)
 		r.Get("/{id}/files/content", handlerInstance.GameserverFileContent)
 		r.Post("/{id}/files/save", handlerInstance.SaveGameserverFile)
-		r.Get("/{id}/files/download", handlerInstance.DownloadGameserverFile)
+		r.With(withoutWriteTimeout).Get("/{id}/files/download", handlerInstance.DownloadGameserverFile)
 		r.Post("/{id}/files/create", handlerInstance.CreateGameserverFile)
 		r.Delete("/{id}/files/delete", handlerInstance.DeleteGameserverFile)
 		r.Post("/{id}/files/rename", handlerInstance.RenameGameserverFile)
-		r.Post("/{id}/files/upload", handlerInstance.UploadGameserverFile)
+		r.With(withoutWriteTimeout).Post("/{id}/files/upload", handlerInstance.UploadGameserverFile)
+		r.With(withoutWriteTimeout).Post("/{id}/files/extract", handlerInstance.ExtractGameserverFile)
+		r.Get("/{id}/addons", handlerInstance.GameserverAddons)
+		r.Get("/{id}/players", handlerInstance.ListPlayerLists)
+		r.Post("/{id}/players/{list}", handlerInstance.ModifyPlayerList)
+		r.Post("/{id}/addons", handlerInstance.UploadGameserverAddon)
+		r.Delete("/{id}/addons", handlerInstance.DeleteGameserverAddon)
+	})
+
+	// Task template routes
+	r.Route("/groups", func(r chi.Router) {
+		r.Get("/", handlerInstance.ListServerGroups)
+		r.Post("/", handlerInstance.CreateServerGroup)
+		r.Get("/{id}", handlerInstance.ShowServerGroup)
+		r.Put("/{id}", handlerInstance.UpdateServerGroup)
+		r.Delete("/{id}", handlerInstance.DeleteServerGroup)
+		r.Post("/{id}/start", handlerInstance.StartServerGroup)
+		r.Post("/{id}/stop", handlerInstance.StopServerGroup)
+		r.With(withoutWriteTimeout).Post("/{id}/backup", handlerInstance.BackupServerGroup)
+	})
+
+	r.Route("/task-templates", func(r chi.Router) {
+		r.Get("/", handlerInstance.ListTaskTemplates)
+		r.Post("/", handlerInstance.CreateTaskTemplate)
+		r.Get("/{id}/edit", handlerInstance.EditTaskTemplate)
+		r.Put("/{id}", handlerInstance.UpdateTaskTemplate)
+		r.Post("/{id}/apply", handlerInstance.ApplyTaskTemplate)
+		r.Delete("/{id}", handlerInstance.DeleteTaskTemplate)
 	})
 
 	// Game routes
@@ -238,14 +515,44 @@ func main() {
 		r.Get("/new", handlerInstance.NewGame)
 		r.Get("/{id}", handlerInstance.ShowGame)
 		r.Get("/{id}/edit", handlerInstance.EditGame)
+		r.Get("/{id}/versions", handlerInstance.GameVersions)
+		r.With(withoutWriteTimeout).Post("/{id}/build-image", handlerInstance.BuildGameImage)
 		r.Put("/{id}", handlerInstance.UpdateGame)
 		r.Delete("/{id}", handlerInstance.DeleteGame)
 	})
 
+	// Admin actions
+	r.Post("/admin/reseed-games", handlerInstance.ReseedGames)
+	r.Get("/admin/scheduler", handlerInstance.SchedulerState)
+	r.Get("/admin/about", handlerInstance.About)
+	r.Get("/audit", handlerInstance.ListAuditLog)
+	r.Get("/admin/users", handlerInstance.ListUsers)
+	r.Post("/admin/users", handlerInstance.CreateUser)
+	r.Delete("/admin/users/{userId}", handlerInstance.DeleteUser)
+	r.Get("/admin/orphans", handlerInstance.ListOrphans)
+	r.Post("/admin/orphans/containers/{containerId}/adopt", handlerInstance.AdoptOrphanContainer)
+	r.Delete("/admin/orphans/containers/{containerId}", handlerInstance.RemoveOrphanContainer)
+	r.Delete("/admin/orphans/volumes/{name}", handlerInstance.RemoveOrphanVolume)
+
+	// Machine-readable API
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Get("/status", handlerInstance.StatusAPI)
+		r.Post("/gameservers/validate", handlerInstance.ValidateGameserverAPI)
+		r.Get("/gameservers", handlerInstance.ListGameserversAPI)
+		r.Get("/gameservers/{id}", handlerInstance.GetGameserverAPI)
+		r.Get("/gameservers/{id}/query", handlerInstance.QueryGameserverAPI)
+		r.Post("/gameservers/{id}/{action}", handlerInstance.GameserverActionAPI)
+	})
+
 	// Setup HTTP server with graceful shutdown
 	srv := &http.Server{
-		Addr:    fmt.Sprintf("%s:%d", config.Host, config.Port),
-		Handler: r,
+		Addr:              fmt.Sprintf("%s:%d", config.Host, config.Port),
+		Handler:           r,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		ReadTimeout:       config.ReadTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+		MaxHeaderBytes:    config.MaxHeaderBytes,
 	}
 
 	// Start server in a goroutine
@@ -273,6 +580,46 @@ func main() {
 	log.Info().Msg("Server exited")
 }
 
+// buildVersion returns the version set at build time. Development builds without one report
+// the VCS revision Go embedded, if any, so they can still be told apart.
+func buildVersion() string {
+	if version != "dev" {
+		return version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return version
+	}
+	var revision, modified string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			if setting.Value == "true" {
+				modified = "-dirty"
+			}
+		}
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if revision == "" {
+		return version
+	}
+	return version + "+" + revision + modified
+}
+
+// withoutWriteTimeout lifts the server's WriteTimeout for long-lived responses: SSE streams,
+// downloads, and operations such as backups that respond only once they finish
+func withoutWriteTimeout(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+			log.Warn().Err(err).Str("path", r.URL.Path).Msg("Failed to lift write timeout")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
 
 func formatFileSize(size int64) string {
 	const unit = 1024
@@ -401,6 +748,28 @@ func loadConfig() Config {
 		return def
 	}
 
+	// Helper to get bool env var
+	getBool := func(key string, def bool) bool {
+		if v := os.Getenv(key); v != "" {
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b
+			}
+			log.Warn().Str("key", key).Str("value", v).Msg("Invalid boolean, using default")
+		}
+		return def
+	}
+
+	// Helper to get comma-separated list env var
+	getList := func(key string) []string {
+		var list []string
+		for _, item := range strings.Split(os.Getenv(key), ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list
+	}
+
 	// Helper to get duration env var
 	getDuration := func(key string, def time.Duration) time.Duration {
 		if v := os.Getenv(key); v != "" {
@@ -412,6 +781,32 @@ func loadConfig() Config {
 		return def
 	}
 
+	// Helper to get an IANA timezone name, e.g. "Europe/Berlin"
+	getTimezone := func(key string) string {
+		v := os.Getenv(key)
+		if v == "" {
+			return ""
+		}
+		if _, err := time.LoadLocation(v); err != nil {
+			log.Warn().Str("key", key).Str("value", v).Msg("Invalid timezone, using container default")
+			return ""
+		}
+		return v
+	}
+
+	// Helper to get a list env var, dropping entries that fail validation
+	getValidList := func(key string, validate func(string) error) []string {
+		var valid []string
+		for _, item := range getList(key) {
+			if err := validate(item); err != nil {
+				log.Warn().Err(err).Str("key", key).Msg("Ignoring invalid entry")
+				continue
+			}
+			valid = append(valid, item)
+		}
+		return valid
+	}
+
 	return Config{
 		// Server defaults
 		Host:            getStr("GAMESERVER_HOST", "localhost"),
@@ -419,16 +814,99 @@ func loadConfig() Config {
 		PublicAddress:   getStr("GAMESERVER_PUBLIC_ADDRESS", "localhost"),
 		ShutdownTimeout: getDuration("GAMESERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
 
+		// HTTP server limits
+		ReadHeaderTimeout: getDuration("GAMESERVER_HTTP_READ_HEADER_TIMEOUT", 10*time.Second),
+		ReadTimeout:       getDuration("GAMESERVER_HTTP_READ_TIMEOUT", 10*time.Minute),
+		WriteTimeout:      getDuration("GAMESERVER_HTTP_WRITE_TIMEOUT", 5*time.Minute),
+		IdleTimeout:       getDuration("GAMESERVER_HTTP_IDLE_TIMEOUT", 2*time.Minute),
+		MaxHeaderBytes:    getInt("GAMESERVER_HTTP_MAX_HEADER_BYTES", 64*1024),
+
 		// Database defaults
-		DatabasePath: getStr("GAMESERVER_DATABASE_PATH", "gameservers.db"),
+		DatabasePath:    getStr("GAMESERVER_DATABASE_PATH", "gameservers.db"),
+		CleanupInterval: getDuration("GAMESERVER_DB_CLEANUP_INTERVAL", 24*time.Hour),
 
 		// Docker defaults
 		DockerSocket:         getStr("GAMESERVER_DOCKER_SOCKET", ""),
+		DockerAPIVersion:     getStr("GAMESERVER_DOCKER_API_VERSION", ""),
+		PodmanCompat:         getBool("GAMESERVER_PODMAN_COMPAT", false),
 		ContainerNamespace:   getStr("GAMESERVER_CONTAINER_NAMESPACE", "gameservers"),
 		ContainerStopTimeout: getDuration("GAMESERVER_CONTAINER_STOP_TIMEOUT", 30*time.Second),
-
-		// File system defaults (10MB edit, 100MB upload)
+		ContainerLogMaxSize:  getStr("GAMESERVER_CONTAINER_LOG_MAX_SIZE", "10m"),
+		ContainerLogMaxFiles: getInt("GAMESERVER_CONTAINER_LOG_MAX_FILES", 3),
+		SecretsAsFiles:       getBool("GAMESERVER_SECRETS_AS_FILES", false),
+		DefaultTimezone:      getTimezone("GAMESERVER_DEFAULT_TIMEZONE"),
+		PersistentContainers: getBool("GAMESERVER_PERSISTENT_CONTAINERS", false),
+		VolumePruneInterval:  getDuration("GAMESERVER_VOLUME_PRUNE_INTERVAL", 0),
+
+		ContainerDNS:        getValidList("GAMESERVER_CONTAINER_DNS", models.ValidateDNSServer),
+		ContainerExtraHosts: getValidList("GAMESERVER_CONTAINER_EXTRA_HOSTS", models.ValidateExtraHost),
+
+		// Query defaults
+		QueryHost:        getStr("GAMESERVER_QUERY_HOST", "127.0.0.1"),
+		QueryContainerIP: getBool("GAMESERVER_QUERY_CONTAINER_IP", false),
+		QueryTimeout:     getDuration("GAMESERVER_QUERY_TIMEOUT", 5*time.Second),
+		QueryConcurrency: getInt("GAMESERVER_QUERY_CONCURRENCY", 8),
+		QueryListTimeout: getDuration("GAMESERVER_QUERY_LIST_TIMEOUT", 2*time.Second),
+
+		// Scheduler defaults
+		TaskMaxRetries:   getInt("GAMESERVER_TASK_MAX_RETRIES", 3),
+		TaskRetryDelay:   getDuration("GAMESERVER_TASK_RETRY_DELAY", 5*time.Minute),
+		TaskHistoryLimit: getInt("GAMESERVER_TASK_HISTORY_LIMIT", 50),
+
+		// Backup defaults
+		MaxConcurrentBackups: getInt("GAMESERVER_MAX_CONCURRENT_BACKUPS", 2),
+		BackupVerifyBootTime: getDuration("GAMESERVER_BACKUP_VERIFY_BOOT_TIME", 60*time.Second),
+		BackupPreHookURL:     getStr("GAMESERVER_BACKUP_PRE_HOOK_URL", ""),
+		BackupPostHookURL:    getStr("GAMESERVER_BACKUP_POST_HOOK_URL", ""),
+		BackupHookTimeout:    getDuration("GAMESERVER_BACKUP_HOOK_TIMEOUT", 5*time.Minute),
+
+		// Host path backup destination
+		BackupHostPath: getStr("GAMESERVER_BACKUP_HOST_PATH", ""),
+
+		// Offsite backup defaults
+		S3Endpoint:  getStr("GAMESERVER_S3_ENDPOINT", ""),
+		S3Region:    getStr("GAMESERVER_S3_REGION", "us-east-1"),
+		S3Bucket:    getStr("GAMESERVER_S3_BUCKET", ""),
+		S3AccessKey: getStr("GAMESERVER_S3_ACCESS_KEY", ""),
+		S3SecretKey: getStr("GAMESERVER_S3_SECRET_KEY", ""),
+		S3Prefix:    getStr("GAMESERVER_S3_PREFIX", ""),
+		S3PathStyle: getBool("GAMESERVER_S3_PATH_STYLE", true),
+
+		// Resource defaults
+		MemoryReserveMB:     getInt("GAMESERVER_MEMORY_RESERVE_MB", 512),
+		MaxConcurrentStarts: getInt("GAMESERVER_MAX_CONCURRENT_STARTS", 2),
+
+		// Startup probe defaults
+		StartupTimeout:       getDuration("GAMESERVER_STARTUP_TIMEOUT", 5*time.Minute),
+		StartupFailOnTimeout: getBool("GAMESERVER_STARTUP_FAIL_ON_TIMEOUT", true),
+
+		// Crash loop defaults
+		CrashLoopRestarts: getInt("GAMESERVER_CRASH_LOOP_RESTARTS", 5),
+		CrashLoopWindow:   getDuration("GAMESERVER_CRASH_LOOP_WINDOW", 10*time.Minute),
+		StopCrashLooping:  getBool("GAMESERVER_CRASH_LOOP_STOP", true),
+
+		// File system defaults (10MB edit, 100MB upload, 512KB parts, 2GB extracted)
 		MaxFileEditSize: getInt64("GAMESERVER_MAX_FILE_EDIT_SIZE", 10*1024*1024),
 		MaxUploadSize:   getInt64("GAMESERVER_MAX_UPLOAD_SIZE", 100*1024*1024),
+		FileTailSize:    getInt64("GAMESERVER_FILE_TAIL_SIZE", 512*1024),
+		MaxExtractSize:  getInt64("GAMESERVER_MAX_EXTRACT_SIZE", 2*1024*1024*1024),
+
+		// Extra editable extensions, e.g. ".vdf,.acf"
+		EditableExtensions: getList("GAMESERVER_EDITABLE_EXTENSIONS"),
+
+		// Streaming defaults
+		StreamReconnectTimeout: getDuration("GAMESERVER_STREAM_RECONNECT_TIMEOUT", 2*time.Minute),
+		StatusRefreshInterval:  getDuration("GAMESERVER_STATUS_REFRESH_INTERVAL", 5*time.Second),
+
+		// Metrics defaults
+		MetricsInterval:  getDuration("GAMESERVER_METRICS_INTERVAL", 30*time.Second),
+		MetricsRetention: getDuration("GAMESERVER_METRICS_RETENTION", 24*time.Hour),
+
+		// Authentication defaults
+		AuthEnabled:   getBool("GAMESERVER_AUTH_ENABLED", true),
+		AdminUsername: getStr("GAMESERVER_ADMIN_USERNAME", "admin"),
+		AdminPassword: getStr("GAMESERVER_ADMIN_PASSWORD", ""),
+		SessionTTL:    getDuration("GAMESERVER_SESSION_TTL", 7*24*time.Hour),
+		SecureCookies: getBool("GAMESERVER_SECURE_COOKIES", false),
 	}
 }
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Audited actions
+const (
+	AuditStart        = "start"
+	AuditStop         = "stop"
+	AuditRestart      = "restart"
+	AuditPause        = "pause"
+	AuditUnpause      = "unpause"
+	AuditDelete       = "delete"
+	AuditRestore      = "restore"
+	AuditFileDelete   = "file_delete"
+	AuditBackupDelete = "backup_delete"
+	AuditTaskModify   = "task_modify"
+	AuditAdopt        = "adopt"
+	AuditOrphanRemove = "orphan_remove"
+)
+
+// AuditLog records who triggered a destructive or lifecycle action, and from where. Entries
+// outlive their gameserver, so a deleted server's history can still be looked up.
+type AuditLog struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Action       string    `json:"action" gorm:"type:varchar(30);not null;index"`
+	GameserverID string    `json:"gameserver_id,omitempty" gorm:"type:varchar(50);index"` // Empty for actions on every gameserver
+	Username     string    `json:"username,omitempty" gorm:"type:varchar(50)"`            // Empty when authentication is disabled
+	RequestID    string    `json:"request_id,omitempty" gorm:"type:varchar(100)"`
+	RemoteAddr   string    `json:"remote_addr,omitempty" gorm:"type:varchar(100)"`
+	Detail       string    `json:"detail,omitempty" gorm:"type:text"` // e.g. the deleted file or restored backup
+	Error        string    `json:"error,omitempty" gorm:"type:text"`  // Why the action failed, if it did
+	CreatedAt    time.Time `json:"created_at" gorm:"not null;index"`
+}
+
+// AuditPage is one page of the audit log, newest first
+type AuditPage struct {
+	Entries []*AuditLog
+	Page    int
+	Pages   int
+	Total   int64
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ErrCommandBlocked is returned when a console command is rejected by the gameserver's command policy
+var ErrCommandBlocked = errors.New("command blocked by policy")
+
+// commandVerb returns the lowercased first word of a console command, ignoring a leading slash
+func commandVerb(command string) string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(fields[0], "/"))
+}
+
+// matchesCommandPattern reports whether verb matches any glob pattern (e.g. "ban*")
+func matchesCommandPattern(verb string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), verb); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckCommand enforces the console command policy: denied patterns always win, and when an
+// allow list is set only matching commands may be sent
+func (g *Gameserver) CheckCommand(command string) error {
+	verb := commandVerb(command)
+	if matchesCommandPattern(verb, g.CommandDeny) {
+		return fmt.Errorf("%w: %q is denied on this server", ErrCommandBlocked, verb)
+	}
+	if len(g.CommandAllow) > 0 && !matchesCommandPattern(verb, g.CommandAllow) {
+		return fmt.Errorf("%w: %q is not in this server's allowed commands", ErrCommandBlocked, verb)
+	}
+	return nil
+}
@@ -1,5 +1,73 @@
 package models
 
+import "errors"
+
+// ErrOperationInProgress is returned when a gameserver is already being started, stopped or deleted
+var ErrOperationInProgress = errors.New("another operation is in progress for this gameserver")
+
+// ErrGameserverNotFound is returned when a gameserver ID doesn't match any gameserver
+var ErrGameserverNotFound = errors.New("gameserver not found")
+
+// ErrContainerNotFound is returned when a gameserver's container no longer exists in Docker
+var ErrContainerNotFound = errors.New("container not found")
+
+// ErrNameTaken is returned when a gameserver name is already used by another gameserver
+var ErrNameTaken = errors.New("gameserver name is already taken")
+
+// ErrNoPreviousImage is returned when rolling back a gameserver that has only ever run one image
+var ErrNoPreviousImage = errors.New("no previous image to roll back to")
+
+// ErrServerNotStopped is returned by operations that need the gameserver's container stopped
+var ErrServerNotStopped = errors.New("the gameserver must be stopped first")
+
+// ErrServerNotRunning is returned by operations that need the gameserver running, e.g. attaching a console
+var ErrServerNotRunning = errors.New("the gameserver is not running")
+
+// ErrInvalidPlayer is returned when a player name or IP isn't valid for a game's player list
+var ErrInvalidPlayer = errors.New("invalid player")
+
+// ErrFileNotFound is returned when reading a file that doesn't exist in a gameserver's container
+var ErrFileNotFound = errors.New("file not found")
+
+// ErrUnknownBackupDestination is returned when a backup destination isn't configured
+var ErrUnknownBackupDestination = errors.New("unknown backup destination")
+
+// ErrUnknownBulkAction is returned when a bulk action isn't start, stop or restart
+var ErrUnknownBulkAction = errors.New("unknown bulk action")
+
+// ErrInvalidArchive is returned when an archive can't be extracted, e.g. it is corrupt, too
+// large once unpacked, or has entries that would land outside the target directory
+var ErrInvalidArchive = errors.New("invalid archive")
+
+// ErrSecretNotRotatable is returned when rotating an env var that isn't a password
+var ErrSecretNotRotatable = errors.New("only password variables can be rotated")
+
+// ErrInvalidCronSchedule is returned for a task schedule that doesn't parse or never fires
+var ErrInvalidCronSchedule = errors.New("invalid cron schedule")
+
+// ErrInvalidResources is returned for a gameserver's CPU set or swap limit that the host can't apply
+var ErrInvalidResources = errors.New("invalid resource limits")
+
+// ErrOrphanNotFound is returned when a container or volume isn't among the orphans Docker
+// reconciliation reports, e.g. because it was adopted or removed meanwhile
+var ErrOrphanNotFound = errors.New("orphaned container or volume not found")
+
+// ErrCannotAdopt is returned when an orphaned container can't be turned back into a gameserver
+var ErrCannotAdopt = errors.New("container can't be adopted")
+
+// ErrInvalidCredentials is returned when a username and password, session or API token is
+// wrong, expired or revoked
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// ErrInvalidUser is returned when a username, password or role doesn't meet the requirements
+var ErrInvalidUser = errors.New("invalid user")
+
+// ErrUsernameTaken is returned when a username is already used by another user
+var ErrUsernameTaken = errors.New("username is already taken")
+
+// ErrLastAdmin is returned when deleting or demoting the only remaining admin
+var ErrLastAdmin = errors.New("at least one admin is required")
+
 // OperationError represents an error that occurred during a database or docker operation
 type OperationError struct {
 	Op  string
@@ -14,5 +82,9 @@ func (e *OperationError) Error() string {
 	return e.Op + ": " + e.Msg
 }
 
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
+
 // DatabaseError is deprecated, use OperationError instead
 type DatabaseError = OperationError
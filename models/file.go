@@ -1,9 +1,174 @@
 package models
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
 type FileInfo struct {
-	Name     string `json:"name"`
-	Path     string `json:"path"`
-	Size     int64  `json:"size"`
-	IsDir    bool   `json:"is_dir"`
-	Modified string `json:"modified"`
+	Name       string    `json:"name"`
+	Path       string    `json:"path"`
+	Size       int64     `json:"size"`
+	IsDir      bool      `json:"is_dir"`
+	IsSymlink  bool      `json:"is_symlink"`
+	LinkTarget string    `json:"link_target,omitempty"` // Symlink target, as stored in the link
+	Modified   string    `json:"modified"`              // ModifiedAt in local time, e.g. 2024-05-01 12:00:00
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// ContentHash returns the hex SHA-256 of a file's content, used to detect conflicting edits
+func ContentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Archive formats the file manager can extract
+const (
+	ArchiveZip   = "zip"
+	ArchiveTarGz = "tar.gz"
+	ArchiveTar   = "tar"
+)
+
+// ArchiveFormat returns the extractable archive format of a file, judged by its name, or ""
+func ArchiveFormat(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return ArchiveZip
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return ArchiveTarGz
+	case strings.HasSuffix(lower, ".tar"):
+		return ArchiveTar
+	}
+	return ""
+}
+
+// IsArchive reports whether the file is an archive the file manager can extract
+func (f *FileInfo) IsArchive() bool {
+	return !f.IsDir && ArchiveFormat(f.Name) != ""
+}
+
+// OffsiteBackup is a backup stored in the offsite backup store
+type OffsiteBackup struct {
+	Key      string    `json:"key"`  // Store key relative to the configured prefix
+	Name     string    `json:"name"` // Backup filename
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// backupLabelInvalid matches runs of characters not allowed in a backup label
+var backupLabelInvalid = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// maxBackupLabelLength caps the label appended to a backup's filename
+const maxBackupLabelLength = 50
+
+// Backup compressions, chosen per gameserver
+const (
+	BackupCompressionGzip = "gzip"
+	BackupCompressionZstd = "zstd" // Falls back to gzip when the game image has no zstd binary
+	BackupCompressionNone = "none"
+)
+
+// backupExtensions maps each backup compression to the extension of its archives
+var backupExtensions = map[string]string{
+	BackupCompressionGzip: ".tar.gz",
+	BackupCompressionZstd: ".tar.zst",
+	BackupCompressionNone: ".tar",
+}
+
+// IsBackupCompression reports whether compression is one backups can be made with
+func IsBackupCompression(compression string) bool {
+	_, ok := backupExtensions[compression]
+	return ok
+}
+
+// BackupCompressionOf returns the compression of a backup archive, judged by its name, or "" if
+// the name isn't a backup archive
+func BackupCompressionOf(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"):
+		return BackupCompressionGzip
+	case strings.HasSuffix(lower, ".tar.zst"):
+		return BackupCompressionZstd
+	case strings.HasSuffix(lower, ".tar"):
+		return BackupCompressionNone
+	}
+	return ""
+}
+
+// BackupContentType returns the MIME type of a backup archive, judged by its name
+func BackupContentType(name string) string {
+	switch BackupCompressionOf(name) {
+	case BackupCompressionGzip:
+		return "application/gzip"
+	case BackupCompressionZstd:
+		return "application/zstd"
+	case BackupCompressionNone:
+		return "application/x-tar"
+	}
+	return "application/octet-stream"
+}
+
+// IsBackupFilename reports whether name is a plain backup archive filename with no path components
+func IsBackupFilename(name string) bool {
+	return !strings.ContainsAny(name, "/\\'") && BackupCompressionOf(name) != ""
+}
+
+// BackupOptions controls how a backup archive is made
+type BackupOptions struct {
+	Compression string   // One of the BackupCompression values (empty = gzip)
+	Excludes    []string // Glob patterns, relative to the server directory, left out of the archive
+}
+
+// backupExcludePattern matches the characters allowed in a backup exclude pattern
+var backupExcludePattern = regexp.MustCompile(`^[A-Za-z0-9 ._*?\[\]/@+=-]+$`)
+
+// ValidateBackupExclude checks a backup exclude glob, e.g. logs/* or cache, which must be
+// relative to the server directory and safe to pass to tar
+func ValidateBackupExclude(pattern string) error {
+	if len(pattern) > 200 || !backupExcludePattern.MatchString(pattern) {
+		return fmt.Errorf("invalid backup exclude %q, use a glob like logs/* made of letters, digits and . _ - * ? [ ] /", pattern)
+	}
+	if strings.HasPrefix(pattern, "/") {
+		return fmt.Errorf("invalid backup exclude %q, must be relative to the server directory", pattern)
+	}
+	for _, part := range strings.Split(pattern, "/") {
+		if part == ".." {
+			return fmt.Errorf("invalid backup exclude %q, must not contain ..", pattern)
+		}
+	}
+	return nil
+}
+
+// BackupFilename names a backup made at t: backup-YYYY-MM-DD_HH-MM-SS.tar.gz, with an optional label
+// appended after the timestamp, e.g. backup-2024-05-01_12-00-00-old-host.tar.gz. The label is
+// reduced to letters, digits, - and _. The extension follows compression (empty = gzip).
+func BackupFilename(t time.Time, label, compression string) string {
+	name := "backup-" + t.Format("2006-01-02_15-04-05")
+	label = strings.Trim(backupLabelInvalid.ReplaceAllString(label, "-"), "-_")
+	if len(label) > maxBackupLabelLength {
+		label = strings.Trim(label[:maxBackupLabelLength], "-_")
+	}
+	if label != "" {
+		name += "-" + label
+	}
+	if ext, ok := backupExtensions[compression]; ok {
+		return name + ext
+	}
+	return name + backupExtensions[BackupCompressionGzip]
+}
+
+// BackupDestinationLocal is the gameserver's own volume, where every backup is created
+const BackupDestinationLocal = "local"
+
+// BackupDestinationResult is the outcome of storing a backup at one destination
+type BackupDestinationResult struct {
+	Destination string `json:"destination"`
+	Success     bool   `json:"success"`
+	Error       string `json:"error,omitempty"`
 }
@@ -1,7 +1,12 @@
 package models
 
 import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"gorm.io/gorm"
@@ -15,29 +20,176 @@ type ConfigVar struct {
 	Required    bool   `json:"required" gorm:"not null;default:false"`         // Whether this config is required
 	Default     string `json:"default" gorm:"type:text"`                       // Default value (empty if no default)
 	Description string `json:"description" gorm:"type:text"`                   // Help text for users
+	AppliesLive bool   `json:"applies_live"`                                   // Takes effect without a restart
+	LiveCommand string `json:"live_command,omitempty"`                         // Console command pushing a change live, text/template e.g. "sv_password {{.Value}}"
+
+	// Optional value constraints, checked on create/edit and mirrored as form input constraints
+	Allowed []string `json:"allowed,omitempty"` // Permitted values, matched case-insensitively
+	Min     *float64 `json:"min,omitempty"`     // Lower bound; setting Min or Max requires a number
+	Max     *float64 `json:"max,omitempty"`
+	Pattern string   `json:"pattern,omitempty"` // Regular expression the whole value must match
+}
+
+// ConfigError is a problem with one config var's value
+type ConfigError struct {
+	Var     string `json:"var"`
+	Message string `json:"message"`
+}
+
+// ConfigValidationError reports every config var that failed validation, so forms can show
+// errors next to the fields
+type ConfigValidationError struct {
+	Errors []ConfigError
+}
+
+func (e *ConfigValidationError) Error() string {
+	problems := make([]string, len(e.Errors))
+	for i, configErr := range e.Errors {
+		problems[i] = configErr.Var + " " + configErr.Message
+	}
+	return strings.Join(problems, "; ")
+}
+
+// Fields maps each invalid var to its problem
+func (e *ConfigValidationError) Fields() map[string]string {
+	fields := make(map[string]string, len(e.Errors))
+	for _, configErr := range e.Errors {
+		fields[configErr.Var] = configErr.Message
+	}
+	return fields
+}
+
+// CompilePattern compiles the var's Pattern anchored to the whole value, or returns nil if it has none
+func (c *ConfigVar) CompilePattern() (*regexp.Regexp, error) {
+	if c.Pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile("^(?:" + c.Pattern + ")$")
+}
+
+// selectValues returns the values a select var offers, parsed from "value1=Label 1,value2=Label 2"
+func (c *ConfigVar) selectValues() []string {
+	var values []string
+	for _, pair := range strings.Split(c.Options, ",") {
+		value, _, _ := strings.Cut(pair, "=")
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// CheckValue returns why value isn't valid for the var, or "" if it is. Empty values are left
+// to the Required check.
+func (c *ConfigVar) CheckValue(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	allowed := c.Allowed
+	if len(allowed) == 0 && c.Type == "select" {
+		allowed = c.selectValues()
+	}
+	if len(allowed) > 0 {
+		found := false
+		for _, option := range allowed {
+			if strings.EqualFold(option, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "must be one of " + strings.Join(allowed, ", ")
+		}
+	}
+
+	if c.Type == "number" || c.Min != nil || c.Max != nil {
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "must be a number"
+		}
+		if c.Min != nil && number < *c.Min {
+			return "must be at least " + strconv.FormatFloat(*c.Min, 'f', -1, 64)
+		}
+		if c.Max != nil && number > *c.Max {
+			return "must be at most " + strconv.FormatFloat(*c.Max, 'f', -1, 64)
+		}
+	}
+
+	if pattern, err := c.CompilePattern(); err == nil && pattern != nil && !pattern.MatchString(value) {
+		return "must match " + c.Pattern
+	}
+	return ""
+}
+
+// LiveConfigCommand is a console command that applies one changed config var to a running server
+type LiveConfigCommand struct {
+	Var     string
+	Command string
+}
+
+// ConfigTemplate is a config file rendered from the server's environment and written on first start
+type ConfigTemplate struct {
+	Path    string `json:"path"`    // Destination relative to /data/server
+	Content string `json:"content"` // text/template source, e.g. "max-players={{.MAX_PLAYERS}}"
 }
 
 type Game struct {
-	ID            string        `json:"id" gorm:"primaryKey;type:varchar(50)"`
-	Name          string        `json:"name" gorm:"type:varchar(100);not null"`
-	Slug          string        `json:"slug" gorm:"type:varchar(100);not null"` // Query slug for gameserver query library
-	Image         string        `json:"image" gorm:"type:varchar(500);not null"`
-	IconPath      string        `json:"icon_path" gorm:"type:varchar(500)"`     // Path to the game icon (.ico)
-	GridImagePath string        `json:"grid_image_path" gorm:"type:varchar(500)"` // Path to the grid image (.png)
-	PortMappings  []PortMapping `json:"port_mappings" gorm:"serializer:json"`
-	ConfigVars    []ConfigVar   `json:"config_vars" gorm:"serializer:json"`   // Required and optional configs
-	MinMemoryMB   int           `json:"min_memory_mb" gorm:"not null;default:512"` // Minimum memory to run
-	RecMemoryMB   int           `json:"rec_memory_mb" gorm:"not null;default:1024"` // Recommended memory
-	CreatedAt     time.Time     `json:"created_at"`
-	UpdatedAt     time.Time     `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
-}
-
-// ValidateEnvironment checks if all required config vars are provided in environment
-func (g *Game) ValidateEnvironment(env []string) []string {
-	var missing []string
-
-	// Convert environment slice to map for easy lookup
+	ID              string            `json:"id" gorm:"primaryKey;type:varchar(50)"`
+	Name            string            `json:"name" gorm:"type:varchar(100);not null"`
+	Slug            string            `json:"slug" gorm:"type:varchar(100);not null"` // Query slug for gameserver query library
+	Image           string            `json:"image" gorm:"type:varchar(500);not null"`
+	IconPath        string            `json:"icon_path" gorm:"type:varchar(500)"`       // Path to the game icon (.ico)
+	GridImagePath   string            `json:"grid_image_path" gorm:"type:varchar(500)"` // Path to the grid image (.png)
+	PortMappings    []PortMapping     `json:"port_mappings" gorm:"serializer:json"`
+	ConfigVars      []ConfigVar       `json:"config_vars" gorm:"serializer:json"`         // Required and optional configs
+	ConfigTemplates []ConfigTemplate  `json:"config_templates" gorm:"serializer:json"`    // Config files seeded on first start
+	MinMemoryMB     int               `json:"min_memory_mb" gorm:"not null;default:512"`  // Minimum memory to run
+	RecMemoryMB     int               `json:"rec_memory_mb" gorm:"not null;default:1024"` // Recommended memory
+	MinCPUCores     float64           `json:"min_cpu_cores" gorm:"not null;default:0"`    // Minimum CPU cores to run (0 = no guidance)
+	RecCPUCores     float64           `json:"rec_cpu_cores" gorm:"not null;default:0"`    // Recommended CPU cores (0 = no guidance)
+	Sysctls         map[string]string `json:"sysctls,omitempty" gorm:"serializer:json"`   // Kernel parameters applied to containers, e.g. net.core.rmem_max
+	CapAdd          []string          `json:"cap_add,omitempty" gorm:"serializer:json"`   // Linux capabilities added to containers, e.g. NET_ADMIN
+	ShmSizeMB       int               `json:"shm_size_mb" gorm:"not null;default:0"`      // Default /dev/shm size (0 = Docker's 64MB)
+	StopCommand     string            `json:"stop_command" gorm:"type:varchar(200)"`      // Console command sent before stopping, e.g. to save the world
+	StopGraceSecs   int               `json:"stop_grace_secs" gorm:"default:0"`           // How long the server gets to exit after StopCommand (0 = 30s)
+	SecretFiles     bool              `json:"secret_files" gorm:"not null;default:false"` // Image sources load-secrets.sh, so password vars may be passed as FILE__ files
+	Customized      bool              `json:"customized" gorm:"not null;default:false"`   // Edited in the panel, so reseeding built-in games leaves it alone
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt    `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// ReseedResult lists which built-in games a reseed added, refreshed and left alone
+type ReseedResult struct {
+	Added   []string `json:"added"`
+	Updated []string `json:"updated"`
+	Skipped []string `json:"skipped"` // Customized in the panel
+}
+
+// CPUWarning describes why a CPU limit is likely to starve the game, or returns "" if it looks fine.
+// Unlimited (0) never warns.
+func (g *Game) CPUWarning(cpuCores float64) string {
+	if cpuCores <= 0 {
+		return ""
+	}
+	if g.MinCPUCores > 0 && cpuCores < g.MinCPUCores {
+		return fmt.Sprintf("%g CPU cores is below the minimum of %g for %s", cpuCores, g.MinCPUCores, g.Name)
+	}
+	if g.RecCPUCores > 0 && cpuCores < g.RecCPUCores/2 {
+		return fmt.Sprintf("%g CPU cores is well below the recommended %g for %s", cpuCores, g.RecCPUCores, g.Name)
+	}
+	return ""
+}
+
+// GameVersions lists the versions selectable for a game and the env var that carries the choice
+type GameVersions struct {
+	Variable string   `json:"variable"`
+	Versions []string `json:"versions"`
+}
+
+// parseEnvironment converts a KEY=VALUE slice to a map for easy lookup
+func parseEnvironment(env []string) map[string]string {
 	envMap := make(map[string]string)
 	for _, envVar := range env {
 		parts := strings.SplitN(envVar, "=", 2)
@@ -45,15 +197,108 @@ func (g *Game) ValidateEnvironment(env []string) []string {
 			envMap[parts[0]] = parts[1]
 		}
 	}
+	return envMap
+}
+
+// RenderConfigTemplates renders the game's config templates with the server environment,
+// falling back to config var defaults for unset variables
+func (g *Game) RenderConfigTemplates(env []string) (map[string][]byte, error) {
+	if len(g.ConfigTemplates) == 0 {
+		return nil, nil
+	}
+
+	values := g.configValues(env)
+
+	files := make(map[string][]byte, len(g.ConfigTemplates))
+	for _, ct := range g.ConfigTemplates {
+		tmpl, err := template.New(ct.Path).Option("missingkey=zero").Parse(ct.Content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config template %s: %w", ct.Path, err)
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, values); err != nil {
+			return nil, fmt.Errorf("failed to render config template %s: %w", ct.Path, err)
+		}
+		files[ct.Path] = buf.Bytes()
+	}
+
+	return files, nil
+}
 
-	// Check each required config var
+// configValues returns every config var's effective value, falling back to its default
+func (g *Game) configValues(env []string) map[string]string {
+	values := make(map[string]string)
 	for _, configVar := range g.ConfigVars {
-		if configVar.Required {
-			if value, exists := envMap[configVar.Name]; !exists || value == "" {
-				missing = append(missing, configVar.Name)
-			}
+		values[configVar.Name] = configVar.Default
+	}
+	for key, value := range parseEnvironment(env) {
+		values[key] = value
+	}
+	return values
+}
+
+// LiveConfigCommands renders the live command of each live-applicable config var whose value
+// differs between oldEnv and newEnv. Templates see the new environment plus .Value, the var's new value.
+func (g *Game) LiveConfigCommands(oldEnv, newEnv []string) ([]LiveConfigCommand, error) {
+	oldValues, newValues := g.configValues(oldEnv), g.configValues(newEnv)
+
+	var commands []LiveConfigCommand
+	for _, configVar := range g.ConfigVars {
+		if !configVar.AppliesLive || configVar.LiveCommand == "" || oldValues[configVar.Name] == newValues[configVar.Name] {
+			continue
+		}
+
+		tmpl, err := template.New(configVar.Name).Option("missingkey=zero").Parse(configVar.LiveCommand)
+		if err != nil {
+			return nil, fmt.Errorf("invalid live command for %s: %w", configVar.Name, err)
+		}
+		data := make(map[string]string, len(newValues)+1)
+		for key, value := range newValues {
+			data[key] = value
 		}
+		data["Value"] = newValues[configVar.Name]
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render live command for %s: %w", configVar.Name, err)
+		}
+		commands = append(commands, LiveConfigCommand{Var: configVar.Name, Command: strings.TrimSpace(buf.String())})
 	}
+	return commands, nil
+}
+
+// MissingConfigDefaults returns the config vars with a default value that env doesn't set at all.
+// Vars present in env, even with an empty value, are treated as deliberately set.
+func (g *Game) MissingConfigDefaults(env []string) []ConfigVar {
+	envMap := parseEnvironment(env)
 
+	var missing []ConfigVar
+	for _, configVar := range g.ConfigVars {
+		if _, exists := envMap[configVar.Name]; !exists && configVar.Default != "" {
+			missing = append(missing, configVar)
+		}
+	}
 	return missing
 }
+
+// ValidateEnvironment checks that all required config vars are provided in environment and
+// that every set value meets its var's constraints
+func (g *Game) ValidateEnvironment(env []string) []ConfigError {
+	var problems []ConfigError
+
+	envMap := parseEnvironment(env)
+
+	for _, configVar := range g.ConfigVars {
+		value, exists := envMap[configVar.Name]
+		if configVar.Required && (!exists || value == "") {
+			problems = append(problems, ConfigError{Var: configVar.Name, Message: "is required"})
+			continue
+		}
+		if message := configVar.CheckValue(value); message != "" {
+			problems = append(problems, ConfigError{Var: configVar.Name, Message: message})
+		}
+	}
+
+	return problems
+}
@@ -1,6 +1,12 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -10,6 +16,7 @@ type GameserverStatus string
 
 const (
 	StatusStopped           GameserverStatus = "stopped"
+	StatusQueued            GameserverStatus = "queued" // Waiting for a free start slot
 	StatusPullingImage      GameserverStatus = "pulling_image"
 	StatusCreatingContainer GameserverStatus = "creating_container"
 	StatusStartingContainer GameserverStatus = "starting_container"
@@ -18,33 +25,175 @@ const (
 	StatusStopping          GameserverStatus = "stopping"
 	StatusDeleting          GameserverStatus = "deleting"
 	StatusError             GameserverStatus = "error"
+	StatusCrashLooping      GameserverStatus = "crash_looping"
+	StatusCrashed           GameserverStatus = "crashed"
+	StatusPaused            GameserverStatus = "paused" // Container frozen: keeps its memory but uses no CPU
 )
 
+// GameserverInspection pairs a gameserver's database record with what Docker reports for
+// its container, for spotting config drift
+type GameserverInspection struct {
+	Gameserver     *Gameserver     `json:"gameserver"`
+	Container      json.RawMessage `json:"container,omitempty"`
+	ContainerError string          `json:"container_error,omitempty"`
+}
+
+// ContainerState is a snapshot of the Docker-side state of a gameserver container
+type ContainerState struct {
+	Status       GameserverStatus
+	RestartCount int       // Restarts performed by the Docker restart policy
+	StartedAt    time.Time // When the current run of the container started
+	ConfigHash   string    // ContainerConfigHash of the gameserver when the container was created
+	ExitCode     int       // Exit code of the last run, once the container has exited
+	OOMKilled    bool      // The last run was killed for exceeding its memory limit
+}
+
+// Crashed reports whether an exited container's last run ended in failure rather than a clean exit
+func (s *ContainerState) Crashed() bool {
+	return s.ExitCode != 0 || s.OOMKilled
+}
+
+// ForceKilled reports whether an exited container's last run was ended by SIGKILL, e.g. because
+// it was still running when the stop timeout ran out
+func (s *ContainerState) ForceKilled() bool {
+	return s.ExitCode == 137 && !s.OOMKilled
+}
+
 // IsTransitional returns true if the status represents an in-progress state
 func (s GameserverStatus) IsTransitional() bool {
 	switch s {
-	case StatusPullingImage, StatusCreatingContainer, StatusStartingContainer, StatusWaitingReady, StatusStopping, StatusDeleting:
+	case StatusQueued, StatusPullingImage, StatusCreatingContainer, StatusStartingContainer, StatusWaitingReady, StatusStopping, StatusDeleting:
 		return true
 	}
 	return false
 }
 
+// ConsumesMemory reports whether a gameserver in this status holds, or is about to hold,
+// its memory allocation: running and paused servers, and those starting or stopping
+func (s GameserverStatus) ConsumesMemory() bool {
+	return s == StatusRunning || s == StatusPaused || s.IsTransitional()
+}
+
+// Bulk lifecycle actions applied to several gameservers at once
+const (
+	BulkActionStart   = "start"
+	BulkActionStop    = "stop"
+	BulkActionRestart = "restart"
+)
+
+// BulkActionResult is the outcome of a bulk action for one gameserver
+type BulkActionResult struct {
+	ID      string `json:"id"`
+	Name    string `json:"name,omitempty"`
+	OK      bool   `json:"ok"`
+	Skipped string `json:"skipped,omitempty"` // Why nothing was done, e.g. already running
+	Error   string `json:"error,omitempty"`
+}
+
+// StatusUpdate is a gameserver status change pushed to the dashboard
+type StatusUpdate struct {
+	ID             string           `json:"id"`
+	Status         GameserverStatus `json:"status"`
+	IsTransitional bool             `json:"isTransitional"`
+	RestartCount   int              `json:"restartCount"`
+	Removed        bool             `json:"removed,omitempty"` // The gameserver was deleted
+}
+
+// ImageUpdateCheck compares the image a gameserver runs with the latest one in its registry
+type ImageUpdateCheck struct {
+	Image           string `json:"image"`                    // Image the server is configured to run
+	Tracked         string `json:"tracked"`                  // Tagged image checked for updates
+	Pinned          bool   `json:"pinned"`                   // Pinned to a digest, so it never updates on its own
+	CurrentDigest   string `json:"current_digest,omitempty"` // Pinned digest, or that of the local copy (empty = not pulled yet)
+	LatestDigest    string `json:"latest_digest"`
+	UpdateAvailable bool   `json:"update_available"`
+}
+
+// GameserverPage is one page of gameservers matching a name/game search
+type GameserverPage struct {
+	Gameservers []*Gameserver `json:"gameservers"`
+	Query       string        `json:"query,omitempty"`
+	Page        int           `json:"page"`
+	PerPage     int           `json:"per_page"`
+	Total       int64         `json:"total"` // Matching gameservers across all pages
+}
+
+// TotalPages returns how many pages the matching gameservers span, at least one
+func (p *GameserverPage) TotalPages() int {
+	if p.PerPage <= 0 || p.Total == 0 {
+		return 1
+	}
+	return int((p.Total + int64(p.PerPage) - 1) / int64(p.PerPage))
+}
+
+// HasPrev reports whether there is a page before this one
+func (p *GameserverPage) HasPrev() bool {
+	return p.Page > 1
+}
+
+// HasNext reports whether there is a page after this one
+func (p *GameserverPage) HasNext() bool {
+	return p.Page < p.TotalPages()
+}
+
 type Gameserver struct {
-	ID           string           `json:"id" gorm:"primaryKey;type:varchar(50)"`
-	Name         string           `json:"name" gorm:"type:varchar(200);not null"`
-	GameID       string           `json:"game_id" gorm:"type:varchar(50);not null;index"`
-	ContainerID  string           `json:"container_id,omitempty" gorm:"type:varchar(100)"`
-	Status       GameserverStatus `json:"status" gorm:"type:varchar(20);not null;default:'stopped'"`
-	PortMappings []PortMapping    `json:"port_mappings" gorm:"serializer:json"`
-	MemoryMB     int              `json:"memory_mb" gorm:"not null;default:1024"`   // Memory limit in MB
-	CPUCores     float64          `json:"cpu_cores" gorm:"not null;default:0"`      // CPU cores (0 = unlimited)
-	MaxBackups   int              `json:"max_backups" gorm:"not null;default:10"`   // Maximum number of backups to keep (0 = unlimited)
-	Environment  []string         `json:"environment,omitempty" gorm:"serializer:json"`
-	EnabledMods  []string         `json:"enabled_mods,omitempty" gorm:"serializer:json"`
-	Volumes      []string         `json:"volumes,omitempty" gorm:"serializer:json"`
-	CreatedAt    time.Time        `json:"created_at"`
-	UpdatedAt    time.Time        `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt   `json:"deleted_at,omitempty" gorm:"index"`
+	ID            string           `json:"id" gorm:"primaryKey;type:varchar(50)"`
+	Name          string           `json:"name" gorm:"type:varchar(200);not null"`
+	GameID        string           `json:"game_id" gorm:"type:varchar(50);not null;index"`
+	ContainerID   string           `json:"container_id,omitempty" gorm:"type:varchar(100)"`
+	Status        GameserverStatus `json:"status" gorm:"type:varchar(20);not null;default:'stopped'"`
+	PortMappings  []PortMapping    `json:"port_mappings" gorm:"serializer:json"`
+	MemoryMB      int              `json:"memory_mb" gorm:"not null;default:1024"`   // Memory limit in MB
+	CPUCores      float64          `json:"cpu_cores" gorm:"not null;default:0"`      // CPU cores (0 = unlimited)
+	MaxBackups    int              `json:"max_backups" gorm:"not null;default:10"`   // Maximum number of backups to keep (0 = unlimited)
+	StartPriority int              `json:"start_priority" gorm:"not null;default:0"` // Start-all order: lower starts first and stops last
+	BackupOnStop  bool             `json:"backup_on_stop" gorm:"default:false"`      // Back up whenever the running server is stopped
+	ShmSizeMB     int              `json:"shm_size_mb" gorm:"not null;default:0"`    // /dev/shm size override (0 = game default)
+	Environment   []string         `json:"environment,omitempty" gorm:"serializer:json"`
+	EnabledMods   []string         `json:"enabled_mods,omitempty" gorm:"serializer:json"`
+	Volumes       []string         `json:"volumes,omitempty" gorm:"serializer:json"`
+	Tags          []string         `json:"tags,omitempty" gorm:"serializer:json"`          // Lowercase labels used to group servers, e.g. for task templates
+	CommandAllow  []string         `json:"command_allow,omitempty" gorm:"serializer:json"` // Console command patterns permitted (empty = all)
+	CommandDeny   []string         `json:"command_deny,omitempty" gorm:"serializer:json"`  // Console command patterns always rejected
+	StartupError  string           `json:"startup_error,omitempty" gorm:"type:text"`       // Why the last start failed, with the container's final log lines
+	Timezone      string           `json:"timezone,omitempty" gorm:"type:varchar(64)"`     // IANA zone passed to the container as TZ (empty = panel default)
+	DataVolume    string           `json:"data_volume,omitempty" gorm:"type:varchar(200)"` // Volume or absolute host path holding /data (empty = volume named after the server)
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt   `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Image tracking, so a bad image update can be rolled back
+	ImageOverride       string `json:"image_override,omitempty" gorm:"type:varchar(300)"`        // Image used instead of the game's, e.g. a pinned digest
+	ImageTag            string `json:"image_tag,omitempty" gorm:"type:varchar(300)"`             // Tag or digest applied to the game's image (empty = game default)
+	ImageDigest         string `json:"image_digest,omitempty" gorm:"type:varchar(300)"`          // Image the server last started with
+	PreviousImageDigest string `json:"previous_image_digest,omitempty" gorm:"type:varchar(300)"` // Image it ran before that, the rollback target
+
+	// Where backups are stored, and how the last backup went at each destination
+	BackupDestinations []string                  `json:"backup_destinations,omitempty" gorm:"serializer:json"` // Destination names, always including local (nil = every configured destination)
+	LastBackupResults  []BackupDestinationResult `json:"last_backup_results,omitempty" gorm:"serializer:json"`
+
+	// How backup archives are made
+	BackupCompression string   `json:"backup_compression,omitempty" gorm:"type:varchar(10)"` // gzip, zstd or none (empty = gzip)
+	BackupExcludes    []string `json:"backup_excludes,omitempty" gorm:"serializer:json"`     // Globs relative to the server directory left out of backups, e.g. logs/*
+
+	// Container networking overrides, for servers that can't use the host's resolvers
+	DNS        []string `json:"dns,omitempty" gorm:"serializer:json"`         // Resolver IPs (empty = panel default)
+	ExtraHosts []string `json:"extra_hosts,omitempty" gorm:"serializer:json"` // /etc/hosts entries as host:ip, added to the panel defaults
+
+	// What Docker does when the game process exits with an error
+	RestartOnCrash     bool `json:"restart_on_crash" gorm:"not null;default:false"` // Restart the container after a non-zero exit
+	MaxRestartAttempts int  `json:"max_restart_attempts" gorm:"not null;default:0"` // Restarts before giving up (0 = unlimited)
+
+	// Host resource controls beyond the memory and CPU limits
+	CPUSetCPUs string `json:"cpuset_cpus,omitempty" gorm:"type:varchar(100)"` // Host cores the container may run on, e.g. 0-3 or 0,2 (empty = any)
+	SwapMB     int    `json:"swap_mb" gorm:"not null;default:0"`              // Swap allowed on top of the memory limit (0 = Docker default, -1 = none)
+
+	// The game didn't exit within the stop timeout the last time it was stopped and was killed
+	LastStopForced bool `json:"last_stop_forced" gorm:"not null;default:false"`
+
+	// App versions that created and last modified the server, for debugging upgrades (empty = before tracking)
+	CreatedByVersion string `json:"created_by_version,omitempty" gorm:"type:varchar(100)"`
+	UpdatedByVersion string `json:"updated_by_version,omitempty" gorm:"type:varchar(100)"`
 
 	// Relations (removed foreign key constraint to avoid migration issues)
 	Game *Game `json:"game,omitempty" gorm:"-"`
@@ -54,11 +203,184 @@ type Gameserver struct {
 	Image    string  `json:"image" gorm:"-"`     // From Game.Image
 	IconPath string  `json:"icon_path" gorm:"-"` // From Game.IconPath
 	MemoryGB float64 `json:"memory_gb" gorm:"-"` // MemoryMB converted to GB for display
+	GameSlug string  `json:"-" gorm:"-"`         // From Game.Slug
+
+	// Container tuning required by the game (derived from Game)
+	Sysctls       map[string]string `json:"-" gorm:"-"`
+	CapAdd        []string          `json:"-" gorm:"-"`
+	GameShmSizeMB int               `json:"-" gorm:"-"`
+
+	// Clean shutdown required by the game (derived from Game)
+	StopCommand string        `json:"-" gorm:"-"`
+	StopGrace   time.Duration `json:"-" gorm:"-"`
+
+	// Env var names whose values are passed as files instead of plain env (derived from password config vars)
+	SecretVars      []string `json:"-" gorm:"-"`
+	GameSecretFiles bool     `json:"-" gorm:"-"` // From Game.SecretFiles: the image can load FILE__ secrets
+
+	// Restarts performed by Docker since the container was created (derived field)
+	RestartCount int `json:"restart_count" gorm:"-"`
+
+	// Live player count and map, filled in by list views that query running servers (derived field)
+	Query *QueryResult `json:"query,omitempty" gorm:"-"`
 
 	// Volume info (derived field)
 	VolumeInfo *VolumeInfo `json:"volume_info,omitempty" gorm:"-"`
 }
 
+// BacksUpTo reports whether the gameserver's backups are stored at the named destination
+func (g *Gameserver) BacksUpTo(destination string) bool {
+	if g.BackupDestinations == nil || destination == BackupDestinationLocal {
+		return true
+	}
+	for _, name := range g.BackupDestinations {
+		if name == destination {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainerConfigHash fingerprints the settings baked into a container, so a
+// persistent container can be reused until any of them change
+func (g *Gameserver) ContainerConfigHash() string {
+	data, _ := json.Marshal(struct {
+		Name         string
+		Image        string
+		PortMappings []PortMapping
+		MemoryMB     int
+		CPUCores     float64
+		Environment  []string
+		EnabledMods  []string
+		Volumes      []string
+		Sysctls      map[string]string
+		CapAdd       []string
+		ShmSizeMB    int
+		Timezone     string
+		DNS          []string `json:",omitempty"`
+		ExtraHosts   []string `json:",omitempty"`
+		Restart      string
+		CPUSetCPUs   string `json:",omitempty"`
+		SwapMB       int    `json:",omitempty"`
+	}{g.Name, g.Image, g.PortMappings, g.MemoryMB, g.CPUCores, g.Environment, g.EnabledMods, g.Volumes, g.Sysctls, g.CapAdd, g.EffectiveShmSizeMB(), g.Timezone, g.DNS, g.ExtraHosts, g.RestartPolicy(), g.CPUSetCPUs, g.SwapMB})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// RestartPolicy returns the Docker restart policy for the gameserver's container, with the
+// retry limit appended as Docker's CLI writes it, e.g. "on-failure:5"
+func (g *Gameserver) RestartPolicy() string {
+	if !g.RestartOnCrash {
+		return "no"
+	}
+	if g.MaxRestartAttempts > 0 {
+		return "on-failure:" + strconv.Itoa(g.MaxRestartAttempts)
+	}
+	return "on-failure"
+}
+
+// EffectiveShmSizeMB returns the /dev/shm size for the container, falling back to the game's default
+func (g *Gameserver) EffectiveShmSizeMB() int {
+	if g.ShmSizeMB > 0 {
+		return g.ShmSizeMB
+	}
+	return g.GameShmSizeMB
+}
+
+// BackupOptions returns how the gameserver's backups are made, defaulting to gzip
+func (g *Gameserver) BackupOptions() BackupOptions {
+	compression := g.BackupCompression
+	if !IsBackupCompression(compression) {
+		compression = BackupCompressionGzip
+	}
+	return BackupOptions{Compression: compression, Excludes: g.BackupExcludes}
+}
+
+// HasTag reports whether the gameserver carries tag
+func (g *Gameserver) HasTag(tag string) bool {
+	for _, t := range g.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddonDir returns the directory, relative to /data/server, the server loads plugins or mods
+// from based on its SERVER_TYPE, or "" if its server type doesn't load any
+func (g *Gameserver) AddonDir() string {
+	switch strings.ToLower(parseEnvironment(g.Environment)["SERVER_TYPE"]) {
+	case "paper":
+		return "plugins"
+	case "fabric", "forge":
+		return "mods"
+	}
+	return ""
+}
+
+// clientDefaultPorts are the ports game clients connect to when none is given, keyed by game slug
+var clientDefaultPorts = map[string]int{
+	"minecraft":        25565,
+	"garrys-mod":       27015,
+	"counter-strike-2": 27015,
+	"rust":             28015,
+}
+
+// ConnectAddress returns the address players connect to on host, formatted for the game:
+// host alone when the game port is the client's default, host:port otherwise. Empty if the
+// server has no ports or no public host is configured.
+func (g *Gameserver) ConnectAddress(host string) string {
+	port := g.GetGamePort()
+	if port == nil || host == "" {
+		return ""
+	}
+	if port.HostPort == clientDefaultPorts[g.GameSlug] {
+		return host
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port.HostPort))
+}
+
+// ImagePinned reports whether the server runs an image pinned to a digest
+func (g *Gameserver) ImagePinned() bool {
+	return ImagePinnedDigest(g.Image) != ""
+}
+
+// WithImageTag returns image with its tag replaced by tag, or pinned to tag when it is a
+// digest ("sha256:..."). An empty tag leaves image unchanged.
+func WithImageTag(image, tag string) string {
+	tag = strings.TrimLeft(tag, ":@")
+	if tag == "" {
+		return image
+	}
+	if strings.HasPrefix(tag, "sha256:") {
+		return ImageRepository(image) + "@" + tag
+	}
+	return ImageRepository(image) + ":" + tag
+}
+
+// ImageRepository strips the tag and digest from an image reference, keeping any registry port
+func ImageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// ImagePinnedDigest returns the digest an image reference is pinned to, or "" when it follows
+// a tag. Bare image IDs, as recorded for local builds, count as pinned.
+func ImagePinnedDigest(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[i+1:]
+	}
+	if strings.HasPrefix(image, "sha256:") {
+		return image
+	}
+	return ""
+}
+
 // GetGamePort returns the primary game connection port
 func (g *Gameserver) GetGamePort() *PortMapping {
 	for i := range g.PortMappings {
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// ServerGroup is a set of gameservers managed together, e.g. the backends behind a Velocity proxy
+type ServerGroup struct {
+	ID          string    `json:"id" gorm:"primaryKey;type:varchar(50)"`
+	Name        string    `json:"name" gorm:"type:varchar(200);not null"`
+	Description string    `json:"description" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Member gameservers, in start priority order (derived field)
+	Members []*Gameserver `json:"members,omitempty" gorm:"-"`
+}
+
+// ServerGroupMember links a gameserver to a group. A gameserver can belong to several groups.
+type ServerGroupMember struct {
+	GroupID      string `gorm:"primaryKey;type:varchar(50)"`
+	GameserverID string `gorm:"primaryKey;type:varchar(50);index"`
+}
+
+// HasMember reports whether the gameserver with id is in the group
+func (g *ServerGroup) HasMember(id string) bool {
+	for _, member := range g.Members {
+		if member.ID == id {
+			return true
+		}
+	}
+	return false
+}
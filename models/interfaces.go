@@ -1,37 +1,80 @@
 package models
 
 import (
+	"context"
+	"encoding/json"
 	"io"
+	"time"
 )
 
 // StatusCallback is called during startup to report status changes
 type StatusCallback func(status GameserverStatus)
 
+// LogStreamOptions selects where a followed container log stream starts
+type LogStreamOptions struct {
+	Tail  int       // Lines of history sent before following (0 = none)
+	Since time.Time // Resume from this log timestamp instead of sending a tail (zero = use Tail)
+}
+
 type DockerManagerInterface interface {
+	Ping() error
 	CreateContainer(server *Gameserver) error
 	CreateContainerWithCallback(server *Gameserver, callback StatusCallback) error
 	StartContainer(containerID string) error
 	StopContainer(containerID string) error
+	PauseContainer(containerID string) error
+	UnpauseContainer(containerID string) error
 	RemoveContainer(containerID string) error
+	BuildImage(tag, dockerfile string, contextArchive io.Reader) (string, error)
+	PullImage(imageName string) error
+	RemoteImageDigest(imageName string) (string, error)
+	LocalImageDigest(imageName string) (string, error)
 	SendCommand(containerID string, command string) (string, error)
+	AttachInteractive(containerID string) (io.ReadWriteCloser, error)
 	GetContainerStatus(containerID string) (GameserverStatus, error)
-	StreamContainerLogs(containerID string) (io.ReadCloser, error)
+	GetContainerState(containerID string) (*ContainerState, error)
+	ContainerImageDigest(containerID string) (string, error)
+	InspectContainer(containerID string, secretVars []string) (json.RawMessage, error)
+	StreamContainerLogs(containerID string, opts LogStreamOptions) (io.ReadCloser, error)
+	ContainerLogTail(containerID string, lines int) string
 	StreamContainerStats(containerID string) (io.ReadCloser, error)
-	ListContainers() ([]string, error)
+	ContainerResourceUsage(containerID string) (*ResourceUsage, error)
+	ListContainers() ([]*ContainerSummary, error)
+	InspectContainerSettings(containerID string) (*ContainerSettings, error)
+	WatchContainerEvents(ctx context.Context, onEvent func(gameserverID string)) error
 	CreateVolume(volumeName string) error
 	RemoveVolume(volumeName string) error
+	ListVolumes() ([]*VolumeInfo, error)
 	GetVolumeInfo(volumeName string) (*VolumeInfo, error)
 	GetVolumeNameForServer(server *Gameserver) string
-	CreateBackup(gameserverID, backupPath string) error
+	VolumeHasServerData(volumeName, image string) (bool, error)
+	MigrateData(source, target, image string) error
+	CreateBackup(containerID string, opts BackupOptions) (string, error)
 	RestoreBackup(gameserverID, backupPath string) error
 	CleanupOldBackups(containerID string, maxBackups int) error
+	VerifyBackup(server *Gameserver, bootTime time.Duration) (string, error)
+	ImportBackup(containerID, backupFilename string, body io.Reader, size int64) error
+	DiskUsage(containerID string) (*DiskUsage, error)
 	// File operations
 	ListFiles(containerID string, path string) ([]*FileInfo, error)
+	FilesModifiedSince(containerID string, dir string, since time.Time) ([]string, error)
 	ReadFile(containerID string, path string) ([]byte, error)
+	ReadFileWithHash(containerID string, path string) ([]byte, string, error)
+	ReadFileRange(containerID string, path string, offset, limit int64) ([]byte, int64, error)
 	WriteFile(containerID string, path string, content []byte) error
+	WriteMissingFiles(containerID string, files map[string][]byte) ([]string, error)
 	CreateDirectory(containerID string, path string) error
 	DeletePath(containerID string, path string) error
 	DownloadFile(containerID string, path string) (io.ReadCloser, error)
 	UploadFile(containerID string, destPath string, reader io.Reader) error
+	ExtractArchive(containerID, destDir, name string, archive io.ReaderAt, size, maxSize int64) (int, error)
+	ExtractFile(containerID, archivePath, destDir string, maxSize int64) (int, error)
 	RenameFile(containerID string, oldPath string, newPath string) error
 }
+
+// BackupStore keeps copies of backups off the host, e.g. in an S3 bucket
+type BackupStore interface {
+	Upload(key string, body io.Reader, size int64) error
+	Download(key string) (io.ReadCloser, int64, error)
+	List(prefix string) ([]*OffsiteBackup, error)
+}
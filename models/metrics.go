@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// GameserverMetric is one resource usage sample of a running gameserver, recorded by the
+// metrics collector and pruned once it ages out of the retention window
+type GameserverMetric struct {
+	ID           uint      `json:"-" gorm:"primaryKey"`
+	GameserverID string    `json:"-" gorm:"type:varchar(50);not null;index"`
+	Timestamp    time.Time `json:"timestamp" gorm:"not null;index"`
+	CPUPercent   float64   `json:"cpu_percent"`
+	MemUsedMB    float64   `json:"mem_used_mb"`
+}
+
+// ResourceUsage is a point-in-time reading of a container's CPU and memory use
+type ResourceUsage struct {
+	CPUPercent    float64
+	MemoryUsedMB  float64
+	MemoryLimitMB float64
+}
+
+// RollupMetrics averages samples into consecutive buckets of step starting at from, for
+// charting a window at a fixed resolution. Samples must be oldest first; buckets without
+// samples (e.g. while the server was stopped) are left out, and each point is stamped with
+// the start of its bucket.
+func RollupMetrics(samples []*GameserverMetric, from time.Time, step time.Duration) []*GameserverMetric {
+	points := []*GameserverMetric{}
+	if step <= 0 {
+		return points
+	}
+
+	var bucket *GameserverMetric
+	var count int
+	flush := func() {
+		if bucket != nil {
+			bucket.CPUPercent /= float64(count)
+			bucket.MemUsedMB /= float64(count)
+			points = append(points, bucket)
+		}
+	}
+
+	for _, sample := range samples {
+		if sample.Timestamp.Before(from) {
+			continue
+		}
+		start := from.Add(sample.Timestamp.Sub(from) / step * step)
+		if bucket == nil || !bucket.Timestamp.Equal(start) {
+			flush()
+			bucket = &GameserverMetric{GameserverID: sample.GameserverID, Timestamp: start}
+			count = 0
+		}
+		bucket.CPUPercent += sample.CPUPercent
+		bucket.MemUsedMB += sample.MemUsedMB
+		count++
+	}
+	flush()
+	return points
+}
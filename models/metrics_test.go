@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRollupMetricsAveragesBuckets(t *testing.T) {
+	from := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(offset time.Duration, cpu, mem float64) *GameserverMetric {
+		return &GameserverMetric{GameserverID: "gs-1", Timestamp: from.Add(offset), CPUPercent: cpu, MemUsedMB: mem}
+	}
+	samples := []*GameserverMetric{
+		at(-time.Second, 99, 999), // Before the window
+		at(0, 10, 100),
+		at(20*time.Second, 20, 200),
+		at(59*time.Second, 30, 300),
+		// Nothing in the second minute: the server was stopped
+		at(2*time.Minute, 50, 500),
+		at(2*time.Minute+30*time.Second, 70, 700),
+	}
+
+	points := RollupMetrics(samples, from, time.Minute)
+
+	want := []struct {
+		start    time.Time
+		cpu, mem float64
+	}{
+		{from, 20, 200},
+		{from.Add(2 * time.Minute), 60, 600},
+	}
+	if len(points) != len(want) {
+		t.Fatalf("got %d points, want %d: %+v", len(points), len(want), points)
+	}
+	for i, w := range want {
+		p := points[i]
+		if !p.Timestamp.Equal(w.start) || p.CPUPercent != w.cpu || p.MemUsedMB != w.mem {
+			t.Errorf("point %d = (%s, %v, %v), want (%s, %v, %v)", i, p.Timestamp, p.CPUPercent, p.MemUsedMB, w.start, w.cpu, w.mem)
+		}
+		if p.GameserverID != "gs-1" {
+			t.Errorf("point %d GameserverID = %q, want gs-1", i, p.GameserverID)
+		}
+	}
+}
+
+func TestRollupMetricsEmpty(t *testing.T) {
+	from := time.Now()
+	samples := []*GameserverMetric{{Timestamp: from, CPUPercent: 1}}
+	if points := RollupMetrics(nil, from, time.Minute); points == nil || len(points) != 0 {
+		t.Errorf("no samples gave %v, want an empty slice", points)
+	}
+	if points := RollupMetrics(samples, from, 0); len(points) != 0 {
+		t.Errorf("zero step gave %d points, want none", len(points))
+	}
+}
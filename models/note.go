@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// ServerNote is a manual entry in a gameserver's log, e.g. "upgraded plugin X" or
+// "player reported lag at 8pm", kept so operators have the context behind changes
+type ServerNote struct {
+	ID           string    `json:"id" gorm:"primaryKey;type:varchar(50)"`
+	GameserverID string    `json:"gameserver_id" gorm:"type:varchar(50);not null;index"`
+	Note         string    `json:"note" gorm:"type:text;not null"`
+	Author       string    `json:"author,omitempty" gorm:"type:varchar(100)"`
+	CreatedAt    time.Time `json:"created_at"`
+}
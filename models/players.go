@@ -0,0 +1,118 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrUnknownPlayerList is returned when a game has no player list by the given name
+var ErrUnknownPlayerList = errors.New("unknown player list")
+
+// PlayerList describes a game's whitelist or ban list: the file the game keeps it in and the
+// console commands that change it
+type PlayerList struct {
+	Name          string         `json:"name"`  // e.g. "whitelist", "bans"
+	Label         string         `json:"label"` // Shown in the UI
+	File          string         `json:"file"`  // JSON file, relative to /data/server
+	Key           string         `json:"-"`     // Field of each file entry naming the player
+	AddCommand    string         `json:"-"`     // Console command, %s is the player
+	RemoveCommand string         `json:"-"`
+	Pattern       *regexp.Regexp `json:"-"` // Valid player values, so they can't inject extra commands
+}
+
+// PlayerListEntry is one player on a player list
+type PlayerListEntry struct {
+	Name    string `json:"name"`
+	Reason  string `json:"reason,omitempty"`
+	Expires string `json:"expires,omitempty"`
+}
+
+// PlayerListEntries is a player list with its current contents
+type PlayerListEntries struct {
+	PlayerList
+	Entries []PlayerListEntry `json:"entries"`
+	Error   string            `json:"error,omitempty"`
+}
+
+var (
+	minecraftNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{1,16}$`)
+	ipPattern            = regexp.MustCompile(`^[0-9A-Fa-f:.]{2,45}$`)
+)
+
+// playerLists are the player lists of supported games, keyed by game slug
+var playerLists = map[string][]PlayerList{
+	"minecraft": {
+		{Name: "whitelist", Label: "Whitelist", File: "whitelist.json", Key: "name",
+			AddCommand: "whitelist add %s", RemoveCommand: "whitelist remove %s", Pattern: minecraftNamePattern},
+		{Name: "bans", Label: "Banned Players", File: "banned-players.json", Key: "name",
+			AddCommand: "ban %s", RemoveCommand: "pardon %s", Pattern: minecraftNamePattern},
+		{Name: "ip-bans", Label: "Banned IPs", File: "banned-ips.json", Key: "ip",
+			AddCommand: "ban-ip %s", RemoveCommand: "pardon-ip %s", Pattern: ipPattern},
+	},
+}
+
+// PlayerListsForGame returns the player lists a game supports, or nil if it has none
+func PlayerListsForGame(slug string) []PlayerList {
+	return playerLists[slug]
+}
+
+// FindPlayerList returns the named player list of a game
+func FindPlayerList(slug, name string) (PlayerList, error) {
+	for _, list := range playerLists[slug] {
+		if list.Name == name {
+			return list, nil
+		}
+	}
+	return PlayerList{}, ErrUnknownPlayerList
+}
+
+// Command returns the console command that adds or removes player from the list
+func (l PlayerList) Command(add bool, player string) (string, error) {
+	if !l.Pattern.MatchString(player) {
+		return "", fmt.Errorf("invalid player %q", player)
+	}
+	if add {
+		return fmt.Sprintf(l.AddCommand, player), nil
+	}
+	return fmt.Sprintf(l.RemoveCommand, player), nil
+}
+
+// Parse reads the list's entries from the contents of its file
+func (l PlayerList) Parse(content []byte) ([]PlayerListEntry, error) {
+	if len(strings.TrimSpace(string(content))) == 0 {
+		return nil, nil
+	}
+
+	var raw []map[string]interface{}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", l.File, err)
+	}
+
+	entries := make([]PlayerListEntry, 0, len(raw))
+	for _, item := range raw {
+		name, _ := item[l.Key].(string)
+		if name == "" {
+			continue
+		}
+		entry := PlayerListEntry{Name: name}
+		entry.Reason, _ = item["reason"].(string)
+		if expires, _ := item["expires"].(string); expires != "forever" {
+			entry.Expires = expires
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Contains reports whether player is on the list, ignoring case as games do
+func (e *PlayerListEntries) Contains(player string) bool {
+	for _, entry := range e.Entries {
+		if strings.EqualFold(entry.Name, player) {
+			return true
+		}
+	}
+	return false
+}
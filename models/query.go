@@ -0,0 +1,20 @@
+package models
+
+// QueryExtra is a game-specific query detail shown as a label/value pair
+type QueryExtra struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// QueryResult is a game-agnostic view of a gameserver query for templates and the API
+type QueryResult struct {
+	Online      bool         `json:"online"`
+	Name        string       `json:"name,omitempty"`
+	Map         string       `json:"map,omitempty"`
+	Version     string       `json:"version,omitempty"`
+	Players     int          `json:"players"`
+	MaxPlayers  int          `json:"max_players"`
+	PlayerNames []string     `json:"player_names,omitempty"` // Only when the protocol reports a player list
+	Ping        int          `json:"ping"`
+	Extras      []QueryExtra `json:"extras,omitempty"` // Sorted by key
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// ContainerSummary is a gameserver container as Docker lists it
+type ContainerSummary struct {
+	ID      string            `json:"id"`
+	Name    string            `json:"name"` // Without Docker's leading slash
+	Image   string            `json:"image"`
+	State   string            `json:"state"` // Docker's state, e.g. running or exited
+	Created time.Time         `json:"created"`
+	Labels  map[string]string `json:"labels"`
+	Ports   []PortMapping     `json:"ports"`            // Published ports, one per container port and protocol
+	Volume  string            `json:"volume,omitempty"` // Volume or host path mounted at /data
+}
+
+// GameserverID returns the gameserver the container was created for
+func (c *ContainerSummary) GameserverID() string {
+	return c.Labels["gameserver.id"]
+}
+
+// GameserverName returns the gameserver name the container was created with
+func (c *ContainerSummary) GameserverName() string {
+	return c.Labels["gameserver.name"]
+}
+
+// ContainerSettings are the settings a container was created with, recovered from Docker so an
+// orphaned container can be adopted back into a gameserver
+type ContainerSettings struct {
+	Env        []string // Variables set on the container, without those the image sets itself
+	MemoryMB   int
+	CPUCores   float64 // 0 = unlimited
+	CPUSetCPUs string
+}
+
+// DockerState is Docker cross-referenced with the database: gameserver containers and managed
+// volumes that no gameserver record accounts for, e.g. after a crash between removing a
+// container and updating the database, or a row deleted by hand
+type DockerState struct {
+	Containers       int                 `json:"containers"` // Gameserver containers checked
+	Volumes          int                 `json:"volumes"`    // Managed volumes checked
+	OrphanContainers []*ContainerSummary `json:"orphan_containers"`
+	OrphanVolumes    []*VolumeInfo       `json:"orphan_volumes"` // Not used by any gameserver or orphaned container
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -9,10 +10,39 @@ import (
 type TaskType string
 
 const (
-	TaskTypeRestart TaskType = "restart"
-	TaskTypeBackup  TaskType = "backup"
+	TaskTypeRestart      TaskType = "restart"
+	TaskTypeBackup       TaskType = "backup"
+	TaskTypeVerifyBackup TaskType = "verify_backup" // Test-restore the latest backup into a throwaway container
+	TaskTypeCommand      TaskType = "command"       // Send console commands to the running server
 )
 
+// IsValid reports whether the task type is one the scheduler can run
+func (t TaskType) IsValid() bool {
+	switch t {
+	case TaskTypeRestart, TaskTypeBackup, TaskTypeVerifyBackup, TaskTypeCommand:
+		return true
+	}
+	return false
+}
+
+// SchedulePreset is a common schedule offered in the task form, so a schedule can be picked
+// without writing cron
+type SchedulePreset struct {
+	Label string
+	Cron  string
+}
+
+// SchedulePresets are the preset schedules in the order they're offered
+var SchedulePresets = []SchedulePreset{
+	{Label: "Every 30 minutes", Cron: "*/30 * * * *"},
+	{Label: "Every hour", Cron: "0 * * * *"},
+	{Label: "Every 6 hours", Cron: "0 */6 * * *"},
+	{Label: "Every 12 hours", Cron: "0 */12 * * *"},
+	{Label: "Every day at 4 AM", Cron: "0 4 * * *"},
+	{Label: "Weekly on Sunday at 4 AM", Cron: "0 4 * * 0"},
+	{Label: "Monthly on the 1st at 4 AM", Cron: "0 4 1 * *"},
+}
+
 type TaskStatus string
 
 const (
@@ -20,19 +50,100 @@ const (
 	TaskStatusDisabled TaskStatus = "disabled"
 )
 
+type TaskRunStatus string
+
+const (
+	TaskRunSuccess TaskRunStatus = "success"
+	TaskRunFailed  TaskRunStatus = "failed"
+)
+
 type ScheduledTask struct {
-	ID           string     `json:"id" gorm:"primaryKey;type:varchar(50)"`
-	GameserverID string     `json:"gameserver_id" gorm:"type:varchar(50);not null;index"`
-	Name         string     `json:"name" gorm:"type:varchar(200);not null"`
-	Type         TaskType   `json:"type" gorm:"type:varchar(20);not null"`
-	Status       TaskStatus `json:"status" gorm:"type:varchar(20);not null;default:'active'"`
-	CronSchedule string     `json:"cron_schedule" gorm:"type:varchar(100);not null"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at"`
+	ID           string         `json:"id" gorm:"primaryKey;type:varchar(50)"`
+	GameserverID string         `json:"gameserver_id" gorm:"type:varchar(50);not null;index"`
+	Name         string         `json:"name" gorm:"type:varchar(200);not null"`
+	Type         TaskType       `json:"type" gorm:"type:varchar(20);not null"`
+	Command      string         `json:"command,omitempty" gorm:"type:text"` // Console commands of a command task, one per line
+	Status       TaskStatus     `json:"status" gorm:"type:varchar(20);not null;default:'active'"`
+	CronSchedule string         `json:"cron_schedule" gorm:"type:varchar(100);not null"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
-	LastRun      *time.Time `json:"last_run,omitempty"`
-	NextRun      *time.Time `json:"next_run,omitempty"`
+	LastRun      *time.Time     `json:"last_run,omitempty"`
+	NextRun      *time.Time     `json:"next_run,omitempty"`
+	RetryCount   int            `json:"retry_count" gorm:"not null;default:0"`               // Failed attempts since the last scheduled run
+	LastStatus   TaskRunStatus  `json:"last_status,omitempty" gorm:"type:varchar(20)"`       // Result of the most recent run (empty = never run)
+	LastError    string         `json:"last_error,omitempty" gorm:"type:text"`               // Error from the most recent failed run
+	TemplateID   string         `json:"template_id,omitempty" gorm:"type:varchar(50);index"` // Task template that manages this task (empty = created by hand)
 
-	// Relations (removed foreign key constraint to avoid migration issues) 
+	// Relations (removed foreign key constraint to avoid migration issues)
 	Gameserver *Gameserver `json:"gameserver,omitempty" gorm:"-"`
 }
+
+// Commands returns the console commands a command task sends, in order
+func (t *ScheduledTask) Commands() []string {
+	return SplitTaskCommand(t.Command)
+}
+
+// SplitTaskCommand splits a command task's text into its non-blank lines
+func SplitTaskCommand(text string) []string {
+	var commands []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			commands = append(commands, line)
+		}
+	}
+	return commands
+}
+
+// TaskExecution records one run of a scheduled task by the scheduler, retries included
+type TaskExecution struct {
+	ID           string        `json:"id" gorm:"primaryKey;type:varchar(50)"`
+	TaskID       string        `json:"task_id" gorm:"type:varchar(50);not null;index"`
+	StartedAt    time.Time     `json:"started_at" gorm:"index"`
+	FinishedAt   time.Time     `json:"finished_at"`
+	Status       TaskRunStatus `json:"status" gorm:"type:varchar(20);not null"`
+	ErrorMessage string        `json:"error_message,omitempty" gorm:"type:text"`
+}
+
+// Duration returns how long the run took, rounded for display
+func (e *TaskExecution) Duration() time.Duration {
+	d := e.FinishedAt.Sub(e.StartedAt)
+	if d < time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(time.Second)
+}
+
+// TaskTemplate defines a scheduled task that is kept in sync on every gameserver carrying Tag
+type TaskTemplate struct {
+	ID           string    `json:"id" gorm:"primaryKey;type:varchar(50)"`
+	Name         string    `json:"name" gorm:"type:varchar(200);not null"`
+	Tag          string    `json:"tag" gorm:"type:varchar(100);not null;index"`
+	Type         TaskType  `json:"type" gorm:"type:varchar(20);not null"`
+	Command      string    `json:"command,omitempty" gorm:"type:text"` // Console commands for command tasks, one per line
+	CronSchedule string    `json:"cron_schedule" gorm:"type:varchar(100);not null"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Gameservers currently carrying the tag (derived field)
+	MatchCount int `json:"match_count" gorm:"-"`
+}
+
+// RunningTask is a scheduled task the scheduler is executing right now
+type RunningTask struct {
+	TaskID       string    `json:"task_id"`
+	Name         string    `json:"name"`
+	Type         TaskType  `json:"type"`
+	GameserverID string    `json:"gameserver_id"`
+	StartedAt    time.Time `json:"started_at"`
+}
+
+// SchedulerState is the task scheduler's in-memory view of its tasks, which can lag behind
+// the database until the next check
+type SchedulerState struct {
+	CheckInterval string          `json:"check_interval"`
+	LastCheck     *time.Time      `json:"last_check,omitempty"` // Nil until the first check
+	NextCheck     *time.Time      `json:"next_check,omitempty"`
+	Running       *RunningTask    `json:"running,omitempty"` // Nil when idle
+	Tasks         []ScheduledTask `json:"tasks"`             // Active tasks as of the last check
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"net/http"
+	"time"
+)
+
+// User roles
+const (
+	RoleAdmin  = "admin"  // Full access, including managing users
+	RoleViewer = "viewer" // Read-only access
+)
+
+// Token kinds
+const (
+	TokenSession = "session" // Set as a cookie by the login form
+	TokenAPI     = "api"     // Sent as a bearer token by API clients
+)
+
+// User is an account that can sign in to the panel
+type User struct {
+	ID           string     `json:"id" gorm:"primaryKey;type:varchar(50)"`
+	Username     string     `json:"username" gorm:"type:varchar(50);not null;uniqueIndex"`
+	PasswordHash string     `json:"-" gorm:"not null"`
+	Role         string     `json:"role" gorm:"type:varchar(20);not null;default:'admin'"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
+}
+
+// IsAdmin reports whether the user has full access
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// CanAccess reports whether the user's role permits a request with the given method.
+// Viewers may only make requests that don't change anything.
+func (u *User) CanAccess(method string) bool {
+	if u.IsAdmin() {
+		return true
+	}
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// UserToken is a login session or an API token. Only a SHA-256 hash of the token is stored,
+// so a leaked database can't be used to sign in.
+type UserToken struct {
+	ID         string     `json:"id" gorm:"primaryKey;type:varchar(50)"`
+	UserID     string     `json:"-" gorm:"type:varchar(50);not null;index"`
+	Kind       string     `json:"kind" gorm:"type:varchar(20);not null"`
+	Name       string     `json:"name,omitempty" gorm:"type:varchar(100)"`
+	TokenHash  string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	Prefix     string     `json:"prefix,omitempty" gorm:"type:varchar(20)"` // Start of an API token, to tell them apart
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"` // nil = never
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// Expired reports whether the token is past its expiry
+func (t *UserToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
@@ -1,7 +1,13 @@
 package models
 
 import (
+	"crypto/rand"
 	"fmt"
+	"math/big"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 )
@@ -9,6 +15,116 @@ import (
 // Utility functions
 var idCounter int64
 
+// secretAlphabet avoids symbols so generated secrets are safe in config files and shell commands
+const secretAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// GenerateSecret returns a cryptographically random alphanumeric string of the given length
+func GenerateSecret(length int) (string, error) {
+	secret := make([]byte, length)
+	max := big.NewInt(int64(len(secretAlphabet)))
+	for i := range secret {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		secret[i] = secretAlphabet[n.Int64()]
+	}
+	return string(secret), nil
+}
+
+// maskedSecret replaces secret values in debugging output
+const maskedSecret = "********"
+
+// sensitiveEnvMarkers flag env vars as secret by name, for secrets the game config doesn't declare
+var sensitiveEnvMarkers = []string{"PASSWORD", "SECRET", "TOKEN", "API_KEY"}
+
+// MaskSecretEnv returns a copy of env with the values of secretVars, and of any var whose
+// name looks sensitive, replaced by a placeholder. Empty values are left as-is.
+func MaskSecretEnv(env []string, secretVars []string) []string {
+	masked := make([]string, len(env))
+	for i, entry := range env {
+		name, value, ok := strings.Cut(entry, "=")
+		if ok && value != "" && isSecretEnvName(name, secretVars) {
+			entry = name + "=" + maskedSecret
+		}
+		masked[i] = entry
+	}
+	return masked
+}
+
+func isSecretEnvName(name string, secretVars []string) bool {
+	for _, secret := range secretVars {
+		if name == secret {
+			return true
+		}
+	}
+	upper := strings.ToUpper(name)
+	for _, marker := range sensitiveEnvMarkers {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostnamePattern matches a DNS hostname usable in /etc/hosts
+var hostnamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*$`)
+
+// ValidateDNSServer checks that server is an IP address Docker can use as a resolver
+func ValidateDNSServer(server string) error {
+	if net.ParseIP(server) == nil {
+		return fmt.Errorf("invalid DNS server %q, must be an IP address", server)
+	}
+	return nil
+}
+
+// ValidateExtraHost checks an /etc/hosts entry in Docker's "host:ip" form. The IP may be
+// host-gateway, which Docker resolves to the host's address.
+func ValidateExtraHost(entry string) error {
+	host, ip, ok := strings.Cut(entry, ":")
+	if !ok || !hostnamePattern.MatchString(host) || len(host) > 253 {
+		return fmt.Errorf("invalid extra host %q, must be host:ip", entry)
+	}
+	if ip != "host-gateway" && net.ParseIP(ip) == nil {
+		return fmt.Errorf("invalid extra host %q, %q is not an IP address", entry, ip)
+	}
+	return nil
+}
+
+// cpuSetPartPattern matches one core or range of a cpuset
+var cpuSetPartPattern = regexp.MustCompile(`^([0-9]+)(?:-([0-9]+))?$`)
+
+// ValidateCPUSet checks a cpuset such as "0-3" or "0,2,4-5": comma-separated cores and
+// ascending ranges, all below numCPU
+func ValidateCPUSet(cpuset string, numCPU int) error {
+	for _, part := range strings.Split(cpuset, ",") {
+		match := cpuSetPartPattern.FindStringSubmatch(part)
+		if match == nil {
+			return fmt.Errorf("invalid CPU set %q, use cores and ranges like 0-3 or 0,2", cpuset)
+		}
+		start, err := strconv.Atoi(match[1])
+		end := start
+		if err == nil && match[2] != "" {
+			end, err = strconv.Atoi(match[2])
+		}
+		if err != nil || end < start {
+			return fmt.Errorf("invalid CPU set %q, use cores and ranges like 0-3 or 0,2", cpuset)
+		}
+		if end >= numCPU {
+			return fmt.Errorf("CPU set %q uses core %d, but the host only has cores 0-%d", cpuset, end, numCPU-1)
+		}
+	}
+	return nil
+}
+
+// ValidateSwapMB checks a swap limit: -1 for no swap, 0 for Docker's default or a size in MB
+func ValidateSwapMB(swapMB int) error {
+	if swapMB < -1 {
+		return fmt.Errorf("invalid swap limit %d MB, use -1 for no swap, 0 for the default or a size in MB", swapMB)
+	}
+	return nil
+}
+
 func GenerateID() string {
 	now := time.Now()
 	// Use atomic increment to ensure uniqueness even within the same nanosecond
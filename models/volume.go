@@ -1,5 +1,13 @@
 package models
 
+import "strings"
+
+// IsHostPath reports whether a gameserver data location is a host directory bind-mounted
+// into the container rather than a named Docker volume
+func IsHostPath(location string) bool {
+	return strings.HasPrefix(location, "/")
+}
+
 type VolumeInfo struct {
 	Name       string            `json:"name"`
 	MountPoint string            `json:"mount_point"`
@@ -7,3 +15,14 @@ type VolumeInfo struct {
 	CreatedAt  string            `json:"created_at"`
 	Labels     map[string]string `json:"labels"`
 }
+
+// DiskUsage is how much disk a gameserver's data takes up
+type DiskUsage struct {
+	ServerBytes  int64 `json:"server_bytes"`  // Game files in /data/server
+	BackupsBytes int64 `json:"backups_bytes"` // Backup archives in /data/backups
+}
+
+// TotalBytes is the server files and backups together
+func (u *DiskUsage) TotalBytes() int64 {
+	return u.ServerBytes + u.BackupsBytes
+}
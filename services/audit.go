@@ -0,0 +1,68 @@
+package services
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// auditPageSize is how many entries one page of the audit log shows
+const auditPageSize = 50
+
+// AuditDatabase defines the database operations needed by the audit service
+type AuditDatabase interface {
+	CreateAuditLog(entry *models.AuditLog) error
+	ListAuditLogs(gameserverID string, offset, limit int) ([]*models.AuditLog, int64, error)
+}
+
+// AuditService records who triggered destructive and lifecycle actions
+type AuditService struct {
+	db AuditDatabase
+}
+
+// NewAuditService creates an audit service
+func NewAuditService(db AuditDatabase) *AuditService {
+	return &AuditService{db: db}
+}
+
+// Record stores an audit log entry. Failing to record never fails the action itself, so
+// errors are logged instead of returned.
+func (as *AuditService) Record(entry *models.AuditLog) {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	event := log.Info()
+	if entry.Error != "" {
+		event = log.Warn().Str("error", entry.Error)
+	}
+	event.Str("action", entry.Action).Str("gameserver_id", entry.GameserverID).Str("user", entry.Username).
+		Str("request_id", entry.RequestID).Str("remote_addr", entry.RemoteAddr).Str("detail", entry.Detail).Msg("Audit")
+
+	if err := as.db.CreateAuditLog(entry); err != nil {
+		log.Error().Err(err).Str("action", entry.Action).Str("gameserver_id", entry.GameserverID).Msg("Failed to record audit log entry")
+	}
+}
+
+// List returns a page of the audit log, newest first, optionally only for one gameserver.
+// Pages are numbered from 1; pages past the end are clamped to the last one.
+func (as *AuditService) List(gameserverID string, page int) (*models.AuditPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	entries, total, err := as.db.ListAuditLogs(gameserverID, (page-1)*auditPageSize, auditPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := int((total + auditPageSize - 1) / auditPageSize)
+	if pages < 1 {
+		pages = 1
+	}
+	if page > pages {
+		return as.List(gameserverID, pages)
+	}
+	return &models.AuditPage{Entries: entries, Page: page, Pages: pages, Total: total}, nil
+}
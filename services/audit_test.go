@@ -0,0 +1,105 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"0xkowalskidev/gameservers/database"
+	"0xkowalskidev/gameservers/models"
+)
+
+func newTestAuditService(t *testing.T) *AuditService {
+	t.Helper()
+	db, err := database.NewDatabaseManager(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewAuditService(db)
+}
+
+func TestAuditRecordStampsAndStoresEntries(t *testing.T) {
+	audit := newTestAuditService(t)
+
+	before := time.Now()
+	audit.Record(&models.AuditLog{Action: models.AuditDelete, GameserverID: "gs-1", Username: "admin",
+		RequestID: "req-1", RemoteAddr: "10.0.0.2:51234", Detail: "world/level.dat", Error: "permission denied"})
+
+	page, err := audit.List("", 1)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(page.Entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(page.Entries))
+	}
+	entry := page.Entries[0]
+	if entry.ID == 0 || entry.CreatedAt.Before(before.Add(-time.Second)) {
+		t.Errorf("entry ID %d created %s, want it stored and stamped with the time of the action", entry.ID, entry.CreatedAt)
+	}
+	if entry.Action != models.AuditDelete || entry.GameserverID != "gs-1" || entry.Username != "admin" || entry.RequestID != "req-1" ||
+		entry.RemoteAddr != "10.0.0.2:51234" || entry.Detail != "world/level.dat" || entry.Error != "permission denied" {
+		t.Errorf("stored entry = %+v, want every field kept", entry)
+	}
+}
+
+func TestAuditListNewestFirstAndPaginated(t *testing.T) {
+	audit := newTestAuditService(t)
+
+	// 120 entries a minute apart, alternating between two gameservers
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 120; i++ {
+		gameserverID := "gs-even"
+		if i%2 == 1 {
+			gameserverID = "gs-odd"
+		}
+		audit.Record(&models.AuditLog{Action: models.AuditStart, GameserverID: gameserverID, CreatedAt: start.Add(time.Duration(i) * time.Minute)})
+	}
+
+	tests := []struct {
+		gameserverID  string
+		page          int
+		wantPage      int
+		wantPages     int
+		wantTotal     int64
+		wantEntries   int
+		wantNewestMin int // Minutes after start of the page's first entry
+	}{
+		{"", 1, 1, 3, 120, 50, 119},
+		{"", 2, 2, 3, 120, 50, 69},
+		{"", 3, 3, 3, 120, 20, 19},
+		{"", 0, 1, 3, 120, 50, 119}, // Pages start at 1
+		{"", 99, 3, 3, 120, 20, 19}, // Past the end clamps to the last page
+		{"gs-odd", 1, 1, 2, 60, 50, 119},
+		{"gs-even", 2, 2, 2, 60, 10, 18},
+		{"gs-missing", 1, 1, 1, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		page, err := audit.List(tt.gameserverID, tt.page)
+		if err != nil {
+			t.Fatalf("List(%q, %d): %v", tt.gameserverID, tt.page, err)
+		}
+		if page.Page != tt.wantPage || page.Pages != tt.wantPages || page.Total != tt.wantTotal || len(page.Entries) != tt.wantEntries {
+			t.Errorf("List(%q, %d) = page %d of %d, %d of %d entries; want page %d of %d, %d of %d entries",
+				tt.gameserverID, tt.page, page.Page, page.Pages, len(page.Entries), page.Total,
+				tt.wantPage, tt.wantPages, tt.wantEntries, tt.wantTotal)
+			continue
+		}
+		if len(page.Entries) == 0 {
+			continue
+		}
+		if want := start.Add(time.Duration(tt.wantNewestMin) * time.Minute); !page.Entries[0].CreatedAt.Equal(want) {
+			t.Errorf("List(%q, %d) starts at %s, want %s", tt.gameserverID, tt.page, page.Entries[0].CreatedAt, want)
+		}
+		for i := 1; i < len(page.Entries); i++ {
+			if !page.Entries[i].CreatedAt.Before(page.Entries[i-1].CreatedAt) {
+				t.Errorf("List(%q, %d) entry %d is not older than the one before it", tt.gameserverID, tt.page, i)
+				break
+			}
+			if tt.gameserverID != "" && page.Entries[i].GameserverID != tt.gameserverID {
+				t.Errorf("List(%q, %d) includes an entry for %s", tt.gameserverID, tt.page, page.Entries[i].GameserverID)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CleanupDatabase defines the database operations needed by the cleanup job
+type CleanupDatabase interface {
+	PurgeOrphanedData() (int64, error)
+	Vacuum() error
+}
+
+// DataCleaner periodically removes data left behind by deleted gameservers and compacts the database
+type DataCleaner struct {
+	db       CleanupDatabase
+	interval time.Duration
+	ticker   *time.Ticker
+	done     chan struct{}
+}
+
+// NewDataCleaner creates a cleanup job that runs every interval (0 disables it)
+func NewDataCleaner(db CleanupDatabase, interval time.Duration) *DataCleaner {
+	return &DataCleaner{
+		db:       db,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs a cleanup immediately and then on every interval
+func (dc *DataCleaner) Start() {
+	if dc.interval <= 0 {
+		log.Info().Msg("Database cleanup disabled")
+		return
+	}
+
+	log.Info().Dur("interval", dc.interval).Msg("Starting database cleanup job")
+	dc.ticker = time.NewTicker(dc.interval)
+
+	go func() {
+		dc.run()
+		for {
+			select {
+			case <-dc.done:
+				return
+			case <-dc.ticker.C:
+				dc.run()
+			}
+		}
+	}()
+}
+
+// Stop halts the cleanup job
+func (dc *DataCleaner) Stop() {
+	if dc.ticker != nil {
+		dc.ticker.Stop()
+	}
+	close(dc.done)
+}
+
+// VolumePruneDocker defines the Docker operation needed by the volume prune job
+type VolumePruneDocker interface {
+	PruneAnonymousVolumes() (int, uint64, error)
+}
+
+// VolumePruner periodically removes dangling anonymous volumes left behind by recreated containers
+type VolumePruner struct {
+	docker   VolumePruneDocker
+	interval time.Duration
+	ticker   *time.Ticker
+	done     chan struct{}
+}
+
+// NewVolumePruner creates a prune job that runs every interval (0 disables it)
+func NewVolumePruner(docker VolumePruneDocker, interval time.Duration) *VolumePruner {
+	return &VolumePruner{
+		docker:   docker,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs a prune immediately and then on every interval
+func (vp *VolumePruner) Start() {
+	if vp.interval <= 0 {
+		log.Info().Msg("Anonymous volume pruning disabled")
+		return
+	}
+
+	log.Info().Dur("interval", vp.interval).Msg("Starting anonymous volume prune job")
+	vp.ticker = time.NewTicker(vp.interval)
+
+	go func() {
+		vp.run()
+		for {
+			select {
+			case <-vp.done:
+				return
+			case <-vp.ticker.C:
+				vp.run()
+			}
+		}
+	}()
+}
+
+// Stop halts the prune job
+func (vp *VolumePruner) Stop() {
+	if vp.ticker != nil {
+		vp.ticker.Stop()
+	}
+	close(vp.done)
+}
+
+func (vp *VolumePruner) run() {
+	removed, reclaimed, err := vp.docker.PruneAnonymousVolumes()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to prune anonymous volumes")
+		return
+	}
+	log.Info().Int("volumes", removed).Uint64("reclaimed_bytes", reclaimed).Msg("Anonymous volume prune completed")
+}
+
+func (dc *DataCleaner) run() {
+	purged, err := dc.db.PurgeOrphanedData()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to purge orphaned data")
+		return
+	}
+
+	start := time.Now()
+	if err := dc.db.Vacuum(); err != nil {
+		log.Error().Err(err).Msg("Failed to vacuum database")
+		return
+	}
+	log.Info().Int64("purged_rows", purged).Dur("vacuum_duration", time.Since(start)).Msg("Database cleanup completed")
+}
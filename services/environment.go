@@ -0,0 +1,115 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// Form field names the gameserver form posts environment variables under
+const (
+	ConfigFieldPrefix     = "config_"           // Followed by the config var name, one field per game config var
+	ExtraEnvironmentField = "environment_extra" // Free-form KEY=VALUE lines for variables the game doesn't define
+)
+
+// envNamePattern matches a portable environment variable name
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// EnvironmentField is a game config var paired with the value the form shows for it
+type EnvironmentField struct {
+	models.ConfigVar
+	Value string // The server's value, or the var's default when the server doesn't set it
+	IsSet bool   // Whether the server's environment sets the var, even to an empty value
+}
+
+// EnvironmentForm is a gameserver environment split into the game's config var fields and everything else
+type EnvironmentForm struct {
+	Fields []EnvironmentField
+	Extra  []string // KEY=VALUE entries for variables the game doesn't define, in their original order
+}
+
+// MergeEnvironment pairs each of the game's config vars with its value from env, prefilling
+// defaults for vars env doesn't set. Pass a nil env for a new gameserver.
+func MergeEnvironment(game *models.Game, env []string) EnvironmentForm {
+	values := make(map[string]string, len(env))
+	known := make(map[string]bool, len(game.ConfigVars))
+	for _, configVar := range game.ConfigVars {
+		known[configVar.Name] = true
+	}
+
+	var form EnvironmentForm
+	for _, entry := range env {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		if known[key] {
+			values[key] = value
+		} else {
+			form.Extra = append(form.Extra, entry)
+		}
+	}
+
+	for _, configVar := range game.ConfigVars {
+		value, isSet := values[configVar.Name]
+		if !isSet {
+			value = configVar.Default
+		}
+		form.Fields = append(form.Fields, EnvironmentField{ConfigVar: configVar, Value: value, IsSet: isSet})
+	}
+	return form
+}
+
+// Values maps each config var name to the value its form field starts with
+func (f EnvironmentForm) Values() map[string]string {
+	values := make(map[string]string, len(f.Fields))
+	for _, field := range f.Fields {
+		values[field.Name] = field.Value
+	}
+	return values
+}
+
+// ExtraText renders the extra variables as the advanced area's KEY=VALUE lines
+func (f EnvironmentForm) ExtraText() string {
+	return strings.Join(f.Extra, "\n")
+}
+
+// ParseEnvironmentForm builds a gameserver environment from the posted config var fields and
+// advanced KEY=VALUE lines. Values are kept exactly as typed, and a posted empty config field is
+// saved as an explicit empty value; fields the form didn't post are left unset.
+func ParseEnvironmentForm(game *models.Game, form url.Values) ([]string, error) {
+	var env []string
+	known := make(map[string]bool, len(game.ConfigVars))
+	for _, configVar := range game.ConfigVars {
+		known[configVar.Name] = true
+		if values, ok := form[ConfigFieldPrefix+configVar.Name]; ok && len(values) > 0 {
+			env = append(env, configVar.Name+"="+values[0])
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(form.Get(ExtraEnvironmentField), "\n") {
+		// Only the line ending is stripped; the value after "=" may carry meaningful whitespace
+		line = strings.TrimSuffix(line, "\r")
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !envNamePattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid environment variable %q: use KEY=VALUE with a name of letters, digits and underscores", strings.TrimSpace(line))
+		}
+		if known[key] {
+			return nil, fmt.Errorf("%s is a %s setting, set it in the configuration fields instead", key, game.Name)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("environment variable %s is set more than once", key)
+		}
+		seen[key] = true
+		env = append(env, key+"="+value)
+	}
+	return env, nil
+}
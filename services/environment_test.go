@@ -0,0 +1,131 @@
+package services
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+func environmentGame() *models.Game {
+	return &models.Game{
+		Name: "Minecraft",
+		ConfigVars: []models.ConfigVar{
+			{Name: "EULA", Required: true},
+			{Name: "MOTD", Default: "A Minecraft Server"},
+			{Name: "DIFFICULTY", Default: "normal"},
+		},
+	}
+}
+
+func TestMergeEnvironment(t *testing.T) {
+	env := []string{"CUSTOM=1", "EULA=true", "MOTD=", "EXTRA_OPTS=-Xss1M -Dfoo=bar", "MALFORMED"}
+	form := MergeEnvironment(environmentGame(), env)
+
+	want := []struct {
+		name, value string
+		isSet       bool
+	}{
+		{"EULA", "true", true},
+		{"MOTD", "", true}, // An explicit empty value is not replaced by the default
+		{"DIFFICULTY", "normal", false},
+	}
+	if len(form.Fields) != len(want) {
+		t.Fatalf("got %d fields, want %d: %+v", len(form.Fields), len(want), form.Fields)
+	}
+	for i, w := range want {
+		f := form.Fields[i]
+		if f.Name != w.name || f.Value != w.value || f.IsSet != w.isSet {
+			t.Errorf("field %d = (%s, %q, set %v), want (%s, %q, set %v)", i, f.Name, f.Value, f.IsSet, w.name, w.value, w.isSet)
+		}
+	}
+
+	if wantExtra := []string{"CUSTOM=1", "EXTRA_OPTS=-Xss1M -Dfoo=bar"}; !reflect.DeepEqual(form.Extra, wantExtra) {
+		t.Errorf("Extra = %q, want %q", form.Extra, wantExtra)
+	}
+	if got := form.ExtraText(); got != "CUSTOM=1\nEXTRA_OPTS=-Xss1M -Dfoo=bar" {
+		t.Errorf("ExtraText = %q", got)
+	}
+	wantValues := map[string]string{"EULA": "true", "MOTD": "", "DIFFICULTY": "normal"}
+	if got := form.Values(); !reflect.DeepEqual(got, wantValues) {
+		t.Errorf("Values = %v, want %v", got, wantValues)
+	}
+}
+
+func TestMergeEnvironmentNewServerUsesDefaults(t *testing.T) {
+	form := MergeEnvironment(environmentGame(), nil)
+	for _, f := range form.Fields {
+		if f.IsSet || f.Value != f.Default {
+			t.Errorf("field %s = (%q, set %v), want its default %q unset", f.Name, f.Value, f.IsSet, f.Default)
+		}
+	}
+	if len(form.Extra) != 0 {
+		t.Errorf("Extra = %q, want none", form.Extra)
+	}
+}
+
+func TestParseEnvironmentFormKeepsValuesAsTyped(t *testing.T) {
+	form := url.Values{
+		"config_EULA": {"true"},
+		"config_MOTD": {"  Welcome, friends!  "},
+		// config_DIFFICULTY isn't posted, so it stays unset
+		ExtraEnvironmentField: {"# tuning\r\n\r\n  JVM_OPTS= -Xss1M -Dfoo=bar \r\nEMPTY=\r\n   \n"},
+	}
+	env, err := ParseEnvironmentForm(environmentGame(), form)
+	if err != nil {
+		t.Fatalf("ParseEnvironmentForm: %v", err)
+	}
+	want := []string{"EULA=true", "MOTD=  Welcome, friends!  ", "JVM_OPTS= -Xss1M -Dfoo=bar ", "EMPTY="}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("env = %q, want %q", env, want)
+	}
+}
+
+func TestParseEnvironmentFormSavesEmptyFieldsAsEmptyValues(t *testing.T) {
+	game := environmentGame()
+	form := url.Values{"config_EULA": {""}, "config_MOTD": {""}, "config_DIFFICULTY": {"hard"}}
+	env, err := ParseEnvironmentForm(game, form)
+	if err != nil {
+		t.Fatalf("ParseEnvironmentForm: %v", err)
+	}
+	want := []string{"EULA=", "MOTD=", "DIFFICULTY=hard"}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("env = %q, want %q", env, want)
+	}
+
+	// An empty value round-trips as set rather than falling back to the default
+	if field := MergeEnvironment(game, env).Fields[1]; !field.IsSet || field.Value != "" {
+		t.Errorf("MOTD field = (%q, set %v), want an explicit empty value", field.Value, field.IsSet)
+	}
+	// An empty required var still fails validation
+	problems := game.ValidateEnvironment(env)
+	if len(problems) != 1 || problems[0].Var != "EULA" {
+		t.Errorf("ValidateEnvironment = %+v, want only EULA to be required", problems)
+	}
+}
+
+func TestParseEnvironmentFormRejectsBadExtraLines(t *testing.T) {
+	tests := map[string]string{
+		"no equals":       "JUST_A_NAME",
+		"bad name":        "1BAD=value",
+		"name with space": "BAD NAME=value",
+		"empty name":      "=value",
+		"duplicate":       "A=1\nA=2",
+		"config var":      "MOTD=hello",
+	}
+	for name, extra := range tests {
+		t.Run(name, func(t *testing.T) {
+			env, err := ParseEnvironmentForm(environmentGame(), url.Values{ExtraEnvironmentField: {extra}})
+			if err == nil {
+				t.Fatalf("ParseEnvironmentForm(%q) = %q, want an error", extra, env)
+			}
+		})
+	}
+
+	_, err := ParseEnvironmentForm(environmentGame(), url.Values{ExtraEnvironmentField: {"MOTD=hello"}})
+	if err == nil || !strings.Contains(err.Error(), "configuration fields") {
+		t.Errorf("config var error = %v, want it to point at the configuration fields", err)
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// metricsConcurrency is how many containers are sampled at once; each stats reading takes about a second
+const metricsConcurrency = 8
+
+// MetricsDatabase defines the database operations needed by the metrics collector
+type MetricsDatabase interface {
+	ListGameservers() ([]*models.Gameserver, error)
+	CreateGameserverMetrics(metrics []*models.GameserverMetric) error
+	PruneGameserverMetrics(cutoff time.Time) (int64, error)
+}
+
+// MetricsDocker defines the Docker operation needed by the metrics collector
+type MetricsDocker interface {
+	ContainerResourceUsage(containerID string) (*models.ResourceUsage, error)
+}
+
+// MetricsCollector periodically records the CPU and memory use of running gameservers, so
+// usage can be charted after the fact, and prunes samples older than the retention window
+type MetricsCollector struct {
+	db        MetricsDatabase
+	docker    MetricsDocker
+	interval  time.Duration
+	retention time.Duration
+	ticker    *time.Ticker
+	done      chan struct{}
+}
+
+// NewMetricsCollector creates a collector that samples every interval (0 disables it) and
+// keeps samples for retention
+func NewMetricsCollector(db MetricsDatabase, docker MetricsDocker, interval, retention time.Duration) *MetricsCollector {
+	return &MetricsCollector{
+		db:        db,
+		docker:    docker,
+		interval:  interval,
+		retention: retention,
+		done:      make(chan struct{}),
+	}
+}
+
+// Start samples running gameservers on every interval
+func (mc *MetricsCollector) Start() {
+	if mc.interval <= 0 {
+		log.Info().Msg("Metrics collection disabled")
+		return
+	}
+
+	log.Info().Dur("interval", mc.interval).Dur("retention", mc.retention).Msg("Starting metrics collector")
+	mc.ticker = time.NewTicker(mc.interval)
+
+	go func() {
+		for {
+			select {
+			case <-mc.done:
+				return
+			case <-mc.ticker.C:
+				mc.run()
+			}
+		}
+	}()
+}
+
+// Stop halts the metrics collector
+func (mc *MetricsCollector) Stop() {
+	if mc.ticker != nil {
+		mc.ticker.Stop()
+	}
+	close(mc.done)
+}
+
+func (mc *MetricsCollector) run() {
+	servers, err := mc.db.ListGameservers()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list gameservers for metrics")
+		return
+	}
+
+	now := time.Now()
+	metrics := mc.sample(servers, now)
+	if err := mc.db.CreateGameserverMetrics(metrics); err != nil {
+		log.Error().Err(err).Msg("Failed to record gameserver metrics")
+	}
+
+	if mc.retention > 0 {
+		pruned, err := mc.db.PruneGameserverMetrics(now.Add(-mc.retention))
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to prune gameserver metrics")
+		} else if pruned > 0 {
+			log.Debug().Int64("samples", pruned).Msg("Pruned gameserver metrics")
+		}
+	}
+}
+
+// sample reads the resource use of every running server's container, stamping each sample with now
+func (mc *MetricsCollector) sample(servers []*models.Gameserver, now time.Time) []*models.GameserverMetric {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, metricsConcurrency)
+	metrics := []*models.GameserverMetric{}
+
+	for _, server := range servers {
+		if server.Status != models.StatusRunning || server.ContainerID == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(server *models.Gameserver) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			usage, err := mc.docker.ContainerResourceUsage(server.ContainerID)
+			if err != nil {
+				log.Debug().Err(err).Str("gameserver_id", server.ID).Msg("Failed to sample gameserver metrics")
+				return
+			}
+			mu.Lock()
+			metrics = append(metrics, &models.GameserverMetric{
+				GameserverID: server.ID,
+				Timestamp:    now,
+				CPUPercent:   usage.CPUPercent,
+				MemUsedMB:    usage.MemoryUsedMB,
+			})
+			mu.Unlock()
+		}(server)
+	}
+	wg.Wait()
+	return metrics
+}
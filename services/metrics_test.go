@@ -0,0 +1,84 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// fakeMetricsDatabase records what the collector writes and prunes
+type fakeMetricsDatabase struct {
+	servers []*models.Gameserver
+	created []*models.GameserverMetric
+	cutoffs []time.Time
+}
+
+func (f *fakeMetricsDatabase) ListGameservers() ([]*models.Gameserver, error) { return f.servers, nil }
+
+func (f *fakeMetricsDatabase) CreateGameserverMetrics(metrics []*models.GameserverMetric) error {
+	f.created = append(f.created, metrics...)
+	return nil
+}
+
+func (f *fakeMetricsDatabase) PruneGameserverMetrics(cutoff time.Time) (int64, error) {
+	f.cutoffs = append(f.cutoffs, cutoff)
+	return 0, nil
+}
+
+// fakeMetricsDocker reports fixed usage per container, failing for unknown ones
+type fakeMetricsDocker map[string]*models.ResourceUsage
+
+func (f fakeMetricsDocker) ContainerResourceUsage(containerID string) (*models.ResourceUsage, error) {
+	if usage, ok := f[containerID]; ok {
+		return usage, nil
+	}
+	return nil, errors.New("no such container")
+}
+
+func TestMetricsCollectorSamplesRunningServers(t *testing.T) {
+	db := &fakeMetricsDatabase{servers: []*models.Gameserver{
+		{ID: "running", ContainerID: "c1", Status: models.StatusRunning},
+		{ID: "also-running", ContainerID: "c2", Status: models.StatusRunning},
+		{ID: "stopped", ContainerID: "c3", Status: models.StatusStopped},
+		{ID: "no-container", Status: models.StatusRunning},
+		{ID: "vanished", ContainerID: "gone", Status: models.StatusRunning},
+	}}
+	docker := fakeMetricsDocker{
+		"c1": {CPUPercent: 12.5, MemoryUsedMB: 512},
+		"c2": {CPUPercent: 50, MemoryUsedMB: 2048},
+		"c3": {CPUPercent: 1, MemoryUsedMB: 1},
+	}
+	collector := NewMetricsCollector(db, docker, time.Minute, 24*time.Hour)
+
+	before := time.Now()
+	collector.run()
+
+	sort.Slice(db.created, func(i, j int) bool { return db.created[i].GameserverID < db.created[j].GameserverID })
+	if len(db.created) != 2 || db.created[0].GameserverID != "also-running" || db.created[1].GameserverID != "running" {
+		t.Fatalf("recorded %+v, want samples for the two running servers only", db.created)
+	}
+	if m := db.created[1]; m.CPUPercent != 12.5 || m.MemUsedMB != 512 || m.Timestamp.Before(before) {
+		t.Errorf("sample = %+v, want the container's usage stamped with the run time", m)
+	}
+	if !db.created[0].Timestamp.Equal(db.created[1].Timestamp) {
+		t.Error("samples from one run must share a timestamp so they roll up together")
+	}
+
+	if len(db.cutoffs) != 1 {
+		t.Fatalf("pruned %d times, want once per run", len(db.cutoffs))
+	}
+	if want := db.created[0].Timestamp.Add(-24 * time.Hour); !db.cutoffs[0].Equal(want) {
+		t.Errorf("prune cutoff = %s, want %s (retention before the run)", db.cutoffs[0], want)
+	}
+}
+
+func TestMetricsCollectorWithoutRetentionKeepsSamples(t *testing.T) {
+	db := &fakeMetricsDatabase{}
+	NewMetricsCollector(db, fakeMetricsDocker{}, time.Minute, 0).run()
+	if len(db.cutoffs) != 0 {
+		t.Errorf("pruned with cutoffs %v, want no pruning when retention is 0", db.cutoffs)
+	}
+}
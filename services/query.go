@@ -3,6 +3,11 @@ package services
 import (
 	"context"
 	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/0xkowalskidev/gameserverquery/protocol"
@@ -12,31 +17,239 @@ import (
 	"0xkowalskidev/gameservers/models"
 )
 
+// queryCacheTTL is how long a query result is reused before the server is queried again
+const queryCacheTTL = 10 * time.Second
+
+// maxQueryCacheEntries caps the query cache; expired results are swept first, then the oldest
+const maxQueryCacheEntries = 1000
+
+// Defaults for QueryOptions
+const (
+	defaultQueryTimeout     = 5 * time.Second
+	defaultQueryListTimeout = 2 * time.Second
+	defaultQueryConcurrency = 8
+)
+
+// cachedQuery holds a query result until it expires
+type cachedQuery struct {
+	info      *protocol.ServerInfo
+	fetchedAt time.Time
+}
+
+// ContainerIPResolver looks up a container's address on its Docker network
+type ContainerIPResolver interface {
+	GetContainerIP(containerID string) (string, error)
+}
+
+// QueryOptions controls which address gameservers are queried on
+type QueryOptions struct {
+	Host        string              // Address published ports are reachable on (default 127.0.0.1)
+	ContainerIP bool                // Query the container's network IP and container port instead of the published port
+	Resolver    ContainerIPResolver // Used for ContainerIP mode and for ports that aren't published to the host
+	Timeout     time.Duration       // How long a single query may take before the server counts as offline
+	Concurrency int                 // Servers queried at once by QueryStatuses
+
+	// Shorter limit for each server's query in QueryStatuses, so list pages render promptly
+	ListTimeout time.Duration
+}
+
 // QueryService handles game server queries
 type QueryService struct {
-	// We can add caching here if needed
+	host        string
+	containerIP bool
+	resolver    ContainerIPResolver
+	timeout     time.Duration
+	listTimeout time.Duration
+	concurrency int
+
+	mu    sync.Mutex
+	cache map[string]cachedQuery // Keyed by gameserver ID
 }
 
 // NewQueryService creates a new query service
-func NewQueryService() *QueryService {
-	return &QueryService{}
+func NewQueryService(opts QueryOptions) *QueryService {
+	if opts.Host == "" {
+		opts.Host = "127.0.0.1"
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultQueryTimeout
+	}
+	if opts.ListTimeout <= 0 {
+		opts.ListTimeout = defaultQueryListTimeout
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultQueryConcurrency
+	}
+	return &QueryService{
+		host:        opts.Host,
+		containerIP: opts.ContainerIP,
+		resolver:    opts.Resolver,
+		timeout:     opts.Timeout,
+		listTimeout: opts.ListTimeout,
+		concurrency: opts.Concurrency,
+		cache:       make(map[string]cachedQuery),
+	}
 }
 
 // QueryGameserver queries a gameserver for its current status
 func (qs *QueryService) QueryGameserver(gameserver *models.Gameserver, game *models.Game) (*protocol.ServerInfo, error) {
+	return qs.queryCached(gameserver, game, qs.timeout)
+}
+
+// queryCached queries a gameserver within timeout, reusing a recent result if there is one
+func (qs *QueryService) queryCached(gameserver *models.Gameserver, game *models.Game, timeout time.Duration) (*protocol.ServerInfo, error) {
 	// Only query running servers
 	if gameserver.Status != models.StatusRunning {
+		qs.Invalidate(gameserver.ID)
 		return &protocol.ServerInfo{
 			Online: false,
 		}, nil
 	}
 
-	return qs.doQuery(gameserver, game)
+	// Reuse recent results so pollers and aggregate views don't hammer the server
+	qs.mu.Lock()
+	cached, ok := qs.cache[gameserver.ID]
+	if ok && time.Since(cached.fetchedAt) >= queryCacheTTL {
+		delete(qs.cache, gameserver.ID)
+		ok = false
+	}
+	qs.mu.Unlock()
+	if ok {
+		return cached.info, nil
+	}
+
+	info, err := qs.doQuery(gameserver, game, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	qs.store(gameserver.ID, info)
+	return info, nil
+}
+
+// store caches a query result, sweeping expired results and then evicting the oldest while
+// the cache is full
+func (qs *QueryService) store(gameserverID string, info *protocol.ServerInfo) {
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+
+	now := time.Now()
+	if _, ok := qs.cache[gameserverID]; !ok && len(qs.cache) >= maxQueryCacheEntries {
+		for id, cached := range qs.cache {
+			if now.Sub(cached.fetchedAt) >= queryCacheTTL {
+				delete(qs.cache, id)
+			}
+		}
+		for len(qs.cache) >= maxQueryCacheEntries {
+			oldestID, oldest := "", now
+			for id, cached := range qs.cache {
+				if cached.fetchedAt.Before(oldest) || oldestID == "" {
+					oldestID, oldest = id, cached.fetchedAt
+				}
+			}
+			delete(qs.cache, oldestID)
+		}
+	}
+	qs.cache[gameserverID] = cachedQuery{info: info, fetchedAt: now}
+}
+
+// Invalidate drops a gameserver's cached query result, e.g. after it was edited, started,
+// stopped or deleted, so the next query asks the server again
+func (qs *QueryService) Invalidate(gameserverID string) {
+	qs.mu.Lock()
+	delete(qs.cache, gameserverID)
+	qs.mu.Unlock()
+}
+
+// QueryStatus queries a gameserver and normalizes the result for display
+func (qs *QueryService) QueryStatus(gameserver *models.Gameserver, game *models.Game) (*models.QueryResult, error) {
+	info, err := qs.QueryGameserver(gameserver, game)
+	if err != nil {
+		return nil, err
+	}
+	return NormalizeQuery(info), nil
+}
+
+// QueryStatuses queries many gameservers at once for list views, keyed by gameserver ID.
+// Queries run concurrently up to the configured limit and each is bounded by the list
+// timeout, so one unresponsive server only costs its own slot. Servers that aren't running,
+// have no game in games (keyed by game ID) or fail to answer are reported offline.
+func (qs *QueryService) QueryStatuses(gameservers []*models.Gameserver, games map[string]*models.Game) map[string]*models.QueryResult {
+	results := make(map[string]*models.QueryResult, len(gameservers))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, qs.concurrency)
+
+	for _, gameserver := range gameservers {
+		game := games[gameserver.GameID]
+		if gameserver.Status != models.StatusRunning || game == nil {
+			results[gameserver.ID] = &models.QueryResult{}
+			continue
+		}
+
+		wg.Add(1)
+		go func(gameserver *models.Gameserver, game *models.Game) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			// A failed query normalizes to offline
+			info, err := qs.queryCached(gameserver, game, qs.listTimeout)
+			if err != nil {
+				log.Debug().Err(err).Str("gameserver_id", gameserver.ID).Msg("Failed to query gameserver")
+			}
+			result := NormalizeQuery(info)
+
+			mu.Lock()
+			results[gameserver.ID] = result
+			mu.Unlock()
+		}(gameserver, game)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// NormalizeQuery maps a protocol-specific query result onto the stable UI model, so
+// templates don't depend on which fields each protocol fills in
+func NormalizeQuery(info *protocol.ServerInfo) *models.QueryResult {
+	result := &models.QueryResult{}
+	if info == nil || !info.Online {
+		return result
+	}
+
+	result.Online = true
+	result.Name = strings.TrimSpace(info.Name)
+	result.Map = strings.TrimSpace(info.Map)
+	result.Version = strings.TrimSpace(info.Version)
+	result.Players = info.Players.Current
+	result.MaxPlayers = info.Players.Max
+	result.Ping = info.Ping
+
+	// Some protocols report anonymous/connecting players with blank names
+	for _, player := range info.Players.List {
+		if name := strings.TrimSpace(player.Name); name != "" {
+			result.PlayerNames = append(result.PlayerNames, name)
+		}
+	}
+	// Protocols that only report a list (no count) still get a usable count
+	if result.Players == 0 && len(info.Players.List) > 0 {
+		result.Players = len(info.Players.List)
+	}
+
+	for key, value := range info.Extra {
+		if value = strings.TrimSpace(value); value != "" {
+			result.Extras = append(result.Extras, models.QueryExtra{Key: key, Value: value})
+		}
+	}
+	sort.Slice(result.Extras, func(i, j int) bool { return result.Extras[i].Key < result.Extras[j].Key })
+
+	return result
 }
 
 // IsServerReady checks if a gameserver is responding to queries (used during startup)
 func (qs *QueryService) IsServerReady(gameserver *models.Gameserver, game *models.Game) bool {
-	result, err := qs.doQuery(gameserver, game)
+	result, err := qs.doQuery(gameserver, game, qs.timeout)
 	if err != nil {
 		return false
 	}
@@ -44,7 +257,7 @@ func (qs *QueryService) IsServerReady(gameserver *models.Gameserver, game *model
 }
 
 // doQuery performs the actual query regardless of server status
-func (qs *QueryService) doQuery(gameserver *models.Gameserver, game *models.Game) (*protocol.ServerInfo, error) {
+func (qs *QueryService) doQuery(gameserver *models.Gameserver, game *models.Game, timeout time.Duration) (*protocol.ServerInfo, error) {
 	// Get the query port (preferred) or game port
 	var queryPort *models.PortMapping
 
@@ -61,18 +274,23 @@ func (qs *QueryService) doQuery(gameserver *models.Gameserver, game *models.Game
 		queryPort = gameserver.GetGamePort()
 	}
 
-	if queryPort == nil || queryPort.HostPort == 0 {
+	if queryPort == nil {
 		log.Warn().Str("gameserver_id", gameserver.ID).Msg("No query or game port found for gameserver")
 		return &protocol.ServerInfo{
 			Online: false,
 		}, nil
 	}
 
-	// Use 127.0.0.1 since we're querying from the same host
-	address := fmt.Sprintf("127.0.0.1:%d", queryPort.HostPort)
+	address, err := qs.queryAddress(gameserver, queryPort)
+	if err != nil {
+		log.Debug().Err(err).Str("gameserver_id", gameserver.ID).Msg("Failed to resolve query address")
+		return &protocol.ServerInfo{
+			Online: false,
+		}, nil
+	}
 
 	// Create a context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	// Query the server using the game slug
@@ -87,3 +305,19 @@ func (qs *QueryService) doQuery(gameserver *models.Gameserver, game *models.Game
 	return result, nil
 }
 
+// queryAddress returns the address to query a port on: the published host port on the
+// configured host, or the container's network IP when the port isn't published
+func (qs *QueryService) queryAddress(gameserver *models.Gameserver, port *models.PortMapping) (string, error) {
+	if !qs.containerIP && port.HostPort != 0 {
+		return net.JoinHostPort(qs.host, strconv.Itoa(port.HostPort)), nil
+	}
+
+	if qs.resolver == nil || gameserver.ContainerID == "" {
+		return "", fmt.Errorf("port %s is not published and the container IP can't be resolved", port.Name)
+	}
+	ip, err := qs.resolver.GetContainerIP(gameserver.ContainerID)
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(ip, strconv.Itoa(port.ContainerPort)), nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+func TestQueryCacheEvictsExpiredThenOldest(t *testing.T) {
+	qs := NewQueryService(QueryOptions{})
+	for i := 0; i < maxQueryCacheEntries; i++ {
+		qs.store(fmt.Sprintf("server-%d", i), &protocol.ServerInfo{Online: true})
+	}
+
+	// An expired entry is swept before any live one is evicted
+	qs.cache["server-5"] = cachedQuery{info: &protocol.ServerInfo{}, fetchedAt: time.Now().Add(-queryCacheTTL)}
+	qs.store("new-1", &protocol.ServerInfo{Online: true})
+	if _, ok := qs.cache["server-5"]; ok {
+		t.Error("expired entry was kept when the cache was full")
+	}
+	if len(qs.cache) != maxQueryCacheEntries {
+		t.Errorf("cache has %d entries, want %d", len(qs.cache), maxQueryCacheEntries)
+	}
+
+	// With nothing expired, the oldest entry makes room
+	qs.cache["server-0"] = cachedQuery{info: &protocol.ServerInfo{}, fetchedAt: time.Now().Add(-time.Second)}
+	qs.store("new-2", &protocol.ServerInfo{Online: true})
+	if _, ok := qs.cache["server-0"]; ok {
+		t.Error("oldest entry was kept when the cache was full")
+	}
+	if len(qs.cache) != maxQueryCacheEntries {
+		t.Errorf("cache has %d entries, want %d", len(qs.cache), maxQueryCacheEntries)
+	}
+}
+
+func TestQueryCacheInvalidate(t *testing.T) {
+	qs := NewQueryService(QueryOptions{})
+	qs.store("a", &protocol.ServerInfo{Online: true})
+	qs.store("b", &protocol.ServerInfo{Online: true})
+
+	qs.Invalidate("a")
+	if _, ok := qs.cache["a"]; ok {
+		t.Error("invalidated entry is still cached")
+	}
+	if _, ok := qs.cache["b"]; !ok {
+		t.Error("invalidating one server dropped another's entry")
+	}
+
+	// Querying a server that isn't running drops its result too
+	info, err := qs.QueryGameserver(&models.Gameserver{ID: "b", Status: models.StatusStopped}, &models.Game{})
+	if err != nil || info.Online {
+		t.Fatalf("QueryGameserver on a stopped server = %+v, %v, want offline", info, err)
+	}
+	if _, ok := qs.cache["b"]; ok {
+		t.Error("stopped server's result is still cached")
+	}
+}
+
+func TestQueryCacheReusesFreshResult(t *testing.T) {
+	qs := NewQueryService(QueryOptions{})
+	cached := &protocol.ServerInfo{Online: true, Name: "cached"}
+	qs.store("a", cached)
+
+	info, err := qs.QueryGameserver(&models.Gameserver{ID: "a", Status: models.StatusRunning}, &models.Game{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info != cached {
+		t.Errorf("QueryGameserver = %+v, want the cached result", info)
+	}
+}
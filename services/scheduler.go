@@ -1,6 +1,7 @@
 package services
 
 import (
+	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
@@ -10,6 +11,13 @@ import (
 	"0xkowalskidev/gameservers/models"
 )
 
+// SchedulerOptions holds tunable scheduler settings loaded from configuration
+type SchedulerOptions struct {
+	MaxRetries   int           // Retries after a failed run before waiting for the next scheduled time (0 = no retries)
+	RetryDelay   time.Duration // Delay between retries of a failed task
+	HistoryLimit int           // Runs kept in each task's execution history (0 = all)
+}
+
 // TaskScheduler handles scheduled task execution
 type TaskScheduler struct {
 	db            DatabaseInterface
@@ -17,21 +25,33 @@ type TaskScheduler struct {
 	ticker        *time.Ticker
 	done          chan struct{}
 	checkInterval time.Duration
+	maxRetries    int
+	retryDelay    time.Duration
+	historyLimit  int
+
+	stateMu   sync.Mutex
+	lastCheck time.Time
+	tasks     []models.ScheduledTask // Active tasks as of the last check
+	running   *models.RunningTask
 }
 
 // DatabaseInterface defines the required database operations for the scheduler
 type DatabaseInterface interface {
 	ListActiveScheduledTasks() ([]*models.ScheduledTask, error)
 	UpdateScheduledTask(task *models.ScheduledTask) error
+	CreateTaskExecution(execution *models.TaskExecution, keep int) error
 }
 
 // NewTaskScheduler creates a new task scheduler instance
-func NewTaskScheduler(db DatabaseInterface, gameserverSvc *database.GameserverRepository) *TaskScheduler {
+func NewTaskScheduler(db DatabaseInterface, gameserverSvc *database.GameserverRepository, opts SchedulerOptions) *TaskScheduler {
 	return &TaskScheduler{
 		db:            db,
 		gameserverSvc: gameserverSvc,
 		done:          make(chan struct{}),
 		checkInterval: time.Minute,
+		maxRetries:    opts.MaxRetries,
+		retryDelay:    opts.RetryDelay,
+		historyLimit:  opts.HistoryLimit,
 	}
 }
 
@@ -75,6 +95,7 @@ func (ts *TaskScheduler) updateNextRunTimes() {
 			ts.updateTaskNextRun(task, now)
 		}
 	}
+	ts.recordCheck(now, tasks)
 }
 
 func (ts *TaskScheduler) processTasks() {
@@ -89,11 +110,121 @@ func (ts *TaskScheduler) processTasks() {
 		if task.NextRun == nil {
 			ts.updateTaskNextRun(task, now)
 		} else if task.NextRun.Before(now) {
-			ts.executeTask(task)
+			ts.setRunning(task)
+			startedAt := time.Now()
+			err := ts.executeTask(task)
+			ts.setRunning(nil)
 			task.LastRun = &now
+			ts.recordResult(task, err)
+			ts.recordExecution(task, startedAt, err)
+			if err != nil && task.RetryCount < ts.maxRetries {
+				ts.scheduleRetry(task, now)
+				continue
+			}
+			task.RetryCount = 0
 			ts.updateTaskNextRun(task, now)
 		}
 	}
+	ts.recordCheck(now, tasks)
+}
+
+// State returns the scheduler's current view of its tasks, for debugging why a task did or didn't run
+func (ts *TaskScheduler) State() *models.SchedulerState {
+	ts.stateMu.Lock()
+	defer ts.stateMu.Unlock()
+
+	state := &models.SchedulerState{
+		CheckInterval: ts.checkInterval.String(),
+		Tasks:         append([]models.ScheduledTask{}, ts.tasks...),
+	}
+	if !ts.lastCheck.IsZero() {
+		lastCheck := ts.lastCheck
+		nextCheck := lastCheck.Add(ts.checkInterval)
+		state.LastCheck, state.NextCheck = &lastCheck, &nextCheck
+	}
+	if ts.running != nil {
+		running := *ts.running
+		state.Running = &running
+	}
+	return state
+}
+
+// recordCheck snapshots the tasks seen by a check for State
+func (ts *TaskScheduler) recordCheck(at time.Time, tasks []*models.ScheduledTask) {
+	snapshot := make([]models.ScheduledTask, 0, len(tasks))
+	for _, task := range tasks {
+		snapshot = append(snapshot, *task)
+	}
+
+	ts.stateMu.Lock()
+	ts.lastCheck = at
+	ts.tasks = snapshot
+	ts.stateMu.Unlock()
+}
+
+// setRunning records the task being executed, or nil once it finishes
+func (ts *TaskScheduler) setRunning(task *models.ScheduledTask) {
+	ts.stateMu.Lock()
+	defer ts.stateMu.Unlock()
+
+	if task == nil {
+		ts.running = nil
+		return
+	}
+	ts.running = &models.RunningTask{
+		TaskID:       task.ID,
+		Name:         task.Name,
+		Type:         task.Type,
+		GameserverID: task.GameserverID,
+		StartedAt:    time.Now(),
+	}
+}
+
+// recordResult stores the outcome of the latest run on the task
+func (ts *TaskScheduler) recordResult(task *models.ScheduledTask, err error) {
+	if err != nil {
+		task.LastStatus = models.TaskRunFailed
+		task.LastError = err.Error()
+		return
+	}
+	task.LastStatus = models.TaskRunSuccess
+	task.LastError = ""
+}
+
+// recordExecution adds a run to the task's execution history
+func (ts *TaskScheduler) recordExecution(task *models.ScheduledTask, startedAt time.Time, err error) {
+	execution := &models.TaskExecution{
+		ID:         models.GenerateID(),
+		TaskID:     task.ID,
+		StartedAt:  startedAt,
+		FinishedAt: time.Now(),
+		Status:     task.LastStatus,
+	}
+	if err != nil {
+		execution.ErrorMessage = err.Error()
+	}
+	if err := ts.db.CreateTaskExecution(execution, ts.historyLimit); err != nil {
+		log.Error().Err(err).Str("task_id", task.ID).Msg("Failed to record task execution")
+	}
+}
+
+// scheduleRetry reschedules a failed task after the retry delay instead of waiting for its next cron run
+func (ts *TaskScheduler) scheduleRetry(task *models.ScheduledTask, from time.Time) {
+	task.RetryCount++
+	retryAt := from.Add(ts.retryDelay)
+	task.NextRun = &retryAt
+	task.UpdatedAt = from
+
+	log.Warn().
+		Str("task_id", task.ID).
+		Int("attempt", task.RetryCount).
+		Int("max_retries", ts.maxRetries).
+		Time("retry_at", retryAt).
+		Msg("Scheduled task failed, retrying")
+
+	if err := ts.db.UpdateScheduledTask(task); err != nil {
+		log.Error().Err(err).Str("task_id", task.ID).Msg("Failed to update task")
+	}
 }
 
 func (ts *TaskScheduler) updateTaskNextRun(task *models.ScheduledTask, from time.Time) {
@@ -112,9 +243,11 @@ func (ts *TaskScheduler) updateTaskNextRun(task *models.ScheduledTask, from time
 	}
 }
 
-func (ts *TaskScheduler) executeTask(task *models.ScheduledTask) {
+func (ts *TaskScheduler) executeTask(task *models.ScheduledTask) error {
 	log.Info().Str("task_id", task.ID).Str("task_name", task.Name).Str("type", string(task.Type)).Msg("Executing scheduled task")
 	if err := ts.gameserverSvc.ExecuteScheduledTask(task); err != nil {
 		log.Error().Err(err).Str("task_id", task.ID).Str("task_name", task.Name).Msg("Failed to execute scheduled task")
+		return err
 	}
+	return nil
 }
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"0xkowalskidev/gameservers/database"
+	"0xkowalskidev/gameservers/models"
+	"0xkowalskidev/gameservers/testutil"
+)
+
+func TestScheduledRestartSendsStopCommandBeforeStopping(t *testing.T) {
+	db, err := database.NewDatabaseManager(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	game := &models.Game{ID: "savegame", Name: "Save Game", Slug: "savegame", Image: "example/savegame:latest",
+		MinMemoryMB: 256, RecMemoryMB: 256, StopCommand: "save-all", StopGraceSecs: 1}
+	if err := db.CreateGame(game); err != nil {
+		t.Fatal(err)
+	}
+	server := &models.Gameserver{ID: "gs-1", Name: "world", GameID: game.ID, MemoryMB: 256,
+		ContainerID: "container-old", Status: models.StatusRunning}
+	if err := db.CreateGameserver(server); err != nil {
+		t.Fatal(err)
+	}
+
+	docker := testutil.NewMockDockerManager()
+	docker.AddContainer("container-old", models.StatusRunning)
+	repo := database.NewGameserverRepository(db, docker, nil, database.RepositoryOptions{})
+	scheduler := NewTaskScheduler(db, repo, SchedulerOptions{})
+
+	task := &models.ScheduledTask{ID: "task-1", Name: "Nightly restart", GameserverID: server.ID, Type: models.TaskTypeRestart}
+	if err := scheduler.executeTask(task); err != nil {
+		t.Fatalf("executeTask: %v", err)
+	}
+
+	command := docker.CallIndex("SendCommand container-old save-all")
+	stop := docker.CallIndex("StopContainer container-old")
+	remove := docker.CallIndex("RemoveContainer container-old")
+	if command < 0 {
+		t.Fatalf("stop command was never sent, calls: %v", docker.Calls())
+	}
+	if stop < 0 || command > stop {
+		t.Errorf("stop command must be sent before the container is stopped, calls: %v", docker.Calls())
+	}
+	if remove < 0 || stop > remove {
+		t.Errorf("container must be stopped before it is removed, calls: %v", docker.Calls())
+	}
+
+	// Let the restart's startup finish before the database is closed
+	deadline := time.Now().Add(5 * time.Second)
+	for docker.CallIndex("StartContainer") < 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if docker.CallIndex("StartContainer") < remove {
+		t.Errorf("restart didn't start a new container after removing the old one, calls: %v", docker.Calls())
+	}
+}
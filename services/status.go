@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// How long to wait before resubscribing after the Docker event stream fails
+const statusEventRetryDelay = 5 * time.Second
+
+// StatusRepository defines the gameserver lookups needed by the status feed
+type StatusRepository interface {
+	GetGameserver(id string) (*models.Gameserver, error)
+	ListGameservers() ([]*models.Gameserver, error)
+}
+
+// StatusEventSource defines the Docker event subscription that drives the status feed
+type StatusEventSource interface {
+	WatchContainerEvents(ctx context.Context, onEvent func(gameserverID string)) error
+}
+
+// StatusFeed pushes gameserver status changes to subscribers (the dashboard's SSE stream).
+// Docker container events trigger an immediate resync of the affected server, and a periodic
+// full refresh catches changes that don't produce an event, such as startup progress.
+type StatusFeed struct {
+	repo     StatusRepository
+	events   StatusEventSource
+	interval time.Duration
+
+	mu          sync.Mutex
+	statuses    map[string]models.StatusUpdate
+	subscribers map[chan models.StatusUpdate]struct{}
+
+	cancel context.CancelFunc
+}
+
+// NewStatusFeed creates a status feed that fully refreshes every interval (0 = Docker events only)
+func NewStatusFeed(repo StatusRepository, events StatusEventSource, interval time.Duration) *StatusFeed {
+	return &StatusFeed{
+		repo:        repo,
+		events:      events,
+		interval:    interval,
+		statuses:    make(map[string]models.StatusUpdate),
+		subscribers: make(map[chan models.StatusUpdate]struct{}),
+	}
+}
+
+// Start loads the current statuses, then follows Docker events and refreshes on every interval
+func (sf *StatusFeed) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	sf.cancel = cancel
+
+	log.Info().Dur("interval", sf.interval).Msg("Starting gameserver status feed")
+	sf.refreshAll()
+
+	go sf.watchEvents(ctx)
+	if sf.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(sf.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				sf.refreshAll()
+			}
+		}
+	}()
+}
+
+// Stop halts the status feed and closes every subscription
+func (sf *StatusFeed) Stop() {
+	if sf.cancel != nil {
+		sf.cancel()
+	}
+
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	for ch := range sf.subscribers {
+		delete(sf.subscribers, ch)
+		close(ch)
+	}
+}
+
+// subscriberBuffer is how many updates a subscriber may fall behind before it is dropped
+const subscriberBuffer = 64
+
+// Subscribe returns a channel of status changes and a function that ends the subscription.
+// A subscriber that falls behind is dropped rather than blocking the feed: its channel is
+// closed, so the client reconnects and starts over from a Snapshot.
+func (sf *StatusFeed) Subscribe() (<-chan models.StatusUpdate, func()) {
+	ch := make(chan models.StatusUpdate, subscriberBuffer)
+
+	sf.mu.Lock()
+	sf.subscribers[ch] = struct{}{}
+	sf.mu.Unlock()
+
+	return ch, func() {
+		sf.mu.Lock()
+		defer sf.mu.Unlock()
+		if _, ok := sf.subscribers[ch]; ok {
+			delete(sf.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Snapshot returns the last known status of every gameserver
+func (sf *StatusFeed) Snapshot() []models.StatusUpdate {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	snapshot := make([]models.StatusUpdate, 0, len(sf.statuses))
+	for _, update := range sf.statuses {
+		snapshot = append(snapshot, update)
+	}
+	return snapshot
+}
+
+// watchEvents resyncs a gameserver whenever Docker reports a change to its container,
+// resubscribing if the event stream fails
+func (sf *StatusFeed) watchEvents(ctx context.Context) {
+	for {
+		err := sf.events.WatchContainerEvents(ctx, sf.refresh)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warn().Err(err).Dur("retry_in", statusEventRetryDelay).Msg("Docker event stream ended, resubscribing")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(statusEventRetryDelay):
+		}
+		// Catch up on anything missed while disconnected
+		sf.refreshAll()
+	}
+}
+
+// refresh resyncs one gameserver's status and publishes it if it changed
+func (sf *StatusFeed) refresh(id string) {
+	server, err := sf.repo.GetGameserver(id)
+	if err != nil {
+		// Deletions are picked up by the next full refresh
+		log.Debug().Err(err).Str("gameserver_id", id).Msg("Status feed could not load gameserver")
+		return
+	}
+	sf.publish(statusUpdate(server))
+}
+
+// refreshAll resyncs every gameserver, publishing changes and deletions
+func (sf *StatusFeed) refreshAll() {
+	servers, err := sf.repo.ListGameservers()
+	if err != nil {
+		log.Error().Err(err).Msg("Status feed failed to list gameservers")
+		return
+	}
+
+	seen := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		seen[server.ID] = true
+		sf.publish(statusUpdate(server))
+	}
+
+	sf.mu.Lock()
+	var removed []string
+	for id := range sf.statuses {
+		if !seen[id] {
+			removed = append(removed, id)
+		}
+	}
+	sf.mu.Unlock()
+
+	for _, id := range removed {
+		sf.publish(models.StatusUpdate{ID: id, Removed: true})
+	}
+}
+
+// publish records update and sends it to subscribers if it differs from the last known status
+func (sf *StatusFeed) publish(update models.StatusUpdate) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	if update.Removed {
+		delete(sf.statuses, update.ID)
+	} else {
+		if previous, ok := sf.statuses[update.ID]; ok && previous == update {
+			return
+		}
+		sf.statuses[update.ID] = update
+	}
+
+	for ch := range sf.subscribers {
+		select {
+		case ch <- update:
+		default:
+			// Skipping the update would leave the subscriber showing a stale status for good
+			log.Warn().Msg("Status feed subscriber fell behind, dropping it")
+			delete(sf.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// statusUpdate returns the dashboard status of a gameserver
+func statusUpdate(server *models.Gameserver) models.StatusUpdate {
+	return models.StatusUpdate{
+		ID:             server.ID,
+		Status:         server.Status,
+		IsTransitional: server.Status.IsTransitional(),
+		RestartCount:   server.RestartCount,
+	}
+}
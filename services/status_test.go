@@ -0,0 +1,69 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+func TestStatusFeedPublishesChangesOnly(t *testing.T) {
+	sf := NewStatusFeed(nil, nil, 0)
+	updates, unsubscribe := sf.Subscribe()
+	defer unsubscribe()
+
+	running := models.StatusUpdate{ID: "gs-1", Status: models.StatusRunning}
+	sf.publish(running)
+	sf.publish(running)
+	sf.publish(models.StatusUpdate{ID: "gs-1", Removed: true})
+
+	if got := <-updates; got != running {
+		t.Errorf("first update = %+v, want %+v", got, running)
+	}
+	if got := <-updates; !got.Removed {
+		t.Errorf("second update = %+v, want the removal; unchanged statuses must not be re-sent", got)
+	}
+	if len(updates) != 0 {
+		t.Errorf("%d more updates queued, want none", len(updates))
+	}
+	if snapshot := sf.Snapshot(); len(snapshot) != 0 {
+		t.Errorf("Snapshot = %+v, want the removed server gone", snapshot)
+	}
+}
+
+func TestStatusFeedDropsSlowSubscriber(t *testing.T) {
+	sf := NewStatusFeed(nil, nil, 0)
+	slow, unsubscribeSlow := sf.Subscribe()
+	defer unsubscribeSlow()
+	fast, unsubscribeFast := sf.Subscribe()
+	defer unsubscribeFast()
+
+	// One more update than the slow subscriber can hold; the fast one reads each as it comes
+	for i := 0; i <= subscriberBuffer; i++ {
+		sf.publish(models.StatusUpdate{ID: fmt.Sprintf("gs-%d", i), Status: models.StatusRunning})
+		if got, ok := <-fast; !ok || got.ID != fmt.Sprintf("gs-%d", i) {
+			t.Fatalf("subscriber that kept up got (%+v, open %v), want gs-%d", got, ok, i)
+		}
+	}
+
+	// The slow subscriber gets what fit in its buffer, then its channel is closed
+	queued := 0
+	for range slow {
+		queued++
+	}
+	if queued != subscriberBuffer {
+		t.Errorf("slow subscriber got %d updates before being dropped, want %d", queued, subscriberBuffer)
+	}
+
+	// Its client reconnects and starts from a snapshot with every status
+	if snapshot := sf.Snapshot(); len(snapshot) != subscriberBuffer+1 {
+		t.Errorf("Snapshot has %d statuses, want %d", len(snapshot), subscriberBuffer+1)
+	}
+
+	// Ending a dropped subscription again is harmless, and the feed keeps serving the rest
+	unsubscribeSlow()
+	sf.publish(models.StatusUpdate{ID: "gs-0", Status: models.StatusStopped})
+	if got := <-fast; got.Status != models.StatusStopped {
+		t.Errorf("update after dropping = %+v, want gs-0 stopped", got)
+	}
+}
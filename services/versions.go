@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// VersionProvider lists the versions a game image can run
+type VersionProvider interface {
+	// Variable is the environment variable the selected version is passed through
+	Variable() string
+	ListVersions(ctx context.Context, client *http.Client) ([]string, error)
+}
+
+// cachedVersions holds a provider result until it expires
+type cachedVersions struct {
+	versions  []string
+	fetchedAt time.Time
+}
+
+// VersionService resolves available versions for games via per-game providers
+type VersionService struct {
+	providers map[string]VersionProvider // Keyed by game slug
+	client    *http.Client
+	cacheTTL  time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedVersions
+}
+
+// NewVersionService creates a version service with the built-in providers registered
+func NewVersionService() *VersionService {
+	return &VersionService{
+		providers: map[string]VersionProvider{
+			"minecraft": &minecraftVersionProvider{},
+		},
+		client:   &http.Client{Timeout: 10 * time.Second},
+		cacheTTL: time.Hour,
+		cache:    make(map[string]cachedVersions),
+	}
+}
+
+// ListVersions returns the selectable versions for a game, or nil if the game has no provider
+func (vs *VersionService) ListVersions(game *models.Game) (*models.GameVersions, error) {
+	provider, ok := vs.providers[game.Slug]
+	if !ok {
+		return nil, nil
+	}
+
+	vs.mu.Lock()
+	cached, ok := vs.cache[game.Slug]
+	vs.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < vs.cacheTTL {
+		return &models.GameVersions{Variable: provider.Variable(), Versions: cached.versions}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	versions, err := provider.ListVersions(ctx, vs.client)
+	if err != nil {
+		log.Warn().Err(err).Str("game", game.Slug).Msg("Failed to fetch game versions")
+		// Serve stale data rather than nothing if the upstream is down
+		if ok {
+			return &models.GameVersions{Variable: provider.Variable(), Versions: cached.versions}, nil
+		}
+		return nil, err
+	}
+
+	vs.mu.Lock()
+	vs.cache[game.Slug] = cachedVersions{versions: versions, fetchedAt: time.Now()}
+	vs.mu.Unlock()
+
+	return &models.GameVersions{Variable: provider.Variable(), Versions: versions}, nil
+}
+
+// minecraftVersionProvider lists release versions from Mojang's version manifest
+type minecraftVersionProvider struct{}
+
+const minecraftManifestURL = "https://launchermeta.mojang.com/mc/game/version_manifest_v2.json"
+
+func (p *minecraftVersionProvider) Variable() string {
+	return "MINECRAFT_VERSION"
+}
+
+func (p *minecraftVersionProvider) ListVersions(ctx context.Context, client *http.Client) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, minecraftManifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch version manifest: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("version manifest returned status %d", resp.StatusCode)
+	}
+
+	var manifest struct {
+		Versions []struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+		} `json:"versions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode version manifest: %w", err)
+	}
+
+	// The image resolves "latest" itself, so offer it first
+	versions := []string{"latest"}
+	for _, v := range manifest.Versions {
+		if v.Type == "release" {
+			versions = append(versions, v.ID)
+		}
+	}
+	return versions, nil
+}
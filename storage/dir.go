@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// DirStore stores backups in a directory on the host, e.g. a second disk or a NAS mount
+type DirStore struct {
+	root string
+}
+
+// NewDirStore creates a store rooted at dir, creating it if needed
+func NewDirStore(dir string) (*DirStore, error) {
+	if !filepath.IsAbs(dir) {
+		return nil, &models.OperationError{Op: "dir_store_config", Msg: fmt.Sprintf("backup path %q must be absolute", dir)}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, &models.OperationError{Op: "dir_store_config", Msg: fmt.Sprintf("failed to create backup path %s", dir), Err: err}
+	}
+	return &DirStore{root: filepath.Clean(dir)}, nil
+}
+
+// Upload writes size bytes from body to key. The file is written under a temporary name and
+// renamed into place, so an interrupted upload never looks like a complete backup.
+func (s *DirStore) Upload(key string, body io.Reader, size int64) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return &models.OperationError{Op: "dir_upload", Msg: "failed to create backup directory", Err: err}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return &models.OperationError{Op: "dir_upload", Msg: "failed to create backup file", Err: err}
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return &models.OperationError{Op: "dir_upload", Msg: fmt.Sprintf("failed to write %s", key), Err: err}
+	}
+	if written != size {
+		return &models.OperationError{Op: "dir_upload", Msg: fmt.Sprintf("wrote %d of %d bytes of %s", written, size, key)}
+	}
+
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return &models.OperationError{Op: "dir_upload", Msg: fmt.Sprintf("failed to save %s", key), Err: err}
+	}
+	return nil
+}
+
+// Download opens key for reading and returns its size
+func (s *DirStore) Download(key string) (io.ReadCloser, int64, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	file, err := os.Open(target)
+	if err != nil {
+		return nil, 0, &models.OperationError{Op: "dir_download", Msg: fmt.Sprintf("failed to open %s", key), Err: err}
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, &models.OperationError{Op: "dir_download", Msg: fmt.Sprintf("failed to stat %s", key), Err: err}
+	}
+	return file, info.Size(), nil
+}
+
+// List returns the backups in the directory named by prefix, newest first
+func (s *DirStore) List(prefix string) ([]*models.OffsiteBackup, error) {
+	dir, err := s.path(prefix)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &models.OperationError{Op: "dir_list", Msg: fmt.Sprintf("failed to list %s", dir), Err: err}
+	}
+
+	var backups []*models.OffsiteBackup
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, &models.OffsiteBackup{
+			Key:      prefix + entry.Name(),
+			Name:     entry.Name(),
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Modified.After(backups[j].Modified) })
+	return backups, nil
+}
+
+// path resolves key inside the store's root, rejecting keys that would escape it
+func (s *DirStore) path(key string) (string, error) {
+	target := filepath.Join(s.root, filepath.FromSlash(key))
+	if target != s.root && !strings.HasPrefix(target, s.root+string(filepath.Separator)) {
+		return "", &models.OperationError{Op: "dir_store", Msg: fmt.Sprintf("invalid backup key %q", key)}
+	}
+	return target, nil
+}
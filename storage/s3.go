@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// S3Options configures an S3-compatible bucket for offsite backups
+type S3Options struct {
+	Endpoint  string // Base URL, e.g. https://s3.eu-central-1.amazonaws.com or http://minio:9000
+	Region    string // Signing region (default us-east-1, which MinIO accepts)
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Prefix    string // Key prefix for all objects, e.g. "gameservers/"
+	PathStyle bool   // Address the bucket as endpoint/bucket instead of bucket.endpoint
+}
+
+// S3Store stores backups in an S3-compatible bucket through minio-go, which signs requests and
+// streams uploads in parts, so large backups never sit in memory whole
+type S3Store struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// NewS3Store creates a store for the configured bucket
+func NewS3Store(opts S3Options) (*S3Store, error) {
+	endpoint, err := url.Parse(strings.TrimRight(opts.Endpoint, "/"))
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, &models.OperationError{Op: "s3_config", Msg: fmt.Sprintf("invalid S3 endpoint %q", opts.Endpoint), Err: err}
+	}
+	if endpoint.Path != "" {
+		return nil, &models.OperationError{Op: "s3_config", Msg: fmt.Sprintf("S3 endpoint %q must not have a path", opts.Endpoint)}
+	}
+	if opts.Bucket == "" {
+		return nil, &models.OperationError{Op: "s3_config", Msg: "S3 bucket is required"}
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+
+	lookup := minio.BucketLookupDNS
+	if opts.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:        credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, ""),
+		Secure:       endpoint.Scheme == "https",
+		Region:       opts.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, &models.OperationError{Op: "s3_config", Msg: "failed to create S3 client", Err: err}
+	}
+
+	return &S3Store{client: client, bucket: opts.Bucket, prefix: opts.Prefix}, nil
+}
+
+// Upload streams size bytes from body to key
+func (s *S3Store) Upload(key string, body io.Reader, size int64) error {
+	// No timeout: uploads of large backups take as long as they take
+	_, err := s.client.PutObject(context.Background(), s.bucket, s.prefix+key, body, size, minio.PutObjectOptions{
+		ContentType: models.BackupContentType(key),
+	})
+	if err != nil {
+		return &models.OperationError{Op: "s3_upload", Msg: fmt.Sprintf("failed to upload %s to S3", key), Err: err}
+	}
+	return nil
+}
+
+// Download opens key for reading and returns its size
+func (s *S3Store) Download(key string) (io.ReadCloser, int64, error) {
+	object, err := s.client.GetObject(context.Background(), s.bucket, s.prefix+key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, 0, &models.OperationError{Op: "s3_download", Msg: fmt.Sprintf("failed to download %s from S3", key), Err: err}
+	}
+	info, err := object.Stat()
+	if err != nil {
+		object.Close()
+		return nil, 0, &models.OperationError{Op: "s3_download", Msg: fmt.Sprintf("failed to download %s from S3", key), Err: err}
+	}
+	return object, info.Size, nil
+}
+
+// List returns the objects under prefix, newest first
+func (s *S3Store) List(prefix string) ([]*models.OffsiteBackup, error) {
+	var backups []*models.OffsiteBackup
+	for object := range s.client.ListObjects(context.Background(), s.bucket, minio.ListObjectsOptions{Prefix: s.prefix + prefix, Recursive: true}) {
+		if object.Err != nil {
+			return nil, &models.OperationError{Op: "s3_list", Msg: "failed to list S3 bucket", Err: object.Err}
+		}
+		key := strings.TrimPrefix(object.Key, s.prefix)
+		backups = append(backups, &models.OffsiteBackup{
+			Key:      key,
+			Name:     key[strings.LastIndex(key, "/")+1:],
+			Size:     object.Size,
+			Modified: object.LastModified,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Modified.After(backups[j].Modified) })
+	return backups, nil
+}
@@ -0,0 +1,447 @@
+// Package testutil holds test doubles shared by the packages' tests
+package testutil
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"0xkowalskidev/gameservers/models"
+)
+
+// MockDockerManager is an in-memory models.DockerManagerInterface. It records every call,
+// keeps container states and files in maps, and returns the error set in Errors for a method.
+type MockDockerManager struct {
+	mu     sync.Mutex
+	calls  []string
+	nextID int
+
+	Containers map[string]*models.ContainerState // Containers that exist, by ID
+	Files      map[string][]byte                 // File contents by path, shared by every container
+	Logs       map[string]string                 // Output StreamContainerLogs returns, by container ID
+	Errors     map[string]error                  // Error returned by a method, by name, e.g. "StopContainer"
+
+	// Exit code a stopped container reports: 0 for a graceful exit, 137 when it had to be killed
+	StopExitCode int
+
+	// Optional overrides for streams tests need to control
+	StreamContainerLogsFunc func(containerID string, opts models.LogStreamOptions) (io.ReadCloser, error)
+	AttachInteractiveFunc   func(containerID string) (io.ReadWriteCloser, error)
+}
+
+// NewMockDockerManager creates an empty mock
+func NewMockDockerManager() *MockDockerManager {
+	return &MockDockerManager{
+		Containers: make(map[string]*models.ContainerState),
+		Files:      make(map[string][]byte),
+		Logs:       make(map[string]string),
+		Errors:     make(map[string]error),
+	}
+}
+
+// record notes a call and returns the error configured for the method
+func (m *MockDockerManager) record(method string, args ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, strings.TrimSpace(method+" "+strings.Join(args, " ")))
+	return m.Errors[method]
+}
+
+// Calls returns the calls made so far, as "Method arg..." strings in order
+func (m *MockDockerManager) Calls() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.calls...)
+}
+
+// CallIndex returns the position of the first call starting with prefix, or -1
+func (m *MockDockerManager) CallIndex(prefix string) int {
+	for i, call := range m.Calls() {
+		if strings.HasPrefix(call, prefix) {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetError makes method fail with err (nil clears it)
+func (m *MockDockerManager) SetError(method string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Errors[method] = err
+}
+
+// AddContainer registers a container in the given state
+func (m *MockDockerManager) AddContainer(containerID string, status models.GameserverStatus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Containers[containerID] = &models.ContainerState{Status: status, StartedAt: time.Now()}
+}
+
+// SetFile sets a file's content
+func (m *MockDockerManager) SetFile(path string, content []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Files[path] = content
+}
+
+// File returns a file's content
+func (m *MockDockerManager) File(path string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	content, ok := m.Files[path]
+	return content, ok
+}
+
+// setStatus updates a container's status, failing for containers that don't exist
+func (m *MockDockerManager) setStatus(containerID string, status models.GameserverStatus) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, ok := m.Containers[containerID]
+	if !ok {
+		return fmt.Errorf("no such container: %s", containerID)
+	}
+	state.Status = status
+	if status == models.StatusStopped {
+		state.ExitCode = m.StopExitCode
+	}
+	return nil
+}
+
+func (m *MockDockerManager) Ping() error { return m.record("Ping") }
+
+func (m *MockDockerManager) CreateContainer(server *models.Gameserver) error {
+	return m.CreateContainerWithCallback(server, nil)
+}
+
+func (m *MockDockerManager) CreateContainerWithCallback(server *models.Gameserver, callback models.StatusCallback) error {
+	if err := m.record("CreateContainer", server.ID); err != nil {
+		return err
+	}
+	if callback != nil {
+		callback(models.StatusCreatingContainer)
+	}
+	m.mu.Lock()
+	m.nextID++
+	server.ContainerID = fmt.Sprintf("container-%d", m.nextID)
+	m.Containers[server.ContainerID] = &models.ContainerState{Status: models.StatusStopped}
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MockDockerManager) StartContainer(containerID string) error {
+	if err := m.record("StartContainer", containerID); err != nil {
+		return err
+	}
+	return m.setStatus(containerID, models.StatusRunning)
+}
+
+func (m *MockDockerManager) StopContainer(containerID string) error {
+	if err := m.record("StopContainer", containerID); err != nil {
+		return err
+	}
+	return m.setStatus(containerID, models.StatusStopped)
+}
+
+func (m *MockDockerManager) PauseContainer(containerID string) error {
+	if err := m.record("PauseContainer", containerID); err != nil {
+		return err
+	}
+	return m.setStatus(containerID, models.StatusPaused)
+}
+
+func (m *MockDockerManager) UnpauseContainer(containerID string) error {
+	if err := m.record("UnpauseContainer", containerID); err != nil {
+		return err
+	}
+	return m.setStatus(containerID, models.StatusRunning)
+}
+
+func (m *MockDockerManager) RemoveContainer(containerID string) error {
+	if err := m.record("RemoveContainer", containerID); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	delete(m.Containers, containerID)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MockDockerManager) BuildImage(tag, dockerfile string, contextArchive io.Reader) (string, error) {
+	return "", m.record("BuildImage", tag)
+}
+
+func (m *MockDockerManager) PullImage(imageName string) error {
+	return m.record("PullImage", imageName)
+}
+
+func (m *MockDockerManager) RemoteImageDigest(imageName string) (string, error) {
+	return "", m.record("RemoteImageDigest", imageName)
+}
+
+func (m *MockDockerManager) LocalImageDigest(imageName string) (string, error) {
+	return "", m.record("LocalImageDigest", imageName)
+}
+
+func (m *MockDockerManager) SendCommand(containerID string, command string) (string, error) {
+	return "", m.record("SendCommand", containerID, command)
+}
+
+func (m *MockDockerManager) AttachInteractive(containerID string) (io.ReadWriteCloser, error) {
+	if err := m.record("AttachInteractive", containerID); err != nil {
+		return nil, err
+	}
+	if m.AttachInteractiveFunc != nil {
+		return m.AttachInteractiveFunc(containerID)
+	}
+	return nil, fmt.Errorf("no interactive session for %s", containerID)
+}
+
+func (m *MockDockerManager) GetContainerStatus(containerID string) (models.GameserverStatus, error) {
+	state, err := m.GetContainerState(containerID)
+	if err != nil {
+		return models.StatusStopped, err
+	}
+	return state.Status, nil
+}
+
+func (m *MockDockerManager) GetContainerState(containerID string) (*models.ContainerState, error) {
+	if err := m.record("GetContainerState", containerID); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, ok := m.Containers[containerID]
+	if !ok {
+		return nil, fmt.Errorf("no such container: %s", containerID)
+	}
+	copied := *state
+	return &copied, nil
+}
+
+func (m *MockDockerManager) ContainerImageDigest(containerID string) (string, error) {
+	return "", m.record("ContainerImageDigest", containerID)
+}
+
+func (m *MockDockerManager) InspectContainer(containerID string, secretVars []string) (json.RawMessage, error) {
+	return json.RawMessage("{}"), m.record("InspectContainer", containerID)
+}
+
+func (m *MockDockerManager) StreamContainerLogs(containerID string, opts models.LogStreamOptions) (io.ReadCloser, error) {
+	if err := m.record("StreamContainerLogs", containerID); err != nil {
+		return nil, err
+	}
+	if m.StreamContainerLogsFunc != nil {
+		return m.StreamContainerLogsFunc(containerID, opts)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return io.NopCloser(strings.NewReader(m.Logs[containerID])), nil
+}
+
+func (m *MockDockerManager) ContainerLogTail(containerID string, lines int) string {
+	m.record("ContainerLogTail", containerID)
+	return ""
+}
+
+func (m *MockDockerManager) StreamContainerStats(containerID string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), m.record("StreamContainerStats", containerID)
+}
+
+func (m *MockDockerManager) ContainerResourceUsage(containerID string) (*models.ResourceUsage, error) {
+	return &models.ResourceUsage{}, m.record("ContainerResourceUsage", containerID)
+}
+
+func (m *MockDockerManager) ListContainers() ([]*models.ContainerSummary, error) {
+	return nil, m.record("ListContainers")
+}
+
+func (m *MockDockerManager) InspectContainerSettings(containerID string) (*models.ContainerSettings, error) {
+	return &models.ContainerSettings{}, m.record("InspectContainerSettings", containerID)
+}
+
+func (m *MockDockerManager) WatchContainerEvents(ctx context.Context, onEvent func(gameserverID string)) error {
+	if err := m.record("WatchContainerEvents"); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func (m *MockDockerManager) CreateVolume(volumeName string) error {
+	return m.record("CreateVolume", volumeName)
+}
+
+func (m *MockDockerManager) RemoveVolume(volumeName string) error {
+	return m.record("RemoveVolume", volumeName)
+}
+
+func (m *MockDockerManager) ListVolumes() ([]*models.VolumeInfo, error) {
+	return nil, m.record("ListVolumes")
+}
+
+func (m *MockDockerManager) GetVolumeInfo(volumeName string) (*models.VolumeInfo, error) {
+	return &models.VolumeInfo{Name: volumeName}, m.record("GetVolumeInfo", volumeName)
+}
+
+func (m *MockDockerManager) GetVolumeNameForServer(server *models.Gameserver) string {
+	if server.DataVolume != "" {
+		return server.DataVolume
+	}
+	return "gameservers-" + server.Name + "-data"
+}
+
+func (m *MockDockerManager) VolumeHasServerData(volumeName, image string) (bool, error) {
+	return false, m.record("VolumeHasServerData", volumeName)
+}
+
+func (m *MockDockerManager) MigrateData(source, target, image string) error {
+	return m.record("MigrateData", source, target)
+}
+
+func (m *MockDockerManager) CreateBackup(containerID string, opts models.BackupOptions) (string, error) {
+	if err := m.record("CreateBackup", containerID); err != nil {
+		return "", err
+	}
+	return "backup-" + time.Now().Format("2006-01-02_15-04-05") + ".tar.gz", nil
+}
+
+func (m *MockDockerManager) RestoreBackup(gameserverID, backupPath string) error {
+	return m.record("RestoreBackup", gameserverID, backupPath)
+}
+
+func (m *MockDockerManager) CleanupOldBackups(containerID string, maxBackups int) error {
+	return m.record("CleanupOldBackups", containerID)
+}
+
+func (m *MockDockerManager) VerifyBackup(server *models.Gameserver, bootTime time.Duration) (string, error) {
+	return "", m.record("VerifyBackup", server.ID)
+}
+
+func (m *MockDockerManager) ImportBackup(containerID, backupFilename string, body io.Reader, size int64) error {
+	return m.record("ImportBackup", containerID, backupFilename)
+}
+
+func (m *MockDockerManager) DiskUsage(containerID string) (*models.DiskUsage, error) {
+	return &models.DiskUsage{}, m.record("DiskUsage", containerID)
+}
+
+func (m *MockDockerManager) ListFiles(containerID string, path string) ([]*models.FileInfo, error) {
+	return nil, m.record("ListFiles", containerID, path)
+}
+
+func (m *MockDockerManager) FilesModifiedSince(containerID string, dir string, since time.Time) ([]string, error) {
+	return nil, m.record("FilesModifiedSince", containerID, dir)
+}
+
+func (m *MockDockerManager) ReadFile(containerID string, path string) ([]byte, error) {
+	if err := m.record("ReadFile", containerID, path); err != nil {
+		return nil, err
+	}
+	content, ok := m.File(path)
+	if !ok {
+		return nil, fmt.Errorf("no such file: %s", path)
+	}
+	return content, nil
+}
+
+func (m *MockDockerManager) ReadFileWithHash(containerID string, path string) ([]byte, string, error) {
+	content, err := m.ReadFile(containerID, path)
+	if err != nil {
+		return nil, "", err
+	}
+	return content, models.ContentHash(content), nil
+}
+
+func (m *MockDockerManager) ReadFileRange(containerID string, path string, offset, limit int64) ([]byte, int64, error) {
+	content, err := m.ReadFile(containerID, path)
+	if err != nil {
+		return nil, 0, err
+	}
+	size := int64(len(content))
+	if offset < 0 {
+		offset = max(size-limit, 0)
+	}
+	end := min(offset+limit, size)
+	if offset > size {
+		offset = size
+	}
+	return content[offset:end], size, nil
+}
+
+func (m *MockDockerManager) WriteFile(containerID string, path string, content []byte) error {
+	if err := m.record("WriteFile", containerID, path); err != nil {
+		return err
+	}
+	m.SetFile(path, content)
+	return nil
+}
+
+func (m *MockDockerManager) WriteMissingFiles(containerID string, files map[string][]byte) ([]string, error) {
+	if err := m.record("WriteMissingFiles", containerID); err != nil {
+		return nil, err
+	}
+	var written []string
+	for path, content := range files {
+		if _, ok := m.File(path); !ok {
+			m.SetFile(path, content)
+			written = append(written, path)
+		}
+	}
+	return written, nil
+}
+
+func (m *MockDockerManager) CreateDirectory(containerID string, path string) error {
+	return m.record("CreateDirectory", containerID, path)
+}
+
+func (m *MockDockerManager) DeletePath(containerID string, path string) error {
+	if err := m.record("DeletePath", containerID, path); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	delete(m.Files, path)
+	m.mu.Unlock()
+	return nil
+}
+
+// DownloadFile wraps the file in a tar stream, as Docker's copy API does
+func (m *MockDockerManager) DownloadFile(containerID string, path string) (io.ReadCloser, error) {
+	content, err := m.ReadFile(containerID, path)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: path[strings.LastIndexByte(path, '/')+1:], Mode: 0644, Size: int64(len(content))}); err != nil {
+		return nil, err
+	}
+	tw.Write(content)
+	tw.Close()
+	return io.NopCloser(&buf), nil
+}
+
+func (m *MockDockerManager) UploadFile(containerID string, destPath string, reader io.Reader) error {
+	return m.record("UploadFile", containerID, destPath)
+}
+
+func (m *MockDockerManager) ExtractArchive(containerID, destDir, name string, archive io.ReaderAt, size, maxSize int64) (int, error) {
+	return 0, m.record("ExtractArchive", containerID, destDir, name)
+}
+
+func (m *MockDockerManager) ExtractFile(containerID, archivePath, destDir string, maxSize int64) (int, error) {
+	return 0, m.record("ExtractFile", containerID, archivePath, destDir)
+}
+
+func (m *MockDockerManager) RenameFile(containerID string, oldPath string, newPath string) error {
+	return m.record("RenameFile", containerID, oldPath, newPath)
+}
+
+// Ensure MockDockerManager implements the interface
+var _ models.DockerManagerInterface = (*MockDockerManager)(nil)